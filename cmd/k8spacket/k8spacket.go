@@ -8,15 +8,32 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
+	"time"
 
 	"github.com/k8spacket/k8spacket/internal/broker"
+	"github.com/k8spacket/k8spacket/internal/config"
 	"github.com/k8spacket/k8spacket/internal/ebpf"
+	ebpf_dns "github.com/k8spacket/k8spacket/internal/ebpf/dns"
+	ebpf_http "github.com/k8spacket/k8spacket/internal/ebpf/http"
 	ebpf_inet "github.com/k8spacket/k8spacket/internal/ebpf/inet"
 	ebpf_socketfilter "github.com/k8spacket/k8spacket/internal/ebpf/socketfilter"
 	ebpf_tc "github.com/k8spacket/k8spacket/internal/ebpf/tc"
+	ebpf_tools "github.com/k8spacket/k8spacket/internal/ebpf/tools"
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/k8spacket/k8spacket/internal/modules/dns"
+	"github.com/k8spacket/k8spacket/internal/modules/httpparser"
+	"github.com/k8spacket/k8spacket/internal/modules/listeners"
 	"github.com/k8spacket/k8spacket/internal/modules/nodegraph"
+	"github.com/k8spacket/k8spacket/internal/modules/recorder"
 	"github.com/k8spacket/k8spacket/internal/modules/tlsparser"
+	"github.com/k8spacket/k8spacket/internal/recording"
+	"github.com/k8spacket/k8spacket/internal/status"
+	"github.com/k8spacket/k8spacket/internal/synthetic"
+	"github.com/k8spacket/k8spacket/internal/thirdparty/db"
+	httpclient "github.com/k8spacket/k8spacket/internal/thirdparty/http"
+	k8sclient "github.com/k8spacket/k8spacket/internal/thirdparty/k8s"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -24,34 +41,111 @@ import (
 
 func main() {
 
+	configFile := os.Getenv("K8S_PACKET_CONFIG_FILE")
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		slog.Error("[config] Cannot load configuration", "Error", err)
+		os.Exit(1)
+	}
+	store := config.NewStore(cfg)
+	buildLogger(cfg.Log)
+	store.OnChange(func(cfg *config.Config) {
+		buildLogger(cfg.Log)
+	})
+
+	k8sclient.Init(cfg.K8s)
+
 	mux := http.NewServeMux()
 
-	nodegraphListener := nodegraph.Init(mux)
-	tlsParserListener := tlsparser.Init(mux)
-	distributionBroker := broker.Init(nodegraphListener, tlsParserListener)
+	distributionBroker := broker.Init(store)
+	registry := modules.NewRegistry(nodegraph.NewModule(), tlsparser.NewModule(), recorder.NewModule(), dns.NewModule(), httpparser.NewModule(), listeners.NewModule())
+	if err := registry.Init(mux, distributionBroker, store); err != nil {
+		slog.Error("[modules] Cannot init modules", "Error", err)
+		os.Exit(1)
+	}
+
+	var loader ebpf.Loader
+	switch cfg.Loader.Source {
+	case "replay":
+		loader = recording.NewReplayer(store, distributionBroker)
+	case "synthetic":
+		loader = synthetic.NewGenerator(store, distributionBroker)
+	default:
+		inetEbpf := &ebpf_inet.EbpfInet{Broker: distributionBroker, Transport: cfg.Loader.Transport, Maps: cfg.Loader.Maps, Listeners: slices.Contains(cfg.Modules.Enabled, "listeners")}
+		tcEbpf := &ebpf_tc.EbpfTc{Broker: distributionBroker, Transport: cfg.Loader.Transport, Maps: cfg.Loader.Maps, Attach: cfg.Loader.Tc}
+		socketFilterEbpf := &ebpf_socketfilter.EbpfSocketFilter{Broker: distributionBroker, Transport: cfg.Loader.Transport, Maps: cfg.Loader.Maps}
+		dnsEbpf := &ebpf_dns.EbpfDns{Broker: distributionBroker}
+		httpEbpf := &ebpf_http.EbpfHttp{Broker: distributionBroker}
+		ebpfLoader := ebpf.Init(store, inetEbpf, tcEbpf, socketFilterEbpf, dnsEbpf, httpEbpf)
+		mux.HandleFunc("/api/interfaces", ebpf.NewHandler(ebpfLoader).InterfacesHandler)
+		loader = ebpfLoader
+	}
+
+	// root context, cancelled on signal, everything running in the background follows it
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	startApp(ctx, store, config.NewReloader(configFile, store), registry, distributionBroker, loader, mux)
+}
+
+func startApp(ctx context.Context, store *config.Store, reloader *config.Reloader, registry *modules.Registry, broker broker.Broker, loader ebpf.Loader, mux *http.ServeMux) {
+	if err := registry.Start(); err != nil {
+		slog.Error("[modules] Cannot start modules", "Error", err)
+	}
+	broker.DistributeEvents()
+	loader.Load(ctx)
+	go reloader.Watch(ctx)
 
-	inetEbpf := &ebpf_inet.EbpfInet{Broker: distributionBroker}
-	tcEbpf := &ebpf_tc.EbpfTc{Broker: distributionBroker}
-	socketFilterEbpf := &ebpf_socketfilter.EbpfSocketFilter{Broker: distributionBroker}
-	loader := ebpf.Init(inetEbpf, tcEbpf, socketFilterEbpf)
+	registerMetrics()
+	mux.HandleFunc("/api/config", config.NewHandler(store).ConfigHandler)
+	statusHandler := status.NewHandler(status.Default())
+	mux.HandleFunc("/healthz", statusHandler.HealthzHandler)
+	mux.HandleFunc("/readyz", statusHandler.ReadyzHandler)
+	mux.HandleFunc("/api/status", statusHandler.StatusHandler)
+	srv := startHttpServer(store.Get().Api, mux)
 
-	buildLogger()
-	startApp(distributionBroker, loader, mux)
+	<-ctx.Done()
+	slog.Info("[graceful] Receive signal, shutting down...")
+	// producers are stopped before consumers, so events already read are still stored before databases are closed
+	gracefulShutdown(store.Get().Shutdown.StepTimeout.Duration,
+		shutdownStep{"eBPF programs", loader.Stop},
+		shutdownStep{"broker", broker.Stop},
+		shutdownStep{"HTTP server", srv.Shutdown},
+		shutdownStep{"modules", registry.Stop})
+	slog.Info("[graceful] Application closed gracefully")
 }
 
-func startApp(broker broker.Broker, loader ebpf.Loader, mux *http.ServeMux) {
-	go broker.DistributeEvents()
-	loader.Load()
+type shutdownStep struct {
+	name string
+	stop func(ctx context.Context) error
+}
 
+func gracefulShutdown(stepTimeout time.Duration, steps ...shutdownStep) {
+	for _, step := range steps {
+		start := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), stepTimeout)
+		if err := step.stop(ctx); err != nil {
+			slog.Error("[graceful] Shutdown step failed", "Step", step.name, "Duration", time.Since(start), "Error", err)
+		} else {
+			slog.Info("[graceful] Shutdown step completed", "Step", step.name, "Duration", time.Since(start))
+		}
+		cancel()
+	}
+}
+
+func registerMetrics() {
 	prometheus.MustRegister(collectors.NewBuildInfoCollector())
-	startHttpServer(mux)
+	prometheus.MustRegister(config.ReloadMetric, config.ReloadTimestampMetric)
+	prometheus.MustRegister(broker.QueueDepthMetric, broker.ProcessedMetric, broker.DroppedMetric)
+	prometheus.MustRegister(ebpf_tools.LostSamplesMetric, ebpf_tools.ReadErrorsMetric, ebpf_tools.ParseErrorsMetric, ebpf_tools.EventsMetric, ebpf_tools.ReassembliesMetric, ebpf_tools.EnrichDurationMetric)
+	prometheus.MustRegister(db.UpsertDurationMetric, db.UpsertErrorsMetric)
+	prometheus.MustRegister(httpclient.PeerRequestDurationMetric)
 }
 
-func startHttpServer(mux *http.ServeMux) {
-	listenerPort := os.Getenv("K8S_PACKET_TCP_LISTENER_PORT")
-	slog.Info("[api] Serving requests", "Port", listenerPort)
+func startHttpServer(api config.ApiConfig, mux *http.ServeMux) *http.Server {
+	slog.Info("[api] Serving requests", "Port", api.Port)
 
-	srv := &http.Server{Addr: fmt.Sprintf(":%s", listenerPort), Handler: mux}
+	srv := &http.Server{Addr: fmt.Sprintf(":%d", api.Port), Handler: mux}
 	go func() {
 		mux.Handle("/metrics", promhttp.Handler())
 		if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
@@ -60,19 +154,12 @@ func startHttpServer(mux *http.ServeMux) {
 
 	}()
 
-	// graceful shutdown
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer stop()
-	<-ctx.Done()
-	if err := srv.Shutdown(ctx); err != nil {
-		slog.Error("[graceful] Server shutdown failed", "Error", err)
-	}
-	slog.Info("[graceful] Application closed gracefully")
+	return srv
 }
 
-func buildLogger() {
+func buildLogger(log config.LogConfig) {
 	var l slog.Level
-	err := l.UnmarshalText([]byte(os.Getenv("LOG_LEVEL")))
+	err := l.UnmarshalText([]byte(log.Level))
 	if err != nil {
 		l = slog.LevelInfo
 	}
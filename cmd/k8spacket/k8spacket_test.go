@@ -1,35 +1,52 @@
 package main
 
 import (
+	"context"
 	"github.com/k8spacket/k8spacket/internal/ebpf"
 	"io"
 	"net/http"
-	"os"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/k8spacket/k8spacket/internal/broker"
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/k8spacket/k8spacket/internal/modules"
 	"github.com/stretchr/testify/assert"
 )
 
 type mockLoader struct {
 	ebpf.Loader
+	stopped atomic.Bool
 }
 
-func (mockLoader *mockLoader) Load() {
+func (mockLoader *mockLoader) Load(ctx context.Context) {
 
 }
 
+func (mockLoader *mockLoader) Stop(ctx context.Context) error {
+	mockLoader.stopped.Store(true)
+	return nil
+}
+
 func TestStartApp(t *testing.T) {
 
-	os.Setenv("K8S_PACKET_TCP_LISTENER_PORT", "6676")
+	cfg := config.Default()
+	cfg.Api.Port = 6676
 
 	mux := http.NewServeMux()
 
-	distBroker := &broker.DistributionBroker{}
+	store := config.NewStore(cfg)
+	distBroker := broker.Init(store)
 	loader := &mockLoader{}
 
-	go startApp(distBroker, loader, mux)
+	ctx, cancel := context.WithCancel(context.Background())
+	closed := make(chan struct{})
+
+	go func() {
+		startApp(ctx, store, config.NewReloader("", store), modules.NewRegistry(), distBroker, loader, mux)
+		close(closed)
+	}()
 
 	assert.Eventually(t, func() bool {
 		resp, err := http.Get("http://127.0.0.1:6676/metrics")
@@ -41,4 +58,32 @@ func TestStartApp(t *testing.T) {
 		return assert.EqualValues(t, resp.StatusCode, http.StatusOK) && assert.Regexp(t, "go_info{version=\"go.*\"}", bodyStr)
 	}, time.Second*20, time.Millisecond*100)
 
+	cancel()
+
+	assert.Eventually(t, func() bool {
+		<-closed
+		_, err := http.Get("http://127.0.0.1:6676/metrics")
+		return loader.stopped.Load() && err != nil
+	}, time.Second*5, time.Millisecond*100)
+}
+
+func TestGracefulShutdown(t *testing.T) {
+
+	var steps []string
+	step := func(name string, wait time.Duration) shutdownStep {
+		return shutdownStep{name, func(ctx context.Context) error {
+			select {
+			case <-time.After(wait):
+				steps = append(steps, name)
+				return nil
+			case <-ctx.Done():
+				steps = append(steps, name+" timeout")
+				return ctx.Err()
+			}
+		}}
+	}
+
+	gracefulShutdown(50*time.Millisecond, step("first", 0), step("second", time.Second), step("third", 0))
+
+	assert.EqualValues(t, []string{"first", "second timeout", "third"}, steps)
 }
@@ -1,6 +1,8 @@
 package broker
 
 import (
+	"context"
+
 	"github.com/k8spacket/k8spacket/internal/modules"
 )
 
@@ -8,4 +10,9 @@ type Broker interface {
 	DistributeEvents()
 	TCPEvent(event modules.TCPEvent)
 	TLSEvent(event modules.TLSEvent)
+	DNSEvent(event modules.DNSEvent)
+	HTTPEvent(event modules.HTTPEvent)
+	ListenEvent(event modules.ListenEvent)
+	Stop(ctx context.Context) error
+	modules.Broker
 }
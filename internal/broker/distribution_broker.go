@@ -1,39 +1,99 @@
 package broker
 
 import (
+	"context"
+	"errors"
+	"hash/fnv"
+
+	"github.com/k8spacket/k8spacket/internal/config"
 	"github.com/k8spacket/k8spacket/internal/modules"
 )
 
 type DistributionBroker struct {
 	Broker
-	NodegraphListener modules.Listener[modules.TCPEvent]
-	TlsParserListener modules.Listener[modules.TLSEvent]
-	tcpEventChannel   chan modules.TCPEvent
-	tlsEventChannel   chan modules.TLSEvent
+	tcpEvents    *topic[modules.TCPEvent]
+	tlsEvents    *topic[modules.TLSEvent]
+	dnsEvents    *topic[modules.DNSEvent]
+	httpEvents   *topic[modules.HTTPEvent]
+	listenEvents *topic[modules.ListenEvent]
 }
 
-func Init(nodegraphListener modules.Listener[modules.TCPEvent], tlsParserListener modules.Listener[modules.TLSEvent]) *DistributionBroker {
-	broker := DistributionBroker{NodegraphListener: nodegraphListener, TlsParserListener: tlsParserListener}
-	broker.tcpEventChannel = make(chan modules.TCPEvent)
-	broker.tlsEventChannel = make(chan modules.TLSEvent)
+func Init(store *config.Store) *DistributionBroker {
+	broker := DistributionBroker{}
+	broker.tcpEvents = newTopic("tcp",
+		func() config.QueueConfig { return store.Get().Broker.Tcp },
+		func(event modules.TCPEvent) string { return "inet" }, nil)
+	broker.tlsEvents = newTopic("tls",
+		func() config.QueueConfig { return store.Get().Broker.Tls },
+		func(event modules.TLSEvent) string { return event.Source.String() }, nil)
+	broker.dnsEvents = newTopic("dns",
+		func() config.QueueConfig { return store.Get().Broker.Dns },
+		func(event modules.DNSEvent) string { return "dns" }, nil)
+	broker.httpEvents = newTopic("http",
+		func() config.QueueConfig { return store.Get().Broker.Http },
+		func(event modules.HTTPEvent) string { return "http" },
+		// responses are matched with requests of their connection in order, a connection stays on one worker
+		func(event modules.HTTPEvent) uint64 {
+			hash := fnv.New64a()
+			hash.Write([]byte(event.Client.Addr))
+			return hash.Sum64() + uint64(event.Client.Port)
+		})
+	broker.listenEvents = newTopic("listen",
+		func() config.QueueConfig { return store.Get().Broker.Listen },
+		func(event modules.ListenEvent) string { return "inet" }, nil)
 	return &broker
 }
 
+func (broker *DistributionBroker) SubscribeTCP(subscriber string, listener modules.Listener[modules.TCPEvent]) (modules.Subscription, error) {
+	return broker.tcpEvents.subscribe(subscriber, listener)
+}
+
+func (broker *DistributionBroker) SubscribeTLS(subscriber string, listener modules.Listener[modules.TLSEvent]) (modules.Subscription, error) {
+	return broker.tlsEvents.subscribe(subscriber, listener)
+}
+
+func (broker *DistributionBroker) SubscribeDNS(subscriber string, listener modules.Listener[modules.DNSEvent]) (modules.Subscription, error) {
+	return broker.dnsEvents.subscribe(subscriber, listener)
+}
+
+func (broker *DistributionBroker) SubscribeHTTP(subscriber string, listener modules.Listener[modules.HTTPEvent]) (modules.Subscription, error) {
+	return broker.httpEvents.subscribe(subscriber, listener)
+}
+
+func (broker *DistributionBroker) SubscribeListen(subscriber string, listener modules.Listener[modules.ListenEvent]) (modules.Subscription, error) {
+	return broker.listenEvents.subscribe(subscriber, listener)
+}
+
 func (broker *DistributionBroker) TCPEvent(event modules.TCPEvent) {
-	broker.tcpEventChannel <- event
+	broker.tcpEvents.publish(event)
 }
 
 func (broker *DistributionBroker) TLSEvent(event modules.TLSEvent) {
-	broker.tlsEventChannel <- event
+	broker.tlsEvents.publish(event)
+}
+
+func (broker *DistributionBroker) DNSEvent(event modules.DNSEvent) {
+	broker.dnsEvents.publish(event)
+}
+
+func (broker *DistributionBroker) HTTPEvent(event modules.HTTPEvent) {
+	broker.httpEvents.publish(event)
+}
+
+func (broker *DistributionBroker) ListenEvent(event modules.ListenEvent) {
+	broker.listenEvents.publish(event)
+}
+
+// Stop stops accepting events and waits until subscribers process events already queued
+func (broker *DistributionBroker) Stop(ctx context.Context) error {
+	return errors.Join(broker.tcpEvents.stop(ctx), broker.tlsEvents.stop(ctx), broker.dnsEvents.stop(ctx), broker.httpEvents.stop(ctx), broker.listenEvents.stop(ctx))
 }
 
+// DistributeEvents starts delivering events to subscribers, those registered later are served right away
 func (broker *DistributionBroker) DistributeEvents() {
-	for {
-		select {
-		case event := <-broker.tcpEventChannel:
-			broker.NodegraphListener.Listen(event)
-		case event := <-broker.tlsEventChannel:
-			broker.TlsParserListener.Listen(event)
-		}
-	}
+	broker.tcpEvents.start()
+	broker.tlsEvents.start()
+	broker.dnsEvents.start()
+	broker.httpEvents.start()
+	broker.listenEvents.start()
 }
@@ -1,9 +1,14 @@
 package broker
 
 import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/k8spacket/k8spacket/internal/config"
 	"github.com/k8spacket/k8spacket/internal/modules"
 	"github.com/stretchr/testify/assert"
 )
@@ -26,12 +31,47 @@ func (mockTlsListener *mockTlsListener) Listen(event modules.TLSEvent) {
 	mockTlsListener.listenerCalled = true
 }
 
+type mockDnsListener struct {
+	modules.Listener[modules.DNSEvent]
+	listenerCalled atomic.Bool
+}
+
+func (mockDnsListener *mockDnsListener) Listen(event modules.DNSEvent) {
+	mockDnsListener.listenerCalled.Store(true)
+}
+
+type mockHttpListener struct {
+	modules.Listener[modules.HTTPEvent]
+	listenerCalled atomic.Bool
+}
+
+func (mockHttpListener *mockHttpListener) Listen(event modules.HTTPEvent) {
+	mockHttpListener.listenerCalled.Store(true)
+}
+
+type mockListenListener struct {
+	modules.Listener[modules.ListenEvent]
+	listenerCalled atomic.Bool
+}
+
+func (mockListenListener *mockListenListener) Listen(event modules.ListenEvent) {
+	mockListenListener.listenerCalled.Store(true)
+}
+
 func TestDistributeEvents(t *testing.T) {
 
 	mockNodegraphListener := &mockTcpListener{}
 	mockTlsParserListener := &mockTlsListener{}
+	mockDnsListener := &mockDnsListener{}
+	mockHttpListener := &mockHttpListener{}
+	mockListenListener := &mockListenListener{}
 
-	broker := Init(mockNodegraphListener, mockTlsParserListener)
+	broker := Init(config.NewStore(config.Default()))
+	broker.SubscribeTCP("nodegraph", mockNodegraphListener)
+	broker.SubscribeTLS("tlsparser", mockTlsParserListener)
+	broker.SubscribeDNS("dns", mockDnsListener)
+	broker.SubscribeHTTP("httpparser", mockHttpListener)
+	broker.SubscribeListen("listeners", mockListenListener)
 
 	go broker.DistributeEvents()
 
@@ -43,8 +83,196 @@ func TestDistributeEvents(t *testing.T) {
 
 	broker.TLSEvent(modules.TLSEvent{Client: modules.Address{Addr: "addr1"}, ServerName: "k8spacket.io"})
 
+	broker.DNSEvent(modules.DNSEvent{Client: modules.Address{Addr: "addr1"}, Name: "k8spacket.io"})
+
+	broker.HTTPEvent(modules.HTTPEvent{Client: modules.Address{Addr: "addr1"}, Method: "GET", Path: "/"})
+
+	broker.ListenEvent(modules.ListenEvent{Listener: modules.Address{Addr: "0.0.0.0", Port: 8080}, Ino: 1})
+
 	assert.Eventually(t, func() bool {
-		return mockNodegraphListener.listenerCalled && mockTlsParserListener.listenerCalled
+		return mockNodegraphListener.listenerCalled && mockTlsParserListener.listenerCalled && mockDnsListener.listenerCalled.Load() && mockHttpListener.listenerCalled.Load() && mockListenListener.listenerCalled.Load()
 	}, time.Second*1, time.Millisecond*100)
 
 }
+
+type countingTcpListener struct {
+	modules.Listener[modules.TCPEvent]
+	count atomic.Int32
+}
+
+func (countingTcpListener *countingTcpListener) Listen(event modules.TCPEvent) {
+	countingTcpListener.count.Add(1)
+}
+
+// pairingHttpListener answers every response with the oldest request of its connection, as the httpparser listener does
+type pairingHttpListener struct {
+	modules.Listener[modules.HTTPEvent]
+	mu      sync.Mutex
+	pending map[uint16][]string
+	paired  map[string]string
+}
+
+func (pairingHttpListener *pairingHttpListener) Listen(event modules.HTTPEvent) {
+	if !event.Response {
+		// storing the request takes longer than answering, a response handled meanwhile would find no request
+		time.Sleep(time.Millisecond)
+	}
+	pairingHttpListener.mu.Lock()
+	defer pairingHttpListener.mu.Unlock()
+	port := event.Client.Port
+	if !event.Response {
+		pairingHttpListener.pending[port] = append(pairingHttpListener.pending[port], event.Path)
+		return
+	}
+	if len(pairingHttpListener.pending[port]) > 0 {
+		pairingHttpListener.paired[fmt.Sprintf("%d%s", port, pairingHttpListener.pending[port][0])] = fmt.Sprintf("%d/%d", port, event.Seq)
+		pairingHttpListener.pending[port] = pairingHttpListener.pending[port][1:]
+	}
+}
+
+func TestHttpPairs(t *testing.T) {
+
+	cfg := config.Default()
+	cfg.Broker.Http.Workers = 4
+	broker := Init(config.NewStore(cfg))
+	listener := &pairingHttpListener{pending: make(map[uint16][]string), paired: make(map[string]string)}
+	broker.SubscribeHTTP("httpparser", listener)
+	broker.DistributeEvents()
+
+	want := make(map[string]string)
+	for i := range 20 {
+		for port := uint16(40000); port < 40008; port++ {
+			client := modules.Address{Addr: "10.0.0.1", Port: port}
+			broker.HTTPEvent(modules.HTTPEvent{Client: client, Method: "GET", Path: fmt.Sprintf("/%d", i)})
+			broker.HTTPEvent(modules.HTTPEvent{Client: client, Response: true, Seq: uint32(i), StatusCode: 200})
+			want[fmt.Sprintf("%d/%d", port, i)] = fmt.Sprintf("%d/%d", port, i)
+		}
+	}
+
+	assert.NoError(t, broker.Stop(context.Background()))
+	assert.EqualValues(t, want, listener.paired)
+}
+
+func TestSubscriptions(t *testing.T) {
+
+	broker := Init(config.NewStore(config.Default()))
+	broker.DistributeEvents()
+
+	first := &countingTcpListener{}
+	second := &countingTcpListener{}
+	firstSubscription, err := broker.SubscribeTCP("first", first)
+	assert.NoError(t, err)
+	_, err = broker.SubscribeTCP("second", second)
+	assert.NoError(t, err)
+
+	_, err = broker.SubscribeTCP("first", second)
+	assert.ErrorContains(t, err, "subscriber \"first\" of tcp events already exists")
+
+	broker.TCPEvent(modules.TCPEvent{TxB: 1})
+
+	assert.Eventually(t, func() bool {
+		return first.count.Load() == 1 && second.count.Load() == 1
+	}, time.Second*1, time.Millisecond*10)
+
+	firstSubscription.Unsubscribe()
+	firstSubscription.Unsubscribe()
+
+	broker.TCPEvent(modules.TCPEvent{TxB: 2})
+
+	assert.Eventually(t, func() bool {
+		return second.count.Load() == 2
+	}, time.Second*1, time.Millisecond*10)
+	assert.EqualValues(t, 1, first.count.Load())
+
+	_, err = broker.SubscribeTCP("first", first)
+	assert.NoError(t, err)
+}
+
+type unsubscribingTcpListener struct {
+	modules.Listener[modules.TCPEvent]
+	release      chan struct{}
+	subscription modules.Subscription
+}
+
+func (unsubscribingTcpListener *unsubscribingTcpListener) Listen(event modules.TCPEvent) {
+	<-unsubscribingTcpListener.release
+	unsubscribingTcpListener.subscription.Unsubscribe()
+}
+
+func TestUnsubscribeInListen(t *testing.T) {
+
+	cfg := config.Default()
+	cfg.Broker.Tcp = config.QueueConfig{Size: 1, DropPolicy: block, Workers: 1}
+	broker := Init(config.NewStore(cfg))
+	broker.DistributeEvents()
+
+	listener := &unsubscribingTcpListener{release: make(chan struct{})}
+	subscription, err := broker.SubscribeTCP("unsubscribing", listener)
+	assert.NoError(t, err)
+	listener.subscription = subscription
+
+	// the worker waits with the first event, the second fills the queue and the third blocks the publisher
+	broker.TCPEvent(modules.TCPEvent{TxB: 1})
+	assert.Eventually(t, func() bool { return broker.tcpEvents.queues["unsubscribing"].depth() == 0 }, time.Second, time.Millisecond*10)
+	broker.TCPEvent(modules.TCPEvent{TxB: 2})
+	published := make(chan struct{})
+	go func() {
+		broker.TCPEvent(modules.TCPEvent{TxB: 3})
+		close(published)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(listener.release)
+
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("publisher still blocked after the listener unsubscribed")
+	}
+	broker.TCPEvent(modules.TCPEvent{TxB: 4})
+	assert.NoError(t, broker.Stop(context.Background()))
+}
+
+type slowTcpListener struct {
+	modules.Listener[modules.TCPEvent]
+	count atomic.Int32
+}
+
+func (slowTcpListener *slowTcpListener) Listen(event modules.TCPEvent) {
+	time.Sleep(10 * time.Millisecond)
+	slowTcpListener.count.Add(1)
+}
+
+func TestStop(t *testing.T) {
+
+	broker := Init(config.NewStore(config.Default()))
+	listener := &slowTcpListener{}
+	broker.SubscribeTCP("slow", listener)
+	broker.DistributeEvents()
+
+	for range 5 {
+		broker.TCPEvent(modules.TCPEvent{TxB: 1})
+	}
+
+	assert.NoError(t, broker.Stop(context.Background()))
+	assert.EqualValues(t, 5, listener.count.Load())
+
+	broker.TCPEvent(modules.TCPEvent{TxB: 1})
+	_, err := broker.SubscribeTCP("late", listener)
+	assert.ErrorContains(t, err, "broker is stopped")
+}
+
+func TestStopTimeout(t *testing.T) {
+
+	broker := Init(config.NewStore(config.Default()))
+	broker.SubscribeTCP("slow", &slowTcpListener{})
+	broker.DistributeEvents()
+
+	for range 100 {
+		broker.TCPEvent(modules.TCPEvent{TxB: 1})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorContains(t, broker.Stop(ctx), "queue slow of tcp not drained")
+}
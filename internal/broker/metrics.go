@@ -0,0 +1,29 @@
+package broker
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	QueueDepthMetric = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "k8s_packet_broker_queue_depth",
+			Help: "Kubernetes packet events waiting in the broker queue of a subscriber",
+		},
+		[]string{"queue", "subscriber"},
+	)
+	ProcessedMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "k8s_packet_broker_events_processed_total",
+			Help: "Kubernetes packet events processed by subscribers",
+		},
+		[]string{"queue", "subscriber", "source"},
+	)
+	DroppedMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "k8s_packet_broker_events_dropped_total",
+			Help: "Kubernetes packet events dropped because the broker queue of a subscriber was full",
+		},
+		[]string{"queue", "subscriber", "source"},
+	)
+)
@@ -0,0 +1,146 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/k8spacket/k8spacket/internal/modules"
+)
+
+const (
+	dropNewest = "drop-newest"
+	dropOldest = "drop-oldest"
+	block      = "block"
+)
+
+// queue is a bounded buffer between eBPF readers and one subscriber, consumed by a pool of workers. Workers share
+// one buffer unless events are sharded by key, every worker has then its own buffer and events of the same key are
+// handled in order by the same worker.
+type queue[T modules.TCPEvent | modules.TLSEvent | modules.DNSEvent | modules.HTTPEvent | modules.ListenEvent] struct {
+	topic      string
+	subscriber string
+	events     []chan T
+	workers    int
+	listener   modules.Listener[T]
+	source     func(event T) string
+	key        func(event T) uint64
+	policy     func() string
+	running    sync.WaitGroup
+	// closing wakes up blocked publishers, so that close can take mu and close events
+	closing chan struct{}
+	mu      sync.RWMutex
+	closed  bool
+}
+
+func newQueue[T modules.TCPEvent | modules.TLSEvent | modules.DNSEvent | modules.HTTPEvent | modules.ListenEvent](topic string, subscriber string, cfg config.QueueConfig, listener modules.Listener[T], source func(event T) string, key func(event T) uint64, policy func() string) *queue[T] {
+	shards := 1
+	if key != nil {
+		shards = max(cfg.Workers, 1)
+	}
+	events := make([]chan T, shards)
+	for i := range events {
+		// the size is shared among shards, rounded up
+		events[i] = make(chan T, (cfg.Size+shards-1)/shards)
+	}
+	return &queue[T]{topic: topic, subscriber: subscriber, events: events, workers: cfg.Workers, listener: listener, source: source, key: key, policy: policy, closing: make(chan struct{})}
+}
+
+func (queue *queue[T]) shard(event T) chan T {
+	if len(queue.events) == 1 {
+		return queue.events[0]
+	}
+	return queue.events[queue.key(event)%uint64(len(queue.events))]
+}
+
+// depth is the number of events waiting in all shards
+func (queue *queue[T]) depth() int {
+	var depth int
+	for _, events := range queue.events {
+		depth += len(events)
+	}
+	return depth
+}
+
+// publish drops events once the queue is closed
+func (queue *queue[T]) publish(event T) {
+	queue.mu.RLock()
+	defer queue.mu.RUnlock()
+	if queue.closed {
+		return
+	}
+	events := queue.shard(event)
+	switch queue.policy() {
+	case block:
+		select {
+		case events <- event:
+		case <-queue.closing:
+		}
+	case dropOldest:
+		for published := false; !published; {
+			select {
+			case events <- event:
+				published = true
+			default:
+				select {
+				case dropped := <-events:
+					DroppedMetric.WithLabelValues(queue.topic, queue.subscriber, queue.source(dropped)).Inc()
+				default:
+				}
+			}
+		}
+	default:
+		select {
+		case events <- event:
+		default:
+			DroppedMetric.WithLabelValues(queue.topic, queue.subscriber, queue.source(event)).Inc()
+		}
+	}
+	QueueDepthMetric.WithLabelValues(queue.topic, queue.subscriber).Set(float64(queue.depth()))
+}
+
+// start runs the workers, they exit when the queue is closed and drained
+func (queue *queue[T]) start() {
+	for i := range queue.workers {
+		events := queue.events[i%len(queue.events)]
+		queue.running.Add(1)
+		go func() {
+			defer queue.running.Done()
+			for event := range events {
+				QueueDepthMetric.WithLabelValues(queue.topic, queue.subscriber).Set(float64(queue.depth()))
+				queue.listener.Listen(event)
+				ProcessedMetric.WithLabelValues(queue.topic, queue.subscriber, queue.source(event)).Inc()
+			}
+		}()
+	}
+}
+
+func (queue *queue[T]) close() {
+	close(queue.closing)
+	queue.mu.Lock()
+	queue.closed = true
+	for _, events := range queue.events {
+		close(events)
+	}
+	queue.mu.Unlock()
+	go func() {
+		queue.running.Wait()
+		QueueDepthMetric.DeleteLabelValues(queue.topic, queue.subscriber)
+	}()
+}
+
+// drain waits until workers hand all queued events to the listener or ctx is done
+func (queue *queue[T]) drain(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		queue.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("queue %s of %s not drained, %d events left: %w", queue.subscriber, queue.topic, queue.depth(), ctx.Err())
+	}
+}
@@ -0,0 +1,83 @@
+package broker
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockBlockingListener struct {
+	modules.Listener[modules.TCPEvent]
+	release chan struct{}
+	mu      sync.Mutex
+	events  []uint64
+}
+
+func (mockBlockingListener *mockBlockingListener) Listen(event modules.TCPEvent) {
+	<-mockBlockingListener.release
+	mockBlockingListener.mu.Lock()
+	defer mockBlockingListener.mu.Unlock()
+	mockBlockingListener.events = append(mockBlockingListener.events, event.TxB)
+}
+
+func (mockBlockingListener *mockBlockingListener) received() []uint64 {
+	mockBlockingListener.mu.Lock()
+	defer mockBlockingListener.mu.Unlock()
+	return append([]uint64{}, mockBlockingListener.events...)
+}
+
+func TestPublish(t *testing.T) {
+
+	var tests = []struct {
+		policy   string
+		want     []uint64
+		dropped  float64
+		blocking bool
+	}{
+		{dropNewest, []uint64{1, 2, 3}, 2, false},
+		{dropOldest, []uint64{1, 4, 5}, 2, false},
+		{block, []uint64{1, 2, 3, 4, 5}, 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.policy, func(t *testing.T) {
+			listener := &mockBlockingListener{release: make(chan struct{})}
+			queue := newQueue("tcp", "test_"+test.policy, config.QueueConfig{Size: 2, Workers: 1}, modules.Listener[modules.TCPEvent](listener),
+				func(event modules.TCPEvent) string { return "inet" }, nil,
+				func() string { return test.policy })
+			dropped := testutil.ToFloat64(DroppedMetric.WithLabelValues(queue.topic, queue.subscriber, "inet"))
+			processed := testutil.ToFloat64(ProcessedMetric.WithLabelValues(queue.topic, queue.subscriber, "inet"))
+			queue.start()
+
+			// the first event is taken by the worker which waits for release, the next two fill the queue
+			queue.publish(modules.TCPEvent{TxB: 1})
+			assert.Eventually(t, func() bool { return queue.depth() == 0 }, time.Second, time.Millisecond*10)
+			queue.publish(modules.TCPEvent{TxB: 2})
+			queue.publish(modules.TCPEvent{TxB: 3})
+
+			published := make(chan struct{})
+			go func() {
+				queue.publish(modules.TCPEvent{TxB: 4})
+				queue.publish(modules.TCPEvent{TxB: 5})
+				close(published)
+			}()
+			if !test.blocking {
+				<-published
+			}
+			close(listener.release)
+			<-published
+
+			assert.Eventually(t, func() bool { return len(listener.received()) == len(test.want) }, time.Second, time.Millisecond*10)
+			assert.EqualValues(t, test.want, listener.received())
+			assert.EqualValues(t, dropped+test.dropped, testutil.ToFloat64(DroppedMetric.WithLabelValues(queue.topic, queue.subscriber, "inet")))
+			assert.Eventually(t, func() bool {
+				return processed+float64(len(test.want)) == testutil.ToFloat64(ProcessedMetric.WithLabelValues(queue.topic, queue.subscriber, "inet"))
+			}, time.Second, time.Millisecond*10)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/k8spacket/k8spacket/internal/modules"
+)
+
+// topic fans out events of one type to every subscriber through the subscriber's own queue,
+// so one slow subscriber cannot starve the others
+type topic[T modules.TCPEvent | modules.TLSEvent | modules.DNSEvent | modules.HTTPEvent | modules.ListenEvent] struct {
+	name    string
+	mu      sync.RWMutex
+	queues  map[string]*queue[T]
+	started bool
+	stopped bool
+	config  func() config.QueueConfig
+	source  func(event T) string
+	key     func(event T) uint64
+}
+
+// key, when not nil, shards queues of subscribers so that events of the same key are handled in order
+func newTopic[T modules.TCPEvent | modules.TLSEvent | modules.DNSEvent | modules.HTTPEvent | modules.ListenEvent](name string, cfg func() config.QueueConfig, source func(event T) string, key func(event T) uint64) *topic[T] {
+	return &topic[T]{name: name, queues: make(map[string]*queue[T]), config: cfg, source: source, key: key}
+}
+
+func (topic *topic[T]) subscribe(subscriber string, listener modules.Listener[T]) (modules.Subscription, error) {
+	topic.mu.Lock()
+	defer topic.mu.Unlock()
+	if topic.stopped {
+		return nil, fmt.Errorf("cannot subscribe %q to %s events, broker is stopped", subscriber, topic.name)
+	}
+	if _, ok := topic.queues[subscriber]; ok {
+		return nil, fmt.Errorf("subscriber %q of %s events already exists", subscriber, topic.name)
+	}
+	queue := newQueue(topic.name, subscriber, topic.config(), listener, topic.source, topic.key, func() string { return topic.config().DropPolicy })
+	topic.queues[subscriber] = queue
+	if topic.started {
+		queue.start()
+	}
+	return &Subscription{unsubscribe: func() { topic.unsubscribe(subscriber) }}, nil
+}
+
+func (topic *topic[T]) unsubscribe(subscriber string) {
+	topic.mu.Lock()
+	defer topic.mu.Unlock()
+	if queue, ok := topic.queues[subscriber]; ok {
+		delete(topic.queues, subscriber)
+		queue.close()
+	}
+}
+
+// publish sends outside the lock, a blocked send must not keep a listener from unsubscribing
+func (topic *topic[T]) publish(event T) {
+	topic.mu.RLock()
+	queues := make([]*queue[T], 0, len(topic.queues))
+	for _, queue := range topic.queues {
+		queues = append(queues, queue)
+	}
+	topic.mu.RUnlock()
+	for _, queue := range queues {
+		queue.publish(event)
+	}
+}
+
+func (topic *topic[T]) start() {
+	topic.mu.Lock()
+	defer topic.mu.Unlock()
+	if topic.started {
+		return
+	}
+	topic.started = true
+	for _, queue := range topic.queues {
+		queue.start()
+	}
+}
+
+// stop closes all queues, so no new events are accepted, and drains events already queued
+func (topic *topic[T]) stop(ctx context.Context) error {
+	topic.mu.Lock()
+	topic.stopped = true
+	queues := topic.queues
+	topic.queues = make(map[string]*queue[T])
+	for _, queue := range queues {
+		queue.close()
+	}
+	topic.mu.Unlock()
+
+	var errs []error
+	for _, queue := range queues {
+		if err := queue.drain(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type Subscription struct {
+	once        sync.Once
+	unsubscribe func()
+}
+
+// Unsubscribe stops delivering new events, events already queued are still handed to the listener
+func (subscription *Subscription) Unsubscribe() {
+	subscription.once.Do(subscription.unsubscribe)
+}
@@ -0,0 +1,314 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Config holds every setting of k8spacket. It is loaded at startup (and on reload) from an optional YAML file
+// (see K8S_PACKET_CONFIG_FILE) and K8S_PACKET_* environment variables which take precedence over the file.
+type Config struct {
+	Api        ApiConfig        `yaml:"api" json:"api"`
+	Log        LogConfig        `yaml:"log" json:"log"`
+	Modules    ModulesConfig    `yaml:"modules" json:"modules"`
+	Loader     LoaderConfig     `yaml:"loader" json:"loader"`
+	Reverse    ReverseConfig    `yaml:"reverse" json:"reverse"`
+	Process    ProcessConfig    `yaml:"process" json:"process"`
+	K8s        K8sConfig        `yaml:"k8s" json:"k8s"`
+	Nodegraph  NodegraphConfig  `yaml:"nodegraph" json:"nodegraph"`
+	TlsParser  TlsParserConfig  `yaml:"tlsparser" json:"tlsparser"`
+	Dns        DnsConfig        `yaml:"dns" json:"dns"`
+	HttpParser HttpParserConfig `yaml:"httpparser" json:"httpparser"`
+	Broker     BrokerConfig     `yaml:"broker" json:"broker"`
+	Reload     ReloadConfig     `yaml:"reload" json:"reload"`
+	Shutdown   ShutdownConfig   `yaml:"shutdown" json:"shutdown"`
+	Recorder   RecorderConfig   `yaml:"recorder" json:"recorder"`
+}
+
+type ApiConfig struct {
+	Port          int    `yaml:"port" json:"port"`
+	FieldSelector string `yaml:"fieldSelector" json:"fieldSelector"`
+	LabelSelector string `yaml:"labelSelector" json:"labelSelector"`
+}
+
+type LogConfig struct {
+	Level string `yaml:"level" json:"level"`
+}
+
+type ModulesConfig struct {
+	Enabled []string `yaml:"enabled" json:"enabled"`
+}
+
+type LoaderConfig struct {
+	Source     string           `yaml:"source" json:"source"`
+	Interfaces InterfacesConfig `yaml:"interfaces" json:"interfaces"`
+	Tc         TcConfig         `yaml:"tc" json:"tc"`
+	Transport  TransportConfig  `yaml:"transport" json:"transport"`
+	Replay     ReplayConfig     `yaml:"replay" json:"replay"`
+	Synthetic  SyntheticConfig  `yaml:"synthetic" json:"synthetic"`
+	Maps       MapsConfig       `yaml:"maps" json:"maps"`
+	Filter     FilterConfig     `yaml:"filter" json:"filter"`
+}
+
+// InterfacesConfig selects network interfaces the TC program is attached to when loader.source is tc. Interfaces
+// come and go as netlink reports them, those matching an Include and no Exclude glob pattern are attached. Include
+// defaults to the host side of pod interfaces of common CNI plugins, so that traffic is not seen again on bridges
+// and uplinks. Command, deprecated, is run every RefreshPeriod instead and prints comma separated interface names.
+// RefreshPeriod is also the delay before subscribing to netlink again after an error.
+type InterfacesConfig struct {
+	Command       string   `yaml:"command" json:"command"`
+	RefreshPeriod Duration `yaml:"refreshPeriod" json:"refreshPeriod"`
+	Include       []string `yaml:"include" json:"include"`
+	Exclude       []string `yaml:"exclude" json:"exclude"`
+}
+
+// TcConfig describes how the TC program is attached to interfaces. AttachMode tcx uses TCX links (kernel 6.6+),
+// clsact adds filters to the clsact qdisc of the interface (created when missing) at Priority and Handle, auto uses
+// TCX links when the kernel supports them. Programs and filters of other tools are left in place in both modes.
+type TcConfig struct {
+	AttachMode string `yaml:"attachMode" json:"attachMode"`
+	Priority   int    `yaml:"priority" json:"priority"`
+	Handle     int    `yaml:"handle" json:"handle"`
+}
+
+// TransportConfig selects how eBPF programs pass events to user space: ringbuf (kernel 5.8+), perf or auto,
+// which uses the ring buffer when the kernel supports it. RingBufferSize is shared by all CPUs and must be
+// a power of 2 multiple of the page size, PerfBufferSize is the size of the buffer of every CPU, both in bytes.
+type TransportConfig struct {
+	Mode           string `yaml:"mode" json:"mode"`
+	RingBufferSize int    `yaml:"ringBufferSize" json:"ringBufferSize"`
+	PerfBufferSize int    `yaml:"perfBufferSize" json:"perfBufferSize"`
+}
+
+// MapsConfig sets the number of entries of eBPF maps when programs are loaded. Connections is the number of
+// open TCP connections tracked by the inet program, Handshakes the number of TLS handshakes awaiting a ServerHello
+// and FilterAddresses the number of excluded networks and pod IPs.
+type MapsConfig struct {
+	Connections     int `yaml:"connections" json:"connections"`
+	Handshakes      int `yaml:"handshakes" json:"handshakes"`
+	FilterAddresses int `yaml:"filterAddresses" json:"filterAddresses"`
+}
+
+// FilterConfig describes traffic dropped in the kernel before it reaches user space: connections from or to
+// ExcludeCIDRs, ExcludePorts (either end) or pods of ExcludeNamespaces. Pod IPs of the namespaces are looked
+// up again every RefreshPeriod.
+type FilterConfig struct {
+	ExcludeCIDRs      []string `yaml:"excludeCIDRs" json:"excludeCIDRs"`
+	ExcludePorts      []int    `yaml:"excludePorts" json:"excludePorts"`
+	ExcludeNamespaces []string `yaml:"excludeNamespaces" json:"excludeNamespaces"`
+	RefreshPeriod     Duration `yaml:"refreshPeriod" json:"refreshPeriod"`
+}
+
+// ReplayConfig describes the recording fed into the broker when loader.source is replay.
+// Speed multiplies the original pace of events, 0 replays them as fast as possible.
+type ReplayConfig struct {
+	Path   string  `yaml:"path" json:"path"`
+	Format string  `yaml:"format" json:"format"`
+	Speed  float64 `yaml:"speed" json:"speed"`
+}
+
+// SyntheticConfig describes fake traffic generated when loader.source is synthetic. Pods talk to services and
+// external hosts, TLS handshakes go to external hosts. Rates are events per second, durations and byte sizes
+// of connections are random up to the maximum, TLS versions and cipher suites are picked from the lists.
+type SyntheticConfig struct {
+	Seed          int64    `yaml:"seed" json:"seed"`
+	Pods          int      `yaml:"pods" json:"pods"`
+	Services      int      `yaml:"services" json:"services"`
+	ExternalHosts int      `yaml:"externalHosts" json:"externalHosts"`
+	TcpRate       float64  `yaml:"tcpRate" json:"tcpRate"`
+	TlsRate       float64  `yaml:"tlsRate" json:"tlsRate"`
+	MaxDuration   Duration `yaml:"maxDuration" json:"maxDuration"`
+	MaxBytes      int      `yaml:"maxBytes" json:"maxBytes"`
+	TlsVersions   []string `yaml:"tlsVersions" json:"tlsVersions"`
+	CipherSuites  []string `yaml:"cipherSuites" json:"cipherSuites"`
+}
+
+type ReverseConfig struct {
+	WhoisRegexp  string `yaml:"whoisRegexp" json:"whoisRegexp"`
+	GeoIP2DbPath string `yaml:"geoip2DbPath" json:"geoip2DbPath"`
+}
+
+// ProcessConfig locates containers of processes owning sockets. CgroupRoot is where the cgroup v2 hierarchy
+// of the node is mounted (empty disables container attribution), it is scanned again after RescanPeriod
+// at most when a cgroup is unknown. ProcRoot is the proc filesystem of the node (requires hostPID), sockets
+// listening before k8spacket started are read from there (empty disables it).
+type ProcessConfig struct {
+	CgroupRoot   string   `yaml:"cgroupRoot" json:"cgroupRoot"`
+	RescanPeriod Duration `yaml:"rescanPeriod" json:"rescanPeriod"`
+	ProcRoot     string   `yaml:"procRoot" json:"procRoot"`
+}
+
+// K8sConfig turns off informers and API calls to run outside a cluster, addresses are not resolved to names then
+type K8sConfig struct {
+	ResourcesDisabled bool `yaml:"resourcesDisabled" json:"resourcesDisabled"`
+}
+
+type NodegraphConfig struct {
+	PersistentDuration Duration               `yaml:"persistentDuration" json:"persistentDuration"`
+	Metrics            NodegraphMetricsConfig `yaml:"metrics" json:"metrics"`
+}
+
+// NodegraphMetricsConfig describes node graph metrics, ProcessLabels fills the src_container, src_process,
+// dst_container and dst_process labels, which are left empty by default as they multiply series
+type NodegraphMetricsConfig struct {
+	Enabled       bool `yaml:"enabled" json:"enabled"`
+	HideSrcPort   bool `yaml:"hideSrcPort" json:"hideSrcPort"`
+	ProcessLabels bool `yaml:"processLabels" json:"processLabels"`
+}
+
+type TlsParserConfig struct {
+	CertificateCacheTTL Duration               `yaml:"certificateCacheTTL" json:"certificateCacheTTL"`
+	Metrics             TlsParserMetricsConfig `yaml:"metrics" json:"metrics"`
+}
+
+type TlsParserMetricsConfig struct {
+	RecordsEnabled      bool `yaml:"recordsEnabled" json:"recordsEnabled"`
+	ExpirationEnabled   bool `yaml:"expirationEnabled" json:"expirationEnabled"`
+	FingerprintsEnabled bool `yaml:"fingerprintsEnabled" json:"fingerprintsEnabled"`
+	AlpnEnabled         bool `yaml:"alpnEnabled" json:"alpnEnabled"`
+}
+
+// DnsConfig describes the dns module. Names resolved by pods are kept at least CacheMinTTL, even when the DNS answer
+// expires earlier, because connections often outlive the TTL. Queries without response within QueryTimeout are forgotten.
+type DnsConfig struct {
+	CacheMinTTL  Duration `yaml:"cacheMinTTL" json:"cacheMinTTL"`
+	QueryTimeout Duration `yaml:"queryTimeout" json:"queryTimeout"`
+}
+
+// HttpParserConfig describes the httpparser module. Paths are turned into templates of at most PathSegments segments,
+// so that IDs do not explode the number of series. Requests without response within RequestTimeout are forgotten.
+type HttpParserConfig struct {
+	PathSegments   int                     `yaml:"pathSegments" json:"pathSegments"`
+	RequestTimeout Duration                `yaml:"requestTimeout" json:"requestTimeout"`
+	Metrics        HttpParserMetricsConfig `yaml:"metrics" json:"metrics"`
+}
+
+type HttpParserMetricsConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+}
+
+type BrokerConfig struct {
+	Tcp    QueueConfig `yaml:"tcp" json:"tcp"`
+	Tls    QueueConfig `yaml:"tls" json:"tls"`
+	Dns    QueueConfig `yaml:"dns" json:"dns"`
+	Http   QueueConfig `yaml:"http" json:"http"`
+	Listen QueueConfig `yaml:"listen" json:"listen"`
+}
+
+// QueueConfig describes the queue between eBPF readers and listeners of one event type.
+// DropPolicy decides what happens when the queue is full: drop-newest, drop-oldest or block.
+type QueueConfig struct {
+	Size       int    `yaml:"size" json:"size"`
+	DropPolicy string `yaml:"dropPolicy" json:"dropPolicy"`
+	Workers    int    `yaml:"workers" json:"workers"`
+}
+
+type ReloadConfig struct {
+	WatchPeriod Duration `yaml:"watchPeriod" json:"watchPeriod"`
+}
+
+// ShutdownConfig limits how long every step of the graceful shutdown may take
+type ShutdownConfig struct {
+	StepTimeout Duration `yaml:"stepTimeout" json:"stepTimeout"`
+}
+
+// RecorderConfig describes the file the recorder module writes events from the broker to, in jsonl or protobuf format
+type RecorderConfig struct {
+	Path   string `yaml:"path" json:"path"`
+	Format string `yaml:"format" json:"format"`
+}
+
+// Duration is a time.Duration written as a string ("10s", "1h") in YAML, JSON and environment variables
+type Duration struct {
+	time.Duration
+}
+
+func (duration Duration) MarshalText() ([]byte, error) {
+	return []byte(duration.String()), nil
+}
+
+func (duration *Duration) UnmarshalText(text []byte) error {
+	value, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	duration.Duration = value
+	return nil
+}
+
+func Default() *Config {
+	return &Config{
+		Api:     ApiConfig{Port: 6676},
+		Log:     LogConfig{Level: "info"},
+		Modules: ModulesConfig{Enabled: []string{"nodegraph", "tlsparser"}},
+		Loader: LoaderConfig{
+			Source: "socketfilter",
+			// veth pairs of bridge based plugins (flannel, kindnet, weave...), Calico, Cilium, GKE, AWS VPC CNI and Azure CNI
+			Interfaces: InterfacesConfig{RefreshPeriod: Duration{10 * time.Second}, Include: []string{"veth*", "cali*", "lxc*", "gke*", "eni*", "azv*"}, Exclude: []string{"lo"}},
+			// the handle spells "kp", filters of other tools usually take handle 1
+			Tc:        TcConfig{AttachMode: "auto", Priority: 1, Handle: 0x6b70},
+			Transport: TransportConfig{Mode: "auto", RingBufferSize: 256 * 1024, PerfBufferSize: 64 * 1024},
+			Replay:    ReplayConfig{Format: "jsonl", Speed: 1},
+			Synthetic: SyntheticConfig{
+				Pods: 20, Services: 5, ExternalHosts: 10,
+				TcpRate: 50, TlsRate: 10,
+				MaxDuration: Duration{2 * time.Second}, MaxBytes: 65536,
+				TlsVersions:  []string{"TLS 1.3", "TLS 1.2"},
+				CipherSuites: []string{"TLS_AES_128_GCM_SHA256", "TLS_AES_256_GCM_SHA384", "TLS_CHACHA20_POLY1305_SHA256", "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+			},
+			Maps:   MapsConfig{Connections: 16384, Handshakes: 4096, FilterAddresses: 4096},
+			Filter: FilterConfig{RefreshPeriod: Duration{30 * time.Second}},
+		},
+		Reverse:    ReverseConfig{WhoisRegexp: "(?:OrgName:|org-name:)\\s*(.*)"},
+		Process:    ProcessConfig{CgroupRoot: "/sys/fs/cgroup", RescanPeriod: Duration{10 * time.Second}, ProcRoot: "/proc"},
+		Nodegraph:  NodegraphConfig{PersistentDuration: Duration{time.Hour}},
+		TlsParser:  TlsParserConfig{CertificateCacheTTL: Duration{24 * time.Hour}},
+		Dns:        DnsConfig{CacheMinTTL: Duration{5 * time.Minute}, QueryTimeout: Duration{5 * time.Second}},
+		HttpParser: HttpParserConfig{PathSegments: 3, RequestTimeout: Duration{time.Minute}},
+		Broker: BrokerConfig{
+			Tcp:  QueueConfig{Size: 4096, DropPolicy: "drop-newest", Workers: 2},
+			Tls:  QueueConfig{Size: 1024, DropPolicy: "drop-newest", Workers: 4},
+			Dns:  QueueConfig{Size: 4096, DropPolicy: "drop-newest", Workers: 1},
+			Http: QueueConfig{Size: 4096, DropPolicy: "drop-newest", Workers: 2},
+			// listening sockets are few but must not be missed, the inventory would keep closed ones forever
+			Listen: QueueConfig{Size: 1024, DropPolicy: "block", Workers: 1},
+		},
+		Reload:   ReloadConfig{WatchPeriod: Duration{10 * time.Second}},
+		Shutdown: ShutdownConfig{StepTimeout: Duration{10 * time.Second}},
+		Recorder: RecorderConfig{Format: "jsonl"},
+	}
+}
+
+// Load builds the configuration from defaults, the YAML file under path (skipped when path is empty)
+// and environment variables, then validates it
+func Load(path string) (*Config, error) {
+	config := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read config file: %w", err)
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("cannot parse config file %s: %w", path, err)
+		}
+	}
+
+	if err := config.applyEnv(); err != nil {
+		return nil, fmt.Errorf("invalid environment variables: %w", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return config, nil
+}
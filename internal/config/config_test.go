@@ -0,0 +1,188 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+
+	cfg, err := Load("")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, Default(), cfg)
+}
+
+func TestLoadFileAndEnv(t *testing.T) {
+
+	path := writeConfigFile(t, `
+api:
+  port: 8080
+loader:
+  source: tc
+  interfaces:
+    command: "echo -n eth0"
+    refreshPeriod: 3s
+nodegraph:
+  persistentDuration: 10s
+  metrics:
+    enabled: true
+tlsparser:
+  certificateCacheTTL: 30s
+`)
+
+	t.Setenv("K8S_PACKET_TCP_LISTENER_PORT", "6677")
+	t.Setenv("K8S_PACKET_TCP_METRICS_HIDE_SRC_PORT", "true")
+	t.Setenv("K8S_PACKET_TCP_METRICS_PROCESS_LABELS", "true")
+	t.Setenv("K8S_PACKET_MODULES_ENABLED", " nodegraph, ")
+	t.Setenv("K8S_PACKET_K8S_RESOURCES_DISABLED", "true")
+
+	cfg, err := Load(path)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 6677, cfg.Api.Port)
+	assert.EqualValues(t, []string{"nodegraph"}, cfg.Modules.Enabled)
+	assert.EqualValues(t, "tc", cfg.Loader.Source)
+	assert.EqualValues(t, "echo -n eth0", cfg.Loader.Interfaces.Command)
+	assert.EqualValues(t, 3*time.Second, cfg.Loader.Interfaces.RefreshPeriod.Duration)
+	assert.EqualValues(t, 10*time.Second, cfg.Nodegraph.PersistentDuration.Duration)
+	assert.True(t, cfg.Nodegraph.Metrics.Enabled)
+	assert.True(t, cfg.Nodegraph.Metrics.HideSrcPort)
+	assert.True(t, cfg.Nodegraph.Metrics.ProcessLabels)
+	assert.True(t, cfg.K8s.ResourcesDisabled)
+	assert.EqualValues(t, 30*time.Second, cfg.TlsParser.CertificateCacheTTL.Duration)
+}
+
+func TestLoadSynthetic(t *testing.T) {
+
+	t.Setenv("K8S_PACKET_LOADER_SOURCE", "synthetic")
+	t.Setenv("K8S_PACKET_SYNTHETIC_SEED", "42")
+	t.Setenv("K8S_PACKET_SYNTHETIC_TCP_RATE", "1000")
+	t.Setenv("K8S_PACKET_SYNTHETIC_TLS_VERSIONS", "TLS 1.2")
+	t.Setenv("K8S_PACKET_SYNTHETIC_CIPHER_SUITES", "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384")
+
+	cfg, err := Load("")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 42, cfg.Loader.Synthetic.Seed)
+	assert.EqualValues(t, 1000, cfg.Loader.Synthetic.TcpRate)
+	assert.EqualValues(t, []string{"TLS 1.2"}, cfg.Loader.Synthetic.TlsVersions)
+	assert.EqualValues(t, []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"}, cfg.Loader.Synthetic.CipherSuites)
+}
+
+func TestLoadReplay(t *testing.T) {
+
+	t.Setenv("K8S_PACKET_LOADER_SOURCE", "replay")
+	t.Setenv("K8S_PACKET_REPLAY_PATH", "events.pb")
+	t.Setenv("K8S_PACKET_REPLAY_FORMAT", "protobuf")
+	t.Setenv("K8S_PACKET_REPLAY_SPEED", "2.5")
+
+	cfg, err := Load("")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, ReplayConfig{Path: "events.pb", Format: "protobuf", Speed: 2.5}, cfg.Loader.Replay)
+}
+
+func TestLoadFilter(t *testing.T) {
+
+	t.Setenv("K8S_PACKET_MAPS_CONNECTIONS", "65536")
+	t.Setenv("K8S_PACKET_FILTER_EXCLUDE_CIDRS", "10.0.0.0/8, fd00::/8")
+	t.Setenv("K8S_PACKET_FILTER_EXCLUDE_PORTS", "9100,10250")
+	t.Setenv("K8S_PACKET_FILTER_EXCLUDE_NAMESPACES", "kube-system")
+
+	cfg, err := Load("")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, MapsConfig{Connections: 65536, Handshakes: 4096, FilterAddresses: 4096}, cfg.Loader.Maps)
+	assert.EqualValues(t, []string{"10.0.0.0/8", "fd00::/8"}, cfg.Loader.Filter.ExcludeCIDRs)
+	assert.EqualValues(t, []int{9100, 10250}, cfg.Loader.Filter.ExcludePorts)
+	assert.EqualValues(t, []string{"kube-system"}, cfg.Loader.Filter.ExcludeNamespaces)
+	assert.EqualValues(t, 30*time.Second, cfg.Loader.Filter.RefreshPeriod.Duration)
+}
+
+func TestLoadErrors(t *testing.T) {
+
+	var tests = []struct {
+		scenario string
+		file     string
+		env      map[string]string
+		error    string
+	}{
+		{"missing file", "", nil, "cannot read config file"},
+		{"unknown field", "api:\n  prot: 8080\n", nil, "field prot not found"},
+		{"bad duration in file", "nodegraph:\n  persistentDuration: ten\n", nil, "cannot parse config file"},
+		{"bad duration in env", "", map[string]string{"K8S_PACKET_TCP_PERSISTENT_DURATION": "ten"}, "K8S_PACKET_TCP_PERSISTENT_DURATION=\"ten\""},
+		{"bad bool in env", "", map[string]string{"K8S_PACKET_TCP_METRICS_ENABLED": "yes please"}, "K8S_PACKET_TCP_METRICS_ENABLED"},
+		{"port", "", map[string]string{"K8S_PACKET_TCP_LISTENER_PORT": "0"}, "api.port: must be between 1 and 65535"},
+		{"log level", "", map[string]string{"LOG_LEVEL": "loud"}, "log.level"},
+		{"loader source", "", map[string]string{"K8S_PACKET_LOADER_SOURCE": "xdp"}, "loader.source: must be one of"},
+		{"tc include", "", map[string]string{"K8S_PACKET_LOADER_SOURCE": "tc", "K8S_PACKET_TCP_LISTENER_INTERFACES_INCLUDE": ""}, "loader.interfaces.include: at least one pattern is required"},
+		{"tc exclude pattern", "", map[string]string{"K8S_PACKET_LOADER_SOURCE": "tc", "K8S_PACKET_TCP_LISTENER_INTERFACES_EXCLUDE": "veth[0-"}, "loader.interfaces.exclude: \"veth[0-\": syntax error in pattern"},
+		{"tc attach mode", "", map[string]string{"K8S_PACKET_LOADER_SOURCE": "tc", "K8S_PACKET_TC_ATTACH_MODE": "replace"}, "loader.tc.attachMode: must be one of"},
+		{"tc priority", "", map[string]string{"K8S_PACKET_LOADER_SOURCE": "tc", "K8S_PACKET_TC_PRIORITY": "0"}, "loader.tc.priority: must be between 1 and 65535"},
+		{"tc handle", "", map[string]string{"K8S_PACKET_LOADER_SOURCE": "tc", "K8S_PACKET_TC_HANDLE": "65536"}, "loader.tc.handle: must be between 1 and 65535"},
+		{"tc refresh period", "", map[string]string{"K8S_PACKET_LOADER_SOURCE": "tc", "K8S_PACKET_TCP_LISTENER_INTERFACES_COMMAND": "echo eth0", "K8S_PACKET_TCP_LISTENER_INTERFACES_REFRESH_PERIOD": "0s"}, "loader.interfaces.refreshPeriod: must be positive"},
+		{"transport mode", "", map[string]string{"K8S_PACKET_TRANSPORT_MODE": "xdp"}, "loader.transport.mode: must be one of"},
+		{"ring buffer size", "", map[string]string{"K8S_PACKET_TRANSPORT_RING_BUFFER_SIZE": "100000"}, "loader.transport.ringBufferSize: must be a power of 2 multiple of the page size"},
+		{"perf buffer size", "", map[string]string{"K8S_PACKET_TRANSPORT_PERF_BUFFER_SIZE": "0"}, "loader.transport.perfBufferSize: must be positive"},
+		{"dns query timeout", "", map[string]string{"K8S_PACKET_DNS_QUERY_TIMEOUT": "0s"}, "dns.queryTimeout: must be positive"},
+		{"http path segments", "", map[string]string{"K8S_PACKET_HTTP_PATH_SEGMENTS": "0"}, "httpparser.pathSegments: must be positive"},
+		{"http request timeout", "", map[string]string{"K8S_PACKET_HTTP_REQUEST_TIMEOUT": "0s"}, "httpparser.requestTimeout: must be positive"},
+		{"whois regexp", "", map[string]string{"K8S_PACKET_REVERSE_WHOIS_REGEXP": "(unclosed"}, "reverse.whoisRegexp"},
+		{"rescan period", "", map[string]string{"K8S_PACKET_PROCESS_RESCAN_PERIOD": "0s"}, "process.rescanPeriod"},
+		{"queue size", "", map[string]string{"K8S_PACKET_BROKER_TLS_QUEUE_SIZE": "0"}, "broker.tls.size: must be positive"},
+		{"drop policy", "", map[string]string{"K8S_PACKET_BROKER_TCP_DROP_POLICY": "lossy"}, "broker.tcp.dropPolicy: must be one of"},
+		{"workers", "", map[string]string{"K8S_PACKET_BROKER_TCP_WORKERS": "0"}, "broker.tcp.workers: must be positive"},
+		{"shutdown step timeout", "", map[string]string{"K8S_PACKET_SHUTDOWN_STEP_TIMEOUT": "0s"}, "shutdown.stepTimeout: must be positive"},
+		{"negative ttl", "", map[string]string{"K8S_PACKET_TLS_CERTIFICATE_CACHE_TTL": "-1m"}, "tlsparser.certificateCacheTTL: must not be negative"},
+		{"replay path", "", map[string]string{"K8S_PACKET_LOADER_SOURCE": "replay"}, "loader.replay.path: required"},
+		{"replay speed", "", map[string]string{"K8S_PACKET_LOADER_SOURCE": "replay", "K8S_PACKET_REPLAY_PATH": "events.jsonl", "K8S_PACKET_REPLAY_SPEED": "-1"}, "loader.replay.speed: must not be negative"},
+		{"bad float in env", "", map[string]string{"K8S_PACKET_REPLAY_SPEED": "fast"}, "K8S_PACKET_REPLAY_SPEED"},
+		{"recorder path", "", map[string]string{"K8S_PACKET_MODULES_ENABLED": "recorder"}, "recorder.path: required"},
+		{"synthetic pods", "", map[string]string{"K8S_PACKET_LOADER_SOURCE": "synthetic", "K8S_PACKET_SYNTHETIC_PODS": "0"}, "loader.synthetic.pods: must be positive"},
+		{"synthetic hosts", "", map[string]string{"K8S_PACKET_LOADER_SOURCE": "synthetic", "K8S_PACKET_SYNTHETIC_SERVICES": "0", "K8S_PACKET_SYNTHETIC_EXTERNAL_HOSTS": "0"}, "loader.synthetic: at least one service or external host is required"},
+		{"synthetic too many hosts", "", map[string]string{"K8S_PACKET_LOADER_SOURCE": "synthetic", "K8S_PACKET_SYNTHETIC_EXTERNAL_HOSTS": "763"}, "loader.synthetic: at most 64000 pods, 64000 services and 762 externalHosts have unique addresses, got 20, 5 and 763"},
+		{"synthetic tls version", "", map[string]string{"K8S_PACKET_LOADER_SOURCE": "synthetic", "K8S_PACKET_SYNTHETIC_TLS_VERSIONS": "TLS 1.4"}, "loader.synthetic.tlsVersions: unknown TLS version \"TLS 1.4\""},
+		{"synthetic cipher suite", "", map[string]string{"K8S_PACKET_LOADER_SOURCE": "synthetic", "K8S_PACKET_SYNTHETIC_CIPHER_SUITES": ""}, "loader.synthetic.cipherSuites: at least one is required"},
+		{"bad int64 in env", "", map[string]string{"K8S_PACKET_SYNTHETIC_SEED": "random"}, "K8S_PACKET_SYNTHETIC_SEED"},
+		{"recorder format", "", map[string]string{"K8S_PACKET_MODULES_ENABLED": "recorder", "K8S_PACKET_RECORDER_PATH": "events", "K8S_PACKET_RECORDER_FORMAT": "csv"}, "recorder.format: must be one of"},
+		{"connections map size", "", map[string]string{"K8S_PACKET_MAPS_CONNECTIONS": "0"}, "loader.maps.connections: must be positive"},
+		{"handshakes map size", "", map[string]string{"K8S_PACKET_MAPS_HANDSHAKES": "-1"}, "loader.maps.handshakes: must be positive"},
+		{"filter addresses map size", "", map[string]string{"K8S_PACKET_MAPS_FILTER_ADDRESSES": "0"}, "loader.maps.filterAddresses: must be positive"},
+		{"filter cidr", "", map[string]string{"K8S_PACKET_FILTER_EXCLUDE_CIDRS": "10.0.0.0"}, "loader.filter.excludeCIDRs"},
+		{"filter port", "", map[string]string{"K8S_PACKET_FILTER_EXCLUDE_PORTS": "70000"}, "loader.filter.excludePorts: must be between 1 and 65535"},
+		{"bad int list in env", "", map[string]string{"K8S_PACKET_FILTER_EXCLUDE_PORTS": "http"}, "K8S_PACKET_FILTER_EXCLUDE_PORTS"},
+		{"filter refresh period", "", map[string]string{"K8S_PACKET_FILTER_REFRESH_PERIOD": "0s"}, "loader.filter.refreshPeriod: must be positive"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+			path := ""
+			if test.scenario == "missing file" {
+				path = filepath.Join(t.TempDir(), "missing.yaml")
+			} else if test.file != "" {
+				path = writeConfigFile(t, test.file)
+			}
+
+			cfg, err := Load(path)
+
+			assert.Nil(t, cfg)
+			assert.ErrorContains(t, err, test.error)
+		})
+	}
+}
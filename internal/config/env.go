@@ -0,0 +1,164 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type envBinding struct {
+	name   string
+	target any
+}
+
+func (config *Config) envBindings() []envBinding {
+	return []envBinding{
+		{"K8S_PACKET_TCP_LISTENER_PORT", &config.Api.Port},
+		{"K8S_PACKET_API_FIELD_SELECTOR", &config.Api.FieldSelector},
+		{"K8S_PACKET_API_LABEL_SELECTOR", &config.Api.LabelSelector},
+		{"LOG_LEVEL", &config.Log.Level},
+		{"K8S_PACKET_MODULES_ENABLED", &config.Modules.Enabled},
+		{"K8S_PACKET_LOADER_SOURCE", &config.Loader.Source},
+		{"K8S_PACKET_TCP_LISTENER_INTERFACES_COMMAND", &config.Loader.Interfaces.Command},
+		{"K8S_PACKET_TCP_LISTENER_INTERFACES_REFRESH_PERIOD", &config.Loader.Interfaces.RefreshPeriod},
+		{"K8S_PACKET_TCP_LISTENER_INTERFACES_INCLUDE", &config.Loader.Interfaces.Include},
+		{"K8S_PACKET_TCP_LISTENER_INTERFACES_EXCLUDE", &config.Loader.Interfaces.Exclude},
+		{"K8S_PACKET_TC_ATTACH_MODE", &config.Loader.Tc.AttachMode},
+		{"K8S_PACKET_TC_PRIORITY", &config.Loader.Tc.Priority},
+		{"K8S_PACKET_TC_HANDLE", &config.Loader.Tc.Handle},
+		{"K8S_PACKET_TRANSPORT_MODE", &config.Loader.Transport.Mode},
+		{"K8S_PACKET_TRANSPORT_RING_BUFFER_SIZE", &config.Loader.Transport.RingBufferSize},
+		{"K8S_PACKET_TRANSPORT_PERF_BUFFER_SIZE", &config.Loader.Transport.PerfBufferSize},
+		{"K8S_PACKET_REPLAY_PATH", &config.Loader.Replay.Path},
+		{"K8S_PACKET_REPLAY_FORMAT", &config.Loader.Replay.Format},
+		{"K8S_PACKET_REPLAY_SPEED", &config.Loader.Replay.Speed},
+		{"K8S_PACKET_SYNTHETIC_SEED", &config.Loader.Synthetic.Seed},
+		{"K8S_PACKET_SYNTHETIC_PODS", &config.Loader.Synthetic.Pods},
+		{"K8S_PACKET_SYNTHETIC_SERVICES", &config.Loader.Synthetic.Services},
+		{"K8S_PACKET_SYNTHETIC_EXTERNAL_HOSTS", &config.Loader.Synthetic.ExternalHosts},
+		{"K8S_PACKET_SYNTHETIC_TCP_RATE", &config.Loader.Synthetic.TcpRate},
+		{"K8S_PACKET_SYNTHETIC_TLS_RATE", &config.Loader.Synthetic.TlsRate},
+		{"K8S_PACKET_SYNTHETIC_MAX_DURATION", &config.Loader.Synthetic.MaxDuration},
+		{"K8S_PACKET_SYNTHETIC_MAX_BYTES", &config.Loader.Synthetic.MaxBytes},
+		{"K8S_PACKET_SYNTHETIC_TLS_VERSIONS", &config.Loader.Synthetic.TlsVersions},
+		{"K8S_PACKET_SYNTHETIC_CIPHER_SUITES", &config.Loader.Synthetic.CipherSuites},
+		{"K8S_PACKET_MAPS_CONNECTIONS", &config.Loader.Maps.Connections},
+		{"K8S_PACKET_MAPS_HANDSHAKES", &config.Loader.Maps.Handshakes},
+		{"K8S_PACKET_MAPS_FILTER_ADDRESSES", &config.Loader.Maps.FilterAddresses},
+		{"K8S_PACKET_FILTER_EXCLUDE_CIDRS", &config.Loader.Filter.ExcludeCIDRs},
+		{"K8S_PACKET_FILTER_EXCLUDE_PORTS", &config.Loader.Filter.ExcludePorts},
+		{"K8S_PACKET_FILTER_EXCLUDE_NAMESPACES", &config.Loader.Filter.ExcludeNamespaces},
+		{"K8S_PACKET_FILTER_REFRESH_PERIOD", &config.Loader.Filter.RefreshPeriod},
+		{"K8S_PACKET_REVERSE_WHOIS_REGEXP", &config.Reverse.WhoisRegexp},
+		{"K8S_PACKET_REVERSE_GEOIP2_DB_PATH", &config.Reverse.GeoIP2DbPath},
+		{"K8S_PACKET_PROCESS_CGROUP_ROOT", &config.Process.CgroupRoot},
+		{"K8S_PACKET_PROCESS_RESCAN_PERIOD", &config.Process.RescanPeriod},
+		{"K8S_PACKET_PROCESS_PROC_ROOT", &config.Process.ProcRoot},
+		{"K8S_PACKET_K8S_RESOURCES_DISABLED", &config.K8s.ResourcesDisabled},
+		{"K8S_PACKET_TCP_PERSISTENT_DURATION", &config.Nodegraph.PersistentDuration},
+		{"K8S_PACKET_TCP_METRICS_ENABLED", &config.Nodegraph.Metrics.Enabled},
+		{"K8S_PACKET_TCP_METRICS_HIDE_SRC_PORT", &config.Nodegraph.Metrics.HideSrcPort},
+		{"K8S_PACKET_TCP_METRICS_PROCESS_LABELS", &config.Nodegraph.Metrics.ProcessLabels},
+		{"K8S_PACKET_TLS_CERTIFICATE_CACHE_TTL", &config.TlsParser.CertificateCacheTTL},
+		{"K8S_PACKET_TLS_RECORDS_METRICS_ENABLED", &config.TlsParser.Metrics.RecordsEnabled},
+		{"K8S_PACKET_TLS_EXPIRATION_METRICS_ENABLED", &config.TlsParser.Metrics.ExpirationEnabled},
+		{"K8S_PACKET_TLS_FINGERPRINT_METRICS_ENABLED", &config.TlsParser.Metrics.FingerprintsEnabled},
+		{"K8S_PACKET_TLS_ALPN_METRICS_ENABLED", &config.TlsParser.Metrics.AlpnEnabled},
+		{"K8S_PACKET_DNS_CACHE_MIN_TTL", &config.Dns.CacheMinTTL},
+		{"K8S_PACKET_DNS_QUERY_TIMEOUT", &config.Dns.QueryTimeout},
+		{"K8S_PACKET_HTTP_PATH_SEGMENTS", &config.HttpParser.PathSegments},
+		{"K8S_PACKET_HTTP_REQUEST_TIMEOUT", &config.HttpParser.RequestTimeout},
+		{"K8S_PACKET_HTTP_METRICS_ENABLED", &config.HttpParser.Metrics.Enabled},
+		{"K8S_PACKET_BROKER_TCP_QUEUE_SIZE", &config.Broker.Tcp.Size},
+		{"K8S_PACKET_BROKER_TCP_DROP_POLICY", &config.Broker.Tcp.DropPolicy},
+		{"K8S_PACKET_BROKER_TCP_WORKERS", &config.Broker.Tcp.Workers},
+		{"K8S_PACKET_BROKER_TLS_QUEUE_SIZE", &config.Broker.Tls.Size},
+		{"K8S_PACKET_BROKER_TLS_DROP_POLICY", &config.Broker.Tls.DropPolicy},
+		{"K8S_PACKET_BROKER_TLS_WORKERS", &config.Broker.Tls.Workers},
+		{"K8S_PACKET_BROKER_DNS_QUEUE_SIZE", &config.Broker.Dns.Size},
+		{"K8S_PACKET_BROKER_DNS_DROP_POLICY", &config.Broker.Dns.DropPolicy},
+		{"K8S_PACKET_BROKER_DNS_WORKERS", &config.Broker.Dns.Workers},
+		{"K8S_PACKET_BROKER_HTTP_QUEUE_SIZE", &config.Broker.Http.Size},
+		{"K8S_PACKET_BROKER_HTTP_DROP_POLICY", &config.Broker.Http.DropPolicy},
+		{"K8S_PACKET_BROKER_HTTP_WORKERS", &config.Broker.Http.Workers},
+		{"K8S_PACKET_BROKER_LISTEN_QUEUE_SIZE", &config.Broker.Listen.Size},
+		{"K8S_PACKET_BROKER_LISTEN_DROP_POLICY", &config.Broker.Listen.DropPolicy},
+		{"K8S_PACKET_BROKER_LISTEN_WORKERS", &config.Broker.Listen.Workers},
+		{"K8S_PACKET_CONFIG_WATCH_PERIOD", &config.Reload.WatchPeriod},
+		{"K8S_PACKET_SHUTDOWN_STEP_TIMEOUT", &config.Shutdown.StepTimeout},
+		{"K8S_PACKET_RECORDER_PATH", &config.Recorder.Path},
+		{"K8S_PACKET_RECORDER_FORMAT", &config.Recorder.Format},
+	}
+}
+
+// applyEnv overrides settings by environment variables which are set, unset variables keep the current value
+func (config *Config) applyEnv() error {
+	var errs []error
+	for _, binding := range config.envBindings() {
+		value, ok := os.LookupEnv(binding.name)
+		if !ok {
+			continue
+		}
+		if err := setValue(binding.target, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s=%q: %w", binding.name, value, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func setValue(target any, value string) error {
+	switch t := target.(type) {
+	case *string:
+		*t = value
+	case *int:
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*t = v
+	case *int64:
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		*t = v
+	case *float64:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		*t = v
+	case *bool:
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*t = v
+	case *[]string:
+		*t = nil
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*t = append(*t, item)
+			}
+		}
+	case *[]int:
+		*t = nil
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			v, err := strconv.Atoi(item)
+			if err != nil {
+				return err
+			}
+			*t = append(*t, v)
+		}
+	case *Duration:
+		return t.UnmarshalText([]byte(value))
+	default:
+		return fmt.Errorf("unsupported type %T", target)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+type Handler struct {
+	store *Store
+}
+
+func NewHandler(store *Store) *Handler {
+	return &Handler{store: store}
+}
+
+// ConfigHandler shows the configuration the running instance actually uses
+func (handler *Handler) ConfigHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(handler.store.Get())
+	if err != nil {
+		slog.Error("[api] Cannot prepare config response", "Error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigHandler(t *testing.T) {
+
+	cfg := Default()
+	cfg.Api.Port = 6677
+
+	req, err := http.NewRequest("GET", "/api/config", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(NewHandler(NewStore(cfg)).ConfigHandler)
+	handler.ServeHTTP(rr, req)
+
+	assert.EqualValues(t, http.StatusOK, rr.Code)
+	assert.EqualValues(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var result map[string]any
+	json.Unmarshal(rr.Body.Bytes(), &result)
+
+	assert.EqualValues(t, 6677, result["api"].(map[string]any)["port"])
+	assert.EqualValues(t, "1h0m0s", result["nodegraph"].(map[string]any)["persistentDuration"])
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	ReloadMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "k8s_packet_config_reload_total",
+			Help: "Kubernetes packet configuration reloads",
+		},
+		[]string{"result"},
+	)
+	ReloadTimestampMetric = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "k8s_packet_config_last_reload_success_timestamp_seconds",
+			Help: "Kubernetes packet timestamp of the last successful configuration reload",
+		},
+	)
+)
+
+type Reloader struct {
+	path    string
+	store   *Store
+	content []byte
+}
+
+func NewReloader(path string, store *Store) *Reloader {
+	reloader := &Reloader{path: path, store: store}
+	if path != "" {
+		reloader.content, _ = os.ReadFile(path)
+	}
+	return reloader
+}
+
+// Reload loads the configuration again and swaps it in the store. Invalid configuration or
+// a change of settings which cannot be applied without a restart is rejected and the old one stays.
+func (reloader *Reloader) Reload() error {
+	cfg, err := Load(reloader.path)
+	if err == nil {
+		err = checkRestartRequired(reloader.store.Get(), cfg)
+	}
+	if err != nil {
+		ReloadMetric.WithLabelValues("failure").Inc()
+		slog.Error("[config] Configuration reload rejected, keeping the current one", "Error", err)
+		return err
+	}
+	reloader.store.Set(cfg)
+	ReloadMetric.WithLabelValues("success").Inc()
+	ReloadTimestampMetric.SetToCurrentTime()
+	slog.Info("[config] Configuration reloaded")
+	return nil
+}
+
+// Watch reloads the configuration on SIGHUP and when the content of the config file changes
+func (reloader *Reloader) Watch(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		var tick <-chan time.Time
+		if period := reloader.store.Get().Reload.WatchPeriod.Duration; reloader.path != "" && period > 0 {
+			tick = time.After(period)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			slog.Info("[config] Receive SIGHUP, reloading configuration...")
+			reloader.content, _ = os.ReadFile(reloader.path)
+			_ = reloader.Reload()
+		case <-tick:
+			content, err := os.ReadFile(reloader.path)
+			if err != nil || bytes.Equal(content, reloader.content) {
+				continue
+			}
+			slog.Info("[config] Config file changed, reloading configuration...", "Path", reloader.path)
+			reloader.content = content
+			_ = reloader.Reload()
+		}
+	}
+}
+
+func checkRestartRequired(current *Config, cfg *Config) error {
+	var errs []error
+	if current.Api.Port != cfg.Api.Port {
+		errs = append(errs, fmt.Errorf("api.port: cannot be changed without restart, current %d", current.Api.Port))
+	}
+	if current.Loader.Source != cfg.Loader.Source {
+		errs = append(errs, fmt.Errorf("loader.source: cannot be changed without restart, current %q", current.Loader.Source))
+	}
+	if current.Loader.Transport != cfg.Loader.Transport {
+		errs = append(errs, fmt.Errorf("loader.transport: cannot be changed without restart, current %q", current.Loader.Transport.Mode))
+	}
+	if current.Loader.Replay.Path != cfg.Loader.Replay.Path || current.Loader.Replay.Format != cfg.Loader.Replay.Format {
+		errs = append(errs, fmt.Errorf("loader.replay: path and format cannot be changed without restart, current %q and %q", current.Loader.Replay.Path, current.Loader.Replay.Format))
+	}
+	if current, synthetic := current.Loader.Synthetic, cfg.Loader.Synthetic; current.Seed != synthetic.Seed || current.Pods != synthetic.Pods ||
+		current.Services != synthetic.Services || current.ExternalHosts != synthetic.ExternalHosts {
+		errs = append(errs, errors.New("loader.synthetic: seed, pods, services and externalHosts cannot be changed without restart"))
+	}
+	if current.Loader.Tc != cfg.Loader.Tc {
+		errs = append(errs, fmt.Errorf("loader.tc: cannot be changed without restart, current %q", current.Loader.Tc.AttachMode))
+	}
+	if current.Loader.Maps != cfg.Loader.Maps {
+		errs = append(errs, fmt.Errorf("loader.maps: cannot be changed without restart, current %+v", current.Loader.Maps))
+	}
+	if current.Recorder != cfg.Recorder {
+		errs = append(errs, fmt.Errorf("recorder: cannot be changed without restart, current %q and %q", current.Recorder.Path, current.Recorder.Format))
+	}
+	if !slices.Equal(current.Modules.Enabled, cfg.Modules.Enabled) {
+		errs = append(errs, fmt.Errorf("modules.enabled: cannot be changed without restart, current %v", current.Modules.Enabled))
+	}
+	if err := checkQueueRestartRequired("broker.tcp", current.Broker.Tcp, cfg.Broker.Tcp); err != nil {
+		errs = append(errs, err)
+	}
+	if err := checkQueueRestartRequired("broker.tls", current.Broker.Tls, cfg.Broker.Tls); err != nil {
+		errs = append(errs, err)
+	}
+	if err := checkQueueRestartRequired("broker.dns", current.Broker.Dns, cfg.Broker.Dns); err != nil {
+		errs = append(errs, err)
+	}
+	if err := checkQueueRestartRequired("broker.http", current.Broker.Http, cfg.Broker.Http); err != nil {
+		errs = append(errs, err)
+	}
+	if err := checkQueueRestartRequired("broker.listen", current.Broker.Listen, cfg.Broker.Listen); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// drop policy of a queue is read on every event, but the queue itself and its workers are created once
+func checkQueueRestartRequired(name string, current QueueConfig, queue QueueConfig) error {
+	if current.Size != queue.Size || current.Workers != queue.Workers {
+		return fmt.Errorf("%s: size and workers cannot be changed without restart, current %d and %d", name, current.Size, current.Workers)
+	}
+	return nil
+}
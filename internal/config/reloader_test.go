@@ -0,0 +1,82 @@
+package config
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReload(t *testing.T) {
+
+	var tests = []struct {
+		scenario string
+		file     string
+		error    string
+		ttl      time.Duration
+	}{
+		{"valid", "tlsparser:\n  certificateCacheTTL: 1m\n", "", time.Minute},
+		{"invalid", "tlsparser:\n  certificateCacheTTL: -1m\n", "tlsparser.certificateCacheTTL: must not be negative", 24 * time.Hour},
+		{"unparsable", "tlsparser: [\n", "cannot parse config file", 24 * time.Hour},
+		{"restart required", "api:\n  port: 8080\n", "api.port: cannot be changed without restart", 24 * time.Hour},
+		{"queue restart required", "broker:\n  tls:\n    size: 10\n", "broker.tls: size and workers cannot be changed without restart", 24 * time.Hour},
+		{"transport restart required", "loader:\n  transport:\n    mode: perf\n", "loader.transport: cannot be changed without restart", 24 * time.Hour},
+		{"tc restart required", "loader:\n  tc:\n    attachMode: clsact\n", "loader.tc: cannot be changed without restart", 24 * time.Hour},
+		{"maps restart required", "loader:\n  maps:\n    connections: 100\n", "loader.maps: cannot be changed without restart", 24 * time.Hour},
+		{"filter", "loader:\n  filter:\n    excludePorts: [9100]\n", "", 24 * time.Hour},
+		{"recorder restart required", "recorder:\n  format: protobuf\n", "recorder: cannot be changed without restart", 24 * time.Hour},
+		{"drop policy", "broker:\n  tls:\n    dropPolicy: block\n", "", 24 * time.Hour},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			path := writeConfigFile(t, "")
+			store := NewStore(Default())
+			reloader := NewReloader(path, store)
+			os.WriteFile(path, []byte(test.file), 0600)
+
+			successes := testutil.ToFloat64(ReloadMetric.WithLabelValues("success"))
+			failures := testutil.ToFloat64(ReloadMetric.WithLabelValues("failure"))
+
+			err := reloader.Reload()
+
+			if test.error == "" {
+				assert.NoError(t, err)
+				assert.EqualValues(t, successes+1, testutil.ToFloat64(ReloadMetric.WithLabelValues("success")))
+			} else {
+				assert.ErrorContains(t, err, test.error)
+				assert.EqualValues(t, failures+1, testutil.ToFloat64(ReloadMetric.WithLabelValues("failure")))
+			}
+			assert.EqualValues(t, test.ttl, store.Get().TlsParser.CertificateCacheTTL.Duration)
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+
+	path := writeConfigFile(t, "reload:\n  watchPeriod: 50ms\n")
+	cfg, _ := Load(path)
+	store := NewStore(cfg)
+	reloader := NewReloader(path, store)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reloader.Watch(ctx)
+
+	os.WriteFile(path, []byte("reload:\n  watchPeriod: 50ms\nnodegraph:\n  persistentDuration: 5s\n"), 0600)
+
+	assert.Eventually(t, func() bool {
+		return store.Get().Nodegraph.PersistentDuration.Duration == 5*time.Second
+	}, time.Second*2, time.Millisecond*10)
+
+	t.Setenv("K8S_PACKET_TCP_METRICS_ENABLED", "true")
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+
+	assert.Eventually(t, func() bool {
+		return store.Get().Nodegraph.Metrics.Enabled
+	}, time.Second*2, time.Millisecond*10)
+}
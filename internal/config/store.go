@@ -0,0 +1,41 @@
+package config
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Store keeps the current configuration. Components read it with Get on every use, so a reload
+// replaces all settings at once and nobody sees a mix of the old and the new configuration.
+type Store struct {
+	current   atomic.Pointer[Config]
+	mu        sync.Mutex
+	observers []func(config *Config)
+}
+
+func NewStore(config *Config) *Store {
+	store := &Store{}
+	store.current.Store(config)
+	return store
+}
+
+func (store *Store) Get() *Config {
+	return store.current.Load()
+}
+
+// OnChange registers an observer called after every swap, for settings which have to be applied
+// actively (metric registration, log level) instead of being read on every use
+func (store *Store) OnChange(observer func(config *Config)) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.observers = append(store.observers, observer)
+}
+
+func (store *Store) Set(config *Config) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.current.Store(config)
+	for _, observer := range store.observers {
+		observer(config)
+	}
+}
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore(t *testing.T) {
+
+	cfg := Default()
+	store := NewStore(cfg)
+
+	assert.Same(t, cfg, store.Get())
+
+	var observed []*Config
+	store.OnChange(func(config *Config) {
+		observed = append(observed, config)
+	})
+
+	newCfg := Default()
+	newCfg.Nodegraph.Metrics.Enabled = true
+	store.Set(newCfg)
+
+	assert.Same(t, newCfg, store.Get())
+	assert.Equal(t, []*Config{newCfg}, observed)
+}
@@ -0,0 +1,274 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+
+	"github.com/k8spacket/k8spacket/internal/modules/tlsparser/dict"
+)
+
+var loaderSources = []string{"tc", "socketfilter", "replay", "synthetic"}
+var transportModes = []string{"auto", "ringbuf", "perf"}
+var tcAttachModes = []string{"auto", "tcx", "clsact"}
+var recordingFormats = []string{"jsonl", "protobuf"}
+var dropPolicies = []string{"drop-newest", "drop-oldest", "block"}
+
+const maxExcludedPorts = 256
+
+// synthetic addresses are unique up to 250 per /24 in 10.244.0.0/16 and 10.96.0.0/16 and 254 in each of the three
+// documentation ranges for external hosts
+const (
+	maxSyntheticPods          = 256 * 250
+	maxSyntheticServices      = 256 * 250
+	maxSyntheticExternalHosts = 3 * 254
+)
+
+// Validate reports every invalid setting at once, so a broken config can be fixed in one go
+func (config *Config) Validate() error {
+	var errs []error
+
+	if config.Api.Port < 1 || config.Api.Port > 65535 {
+		errs = append(errs, fmt.Errorf("api.port: must be between 1 and 65535, got %d", config.Api.Port))
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(config.Log.Level)); err != nil {
+		errs = append(errs, fmt.Errorf("log.level: %w", err))
+	}
+
+	if !slices.Contains(loaderSources, config.Loader.Source) {
+		errs = append(errs, fmt.Errorf("loader.source: must be one of %v, got %q", loaderSources, config.Loader.Source))
+	}
+	if config.Loader.Source == "tc" {
+		errs = append(errs, validateInterfaces(config.Loader.Interfaces)...)
+		errs = append(errs, validateTc(config.Loader.Tc)...)
+	}
+
+	if config.Loader.Source == "tc" || config.Loader.Source == "socketfilter" {
+		errs = append(errs, validateTransport(config.Loader.Transport)...)
+	}
+
+	if config.Loader.Source == "replay" {
+		if config.Loader.Replay.Path == "" {
+			errs = append(errs, errors.New("loader.replay.path: required when loader.source is replay"))
+		}
+		if !slices.Contains(recordingFormats, config.Loader.Replay.Format) {
+			errs = append(errs, fmt.Errorf("loader.replay.format: must be one of %v, got %q", recordingFormats, config.Loader.Replay.Format))
+		}
+		if config.Loader.Replay.Speed < 0 {
+			errs = append(errs, fmt.Errorf("loader.replay.speed: must not be negative, got %g", config.Loader.Replay.Speed))
+		}
+	}
+
+	if config.Loader.Source == "synthetic" {
+		errs = append(errs, validateSynthetic(config.Loader.Synthetic)...)
+	}
+
+	errs = append(errs, validateMaps(config.Loader.Maps)...)
+	errs = append(errs, validateFilter(config.Loader.Filter)...)
+
+	if _, err := regexp.Compile(config.Reverse.WhoisRegexp); err != nil {
+		errs = append(errs, fmt.Errorf("reverse.whoisRegexp: %w", err))
+	}
+
+	if config.Process.RescanPeriod.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("process.rescanPeriod: must be positive, got %s", config.Process.RescanPeriod))
+	}
+
+	if config.Nodegraph.PersistentDuration.Duration < 0 {
+		errs = append(errs, fmt.Errorf("nodegraph.persistentDuration: must not be negative, got %s", config.Nodegraph.PersistentDuration))
+	}
+
+	if config.TlsParser.CertificateCacheTTL.Duration < 0 {
+		errs = append(errs, fmt.Errorf("tlsparser.certificateCacheTTL: must not be negative, got %s", config.TlsParser.CertificateCacheTTL))
+	}
+
+	if config.Dns.CacheMinTTL.Duration < 0 {
+		errs = append(errs, fmt.Errorf("dns.cacheMinTTL: must not be negative, got %s", config.Dns.CacheMinTTL))
+	}
+	if config.Dns.QueryTimeout.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("dns.queryTimeout: must be positive, got %s", config.Dns.QueryTimeout))
+	}
+
+	if config.HttpParser.PathSegments < 1 {
+		errs = append(errs, fmt.Errorf("httpparser.pathSegments: must be positive, got %d", config.HttpParser.PathSegments))
+	}
+	if config.HttpParser.RequestTimeout.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("httpparser.requestTimeout: must be positive, got %s", config.HttpParser.RequestTimeout))
+	}
+
+	errs = append(errs, validateQueue("broker.tcp", config.Broker.Tcp)...)
+	errs = append(errs, validateQueue("broker.tls", config.Broker.Tls)...)
+	errs = append(errs, validateQueue("broker.dns", config.Broker.Dns)...)
+	errs = append(errs, validateQueue("broker.http", config.Broker.Http)...)
+	errs = append(errs, validateQueue("broker.listen", config.Broker.Listen)...)
+
+	if config.Reload.WatchPeriod.Duration < 0 {
+		errs = append(errs, fmt.Errorf("reload.watchPeriod: must not be negative, got %s", config.Reload.WatchPeriod))
+	}
+
+	if config.Shutdown.StepTimeout.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("shutdown.stepTimeout: must be positive, got %s", config.Shutdown.StepTimeout))
+	}
+
+	if slices.Contains(config.Modules.Enabled, "recorder") {
+		if config.Recorder.Path == "" {
+			errs = append(errs, errors.New("recorder.path: required when the recorder module is enabled"))
+		}
+		if !slices.Contains(recordingFormats, config.Recorder.Format) {
+			errs = append(errs, fmt.Errorf("recorder.format: must be one of %v, got %q", recordingFormats, config.Recorder.Format))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateQueue(name string, queue QueueConfig) []error {
+	var errs []error
+	if queue.Size < 1 {
+		errs = append(errs, fmt.Errorf("%s.size: must be positive, got %d", name, queue.Size))
+	}
+	if !slices.Contains(dropPolicies, queue.DropPolicy) {
+		errs = append(errs, fmt.Errorf("%s.dropPolicy: must be one of %v, got %q", name, dropPolicies, queue.DropPolicy))
+	}
+	if queue.Workers < 1 {
+		errs = append(errs, fmt.Errorf("%s.workers: must be positive, got %d", name, queue.Workers))
+	}
+	return errs
+}
+
+func validateTransport(transport TransportConfig) []error {
+	var errs []error
+	if !slices.Contains(transportModes, transport.Mode) {
+		errs = append(errs, fmt.Errorf("loader.transport.mode: must be one of %v, got %q", transportModes, transport.Mode))
+	}
+	// the kernel accepts ring buffers of a power of 2 pages only
+	pageSize := os.Getpagesize()
+	if size := transport.RingBufferSize; size < pageSize || size%pageSize != 0 || size&(size-1) != 0 {
+		errs = append(errs, fmt.Errorf("loader.transport.ringBufferSize: must be a power of 2 multiple of the page size %d, got %d", pageSize, size))
+	}
+	if transport.PerfBufferSize < 1 {
+		errs = append(errs, fmt.Errorf("loader.transport.perfBufferSize: must be positive, got %d", transport.PerfBufferSize))
+	}
+	return errs
+}
+
+func validateInterfaces(interfaces InterfacesConfig) []error {
+	var errs []error
+	if interfaces.RefreshPeriod.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("loader.interfaces.refreshPeriod: must be positive, got %s", interfaces.RefreshPeriod))
+	}
+	if interfaces.Command == "" && len(interfaces.Include) == 0 {
+		errs = append(errs, errors.New("loader.interfaces.include: at least one pattern is required when loader.interfaces.command is not set"))
+	}
+	for _, pattern := range interfaces.Include {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Errorf("loader.interfaces.include: %q: %w", pattern, err))
+		}
+	}
+	for _, pattern := range interfaces.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Errorf("loader.interfaces.exclude: %q: %w", pattern, err))
+		}
+	}
+	return errs
+}
+
+func validateTc(tc TcConfig) []error {
+	var errs []error
+	if !slices.Contains(tcAttachModes, tc.AttachMode) {
+		errs = append(errs, fmt.Errorf("loader.tc.attachMode: must be one of %v, got %q", tcAttachModes, tc.AttachMode))
+	}
+	if tc.Priority < 1 || tc.Priority > 65535 {
+		errs = append(errs, fmt.Errorf("loader.tc.priority: must be between 1 and 65535, got %d", tc.Priority))
+	}
+	if tc.Handle < 1 || tc.Handle > 65535 {
+		errs = append(errs, fmt.Errorf("loader.tc.handle: must be between 1 and 65535, got %d", tc.Handle))
+	}
+	return errs
+}
+
+func validateMaps(maps MapsConfig) []error {
+	var errs []error
+	if maps.Connections < 1 {
+		errs = append(errs, fmt.Errorf("loader.maps.connections: must be positive, got %d", maps.Connections))
+	}
+	if maps.Handshakes < 1 {
+		errs = append(errs, fmt.Errorf("loader.maps.handshakes: must be positive, got %d", maps.Handshakes))
+	}
+	if maps.FilterAddresses < 1 {
+		errs = append(errs, fmt.Errorf("loader.maps.filterAddresses: must be positive, got %d", maps.FilterAddresses))
+	}
+	return errs
+}
+
+func validateFilter(filter FilterConfig) []error {
+	var errs []error
+	for _, cidr := range filter.ExcludeCIDRs {
+		if _, err := netip.ParsePrefix(cidr); err != nil {
+			errs = append(errs, fmt.Errorf("loader.filter.excludeCIDRs: %w", err))
+		}
+	}
+	// size of the excluded_ports map of eBPF programs
+	if len(filter.ExcludePorts) > maxExcludedPorts {
+		errs = append(errs, fmt.Errorf("loader.filter.excludePorts: at most %d ports, got %d", maxExcludedPorts, len(filter.ExcludePorts)))
+	}
+	for _, port := range filter.ExcludePorts {
+		if port < 1 || port > 65535 {
+			errs = append(errs, fmt.Errorf("loader.filter.excludePorts: must be between 1 and 65535, got %d", port))
+		}
+	}
+	if filter.RefreshPeriod.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("loader.filter.refreshPeriod: must be positive, got %s", filter.RefreshPeriod))
+	}
+	return errs
+}
+
+func validateSynthetic(synthetic SyntheticConfig) []error {
+	var errs []error
+	if synthetic.Pods < 1 {
+		errs = append(errs, fmt.Errorf("loader.synthetic.pods: must be positive, got %d", synthetic.Pods))
+	}
+	if synthetic.Services < 0 || synthetic.ExternalHosts < 0 {
+		errs = append(errs, fmt.Errorf("loader.synthetic: services and externalHosts must not be negative, got %d and %d", synthetic.Services, synthetic.ExternalHosts))
+	}
+	if synthetic.Pods > maxSyntheticPods || synthetic.Services > maxSyntheticServices || synthetic.ExternalHosts > maxSyntheticExternalHosts {
+		errs = append(errs, fmt.Errorf("loader.synthetic: at most %d pods, %d services and %d externalHosts have unique addresses, got %d, %d and %d",
+			maxSyntheticPods, maxSyntheticServices, maxSyntheticExternalHosts, synthetic.Pods, synthetic.Services, synthetic.ExternalHosts))
+	}
+	if synthetic.Services+synthetic.ExternalHosts < 1 {
+		errs = append(errs, errors.New("loader.synthetic: at least one service or external host is required"))
+	}
+	if synthetic.TcpRate < 0 || synthetic.TlsRate < 0 {
+		errs = append(errs, fmt.Errorf("loader.synthetic: tcpRate and tlsRate must not be negative, got %g and %g", synthetic.TcpRate, synthetic.TlsRate))
+	}
+	if synthetic.MaxDuration.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("loader.synthetic.maxDuration: must be positive, got %s", synthetic.MaxDuration))
+	}
+	if synthetic.MaxBytes < 1 {
+		errs = append(errs, fmt.Errorf("loader.synthetic.maxBytes: must be positive, got %d", synthetic.MaxBytes))
+	}
+	if len(synthetic.TlsVersions) == 0 {
+		errs = append(errs, errors.New("loader.synthetic.tlsVersions: at least one is required"))
+	}
+	for _, version := range synthetic.TlsVersions {
+		if _, ok := dict.TLSVersionCode(version); !ok {
+			errs = append(errs, fmt.Errorf("loader.synthetic.tlsVersions: unknown TLS version %q", version))
+		}
+	}
+	if len(synthetic.CipherSuites) == 0 {
+		errs = append(errs, errors.New("loader.synthetic.cipherSuites: at least one is required"))
+	}
+	for _, cipherSuite := range synthetic.CipherSuites {
+		if _, ok := dict.CipherSuiteCode(cipherSuite); !ok {
+			errs = append(errs, fmt.Errorf("loader.synthetic.cipherSuites: unknown cipher suite %q", cipherSuite))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,66 @@
+#include "vmlinux.h"
+#include "bpf_helpers.h"
+#include "bpf_endian.h"
+
+#define ETH_HLEN 14
+#define ETH_P_IP 0x0800
+#define ETH_P_IPV6 0x86DD
+#define IP_OFFSET 0x1fff
+#define DNS_PORT 53
+
+// ports of the UDP or TCP header at offset, DNS goes through port 53 on either end
+static __always_inline bool dns_ports(struct __sk_buff *skb, __u32 offset) {
+    __be16 ports[2];
+    if (bpf_skb_load_bytes(skb, offset, ports, sizeof(ports)) != 0)
+        return false;
+    return ports[0] == bpf_htons(DNS_PORT) || ports[1] == bpf_htons(DNS_PORT);
+}
+
+// accepts UDP and TCP packets from or to port 53 over IPv4 and IPv6,
+// packets are kept whole for the raw socket and DNS messages are parsed in user space
+SEC("socket/dns_filter")
+int dns_filter(struct __sk_buff *skb) {
+
+    __be16 proto;
+    __u8 ip_proto;
+
+    if (bpf_skb_load_bytes(skb, offsetof(struct ethhdr, h_proto), &proto, sizeof(proto)) != 0)
+        return 0;
+    if (proto == bpf_htons(ETH_P_IP)) {
+        if (bpf_skb_load_bytes(skb, ETH_HLEN + offsetof(struct iphdr, protocol), &ip_proto, sizeof(ip_proto)) != 0)
+            return 0;
+        if (ip_proto != IPPROTO_UDP && ip_proto != IPPROTO_TCP)
+            return 0;
+
+        // ports are in the first fragment only
+        __be16 frag_off;
+        if (bpf_skb_load_bytes(skb, ETH_HLEN + offsetof(struct iphdr, frag_off), &frag_off, sizeof(frag_off)) != 0)
+            return 0;
+        if (frag_off & bpf_htons(IP_OFFSET))
+            return 0;
+
+        // IPv4 header length is variable
+        __u8 hdr_len;
+        if (bpf_skb_load_bytes(skb, ETH_HLEN, &hdr_len, sizeof(hdr_len)) != 0)
+            return 0;
+        hdr_len &= 0x0f;
+        hdr_len *= 4;
+        if (!dns_ports(skb, ETH_HLEN + hdr_len))
+            return 0;
+    } else if (proto == bpf_htons(ETH_P_IPV6)) {
+        // extension headers are not followed
+        if (bpf_skb_load_bytes(skb, ETH_HLEN + offsetof(struct ipv6hdr, nexthdr), &ip_proto, sizeof(ip_proto)) != 0)
+            return 0;
+        if (ip_proto != IPPROTO_UDP && ip_proto != IPPROTO_TCP)
+            return 0;
+        if (!dns_ports(skb, ETH_HLEN + sizeof(struct ipv6hdr)))
+            return 0;
+    } else {
+        return 0;
+    }
+
+    // the return value is the number of bytes to keep, all of them
+    return skb->len;
+}
+
+char __license[] SEC("license") = "Dual MIT/GPL";
@@ -0,0 +1,7 @@
+package ebpf_dns
+
+import "context"
+
+type Dns interface {
+	Init(ctx context.Context)
+}
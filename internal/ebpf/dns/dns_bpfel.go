@@ -0,0 +1,129 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build 386 || amd64 || arm || arm64 || loong64 || mips64le || mipsle || ppc64le || riscv64 || wasm
+
+package ebpf_dns
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+// loadDns returns the embedded CollectionSpec for dns.
+func loadDns() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_DnsBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load dns: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadDnsObjects loads dns and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*dnsObjects
+//	*dnsPrograms
+//	*dnsMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadDnsObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadDns()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// dnsSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type dnsSpecs struct {
+	dnsProgramSpecs
+	dnsMapSpecs
+	dnsVariableSpecs
+}
+
+// dnsProgramSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type dnsProgramSpecs struct {
+	DnsFilter *ebpf.ProgramSpec `ebpf:"dns_filter"`
+}
+
+// dnsMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type dnsMapSpecs struct {
+}
+
+// dnsVariableSpecs contains global variables before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type dnsVariableSpecs struct {
+}
+
+// dnsObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadDnsObjects or ebpf.CollectionSpec.LoadAndAssign.
+type dnsObjects struct {
+	dnsPrograms
+	dnsMaps
+	dnsVariables
+}
+
+func (o *dnsObjects) Close() error {
+	return _DnsClose(
+		&o.dnsPrograms,
+		&o.dnsMaps,
+	)
+}
+
+// dnsMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadDnsObjects or ebpf.CollectionSpec.LoadAndAssign.
+type dnsMaps struct {
+}
+
+func (m *dnsMaps) Close() error {
+	return _DnsClose()
+}
+
+// dnsVariables contains all global variables after they have been loaded into the kernel.
+//
+// It can be passed to loadDnsObjects or ebpf.CollectionSpec.LoadAndAssign.
+type dnsVariables struct {
+}
+
+// dnsPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadDnsObjects or ebpf.CollectionSpec.LoadAndAssign.
+type dnsPrograms struct {
+	DnsFilter *ebpf.Program `ebpf:"dns_filter"`
+}
+
+func (p *dnsPrograms) Close() error {
+	return _DnsClose(
+		p.DnsFilter,
+	)
+}
+
+func _DnsClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed dns_bpfel.o
+var _DnsBytes []byte
@@ -0,0 +1,93 @@
+package ebpf_dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/cilium/ebpf"
+
+	"github.com/k8spacket/k8spacket/internal/broker"
+	ebpf_tools "github.com/k8spacket/k8spacket/internal/ebpf/tools"
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/k8spacket/k8spacket/internal/status"
+	"golang.org/x/sys/unix"
+)
+
+const component = "dns"
+
+// maximum size of a captured frame, DNS messages over UDP rarely exceed a few kilobytes
+const frameSize = 64 * 1024
+
+// how often the read loop wakes up to check whether it should exit
+var readTimeout = time.Second
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -go-package ebpf_dns dns ./bpf/dns.bpf.c
+
+type EbpfDns struct {
+	Broker broker.Broker
+}
+
+func (ebpfDns *EbpfDns) Init(ctx context.Context) {
+
+	status.Report(component, status.Starting, "")
+
+	// Load pre-compiled programs into the kernel.
+	objs := dnsObjects{}
+	if err := loadDnsObjects(&objs, nil); err != nil {
+		var verr *ebpf.VerifierError
+		if errors.As(err, &verr) {
+			slog.Error("[dns] Loading program", "Error", fmt.Sprintf("%+v", verr))
+		} else {
+			slog.Error("[dns] Loading program", "Error", err)
+		}
+		status.Report(component, status.Down, "cannot load program: "+err.Error())
+		return
+	}
+	defer objs.Close()
+
+	fd, err := ebpf_tools.OpenRawSocket(objs.DnsFilter, readTimeout)
+	if err != nil {
+		slog.Error("[dns] Cannot open socket", "Error", err)
+		status.Report(component, status.Down, err.Error())
+		return
+	}
+	defer unix.Close(fd)
+	status.Report(component, status.Up, "")
+
+	frame := make([]byte, frameSize)
+	for ctx.Err() == nil {
+		n, _, err := unix.Recvfrom(fd, frame, 0)
+		if err != nil {
+			if errors.Is(err, unix.EAGAIN) || errors.Is(err, unix.EINTR) {
+				continue
+			}
+			ebpf_tools.ReadErrorsMetric.WithLabelValues("dns", ebpf_tools.AnyInterface).Inc()
+			slog.Error("[dns] Reading from socket", "Error", err)
+			continue
+		}
+		event, ok, err := parsePacket(frame[:n], time.Now())
+		if err != nil {
+			ebpf_tools.ParseErrorsMetric.WithLabelValues("dns", ebpf_tools.AnyInterface).Inc()
+			slog.Debug("[dns] Parsing packet", "Error", err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		ebpf_tools.EventsMetric.WithLabelValues("dns").Inc()
+
+		distribute(event, ebpfDns)
+	}
+
+	slog.Info("[dns] Closed gracefully")
+}
+
+func distribute(event modules.DNSEvent, ebpfDns *EbpfDns) {
+	// the resolver is named by the k8s resources or whois, never by names it resolved itself
+	ebpf_tools.EnrichAddress(&event.Client)
+	ebpf_tools.EnrichAddress(&event.Server)
+	ebpfDns.Broker.DNSEvent(event)
+}
@@ -0,0 +1,91 @@
+package ebpf_dns
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/cilium/ebpf"
+	"github.com/k8spacket/k8spacket/internal/broker"
+	ebpf_tools "github.com/k8spacket/k8spacket/internal/ebpf/tools"
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+)
+
+type fakeBrokerDns struct {
+	broker.Broker
+	last modules.DNSEvent
+}
+
+func (f *fakeBrokerDns) DNSEvent(event modules.DNSEvent) { f.last = event }
+
+func TestDistribute(t *testing.T) {
+	event := modules.DNSEvent{
+		Client:   modules.Address{Addr: "10.244.1.5", Port: 40000},
+		Server:   modules.Address{Addr: "10.96.0.10", Port: 53},
+		ID:       4242,
+		Response: true,
+		Name:     "db.example.com",
+		Type:     "A",
+		RCode:    "NOERROR",
+		Answers:  []modules.DNSAnswer{{Addr: "203.0.113.7", TTL: 30}},
+		Time:     time.Now(),
+	}
+
+	fb := &fakeBrokerDns{}
+	distribute(event, &EbpfDns{Broker: fb})
+
+	got := fb.last
+	assert.Equal(t, "10.244.1.5", got.Client.Addr)
+	assert.Equal(t, "N/A", got.Client.Name)
+	assert.Equal(t, "10.96.0.10", got.Server.Addr)
+	assert.Equal(t, "N/A", got.Server.Name)
+	assert.Equal(t, event.Answers, got.Answers)
+	assert.Equal(t, "db.example.com", got.Name)
+}
+
+// every program of the generated bindings is in the objects of both byte orders
+func TestObjects(t *testing.T) {
+	for _, object := range []string{"dns_bpfel.o", "dns_bpfeb.o"} {
+		spec, err := ebpf.LoadCollectionSpec(object)
+		assert.Nil(t, err, object)
+		assert.Nil(t, spec.Assign(&dnsSpecs{}), object)
+	}
+}
+
+// the program passes the verifier of the running kernel and keeps DNS packets only, loading it requires root
+func TestLoad(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("loading eBPF programs requires root")
+	}
+	objs := dnsObjects{}
+	assert.Nil(t, loadDnsObjects(&objs, nil))
+	defer objs.Close()
+
+	// datagrams sent over loopback reach the raw socket only when they go to port 53
+	fd, err := ebpf_tools.OpenRawSocket(objs.DnsFilter, 100*time.Millisecond)
+	assert.Nil(t, err)
+	defer unix.Close(fd)
+	for _, address := range []string{"127.0.0.1:53", "127.0.0.1:443", "[::1]:53"} {
+		conn, err := net.Dial("udp", address)
+		assert.Nil(t, err)
+		conn.Write([]byte("k8spacket " + address))
+		conn.Close()
+	}
+	var received []string
+	frame := make([]byte, frameSize)
+	for {
+		n, _, err := unix.Recvfrom(fd, frame, 0)
+		if err != nil {
+			break
+		}
+		if _, payload, ok := bytes.Cut(frame[:n], []byte("k8spacket ")); ok && !slices.Contains(received, string(payload)) {
+			received = append(received, string(payload))
+		}
+	}
+	assert.ElementsMatch(t, []string{"127.0.0.1:53", "[::1]:53"}, received)
+}
@@ -0,0 +1,144 @@
+package ebpf_dns
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	ebpf_tools "github.com/k8spacket/k8spacket/internal/ebpf/tools"
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"golang.org/x/net/dns/dnsmessage"
+	"golang.org/x/sys/unix"
+)
+
+var rcodes = map[dnsmessage.RCode]string{
+	dnsmessage.RCodeSuccess:        "NOERROR",
+	dnsmessage.RCodeFormatError:    "FORMERR",
+	dnsmessage.RCodeServerFailure:  "SERVFAIL",
+	dnsmessage.RCodeNameError:      "NXDOMAIN",
+	dnsmessage.RCodeNotImplemented: "NOTIMP",
+	dnsmessage.RCodeRefused:        "REFUSED",
+}
+
+// parsePacket reads the DNS message carried by an Ethernet frame accepted by the filter,
+// false is returned for TCP segments without payload (handshake, acknowledgements)
+func parsePacket(frame []byte, at time.Time) (modules.DNSEvent, bool, error) {
+	packet, err := ebpf_tools.DecodeFrame(frame)
+	if err != nil {
+		return modules.DNSEvent{}, false, err
+	}
+	segment := packet.Segment
+
+	var sport, dport uint16
+	var payload []byte
+	switch packet.Protocol {
+	case unix.IPPROTO_UDP:
+		if len(segment) < 8 {
+			return modules.DNSEvent{}, false, ebpf_tools.ErrTruncated
+		}
+		sport, dport = binary.BigEndian.Uint16(segment[0:2]), binary.BigEndian.Uint16(segment[2:4])
+		payload = segment[8:]
+	case unix.IPPROTO_TCP:
+		if len(segment) < 20 {
+			return modules.DNSEvent{}, false, ebpf_tools.ErrTruncated
+		}
+		sport, dport = binary.BigEndian.Uint16(segment[0:2]), binary.BigEndian.Uint16(segment[2:4])
+		dataOffset := int(segment[12]>>4) * 4
+		if dataOffset < 20 || dataOffset > len(segment) {
+			return modules.DNSEvent{}, false, ebpf_tools.ErrTruncated
+		}
+		payload = segment[dataOffset:]
+		if len(payload) == 0 {
+			return modules.DNSEvent{}, false, nil
+		}
+		// DNS over TCP prefixes every message with its length, only messages starting a segment are read
+		if len(payload) < 2 {
+			return modules.DNSEvent{}, false, ebpf_tools.ErrTruncated
+		}
+		if length := int(binary.BigEndian.Uint16(payload[0:2])); length+2 <= len(payload) {
+			payload = payload[2 : length+2]
+		} else {
+			payload = payload[2:]
+		}
+	default:
+		return modules.DNSEvent{}, false, fmt.Errorf("unsupported protocol %d", packet.Protocol)
+	}
+
+	event, err := parseMessage(payload, at)
+	if err != nil {
+		return modules.DNSEvent{}, false, err
+	}
+	// the client is the side asking, also when resolvers talk to each other on port 53
+	event.Client = modules.Address{Addr: packet.Src.String(), Port: sport}
+	event.Server = modules.Address{Addr: packet.Dst.String(), Port: dport}
+	if event.Response {
+		event.Client, event.Server = event.Server, event.Client
+	}
+	return event, true, nil
+}
+
+func parseMessage(payload []byte, at time.Time) (modules.DNSEvent, error) {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(payload)
+	if err != nil {
+		return modules.DNSEvent{}, err
+	}
+	question, err := parser.Question()
+	if err != nil {
+		return modules.DNSEvent{}, err
+	}
+	event := modules.DNSEvent{
+		ID:       header.ID,
+		Response: header.Response,
+		Name:     strings.TrimSuffix(question.Name.String(), "."),
+		Type:     strings.TrimPrefix(question.Type.String(), "Type"),
+		Time:     at,
+	}
+	if !header.Response {
+		return event, nil
+	}
+
+	event.RCode = rcodeName(header.RCode)
+	if err := parser.SkipAllQuestions(); err != nil {
+		return modules.DNSEvent{}, err
+	}
+	for {
+		answer, err := parser.AnswerHeader()
+		if errors.Is(err, dnsmessage.ErrSectionDone) {
+			break
+		}
+		if err != nil {
+			return modules.DNSEvent{}, err
+		}
+		switch answer.Type {
+		case dnsmessage.TypeA:
+			resource, err := parser.AResource()
+			if err != nil {
+				return modules.DNSEvent{}, err
+			}
+			event.Answers = append(event.Answers, modules.DNSAnswer{Addr: net.IP(resource.A[:]).String(), TTL: answer.TTL})
+		case dnsmessage.TypeAAAA:
+			resource, err := parser.AAAAResource()
+			if err != nil {
+				return modules.DNSEvent{}, err
+			}
+			event.Answers = append(event.Answers, modules.DNSAnswer{Addr: net.IP(resource.AAAA[:]).String(), TTL: answer.TTL})
+		default:
+			// CNAME chains are followed by their A/AAAA records in the same answer section
+			if err := parser.SkipAnswer(); err != nil {
+				return modules.DNSEvent{}, err
+			}
+		}
+	}
+	return event, nil
+}
+
+func rcodeName(rcode dnsmessage.RCode) string {
+	if name, ok := rcodes[rcode]; ok {
+		return name
+	}
+	return strings.ToUpper(strings.TrimPrefix(rcode.String(), "RCode"))
+}
@@ -0,0 +1,129 @@
+package ebpf_dns
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/dns/dnsmessage"
+	"golang.org/x/sys/unix"
+)
+
+func dnsMessage(t *testing.T, response bool, rcode dnsmessage.RCode, answers ...net.IP) []byte {
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: 4242, Response: response, RCode: rcode})
+	assert.NoError(t, builder.StartQuestions())
+	name := dnsmessage.MustNewName("db.example.com.")
+	assert.NoError(t, builder.Question(dnsmessage.Question{Name: name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET}))
+	assert.NoError(t, builder.StartAnswers())
+	assert.NoError(t, builder.CNAMEResource(dnsmessage.ResourceHeader{Name: name, Class: dnsmessage.ClassINET, TTL: 60},
+		dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName("db.eu.example.com.")}))
+	for _, answer := range answers {
+		header := dnsmessage.ResourceHeader{Name: name, Class: dnsmessage.ClassINET, TTL: 30}
+		if ip4 := answer.To4(); ip4 != nil {
+			assert.NoError(t, builder.AResource(header, dnsmessage.AResource{A: [4]byte(ip4)}))
+		} else {
+			assert.NoError(t, builder.AAAAResource(header, dnsmessage.AAAAResource{AAAA: [16]byte(answer)}))
+		}
+	}
+	message, err := builder.Finish()
+	assert.NoError(t, err)
+	return message
+}
+
+func frame(src, dst net.IP, protocol uint8, sport, dport uint16, payload []byte) []byte {
+	var segment bytes.Buffer
+	if protocol == unix.IPPROTO_UDP {
+		binary.Write(&segment, binary.BigEndian, []uint16{sport, dport, uint16(8 + len(payload)), 0})
+		segment.Write(payload)
+	} else {
+		binary.Write(&segment, binary.BigEndian, []uint16{sport, dport, 0, 1, 0, 1, 5 << 12, 0xffff, 0, 0})
+		if len(payload) > 0 {
+			binary.Write(&segment, binary.BigEndian, uint16(len(payload)))
+			segment.Write(payload)
+		}
+	}
+
+	var packet bytes.Buffer
+	packet.Write(make([]byte, 12))
+	if src.To4() != nil {
+		binary.Write(&packet, binary.BigEndian, uint16(unix.ETH_P_IP))
+		binary.Write(&packet, binary.BigEndian, []uint16{0x4500, uint16(20 + segment.Len()), 0, 0})
+		packet.Write([]byte{64, protocol, 0, 0})
+		packet.Write(src.To4())
+		packet.Write(dst.To4())
+	} else {
+		binary.Write(&packet, binary.BigEndian, uint16(unix.ETH_P_IPV6))
+		binary.Write(&packet, binary.BigEndian, []uint16{0x6000, 0, uint16(segment.Len())})
+		packet.Write([]byte{protocol, 64})
+		packet.Write(src.To16())
+		packet.Write(dst.To16())
+	}
+	packet.Write(segment.Bytes())
+	// Ethernet padding must not be read as DNS payload
+	packet.Write(make([]byte, 4))
+	return packet.Bytes()
+}
+
+func TestParsePacket(t *testing.T) {
+	now := time.Now()
+	pod, resolver := net.ParseIP("10.244.1.5"), net.ParseIP("10.96.0.10")
+	pod6, resolver6 := net.ParseIP("fd00:10:244::5"), net.ParseIP("fd00:10:96::a")
+
+	var tests = []struct {
+		name     string
+		frame    []byte
+		ok       bool
+		err      bool
+		client   string
+		server   string
+		response bool
+		rcode    string
+		answers  []string
+	}{
+		{"udp query", frame(pod, resolver, unix.IPPROTO_UDP, 40000, 53, dnsMessage(t, false, dnsmessage.RCodeSuccess)),
+			true, false, "10.244.1.5", "10.96.0.10", false, "", nil},
+		{"udp response", frame(resolver, pod, unix.IPPROTO_UDP, 53, 40000, dnsMessage(t, true, dnsmessage.RCodeSuccess, net.ParseIP("203.0.113.7"), net.ParseIP("2001:db8::7"))),
+			true, false, "10.244.1.5", "10.96.0.10", true, "NOERROR", []string{"203.0.113.7", "2001:db8::7"}},
+		{"nxdomain", frame(resolver, pod, unix.IPPROTO_UDP, 53, 40000, dnsMessage(t, true, dnsmessage.RCodeNameError)),
+			true, false, "10.244.1.5", "10.96.0.10", true, "NXDOMAIN", nil},
+		{"tcp response over ipv6", frame(resolver6, pod6, unix.IPPROTO_TCP, 53, 40000, dnsMessage(t, true, dnsmessage.RCodeServerFailure)),
+			true, false, "fd00:10:244::5", "fd00:10:96::a", true, "SERVFAIL", nil},
+		{"tcp handshake", frame(pod, resolver, unix.IPPROTO_TCP, 40000, 53, nil), false, false, "", "", false, "", nil},
+		{"not dns", frame(pod, resolver, unix.IPPROTO_UDP, 40000, 53, []byte{1, 2, 3}), false, true, "", "", false, "", nil},
+		{"truncated", []byte{0, 1, 2}, false, true, "", "", false, "", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			event, ok, err := parsePacket(test.frame, now)
+			assert.Equal(t, test.ok, ok)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			if !ok {
+				return
+			}
+			assert.Equal(t, test.client, event.Client.Addr)
+			assert.Equal(t, uint16(40000), event.Client.Port)
+			assert.Equal(t, test.server, event.Server.Addr)
+			assert.Equal(t, uint16(53), event.Server.Port)
+			assert.Equal(t, uint16(4242), event.ID)
+			assert.Equal(t, test.response, event.Response)
+			assert.Equal(t, "db.example.com", event.Name)
+			assert.Equal(t, "A", event.Type)
+			assert.Equal(t, test.rcode, event.RCode)
+			assert.Equal(t, now, event.Time)
+			var answers []string
+			for _, answer := range event.Answers {
+				answers = append(answers, answer.Addr)
+				assert.Equal(t, uint32(30), answer.TTL)
+			}
+			assert.Equal(t, test.answers, answers)
+		})
+	}
+}
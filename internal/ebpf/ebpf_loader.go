@@ -2,80 +2,101 @@ package ebpf
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
-	"os"
-	"os/exec"
-	"os/signal"
 	"slices"
-	"strings"
-	"syscall"
-	"time"
+	"sync"
 
+	"github.com/k8spacket/k8spacket/internal/config"
+	ebpf_dns "github.com/k8spacket/k8spacket/internal/ebpf/dns"
+	ebpf_http "github.com/k8spacket/k8spacket/internal/ebpf/http"
 	ebpf_inet "github.com/k8spacket/k8spacket/internal/ebpf/inet"
 	ebpf_socketfilter "github.com/k8spacket/k8spacket/internal/ebpf/socketfilter"
 	ebpf_tc "github.com/k8spacket/k8spacket/internal/ebpf/tc"
+	ebpf_tools "github.com/k8spacket/k8spacket/internal/ebpf/tools"
 )
 
 type EbpfLoader struct {
+	store            *config.Store
 	inetEbpf         ebpf_inet.Inet
 	tcEbpf           ebpf_tc.Tc
 	socketFilterEbpf ebpf_socketfilter.SocketFilter
-	interfaces       []string
+	dnsEbpf          ebpf_dns.Dns
+	httpEbpf         ebpf_http.Http
+	links            Links
+	mu               sync.Mutex
+	interfaces       map[string]*networkInterface
+	// signalled on configuration reloads, interface patterns may have changed
+	reconfigure chan struct{}
+	cancel      context.CancelFunc
+	programs    sync.WaitGroup
 }
 
-func Init(inetEbpf ebpf_inet.Inet, tcEbpf ebpf_tc.Tc, socketFilterEbpf ebpf_socketfilter.SocketFilter) *EbpfLoader {
-	return &EbpfLoader{inetEbpf: inetEbpf, tcEbpf: tcEbpf, socketFilterEbpf: socketFilterEbpf}
+func Init(store *config.Store, inetEbpf ebpf_inet.Inet, tcEbpf ebpf_tc.Tc, socketFilterEbpf ebpf_socketfilter.SocketFilter, dnsEbpf ebpf_dns.Dns, httpEbpf ebpf_http.Http) *EbpfLoader {
+	ebpf_tools.Configure(store.Get().Reverse)
+	ebpf_tools.ConfigureProcess(store.Get().Process)
+	ebpf_tools.ConfigureFilter(store.Get().Loader.Filter)
+	loader := &EbpfLoader{store: store, inetEbpf: inetEbpf, tcEbpf: tcEbpf, socketFilterEbpf: socketFilterEbpf, dnsEbpf: dnsEbpf, httpEbpf: httpEbpf,
+		links: netlinkLinks{}, interfaces: make(map[string]*networkInterface), reconfigure: make(chan struct{}, 1)}
+	store.OnChange(func(cfg *config.Config) {
+		ebpf_tools.Configure(cfg.Reverse)
+		ebpf_tools.ConfigureProcess(cfg.Process)
+		ebpf_tools.ConfigureFilter(cfg.Loader.Filter)
+		select {
+		case loader.reconfigure <- struct{}{}:
+		default:
+		}
+	})
+	return loader
 }
 
-func (loader *EbpfLoader) Load() {
+func (loader *EbpfLoader) Load(ctx context.Context) {
+	ctx, loader.cancel = context.WithCancel(ctx)
+	// pods of excluded namespaces come and go, their IPs are looked up again periodically
+	loader.run(func() { ebpf_tools.RefreshFilter(ctx) })
 	// load inet_sock_set_state ebpf program
 	slog.Info("[loader] Tracepoint (sock/inet_sock_set_state) eBPF program is activating...")
-	go loader.inetEbpf.Init()
-	listenerSource := os.Getenv("K8S_PACKET_LOADER_SOURCE")
-	if listenerSource == "tc" {
+	loader.run(func() { loader.inetEbpf.Init(ctx) })
+	if loader.store.Get().Loader.Source == "tc" {
 		slog.Info("[loader] Traffic Control (TC) eBPF program is activating...")
-		go interfacesRefresher(*loader)
+		loader.run(func() { loader.watchInterfaces(ctx) })
 	} else {
 		slog.Info("[loader] Socket Filter eBPF program is activating...")
-		go loader.socketFilterEbpf.Init()
+		loader.run(func() { loader.socketFilterEbpf.Init(ctx) })
 	}
-}
-
-func interfacesRefresher(loader EbpfLoader) {
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer stop()
-
-	var currentInterfaces []string
-	var refreshPeriod, _ = time.ParseDuration(os.Getenv("K8S_PACKET_TCP_LISTENER_INTERFACES_REFRESH_PERIOD"))
-
-	for {
-		select {
-		case <-ctx.Done():
-			slog.Info("[tc-loop] Receive signal, exiting...")
-			return
-		case <-time.After(refreshPeriod):
-			slog.Info("[tc-loop] Refreshing interfaces for capturing...")
-			loader.interfaces = findInterfaces()
-			for _, el := range loader.interfaces {
-				if (strings.TrimSpace(el) != "") && (!slices.Contains(currentInterfaces, el)) {
-					// load traffic control ebpf program (qdisc filter)
-					go loader.tcEbpf.Init(el)
-				}
-			}
-			currentInterfaces = loader.interfaces
-		}
+	// DNS packets are captured only for the dns module, whatever the source of TLS handshakes
+	if slices.Contains(loader.store.Get().Modules.Enabled, "dns") {
+		slog.Info("[loader] DNS Socket Filter eBPF program is activating...")
+		loader.run(func() { loader.dnsEbpf.Init(ctx) })
+	}
+	if slices.Contains(loader.store.Get().Modules.Enabled, "httpparser") {
+		slog.Info("[loader] HTTP Socket Filter eBPF program is activating...")
+		loader.run(func() { loader.httpEbpf.Init(ctx) })
 	}
 }
 
-// looking for network interfaces on cluster nodes regarding started containers based on the command `ip address`
-func findInterfaces() []string {
-	command := os.Getenv("K8S_PACKET_TCP_LISTENER_INTERFACES_COMMAND")
-	cmd := exec.Command("sh", "-c", command)
-	out, err := cmd.Output()
-
-	if err != nil {
-		slog.Error("[tc-loop] Cannot find interfaces to listen", "Error", err)
+// Stop detaches all eBPF programs and waits until they are closed or ctx is done
+func (loader *EbpfLoader) Stop(ctx context.Context) error {
+	if loader.cancel != nil {
+		loader.cancel()
+	}
+	done := make(chan struct{})
+	go func() {
+		loader.programs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
 		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("eBPF programs not closed: %w", ctx.Err())
 	}
-	return strings.Split(string(out), ",")
+}
+
+func (loader *EbpfLoader) run(program func()) {
+	loader.programs.Add(1)
+	go func() {
+		defer loader.programs.Done()
+		program()
+	}()
 }
@@ -2,12 +2,15 @@ package ebpf
 
 import (
 	"bytes"
+	"context"
 	"log/slog"
-	"os"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/k8spacket/k8spacket/internal/config"
+	ebpf_dns "github.com/k8spacket/k8spacket/internal/ebpf/dns"
+	ebpf_http "github.com/k8spacket/k8spacket/internal/ebpf/http"
 	ebpf_inet "github.com/k8spacket/k8spacket/internal/ebpf/inet"
 	ebpf_socketfilter "github.com/k8spacket/k8spacket/internal/ebpf/socketfilter"
 	ebpf_tc "github.com/k8spacket/k8spacket/internal/ebpf/tc"
@@ -19,8 +22,9 @@ type mockEbpfInet struct {
 	initCalled bool
 }
 
-func (mockEbpfInet *mockEbpfInet) Init() {
+func (mockEbpfInet *mockEbpfInet) Init(ctx context.Context) {
 	mockEbpfInet.initCalled = true
+	<-ctx.Done()
 }
 
 type mockEbpfTc struct {
@@ -28,8 +32,9 @@ type mockEbpfTc struct {
 	initCalledCount int
 }
 
-func (mockEbpfTc *mockEbpfTc) Init(iface string) {
+func (mockEbpfTc *mockEbpfTc) Init(ctx context.Context, iface string) {
 	mockEbpfTc.initCalledCount++
+	<-ctx.Done()
 }
 
 type mockEbpfSocketfilter struct {
@@ -37,8 +42,29 @@ type mockEbpfSocketfilter struct {
 	initCalledCount int
 }
 
-func (mockEbpfSocketfilter *mockEbpfSocketfilter) Init() {
+func (mockEbpfSocketfilter *mockEbpfSocketfilter) Init(ctx context.Context) {
 	mockEbpfSocketfilter.initCalledCount++
+	<-ctx.Done()
+}
+
+type mockEbpfDns struct {
+	ebpf_dns.Dns
+	initCalledCount int
+}
+
+func (mockEbpfDns *mockEbpfDns) Init(ctx context.Context) {
+	mockEbpfDns.initCalledCount++
+	<-ctx.Done()
+}
+
+type mockEbpfHttp struct {
+	ebpf_http.Http
+	initCalledCount int
+}
+
+func (mockEbpfHttp *mockEbpfHttp) Init(ctx context.Context) {
+	mockEbpfHttp.initCalledCount++
+	<-ctx.Done()
 }
 
 func TestLoad(t *testing.T) {
@@ -49,13 +75,18 @@ func TestLoad(t *testing.T) {
 		inetCalled              bool
 		socketfilterCalledCount int
 		tcCalledCount           int
+		modules                 []string
+		dnsCalledCount          int
+		httpCalledCount         int
 		err                     string
 	}{
-		{"echo 'iface1,iface2'", "socketfilter", true, 1, 0, ""},
-		{"echo 'iface1,iface2'", "tc", true, 0, 2, ""},
-		{"echo 'iface1,iface2'", "", true, 1, 0, ""},
-		{"echo 'iface1,iface2'", "some_other_value", true, 1, 0, ""},
-		{"exit 1", "tc", true, 0, 0, "[tc-loop] Cannot find interfaces to listen"},
+		{"echo 'iface1,iface2'", "socketfilter", true, 1, 0, nil, 0, 0, ""},
+		{"echo 'iface1,iface2'", "tc", true, 0, 2, nil, 0, 0, ""},
+		{"echo 'iface1,iface2'", "", true, 1, 0, nil, 0, 0, ""},
+		{"echo 'iface1,iface2'", "some_other_value", true, 1, 0, nil, 0, 0, ""},
+		{"exit 1", "tc", true, 0, 0, nil, 0, 0, "[tc-loop] Cannot find interfaces to listen"},
+		{"echo 'iface1'", "tc", true, 0, 1, []string{"nodegraph", "dns"}, 1, 0, "[tc-loop] loader.interfaces.command is deprecated"},
+		{"echo 'iface1'", "socketfilter", true, 1, 0, []string{"httpparser"}, 0, 1, ""},
 	}
 
 	var str bytes.Buffer
@@ -64,23 +95,49 @@ func TestLoad(t *testing.T) {
 
 	slog.SetDefault(logger)
 
-	os.Setenv("K8S_PACKET_TCP_LISTENER_INTERFACES_REFRESH_PERIOD", "100ms")
-
 	for _, test := range tests {
 		t.Run(test.command, func(t *testing.T) {
 
-			os.Setenv("K8S_PACKET_TCP_LISTENER_INTERFACES_COMMAND", test.command)
-			os.Setenv("K8S_PACKET_LOADER_SOURCE", test.loaderSource)
+			cfg := config.Default()
+			cfg.Loader.Source = test.loaderSource
+			cfg.Loader.Interfaces.Command = test.command
+			cfg.Loader.Interfaces.RefreshPeriod.Duration = 100 * time.Millisecond
+			cfg.Modules.Enabled = test.modules
 
 			mockInetEbpf := &mockEbpfInet{}
 			mockItcEbpf := &mockEbpfTc{}
 			mockIsocketfilterEbpf := &mockEbpfSocketfilter{}
-			loader := Init(mockInetEbpf, mockItcEbpf, mockIsocketfilterEbpf)
-			loader.Load()
+			mockDnsEbpf := &mockEbpfDns{}
+			mockHttpEbpf := &mockEbpfHttp{}
+			loader := Init(config.NewStore(cfg), mockInetEbpf, mockItcEbpf, mockIsocketfilterEbpf, mockDnsEbpf, mockHttpEbpf)
+			loader.Load(context.Background())
 
 			assert.Eventually(t, func() bool {
-				return mockInetEbpf.initCalled == test.inetCalled && mockItcEbpf.initCalledCount == test.tcCalledCount && mockIsocketfilterEbpf.initCalledCount == test.socketfilterCalledCount && strings.Contains(str.String(), test.err)
+				return mockInetEbpf.initCalled == test.inetCalled && mockItcEbpf.initCalledCount == test.tcCalledCount && mockIsocketfilterEbpf.initCalledCount == test.socketfilterCalledCount && mockDnsEbpf.initCalledCount == test.dnsCalledCount && mockHttpEbpf.initCalledCount == test.httpCalledCount && strings.Contains(str.String(), test.err)
 			}, time.Second*1, time.Millisecond*100)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			assert.NoError(t, loader.Stop(ctx))
 		})
 	}
 }
+
+type stuckEbpfInet struct {
+	ebpf_inet.Inet
+}
+
+func (stuckEbpfInet *stuckEbpfInet) Init(ctx context.Context) {
+	select {}
+}
+
+func TestStopTimeout(t *testing.T) {
+
+	loader := Init(config.NewStore(config.Default()), &stuckEbpfInet{}, &mockEbpfTc{}, &mockEbpfSocketfilter{}, &mockEbpfDns{}, &mockEbpfHttp{})
+	loader.Load(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorContains(t, loader.Stop(ctx), "eBPF programs not closed: context deadline exceeded")
+}
@@ -0,0 +1,24 @@
+package ebpf
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+type Handler struct {
+	loader *EbpfLoader
+}
+
+func NewHandler(loader *EbpfLoader) *Handler {
+	return &Handler{loader: loader}
+}
+
+// InterfacesHandler lists network interfaces of the node and whether the TC program is attached to them
+func (handler *Handler) InterfacesHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(handler.loader.Interfaces()); err != nil {
+		slog.Error("[api] Cannot prepare interfaces response", "Error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
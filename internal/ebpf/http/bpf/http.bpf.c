@@ -0,0 +1,79 @@
+#include "vmlinux.h"
+#include "bpf_helpers.h"
+#include "bpf_endian.h"
+
+#define ETH_HLEN 14
+#define ETH_P_IP 0x0800
+#define ETH_P_IPV6 0x86DD
+#define IP_OFFSET 0x1fff
+// bytes of a frame kept by the filter, enough for the request line and the usual headers, as read in user space
+#define SNAP_LEN 2048
+
+// payloads of HTTP/1.x requests and responses start with a method or the version
+static const __u32 prefixes[] = {
+    0x47455420, // "GET "
+    0x504f5354, // "POST"
+    0x50555420, // "PUT "
+    0x44454c45, // "DELE"
+    0x48454144, // "HEAD"
+    0x50415443, // "PATC"
+    0x4f505449, // "OPTI"
+    0x48545450, // "HTTP"
+};
+
+// accepts TCP segments over IPv4 and IPv6 whose payload starts like an HTTP/1.x request or status line
+SEC("socket/http_filter")
+int http_filter(struct __sk_buff *skb) {
+
+    __be16 proto;
+    __u8 ip_proto;
+    __u32 tcp_offset;
+
+    if (bpf_skb_load_bytes(skb, offsetof(struct ethhdr, h_proto), &proto, sizeof(proto)) != 0)
+        return 0;
+    if (proto == bpf_htons(ETH_P_IP)) {
+        if (bpf_skb_load_bytes(skb, ETH_HLEN + offsetof(struct iphdr, protocol), &ip_proto, sizeof(ip_proto)) != 0)
+            return 0;
+        if (ip_proto != IPPROTO_TCP)
+            return 0;
+
+        // the payload is in the first fragment only
+        __be16 frag_off;
+        if (bpf_skb_load_bytes(skb, ETH_HLEN + offsetof(struct iphdr, frag_off), &frag_off, sizeof(frag_off)) != 0)
+            return 0;
+        if (frag_off & bpf_htons(IP_OFFSET))
+            return 0;
+
+        // IPv4 header length is variable
+        __u8 hdr_len;
+        if (bpf_skb_load_bytes(skb, ETH_HLEN, &hdr_len, sizeof(hdr_len)) != 0)
+            return 0;
+        tcp_offset = ETH_HLEN + (hdr_len & 0x0f) * 4;
+    } else if (proto == bpf_htons(ETH_P_IPV6)) {
+        // extension headers are not followed
+        if (bpf_skb_load_bytes(skb, ETH_HLEN + offsetof(struct ipv6hdr, nexthdr), &ip_proto, sizeof(ip_proto)) != 0)
+            return 0;
+        if (ip_proto != IPPROTO_TCP)
+            return 0;
+        tcp_offset = ETH_HLEN + sizeof(struct ipv6hdr);
+    } else {
+        return 0;
+    }
+
+    // the data offset is the upper half of the 13th byte of the TCP header
+    __u8 doff;
+    if (bpf_skb_load_bytes(skb, tcp_offset + 12, &doff, sizeof(doff)) != 0)
+        return 0;
+    // segments without payload cannot be read that far and are dropped
+    __be32 start;
+    if (bpf_skb_load_bytes(skb, tcp_offset + (doff >> 4) * 4, &start, sizeof(start)) != 0)
+        return 0;
+    for (int i = 0; i < sizeof(prefixes) / sizeof(prefixes[0]); i++) {
+        if (start == bpf_htonl(prefixes[i]))
+            // the return value is the number of bytes to keep
+            return SNAP_LEN;
+    }
+    return 0;
+}
+
+char __license[] SEC("license") = "Dual MIT/GPL";
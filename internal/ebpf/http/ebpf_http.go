@@ -0,0 +1,88 @@
+package ebpf_http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/cilium/ebpf"
+
+	"github.com/k8spacket/k8spacket/internal/broker"
+	ebpf_tools "github.com/k8spacket/k8spacket/internal/ebpf/tools"
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/k8spacket/k8spacket/internal/status"
+	"golang.org/x/sys/unix"
+)
+
+const component = "http"
+
+// bytes of a frame kept by the filter (SNAP_LEN), enough for the request line and the usual headers
+const snapLen = 2048
+
+// how often the read loop wakes up to check whether it should exit
+var readTimeout = time.Second
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -go-package ebpf_http http ./bpf/http.bpf.c
+
+type EbpfHttp struct {
+	Broker broker.Broker
+}
+
+func (ebpfHttp *EbpfHttp) Init(ctx context.Context) {
+
+	status.Report(component, status.Starting, "")
+
+	// Load pre-compiled programs into the kernel.
+	objs := httpObjects{}
+	if err := loadHttpObjects(&objs, nil); err != nil {
+		var verr *ebpf.VerifierError
+		if errors.As(err, &verr) {
+			slog.Error("[http] Loading program", "Error", fmt.Sprintf("%+v", verr))
+		} else {
+			slog.Error("[http] Loading program", "Error", err)
+		}
+		status.Report(component, status.Down, "cannot load program: "+err.Error())
+		return
+	}
+	defer objs.Close()
+
+	fd, err := ebpf_tools.OpenRawSocket(objs.HttpFilter, readTimeout)
+	if err != nil {
+		slog.Error("[http] Cannot open socket", "Error", err)
+		status.Report(component, status.Down, err.Error())
+		return
+	}
+	defer unix.Close(fd)
+	status.Report(component, status.Up, "")
+
+	frame := make([]byte, snapLen)
+	for ctx.Err() == nil {
+		n, _, err := unix.Recvfrom(fd, frame, 0)
+		if err != nil {
+			if errors.Is(err, unix.EAGAIN) || errors.Is(err, unix.EINTR) {
+				continue
+			}
+			ebpf_tools.ReadErrorsMetric.WithLabelValues("http", ebpf_tools.AnyInterface).Inc()
+			slog.Error("[http] Reading from socket", "Error", err)
+			continue
+		}
+		event, err := parsePacket(frame[:n], time.Now())
+		if err != nil {
+			ebpf_tools.ParseErrorsMetric.WithLabelValues("http", ebpf_tools.AnyInterface).Inc()
+			slog.Debug("[http] Parsing packet", "Error", err)
+			continue
+		}
+		ebpf_tools.EventsMetric.WithLabelValues("http").Inc()
+
+		distribute(event, ebpfHttp)
+	}
+
+	slog.Info("[http] Closed gracefully")
+}
+
+func distribute(event modules.HTTPEvent, ebpfHttp *EbpfHttp) {
+	ebpf_tools.EnrichConnection(&event.Client, &event.Server)
+	ebpfHttp.Broker.HTTPEvent(event)
+}
@@ -0,0 +1,109 @@
+package ebpf_http
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/cilium/ebpf"
+	"github.com/k8spacket/k8spacket/internal/broker"
+	ebpf_tools "github.com/k8spacket/k8spacket/internal/ebpf/tools"
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+)
+
+type fakeBrokerHttp struct {
+	broker.Broker
+	last modules.HTTPEvent
+}
+
+func (f *fakeBrokerHttp) HTTPEvent(event modules.HTTPEvent) { f.last = event }
+
+func TestDistribute(t *testing.T) {
+	event := modules.HTTPEvent{
+		Client: modules.Address{Addr: "10.244.1.5", Port: 40000},
+		Server: modules.Address{Addr: "10.244.2.7", Port: 8080},
+		Seq:    7,
+		Method: "GET",
+		Path:   "/api/orders/42",
+		Time:   time.Now(),
+	}
+
+	fb := &fakeBrokerHttp{}
+	distribute(event, &EbpfHttp{Broker: fb})
+
+	got := fb.last
+	assert.Equal(t, "10.244.1.5", got.Client.Addr)
+	assert.Equal(t, "N/A", got.Client.Name)
+	assert.Equal(t, "10.244.2.7", got.Server.Addr)
+	assert.Equal(t, "N/A", got.Server.Name)
+	assert.Equal(t, "/api/orders/42", got.Path)
+}
+
+// every program of the generated bindings is in the objects of both byte orders
+func TestObjects(t *testing.T) {
+	for _, object := range []string{"http_bpfel.o", "http_bpfeb.o"} {
+		spec, err := ebpf.LoadCollectionSpec(object)
+		assert.Nil(t, err, object)
+		assert.Nil(t, spec.Assign(&httpSpecs{}), object)
+	}
+}
+
+// the program passes the verifier of the running kernel and keeps HTTP segments only, loading it requires root
+func TestLoad(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("loading eBPF programs requires root")
+	}
+	objs := httpObjects{}
+	assert.Nil(t, loadHttpObjects(&objs, nil))
+	defer objs.Close()
+
+	// segments sent over loopback reach the raw socket only when they start like a request or a status line
+	fd, err := ebpf_tools.OpenRawSocket(objs.HttpFilter, 100*time.Millisecond)
+	assert.Nil(t, err)
+	defer unix.Close(fd)
+	var sent, want []string
+	for _, address := range []string{"127.0.0.1:0", "[::1]:0"} {
+		server, err := net.Listen("tcp", address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			conn, err := server.Accept()
+			if err == nil {
+				conn.Read(make([]byte, 1024))
+				conn.Close()
+			}
+		}()
+		payloads := []string{"GET /k8spacket HTTP/1.1\r\nHost: " + address + "\r\n", "HTTP/1.1 200 OK\r\nServer: " + address + "\r\n", "\x16\x03\x01 " + address}
+		for _, payload := range payloads {
+			conn, err := net.Dial("tcp", server.Addr().String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			conn.Write([]byte(payload))
+			conn.Close()
+		}
+		server.Close()
+		sent = append(sent, payloads...)
+		want = append(want, payloads[:2]...)
+	}
+	var received []string
+	frame := make([]byte, snapLen)
+	for {
+		n, _, err := unix.Recvfrom(fd, frame, 0)
+		if err != nil {
+			break
+		}
+		for _, payload := range sent {
+			if bytes.HasSuffix(frame[:n], []byte(payload)) && !slices.Contains(received, payload) {
+				received = append(received, payload)
+			}
+		}
+	}
+	assert.ElementsMatch(t, want, received)
+}
@@ -0,0 +1,7 @@
+package ebpf_http
+
+import "context"
+
+type Http interface {
+	Init(ctx context.Context)
+}
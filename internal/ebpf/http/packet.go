@@ -0,0 +1,106 @@
+package ebpf_http
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	ebpf_tools "github.com/k8spacket/k8spacket/internal/ebpf/tools"
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"golang.org/x/sys/unix"
+)
+
+var errNotHttp = errors.New("not an HTTP/1.x message")
+
+// parsePacket reads the request line (with the Host header) or the status line of an Ethernet frame accepted by the filter
+func parsePacket(frame []byte, at time.Time) (modules.HTTPEvent, error) {
+	packet, err := ebpf_tools.DecodeFrame(frame)
+	if err != nil {
+		return modules.HTTPEvent{}, err
+	}
+	if packet.Protocol != unix.IPPROTO_TCP {
+		return modules.HTTPEvent{}, fmt.Errorf("unsupported protocol %d", packet.Protocol)
+	}
+	segment := packet.Segment
+	if len(segment) < 20 {
+		return modules.HTTPEvent{}, ebpf_tools.ErrTruncated
+	}
+	dataOffset := int(segment[12]>>4) * 4
+	if dataOffset < 20 || dataOffset > len(segment) {
+		return modules.HTTPEvent{}, ebpf_tools.ErrTruncated
+	}
+
+	event := modules.HTTPEvent{
+		Client: modules.Address{Addr: packet.Src.String(), Port: binary.BigEndian.Uint16(segment[0:2])},
+		Server: modules.Address{Addr: packet.Dst.String(), Port: binary.BigEndian.Uint16(segment[2:4])},
+		Seq:    binary.BigEndian.Uint32(segment[4:8]),
+		Time:   at,
+	}
+	payload := segment[dataOffset:]
+	line, headers, _ := bytes.Cut(payload, []byte("\r\n"))
+	if bytes.HasPrefix(line, []byte("HTTP/1.")) {
+		// the client is the side receiving the response
+		event.Client, event.Server = event.Server, event.Client
+		event.Response = true
+		event.StatusCode, err = parseStatusLine(line)
+		return event, err
+	}
+	event.Method, event.Host, event.Path, err = parseRequestLine(line)
+	if err != nil {
+		return modules.HTTPEvent{}, err
+	}
+	if event.Host == "" {
+		event.Host = hostHeader(headers)
+	}
+	return event, nil
+}
+
+// parseStatusLine reads the status code of "HTTP/1.1 200 OK"
+func parseStatusLine(line []byte) (int, error) {
+	fields := bytes.SplitN(line, []byte(" "), 3)
+	if len(fields) < 2 || len(fields[1]) != 3 {
+		return 0, errNotHttp
+	}
+	code, err := strconv.Atoi(string(fields[1]))
+	if err != nil {
+		return 0, errNotHttp
+	}
+	return code, nil
+}
+
+// parseRequestLine reads the method and the path without query of "GET /path?query HTTP/1.1",
+// targets in absolute form also give the host
+func parseRequestLine(line []byte) (string, string, string, error) {
+	fields := bytes.Split(line, []byte(" "))
+	if len(fields) != 3 || !bytes.HasPrefix(fields[2], []byte("HTTP/1.")) {
+		return "", "", "", errNotHttp
+	}
+	method, target := string(fields[0]), string(fields[1])
+	if method == "OPTIONS" && target == "*" {
+		return method, "", target, nil
+	}
+	uri, err := url.ParseRequestURI(target)
+	if err != nil {
+		return "", "", "", errNotHttp
+	}
+	return method, uri.Host, uri.EscapedPath(), nil
+}
+
+func hostHeader(headers []byte) string {
+	for len(headers) > 0 {
+		var line []byte
+		line, headers, _ = bytes.Cut(headers, []byte("\r\n"))
+		if len(line) == 0 {
+			break
+		}
+		name, value, ok := bytes.Cut(line, []byte(":"))
+		if ok && bytes.EqualFold(name, []byte("host")) {
+			return string(bytes.TrimSpace(value))
+		}
+	}
+	return ""
+}
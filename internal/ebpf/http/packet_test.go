@@ -0,0 +1,94 @@
+package ebpf_http
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+)
+
+func frame(src, dst net.IP, sport, dport uint16, seq uint32, payload string) []byte {
+	var segment bytes.Buffer
+	binary.Write(&segment, binary.BigEndian, []uint16{sport, dport})
+	binary.Write(&segment, binary.BigEndian, []uint32{seq, 1})
+	binary.Write(&segment, binary.BigEndian, []uint16{5 << 12, 0xffff, 0, 0})
+	segment.WriteString(payload)
+
+	var packet bytes.Buffer
+	packet.Write(make([]byte, 12))
+	if src.To4() != nil {
+		binary.Write(&packet, binary.BigEndian, uint16(unix.ETH_P_IP))
+		binary.Write(&packet, binary.BigEndian, []uint16{0x4500, uint16(20 + segment.Len()), 0, 0})
+		packet.Write([]byte{64, unix.IPPROTO_TCP, 0, 0})
+		packet.Write(src.To4())
+		packet.Write(dst.To4())
+	} else {
+		binary.Write(&packet, binary.BigEndian, uint16(unix.ETH_P_IPV6))
+		binary.Write(&packet, binary.BigEndian, []uint16{0x6000, 0, uint16(segment.Len())})
+		packet.Write([]byte{unix.IPPROTO_TCP, 64})
+		packet.Write(src.To16())
+		packet.Write(dst.To16())
+	}
+	packet.Write(segment.Bytes())
+	return packet.Bytes()
+}
+
+func TestParsePacket(t *testing.T) {
+	now := time.Now()
+	client, server := net.ParseIP("10.244.1.5"), net.ParseIP("10.244.2.7")
+	client6, server6 := net.ParseIP("fd00:10:244::5"), net.ParseIP("fd00:10:244::7")
+
+	var tests = []struct {
+		name     string
+		frame    []byte
+		err      bool
+		client   string
+		server   string
+		response bool
+		method   string
+		host     string
+		path     string
+		status   int
+	}{
+		{"request", frame(client, server, 40000, 8080, 7, "GET /api/orders/42?full=true HTTP/1.1\r\nAccept: */*\r\nHost: orders:8080\r\n\r\n"),
+			false, "10.244.1.5", "10.244.2.7", false, "GET", "orders:8080", "/api/orders/42", 0},
+		{"request in absolute form", frame(client, server, 40000, 8080, 7, "POST http://orders/api/orders HTTP/1.0\r\n\r\n"),
+			false, "10.244.1.5", "10.244.2.7", false, "POST", "orders", "/api/orders", 0},
+		{"request line only", frame(client, server, 40000, 8080, 7, "DELETE /api/orders/42 HTTP/1.1"),
+			false, "10.244.1.5", "10.244.2.7", false, "DELETE", "", "/api/orders/42", 0},
+		{"response", frame(server, client, 8080, 40000, 7, "HTTP/1.1 503 Service Unavailable\r\nContent-Length: 0\r\n\r\n"),
+			false, "10.244.1.5", "10.244.2.7", true, "", "", "", 503},
+		{"response over ipv6", frame(server6, client6, 8080, 40000, 7, "HTTP/1.1 204 No Content\r\n\r\n"),
+			false, "fd00:10:244::5", "fd00:10:244::7", true, "", "", "", 204},
+		{"http2", frame(client, server, 40000, 8080, 7, "GET / HTTP/2.0\r\n\r\n"), true, "", "", false, "", "", "", 0},
+		{"body", frame(client, server, 40000, 8080, 7, "POSTED,2024-01-01\n"), true, "", "", false, "", "", "", 0},
+		{"bad status", frame(server, client, 8080, 40000, 7, "HTTP/1.1 OK\r\n\r\n"), true, "", "", false, "", "", "", 0},
+		{"truncated", []byte{0, 1, 2}, true, "", "", false, "", "", "", 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			event, err := parsePacket(test.frame, now)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.client, event.Client.Addr)
+			assert.Equal(t, uint16(40000), event.Client.Port)
+			assert.Equal(t, test.server, event.Server.Addr)
+			assert.Equal(t, uint16(8080), event.Server.Port)
+			assert.Equal(t, uint32(7), event.Seq)
+			assert.Equal(t, test.response, event.Response)
+			assert.Equal(t, test.method, event.Method)
+			assert.Equal(t, test.host, event.Host)
+			assert.Equal(t, test.path, event.Path)
+			assert.Equal(t, test.status, event.StatusCode)
+			assert.Equal(t, now, event.Time)
+		})
+	}
+}
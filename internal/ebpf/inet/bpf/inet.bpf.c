@@ -2,23 +2,45 @@
 #include "bpf_core_read.h"
 #include "bpf_tracing.h"
 
-#define MAX_ENTRIES	100
-//#define AF_INET		2
+#define MAX_ENTRIES	100	// sized by the loader from loader.maps.connections
+#define FILTER_MAX_ENTRIES	4096
+#define FILTER_PORTS_MAX_ENTRIES	256
+#define AF_INET		2
+#define AF_INET6	10
+#define TASK_COMM_LEN	16
 
 struct event {
-	__be32 saddr; 	// source IP
-	__be32 daddr; 	// destination IP
+	__u8 saddr[16];	// source IP, IPv4 in the first 4 bytes
+	__u8 daddr[16];	// destination IP, IPv4 in the first 4 bytes
     __be16 sport; 	// source port
 	__be16 dport; 	// destination port
+	__u16 family;	// address family, AF_INET or AF_INET6
+	bool listen;	// a socket started listening on saddr and sport, or stopped when closed is set
 	__u64 delta_us;	// duration in microseconds 
 	__u64 rx_b;		// received bytes
 	__u64 tx_b;		// transmited bytes
 	bool closed; 	// close connection
+	bool reset;		// connection torn down by RST, sent or received
+	bool established;	// TCP_ESTABLISHED was reached, false for refused or timed out connects
+	bool initiator;	// the local socket is the client, pid, comm and cgroup_id describe the client then
+	__u32 retransmits;	// retransmitted segments
+	__u32 srtt_us;	// smoothed round-trip time in microseconds
+	__u32 mdev_us;	// round-trip time mean deviation in microseconds
+	__u32 pid;		// process which connected, 0 for accepted connections (SYN_RECV runs in softirq)
+	char comm[TASK_COMM_LEN];	// command of that process
+	__u64 cgroup_id;	// cgroup v2 id of the local socket
+	__u64 ino;		// inode of a listening socket, as in /proc/net/tcp
 };
 
 struct birth {
     __u64 ts;		// timestamp of first packet
     bool initiator;	// am i the initiator?
+    bool reset;		// RST sent or received
+    bool established;	// TCP_ESTABLISHED reached
+    __u32 retransmits;	// retransmitted segments
+    __u32 pid;		// process which connected
+    char comm[TASK_COMM_LEN];	// command of that process
+    __u64 cgroup_id;	// cgroup v2 id of the local socket
 };
 
 //dummy unused instance declaration of type to not be optimized, lack causes: "Error: collect C types: type name event: not found"
@@ -31,24 +53,94 @@ struct {
 	__type(value, struct birth);
 } births SEC(".maps");
 
+// set by the loader before loading, true when events go through the ring buffer (kernel 5.8+),
+// otherwise the loader turns events into a perf event array and the ring buffer branch is pruned by the verifier
+const volatile bool use_ringbuf = false;
+
 struct {
-	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
-	__uint(key_size, sizeof(__u32));
-	__uint(value_size, sizeof(__u32));
+	__uint(type, BPF_MAP_TYPE_RINGBUF);
+	__uint(max_entries, 256 * 1024);
 } events SEC(".maps");
 
-static void source_and_destination(struct trace_event_raw_inet_sock_set_state *args, __be32 *saddr, __u16 *sport, __be32 *daddr, __u16 *dport) {
-    //source and destination IPs
+// events dropped because the ring buffer was full, perf event array reports its losses itself
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+	__uint(max_entries, 1);
+	__type(key, __u32);
+	__type(value, __u64);
+} lost_events SEC(".maps");
+
+static __always_inline void output_event(void *ctx, void *event, __u64 size) {
+	if (use_ringbuf) {
+		if (bpf_ringbuf_output(&events, event, size, 0) != 0) {
+			__u32 zero = 0;
+			__u64 *lost = bpf_map_lookup_elem(&lost_events, &zero);
+			if (lost)
+				__sync_fetch_and_add(lost, 1);
+		}
+	} else {
+		bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, event, size);
+	}
+}
+
+// key of excluded_addrs, IPv4 addresses are mapped into IPv6 (::ffff:0:0/96) so both families share the trie
+struct filter_key {
+	__u32 prefixlen;
+	__u8 addr[16];
+};
+
+// networks and pod IPs whose traffic is not reported, filled by the loader from loader.filter and sized by loader.maps
+struct {
+	__uint(type, BPF_MAP_TYPE_LPM_TRIE);
+	__uint(max_entries, FILTER_MAX_ENTRIES);
+	__type(key, struct filter_key);
+	__type(value, __u8);
+	__uint(map_flags, BPF_F_NO_PREALLOC);
+} excluded_addrs SEC(".maps");
+
+// ports in host byte order whose traffic is not reported, on either end
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, FILTER_PORTS_MAX_ENTRIES);
+	__type(key, __u16);
+	__type(value, __u8);
+} excluded_ports SEC(".maps");
 
-    //IP4 supported only at this moment
-    //__u16 family = BPF_CORE_READ(args, family);
-    //if (family == AF_INET) {
-    bpf_probe_read_kernel(saddr, sizeof(args->saddr), BPF_CORE_READ(args, saddr));
-    bpf_probe_read_kernel(daddr, sizeof(args->daddr), BPF_CORE_READ(args, daddr));
-    //} else {	/*  AF_INET6 */
-    //    bpf_probe_read_kernel(saddr, sizeof(args->saddr_v6), BPF_CORE_READ(args, saddr_v6));
-    //    bpf_probe_read_kernel(daddr, sizeof(args->daddr_v6), BPF_CORE_READ(args, daddr_v6));
-    //}
+static __always_inline bool excluded_addr(__u16 family, __u8 *addr) {
+	struct filter_key key = {.prefixlen = 128};
+	if (family == AF_INET) {
+		key.addr[10] = 0xff;
+		key.addr[11] = 0xff;
+		__builtin_memcpy(&key.addr[12], addr, 4);
+	} else {
+		__builtin_memcpy(key.addr, addr, 16);
+	}
+	return bpf_map_lookup_elem(&excluded_addrs, &key) != NULL;
+}
+
+// traffic from or to excluded networks and ports never reaches user space
+static __always_inline bool excluded(__u16 family, __u8 *saddr, __u8 *daddr, __u16 sport, __u16 dport) {
+	return bpf_map_lookup_elem(&excluded_ports, &sport) || bpf_map_lookup_elem(&excluded_ports, &dport) ||
+		excluded_addr(family, saddr) || excluded_addr(family, daddr);
+}
+
+// cgroup of a socket, accepted sockets inherit it from the listening one
+static __always_inline __u64 sock_cgroup_id(struct sock *sk) {
+	if (!bpf_core_field_exists(sk->sk_cgrp_data.cgroup))
+		return 0;
+	return BPF_CORE_READ(sk, sk_cgrp_data.cgroup, kn, id);
+}
+
+static void source_and_destination(struct trace_event_raw_inet_sock_set_state *args, __u8 *saddr, __u16 *sport, __u8 *daddr, __u16 *dport) {
+    //source and destination IPs
+    __u16 family = BPF_CORE_READ(args, family);
+    if (family == AF_INET) {
+        bpf_probe_read_kernel(saddr, sizeof(args->saddr), args->saddr);
+        bpf_probe_read_kernel(daddr, sizeof(args->daddr), args->daddr);
+    } else {	/*  AF_INET6 */
+        bpf_probe_read_kernel(saddr, sizeof(args->saddr_v6), args->saddr_v6);
+        bpf_probe_read_kernel(daddr, sizeof(args->daddr_v6), args->daddr_v6);
+    }
     *sport = BPF_CORE_READ(args, sport);
     *dport = BPF_CORE_READ(args, dport);
 }
@@ -57,9 +149,10 @@ SEC("tracepoint/sock/inet_sock_set_state")
 int inet_sock_set_state(struct trace_event_raw_inet_sock_set_state *args)
 {
 	__u64 ts, rx_b, tx_b;
+	__u32 total_retrans;
 	__u16 sport, dport;
 	__u8 protocol;
-	int new_state;
+	int old_state, new_state;
 	struct event event = {};
 	struct birth start = {}, *startp;
 	struct tcp_sock *tp;
@@ -70,15 +163,43 @@ int inet_sock_set_state(struct trace_event_raw_inet_sock_set_state *args)
 	if (protocol != IPPROTO_TCP)
 		return 0;
 
+	//allow IPv4 and IPv6 only
+	event.family = BPF_CORE_READ(args, family);
+	if (event.family != AF_INET && event.family != AF_INET6)
+		return 0;
+
     sk = (struct sock *)BPF_CORE_READ(args, skaddr);
     sport = BPF_CORE_READ(args, sport);
     dport = BPF_CORE_READ(args, dport);
 
+    old_state = BPF_CORE_READ(args, oldstate);
     new_state = BPF_CORE_READ(args, newstate);
 
-	//interested in TCP_SYN_SENT, TCP_SYN_RECV and TCP_CLOSE only
-	if (new_state != TCP_SYN_SENT && new_state != TCP_SYN_RECV && new_state != TCP_CLOSE)
+	//listen() and close() of a listening socket run in the context of the process owning it,
+	//accepted sockets are cloned from the listening one and go from TCP_LISTEN to TCP_SYN_RECV
+	if (new_state == TCP_LISTEN || (old_state == TCP_LISTEN && new_state == TCP_CLOSE)) {
+		event.listen = true;
+		event.closed = new_state == TCP_CLOSE;
+		event.pid = bpf_get_current_pid_tgid() >> 32;
+		bpf_get_current_comm(&event.comm, sizeof(event.comm));
+		event.cgroup_id = sock_cgroup_id(sk);
+		event.ino = BPF_CORE_READ(sk, sk_socket, file, f_inode, i_ino);
+		source_and_destination(args, &event.saddr, &event.sport, &event.daddr, &event.dport);
+		output_event(args, &event, sizeof(event));
 		return 0;
+	}
+
+	//interested in TCP_SYN_SENT, TCP_SYN_RECV, TCP_ESTABLISHED and TCP_CLOSE only
+	if (new_state != TCP_SYN_SENT && new_state != TCP_SYN_RECV && new_state != TCP_ESTABLISHED && new_state != TCP_CLOSE)
+		return 0;
+
+	//remember the handshake completed, a close without it is a failed attempt
+	if (new_state == TCP_ESTABLISHED) {
+		startp = bpf_map_lookup_elem(&births, &sk);
+		if (startp)
+			startp->established = true;
+		return 0;
+	}
 
 	if (new_state == TCP_SYN_SENT || new_state == TCP_SYN_RECV) {
 
@@ -89,14 +210,31 @@ int inet_sock_set_state(struct trace_event_raw_inet_sock_set_state *args)
 		//am I the initiator of the connection
 		start.initiator = new_state == TCP_SYN_SENT;
 
+		//connect() runs in the context of the process, SYN_RECV in softirq where only the socket tells the cgroup
+		if (start.initiator) {
+			start.pid = bpf_get_current_pid_tgid() >> 32;
+			bpf_get_current_comm(&start.comm, sizeof(start.comm));
+			start.cgroup_id = bpf_get_current_cgroup_id();
+		} else {
+			start.cgroup_id = sock_cgroup_id(sk);
+		}
+		event.initiator = start.initiator;
+		event.pid = start.pid;
+		__builtin_memcpy(event.comm, start.comm, sizeof(event.comm));
+		event.cgroup_id = start.cgroup_id;
+
 		//source and destination IPs and ports depend on initiator flag
 		if(start.initiator)
 		    source_and_destination(args, &event.saddr, &event.sport, &event.daddr, &event.dport);
 		else
 		    source_and_destination(args, &event.daddr, &event.dport, &event.saddr, &event.sport);
 
-		//store event in BPF perf event
-		bpf_perf_event_output(args, &events, 0xffffffffULL, &event, sizeof(event));
+		//excluded connections get no birth, so their close is ignored too
+		if (excluded(event.family, event.saddr, event.daddr, event.sport, event.dport))
+			return 0;
+
+		//store event in BPF ring buffer or perf event array
+		output_event(args, &event, sizeof(event));
 
 		//store in map births, sk sock struct (network layer representation of sockets) as a key
 		bpf_map_update_elem(&births, &sk, &start, 0);
@@ -130,10 +268,26 @@ int inet_sock_set_state(struct trace_event_raw_inet_sock_set_state *args)
             event.tx_b = rx_b;
 		}
 
+		//smoothed RTT and its mean deviation are kept by the kernel shifted by 3 and 2 bits
+		event.srtt_us = BPF_CORE_READ(tp, srtt_us) >> 3;
+		event.mdev_us = BPF_CORE_READ(tp, mdev_us) >> 2;
+
 		event.closed = true;
+		event.reset = startp->reset;
+		event.established = startp->established;
+		event.initiator = startp->initiator;
+		event.pid = startp->pid;
+		__builtin_memcpy(event.comm, startp->comm, sizeof(event.comm));
+		event.cgroup_id = startp->cgroup_id;
 
-        //store event in BPF perf event
-		bpf_perf_event_output(args, &events, 0xffffffffULL, &event, sizeof(event));
+		//prefer the kernel counter when it saw more retransmits than the tracepoint
+		event.retransmits = startp->retransmits;
+		total_retrans = BPF_CORE_READ(tp, total_retrans);
+		if (total_retrans > event.retransmits)
+			event.retransmits = total_retrans;
+
+        //store event in BPF ring buffer or perf event array
+		output_event(args, &event, sizeof(event));
 
 		//remove element from births based on sock struct
 		bpf_map_delete_elem(&births, &sk);
@@ -141,4 +295,52 @@ int inet_sock_set_state(struct trace_event_raw_inet_sock_set_state *args)
 	}
 }
 
+// context of tcp_retransmit_skb and tcp_send_reset, they share the tcp_event_sk_skb class until kernel 6.10 and have
+// their own classes since, so no kernel type matches both, but all of them start with skbaddr and skaddr
+struct tcp_event_skb_args {
+	__u64 common;	// struct trace_entry
+	const void *skbaddr;
+	const void *skaddr;
+};
+
+// count retransmitted segments of a tracked connection
+SEC("tracepoint/tcp/tcp_retransmit_skb")
+int tcp_retransmit_skb(struct tcp_event_skb_args *args)
+{
+	struct birth *startp;
+	struct sock *sk;
+
+	sk = (struct sock *)args->skaddr;
+	startp = bpf_map_lookup_elem(&births, &sk);
+	if (startp)
+		__sync_fetch_and_add(&startp->retransmits, 1);
+	return 0;
+}
+
+static __always_inline void mark_reset(struct sock *sk) {
+	struct birth *startp;
+
+	startp = bpf_map_lookup_elem(&births, &sk);
+	if (startp)
+		startp->reset = true;
+}
+
+// RST sent by the local side, sk is NULL when no socket matched the segment
+SEC("tracepoint/tcp/tcp_send_reset")
+int tcp_send_reset(struct tcp_event_skb_args *args)
+{
+	struct sock *sk = (struct sock *)args->skaddr;
+	if (sk)
+		mark_reset(sk);
+	return 0;
+}
+
+// RST received from the peer
+SEC("tracepoint/tcp/tcp_receive_reset")
+int tcp_receive_reset(struct trace_event_raw_tcp_event_sk *args)
+{
+	mark_reset((struct sock *)BPF_CORE_READ(args, skaddr));
+	return 0;
+}
+
 char __license[] SEC("license") = "Dual MIT/GPL";
\ No newline at end of file
@@ -14,24 +14,49 @@ import (
 )
 
 type bpfBirth struct {
-	_         structs.HostLayout
-	Ts        uint64
-	Initiator bool
-	_         [7]byte
+	_           structs.HostLayout
+	Ts          uint64
+	Initiator   bool
+	Reset       bool
+	Established bool
+	_           [1]byte
+	Retransmits uint32
+	Pid         uint32
+	Comm        [16]int8
+	_           [4]byte
+	CgroupId    uint64
 }
 
 type bpfEvent struct {
-	_       structs.HostLayout
-	Saddr   uint32
-	Daddr   uint32
-	Sport   uint16
-	Dport   uint16
-	_       [4]byte
-	DeltaUs uint64
-	RxB     uint64
-	TxB     uint64
-	Closed  bool
-	_       [7]byte
+	_           structs.HostLayout
+	Saddr       [16]uint8
+	Daddr       [16]uint8
+	Sport       uint16
+	Dport       uint16
+	Family      uint16
+	Listen      bool
+	_           [1]byte
+	DeltaUs     uint64
+	RxB         uint64
+	TxB         uint64
+	Closed      bool
+	Reset       bool
+	Established bool
+	Initiator   bool
+	Retransmits uint32
+	SrttUs      uint32
+	MdevUs      uint32
+	Pid         uint32
+	Comm        [16]int8
+	_           [4]byte
+	CgroupId    uint64
+	Ino         uint64
+}
+
+type bpfFilterKey struct {
+	_         structs.HostLayout
+	Prefixlen uint32
+	Addr      [16]uint8
 }
 
 // loadBpf returns the embedded CollectionSpec for bpf.
@@ -77,21 +102,28 @@ type bpfSpecs struct {
 // It can be passed ebpf.CollectionSpec.Assign.
 type bpfProgramSpecs struct {
 	InetSockSetState *ebpf.ProgramSpec `ebpf:"inet_sock_set_state"`
+	TcpReceiveReset  *ebpf.ProgramSpec `ebpf:"tcp_receive_reset"`
+	TcpRetransmitSkb *ebpf.ProgramSpec `ebpf:"tcp_retransmit_skb"`
+	TcpSendReset     *ebpf.ProgramSpec `ebpf:"tcp_send_reset"`
 }
 
 // bpfMapSpecs contains maps before they are loaded into the kernel.
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type bpfMapSpecs struct {
-	Births *ebpf.MapSpec `ebpf:"births"`
-	Events *ebpf.MapSpec `ebpf:"events"`
+	Births        *ebpf.MapSpec `ebpf:"births"`
+	Events        *ebpf.MapSpec `ebpf:"events"`
+	ExcludedAddrs *ebpf.MapSpec `ebpf:"excluded_addrs"`
+	ExcludedPorts *ebpf.MapSpec `ebpf:"excluded_ports"`
+	LostEvents    *ebpf.MapSpec `ebpf:"lost_events"`
 }
 
 // bpfVariableSpecs contains global variables before they are loaded into the kernel.
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type bpfVariableSpecs struct {
-	Unused *ebpf.VariableSpec `ebpf:"unused"`
+	Unused     *ebpf.VariableSpec `ebpf:"unused"`
+	UseRingbuf *ebpf.VariableSpec `ebpf:"use_ringbuf"`
 }
 
 // bpfObjects contains all objects after they have been loaded into the kernel.
@@ -114,14 +146,20 @@ func (o *bpfObjects) Close() error {
 //
 // It can be passed to loadBpfObjects or ebpf.CollectionSpec.LoadAndAssign.
 type bpfMaps struct {
-	Births *ebpf.Map `ebpf:"births"`
-	Events *ebpf.Map `ebpf:"events"`
+	Births        *ebpf.Map `ebpf:"births"`
+	Events        *ebpf.Map `ebpf:"events"`
+	ExcludedAddrs *ebpf.Map `ebpf:"excluded_addrs"`
+	ExcludedPorts *ebpf.Map `ebpf:"excluded_ports"`
+	LostEvents    *ebpf.Map `ebpf:"lost_events"`
 }
 
 func (m *bpfMaps) Close() error {
 	return _BpfClose(
 		m.Births,
 		m.Events,
+		m.ExcludedAddrs,
+		m.ExcludedPorts,
+		m.LostEvents,
 	)
 }
 
@@ -129,7 +167,8 @@ func (m *bpfMaps) Close() error {
 //
 // It can be passed to loadBpfObjects or ebpf.CollectionSpec.LoadAndAssign.
 type bpfVariables struct {
-	Unused *ebpf.Variable `ebpf:"unused"`
+	Unused     *ebpf.Variable `ebpf:"unused"`
+	UseRingbuf *ebpf.Variable `ebpf:"use_ringbuf"`
 }
 
 // bpfPrograms contains all programs after they have been loaded into the kernel.
@@ -137,11 +176,17 @@ type bpfVariables struct {
 // It can be passed to loadBpfObjects or ebpf.CollectionSpec.LoadAndAssign.
 type bpfPrograms struct {
 	InetSockSetState *ebpf.Program `ebpf:"inet_sock_set_state"`
+	TcpReceiveReset  *ebpf.Program `ebpf:"tcp_receive_reset"`
+	TcpRetransmitSkb *ebpf.Program `ebpf:"tcp_retransmit_skb"`
+	TcpSendReset     *ebpf.Program `ebpf:"tcp_send_reset"`
 }
 
 func (p *bpfPrograms) Close() error {
 	return _BpfClose(
 		p.InetSockSetState,
+		p.TcpReceiveReset,
+		p.TcpRetransmitSkb,
+		p.TcpSendReset,
 	)
 }
 
@@ -6,16 +6,16 @@ import (
 	"encoding/binary"
 	"errors"
 	"log/slog"
-	"os"
-	"os/signal"
-	"syscall"
+	"time"
 
+	"github.com/cilium/ebpf"
 	"github.com/cilium/ebpf/link"
-	"github.com/cilium/ebpf/perf"
 	"github.com/cilium/ebpf/rlimit"
 	"github.com/k8spacket/k8spacket/internal/broker"
+	"github.com/k8spacket/k8spacket/internal/config"
 	"github.com/k8spacket/k8spacket/internal/ebpf/tools"
 	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/k8spacket/k8spacket/internal/status"
 )
 
 /*
@@ -30,13 +30,20 @@ bpf - identity name of generating program
 */
 //go:generate go run github.com/cilium/ebpf/cmd/bpf2go -cc clang -target native -type event -go-package ebpf_inet bpf ./bpf/inet.bpf.c
 
+const component = "inet"
+
 type EbpfInet struct {
-	Broker broker.Broker
+	Broker    broker.Broker
+	Transport config.TransportConfig
+	Maps      config.MapsConfig
+	// Listeners publishes sockets already listening at startup, the program sees only those listening later
+	Listeners bool
 }
 
-func (ebpfInet *EbpfInet) Init() {
+func (ebpfInet *EbpfInet) Init(ctx context.Context) {
 
 	slog.Info("INIT inet")
+	status.Report(component, status.Starting, "")
 	// Allow the current process to lock more memory than the default for eBPF resources. Default value is 64KB
 	// https://prototype-kernel.readthedocs.io/en/latest/bpf/troubleshooting.html#memory-ulimits
 	// requires on kernels < 5.11 to remove memlock (error: failed to set memlock rlimit: operation not permitted)
@@ -46,24 +53,48 @@ func (ebpfInet *EbpfInet) Init() {
 
 	// Load pre-compiled programs and maps into the kernel.
 	objs := bpfObjects{}
-	if err := loadBpfObjects(&objs, nil); err != nil {
+	if err := ebpfInet.load(&objs); err != nil {
 		slog.Error("[inet] Loading objects", "Error", err)
+		status.Report(component, status.Down, "cannot load objects: "+err.Error())
+		return
 	}
 	defer objs.Close()
+	// excluded traffic is filtered in the kernel as soon as the program is attached
+	defer ebpf_tools.AttachFilter(objs.ExcludedAddrs, objs.ExcludedPorts)()
 
 	// attach the eBPF program to the tracepoint sock/inet_sock_set_state
 	ln, err := link.Tracepoint("sock", "inet_sock_set_state", objs.bpfPrograms.InetSockSetState, nil)
 	if err != nil {
 		slog.Error("[inet] Cannot attach tracepoint", "Error", err)
+		status.Report(component, status.Down, "cannot attach tracepoint: "+err.Error())
+		return
 	}
 	defer ln.Close()
 
-	// create new reader for perf events
-	rd, err := perf.NewReader(objs.bpfMaps.Events, os.Getpagesize())
+	// retransmits and resets are counted per connection tracked by inet_sock_set_state
+	for name, program := range map[string]*ebpf.Program{
+		"tcp_retransmit_skb": objs.bpfPrograms.TcpRetransmitSkb,
+		"tcp_send_reset":     objs.bpfPrograms.TcpSendReset,
+		"tcp_receive_reset":  objs.bpfPrograms.TcpReceiveReset,
+	} {
+		ln, err := link.Tracepoint("tcp", name, program, nil)
+		if err != nil {
+			slog.Error("[inet] Cannot attach tracepoint", "Tracepoint", name, "Error", err)
+			status.Report(component, status.Down, "cannot attach tracepoint "+name+": "+err.Error())
+			return
+		}
+		defer ln.Close()
+	}
+
+	// create new reader for ring buffer or perf events
+	rd, err := ebpf_tools.NewEventReader(objs.bpfMaps.Events, ebpfInet.Transport)
 	if err != nil {
-		slog.Error("[inet] Creating perf event reader", "Error", err)
+		slog.Error("[inet] Creating event reader", "Error", err)
+		status.Report(component, status.Down, "cannot create event reader: "+err.Error())
+		return
 	}
 	defer rd.Close()
+	status.Report(component, status.Up, "")
 
 	go func() {
 		// bpfEvent is generated by bpf2go and represents perf event type in eBPF program
@@ -71,47 +102,104 @@ func (ebpfInet *EbpfInet) Init() {
 		for {
 			record, err := rd.Read()
 			if err != nil {
-				if errors.Is(err, perf.ErrClosed) {
+				if errors.Is(err, ebpf_tools.ErrClosed) {
 					slog.Info("[inet] Received signal, exiting..")
 					return
 				}
+				ebpf_tools.ReadErrorsMetric.WithLabelValues("inet", ebpf_tools.AnyInterface).Inc()
 				slog.Error("[inet] Reading from reader", "Error", err)
 				continue
 			}
+			if record.LostSamples > 0 {
+				ebpf_tools.LostSamplesMetric.WithLabelValues("inet", ebpf_tools.AnyInterface).Add(float64(record.LostSamples))
+				slog.Warn("[inet] Perf buffer full, samples lost", "Lost", record.LostSamples)
+				continue
+			}
 
 			// Parse the perf event into a go bpfEvent structure.
 			if err := binary.Read(bytes.NewBuffer(record.RawSample), binary.LittleEndian, &event); err != nil {
+				ebpf_tools.ParseErrorsMetric.WithLabelValues("inet", ebpf_tools.AnyInterface).Inc()
 				slog.Error("[inet] Parsing perf event", "Error", err)
 				continue
 			}
+			ebpf_tools.EventsMetric.WithLabelValues("inet").Inc()
 
 			distribute(event, ebpfInet)
 		}
 	}()
 
-	// graceful shutdown
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer stop()
+	// sockets starting to listen from now on are reported by the program, so this scan misses none of them
+	if ebpfInet.Listeners {
+		listeners := ebpf_tools.ScanListeners()
+		for _, event := range listeners {
+			ebpfInet.Broker.ListenEvent(event)
+		}
+		slog.Info("[inet] Listening sockets found", "Count", len(listeners))
+	}
 
-	<-ctx.Done()
+	// count events lost by the ring buffer until shutdown, deferred closes detach the program
+	ebpf_tools.WatchLostEvents(ctx, objs.LostEvents, "inet", ebpf_tools.AnyInterface)
 
 	slog.Info("[inet] Closed gracefully")
 }
 
 func distribute(event bpfEvent, inet *EbpfInet) {
+	if event.Listen {
+		distributeListen(event, inet)
+		return
+	}
 	tcpEvent := modules.TCPEvent{
 		Client: modules.Address{
-			Addr: ebpf_tools.IntToIP4(event.Saddr, binary.LittleEndian.PutUint32),
+			Addr: ebpf_tools.BytesToIP(event.Family, event.Saddr),
 			Port: event.Sport},
 		Server: modules.Address{
-			Addr: ebpf_tools.IntToIP4(event.Daddr, binary.LittleEndian.PutUint32),
+			Addr: ebpf_tools.BytesToIP(event.Family, event.Daddr),
 			Port: event.Dport},
-		TxB:     event.TxB,
-		RxB:     event.RxB,
-		DeltaUs: event.DeltaUs / 1000,
-		Closed:  event.Closed}
-	ebpf_tools.EnrichAddress(&tcpEvent.Client)
-	ebpf_tools.EnrichAddress(&tcpEvent.Server)
+		TxB:         event.TxB,
+		RxB:         event.RxB,
+		DeltaUs:     event.DeltaUs / 1000,
+		Closed:      event.Closed,
+		Failed:      event.Closed && !event.Established,
+		Retransmits: event.Retransmits,
+		Reset:       event.Reset,
+		SrttUs:      event.SrttUs,
+		RttVarUs:    event.MdevUs,
+		Time:        time.Now()}
+	ebpf_tools.EnrichConnection(&tcpEvent.Client, &tcpEvent.Server)
+
+	// pid, comm and cgroup belong to the local socket
+	local := &tcpEvent.Server
+	if event.Initiator {
+		local = &tcpEvent.Client
+	}
+	ebpf_tools.AttributeProcess(local, event.Pid, ebpf_tools.CommToString(event.Comm), event.CgroupId)
 
 	inet.Broker.TCPEvent(tcpEvent)
 }
+
+func distributeListen(event bpfEvent, inet *EbpfInet) {
+	listenEvent := modules.ListenEvent{
+		Listener: modules.Address{
+			Addr: ebpf_tools.BytesToIP(event.Family, event.Saddr),
+			Port: event.Sport},
+		Ino:    event.Ino,
+		Closed: event.Closed,
+		Time:   time.Now()}
+	ebpf_tools.EnrichAddress(&listenEvent.Listener)
+	ebpf_tools.AttributeProcess(&listenEvent.Listener, event.Pid, ebpf_tools.CommToString(event.Comm), event.CgroupId)
+
+	inet.Broker.ListenEvent(listenEvent)
+}
+
+// loadBpfObjects with the events map set up for the configured transport and maps sized from the config
+func (ebpfInet *EbpfInet) load(objs *bpfObjects) error {
+	spec, err := loadBpf()
+	if err != nil {
+		return err
+	}
+	ebpf_tools.ResizeMaps(spec, map[string]int{"births": ebpfInet.Maps.Connections, "excluded_addrs": ebpfInet.Maps.FilterAddresses})
+	if err := ebpf_tools.PrepareTransport(spec, "events", ebpfInet.Transport); err != nil {
+		return err
+	}
+	return spec.LoadAndAssign(objs, nil)
+}
@@ -1,57 +1,170 @@
 package ebpf_inet
 
 import (
-	"encoding/binary"
+	"os"
+	"syscall"
 	"testing"
 
+	"github.com/k8spacket/k8spacket/internal/broker"
+	"github.com/k8spacket/k8spacket/internal/config"
 	ebpf_tools "github.com/k8spacket/k8spacket/internal/ebpf/tools"
 	"github.com/k8spacket/k8spacket/internal/modules"
 	"github.com/stretchr/testify/assert"
 )
 
 type fakeBroker struct {
-	last modules.TCPEvent
+	broker.Broker
+	last       modules.TCPEvent
+	lastListen *modules.ListenEvent
 }
 
 func (f *fakeBroker) DistributeEvents()               {}
 func (f *fakeBroker) TCPEvent(event modules.TCPEvent) { f.last = event }
 func (f *fakeBroker) TLSEvent(event modules.TLSEvent) {}
+func (f *fakeBroker) ListenEvent(event modules.ListenEvent) {
+	f.lastListen = &event
+}
 
 func TestDistribute(t *testing.T) {
 	// use private IPs so EnrichAddress will set Name to "N/A"
-	sBytes := []byte{192, 168, 0, 1}
-	dBytes := []byte{10, 0, 0, 2}
-	sNum := binary.LittleEndian.Uint32(sBytes)
-	dNum := binary.LittleEndian.Uint32(dBytes)
-
-	evt := bpfEvent{
-		Saddr:   sNum,
-		Daddr:   dNum,
-		Sport:   12345,
-		Dport:   80,
-		TxB:     1000,
-		RxB:     2000,
-		DeltaUs: 5000,
-		Closed:  true,
-	}
-
-	fb := &fakeBroker{}
-	inet := &EbpfInet{Broker: fb}
-
-	distribute(evt, inet)
-
-	got := fb.last
-
-	assert.Equal(t, ebpf_tools.IntToIP4(sNum, binary.LittleEndian.PutUint32), got.Client.Addr)
-	assert.Equal(t, uint16(12345), got.Client.Port)
-	assert.Equal(t, ebpf_tools.IntToIP4(dNum, binary.LittleEndian.PutUint32), got.Server.Addr)
-	assert.Equal(t, uint16(80), got.Server.Port)
-	assert.Equal(t, uint64(1000), got.TxB)
-	assert.Equal(t, uint64(2000), got.RxB)
-	// DeltaUs is divided by 1000 in distribute
-	assert.Equal(t, uint64(5), got.DeltaUs)
-	assert.True(t, got.Closed)
-	// EnrichAddress for private IPs should set Name to "N/A"
-	assert.Equal(t, "N/A", got.Client.Name)
-	assert.Equal(t, "N/A", got.Server.Name)
+	var tests = []struct {
+		family       uint16
+		saddr, daddr [16]uint8
+		client       string
+		server       string
+	}{
+		{syscall.AF_INET, [16]uint8{192, 168, 0, 1}, [16]uint8{10, 0, 0, 2}, "192.168.0.1", "10.0.0.2"},
+		{syscall.AF_INET6, [16]uint8{0xfd, 0x00, 15: 1}, [16]uint8{0xfd, 0x00, 15: 2}, "fd00::1", "fd00::2"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.client, func(t *testing.T) {
+			evt := bpfEvent{
+				Saddr:       test.saddr,
+				Daddr:       test.daddr,
+				Sport:       12345,
+				Dport:       80,
+				Family:      test.family,
+				TxB:         1000,
+				RxB:         2000,
+				DeltaUs:     5000,
+				Closed:      true,
+				Retransmits: 3,
+				Reset:       true,
+				Established: true,
+				Initiator:   true,
+				Pid:         4321,
+				Comm:        [16]int8{'c', 'u', 'r', 'l'},
+				SrttUs:      250,
+				MdevUs:      40,
+			}
+
+			fb := &fakeBroker{}
+			inet := &EbpfInet{Broker: fb}
+
+			distribute(evt, inet)
+
+			got := fb.last
+
+			assert.Equal(t, test.client, got.Client.Addr)
+			assert.Equal(t, uint16(12345), got.Client.Port)
+			assert.Equal(t, test.server, got.Server.Addr)
+			assert.Equal(t, uint16(80), got.Server.Port)
+			assert.Equal(t, uint64(1000), got.TxB)
+			assert.Equal(t, uint64(2000), got.RxB)
+			// DeltaUs is divided by 1000 in distribute
+			assert.Equal(t, uint64(5), got.DeltaUs)
+			assert.True(t, got.Closed)
+			assert.Equal(t, uint32(3), got.Retransmits)
+			assert.True(t, got.Reset)
+			assert.False(t, got.Failed)
+			assert.Equal(t, uint32(250), got.SrttUs)
+			assert.Equal(t, uint32(40), got.RttVarUs)
+			// EnrichAddress for private IPs should set Name to "N/A"
+			assert.Equal(t, "N/A", got.Client.Name)
+			assert.Equal(t, "N/A", got.Server.Name)
+			// the initiator is the local socket
+			assert.Equal(t, uint32(4321), got.Client.Pid)
+			assert.Equal(t, "curl", got.Client.Process)
+			assert.Empty(t, got.Server.Process)
+		})
+	}
+}
+
+func TestDistributeFailed(t *testing.T) {
+	var tests = []struct {
+		name        string
+		closed      bool
+		established bool
+		want        bool
+	}{
+		{"connect", false, false, false},
+		{"closed", true, true, false},
+		{"refused or timed out", true, false, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fb := &fakeBroker{}
+			distribute(bpfEvent{Family: syscall.AF_INET, Closed: test.closed, Established: test.established}, &EbpfInet{Broker: fb})
+			assert.Equal(t, test.want, fb.last.Failed)
+		})
+	}
+}
+
+func TestDistributeListen(t *testing.T) {
+	var tests = []struct {
+		name   string
+		family uint16
+		saddr  [16]uint8
+		addr   string
+		closed bool
+	}{
+		{"wildcard", syscall.AF_INET, [16]uint8{}, "0.0.0.0", false},
+		{"ipv6 wildcard closed", syscall.AF_INET6, [16]uint8{}, "::", true},
+		{"loopback", syscall.AF_INET, [16]uint8{127, 0, 0, 1}, "127.0.0.1", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fb := &fakeBroker{}
+			distribute(bpfEvent{Family: test.family, Saddr: test.saddr, Sport: 8080, Listen: true, Closed: test.closed,
+				Pid: 77, Comm: [16]int8{'n', 'g', 'i', 'n', 'x'}, Ino: 4711}, &EbpfInet{Broker: fb})
+
+			// listening sockets are not connections
+			assert.Equal(t, modules.TCPEvent{}, fb.last)
+			if assert.NotNil(t, fb.lastListen) {
+				assert.Equal(t, test.addr, fb.lastListen.Listener.Addr)
+				assert.Equal(t, uint16(8080), fb.lastListen.Listener.Port)
+				assert.Equal(t, "N/A", fb.lastListen.Listener.Name)
+				assert.Equal(t, uint32(77), fb.lastListen.Listener.Pid)
+				assert.Equal(t, "nginx", fb.lastListen.Listener.Process)
+				assert.Equal(t, uint64(4711), fb.lastListen.Ino)
+				assert.Equal(t, test.closed, fb.lastListen.Closed)
+				assert.False(t, fb.lastListen.Time.IsZero())
+			}
+		})
+	}
+}
+
+// every map, program and variable of the generated bindings is in the object
+func TestObjects(t *testing.T) {
+	spec, err := loadBpf()
+	assert.Nil(t, err)
+	assert.Nil(t, spec.Assign(&bpfSpecs{}))
+}
+
+// the programs pass the verifier of the running kernel with both transports, loading them requires root
+func TestLoad(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("loading eBPF programs requires root")
+	}
+	for _, mode := range []string{ebpf_tools.TransportRingBuf, ebpf_tools.TransportPerf} {
+		ebpfInet := EbpfInet{
+			Transport: config.TransportConfig{Mode: mode, RingBufferSize: 256 * 1024, PerfBufferSize: 64 * 1024},
+			Maps:      config.MapsConfig{Connections: 16384, FilterAddresses: 4096}}
+		objs := bpfObjects{}
+		assert.Nil(t, ebpfInet.load(&objs), mode)
+		objs.Close()
+	}
 }
@@ -1,5 +1,7 @@
 package ebpf_inet
 
+import "context"
+
 type Inet interface {
-	Init()
+	Init(ctx context.Context)
 }
@@ -0,0 +1,267 @@
+package ebpf
+
+import (
+	"context"
+	"log/slog"
+	"os/exec"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/k8spacket/k8spacket/internal/status"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// Links lists network interfaces of the node and reports them as they come and go
+type Links interface {
+	Subscribe(ctx context.Context) (<-chan netlink.LinkUpdate, error)
+	List() ([]netlink.Link, error)
+}
+
+type netlinkLinks struct{}
+
+// Subscribe streams link updates until ctx is done, the channel is closed then or when receiving fails
+func (netlinkLinks) Subscribe(ctx context.Context) (<-chan netlink.LinkUpdate, error) {
+	updates := make(chan netlink.LinkUpdate)
+	err := netlink.LinkSubscribeWithOptions(updates, ctx.Done(), netlink.LinkSubscribeOptions{
+		ErrorCallback: func(err error) { slog.Error("[tc-loop] Receiving link updates", "Error", err) },
+	})
+	return updates, err
+}
+
+func (netlinkLinks) List() ([]netlink.Link, error) {
+	return netlink.LinkList()
+}
+
+// Interface is a network interface of the node known to the loader. The TC program runs on attached interfaces,
+// State and Reason are the ones it reports.
+type Interface struct {
+	Name     string       `json:"name"`
+	Index    int          `json:"index,omitempty"`
+	Attached bool         `json:"attached"`
+	State    status.State `json:"state,omitempty"`
+	Reason   string       `json:"reason,omitempty"`
+	Since    time.Time    `json:"since"`
+}
+
+type networkInterface struct {
+	index  int
+	since  time.Time
+	cancel context.CancelFunc
+}
+
+// Interfaces returns known network interfaces sorted by name
+func (loader *EbpfLoader) Interfaces() []Interface {
+	loader.mu.Lock()
+	defer loader.mu.Unlock()
+	result := make([]Interface, 0, len(loader.interfaces))
+	for name, known := range loader.interfaces {
+		item := Interface{Name: name, Index: known.index, Attached: known.cancel != nil, Since: known.since}
+		if component, ok := status.Default().Component("tc/" + name); ok && item.Attached {
+			item.State, item.Reason = component.State, component.Reason
+		}
+		result = append(result, item)
+	}
+	slices.SortFunc(result, func(a, b Interface) int { return strings.Compare(a.Name, b.Name) })
+	return result
+}
+
+// watchInterfaces attaches the TC program to interfaces as netlink reports them and detaches it from removed ones
+func (loader *EbpfLoader) watchInterfaces(ctx context.Context) {
+	if loader.store.Get().Loader.Interfaces.Command != "" {
+		slog.Warn("[tc-loop] loader.interfaces.command is deprecated and will be removed, use include and exclude patterns instead")
+		loader.interfacesRefresher(ctx)
+		return
+	}
+	for {
+		loader.followLinks(ctx)
+		select {
+		case <-ctx.Done():
+			slog.Info("[tc-loop] Receive signal, exiting...")
+			return
+		case <-time.After(loader.store.Get().Loader.Interfaces.RefreshPeriod.Duration):
+		}
+	}
+}
+
+// followLinks syncs interfaces with the links of the node, then follows link updates until the subscription fails
+func (loader *EbpfLoader) followLinks(ctx context.Context) {
+	subscriptionCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// links are listed after subscribing so that none is missed, updates of listed links are applied twice
+	updates, err := loader.links.Subscribe(subscriptionCtx)
+	if err != nil {
+		slog.Error("[tc-loop] Cannot subscribe to link updates", "Error", err)
+		return
+	}
+	// the subscription blocks on sending updates until its channel is closed
+	defer func() {
+		go func() {
+			for range updates {
+			}
+		}()
+	}()
+	links, err := loader.links.List()
+	if err != nil {
+		slog.Error("[tc-loop] Cannot list links", "Error", err)
+		return
+	}
+	present := make(map[string]int)
+	for _, link := range links {
+		present[link.Attrs().Name] = link.Attrs().Index
+	}
+	loader.setInterfaces(ctx, present)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-loader.reconfigure:
+			loader.applyPatterns(ctx)
+		case update, ok := <-updates:
+			if !ok {
+				slog.Warn("[tc-loop] Link updates stopped, subscribing again later")
+				return
+			}
+			switch update.Header.Type {
+			case unix.RTM_NEWLINK:
+				loader.addInterface(ctx, update.Link.Attrs().Name, update.Link.Attrs().Index)
+			case unix.RTM_DELLINK:
+				loader.removeInterface(update.Link.Attrs().Name)
+			}
+		}
+	}
+}
+
+// interfacesRefresher runs the interfaces command periodically, interfaces it no longer prints are detached
+func (loader *EbpfLoader) interfacesRefresher(ctx context.Context) {
+	for {
+		// interfaces settings are read on every iteration to follow configuration reloads
+		interfaces := loader.store.Get().Loader.Interfaces
+		select {
+		case <-ctx.Done():
+			slog.Info("[tc-loop] Receive signal, exiting...")
+			return
+		case <-time.After(interfaces.RefreshPeriod.Duration):
+			slog.Info("[tc-loop] Refreshing interfaces for capturing...")
+			names := findInterfaces(interfaces.Command)
+			// keep programs attached when interfaces cannot be listed at the moment
+			if names == nil {
+				continue
+			}
+			present := make(map[string]int)
+			for _, name := range names {
+				if name = strings.TrimSpace(name); name != "" {
+					present[name] = 0
+				}
+			}
+			loader.setInterfaces(ctx, present)
+		}
+	}
+}
+
+// setInterfaces replaces known interfaces, programs of the missing ones are detached
+func (loader *EbpfLoader) setInterfaces(ctx context.Context, present map[string]int) {
+	loader.mu.Lock()
+	for name, known := range loader.interfaces {
+		if _, ok := present[name]; !ok {
+			detach(known)
+			delete(loader.interfaces, name)
+		}
+	}
+	for name, index := range present {
+		loader.knowInterface(name, index)
+	}
+	loader.mu.Unlock()
+	loader.applyPatterns(ctx)
+}
+
+func (loader *EbpfLoader) addInterface(ctx context.Context, name string, index int) {
+	loader.mu.Lock()
+	// a renamed interface keeps its index
+	for other, known := range loader.interfaces {
+		if other != name && index != 0 && known.index == index {
+			detach(known)
+			delete(loader.interfaces, other)
+		}
+	}
+	loader.knowInterface(name, index)
+	loader.mu.Unlock()
+	loader.applyPatterns(ctx)
+}
+
+func (loader *EbpfLoader) removeInterface(name string) {
+	loader.mu.Lock()
+	if known, ok := loader.interfaces[name]; ok {
+		slog.Info("[tc-loop] Interface removed", "interface", name)
+		detach(known)
+		delete(loader.interfaces, name)
+	}
+	loader.mu.Unlock()
+}
+
+func (loader *EbpfLoader) knowInterface(name string, index int) {
+	if known, ok := loader.interfaces[name]; ok {
+		known.index = index
+		return
+	}
+	loader.interfaces[name] = &networkInterface{index: index, since: time.Now()}
+}
+
+// applyPatterns attaches the TC program to known interfaces matching the patterns and detaches it from the others
+func (loader *EbpfLoader) applyPatterns(ctx context.Context) {
+	interfaces := loader.store.Get().Loader.Interfaces
+	loader.mu.Lock()
+	defer loader.mu.Unlock()
+	for name, known := range loader.interfaces {
+		included := isIncluded(interfaces, name)
+		if included && known.cancel == nil {
+			slog.Info("[tc-loop] Attaching to interface", "interface", name)
+			// every interface has its own context to detach the program when the interface disappears
+			ifaceCtx, cancel := context.WithCancel(ctx)
+			known.cancel, known.since = cancel, time.Now()
+			loader.run(func() { loader.tcEbpf.Init(ifaceCtx, name) })
+		} else if !included && known.cancel != nil {
+			slog.Info("[tc-loop] Detaching from interface", "interface", name)
+			detach(known)
+			known.since = time.Now()
+		}
+	}
+}
+
+func detach(known *networkInterface) {
+	if known.cancel != nil {
+		known.cancel()
+		known.cancel = nil
+	}
+}
+
+// interfaces printed by a command are all included, patterns select among links reported by netlink
+func isIncluded(interfaces config.InterfacesConfig, name string) bool {
+	if interfaces.Command != "" {
+		return true
+	}
+	return matchesAny(interfaces.Include, name) && !matchesAny(interfaces.Exclude, name)
+}
+
+func matchesAny(patterns []string, name string) bool {
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		matched, _ := path.Match(pattern, name)
+		return matched
+	})
+}
+
+// looking for network interfaces on cluster nodes regarding started containers based on the command `ip address`
+func findInterfaces(command string) []string {
+	cmd := exec.Command("sh", "-c", command)
+	out, err := cmd.Output()
+
+	if err != nil {
+		slog.Error("[tc-loop] Cannot find interfaces to listen", "Error", err)
+		return nil
+	}
+	return strings.Split(string(out), ",")
+}
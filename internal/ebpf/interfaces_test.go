@@ -0,0 +1,174 @@
+package ebpf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/config"
+	ebpf_tc "github.com/k8spacket/k8spacket/internal/ebpf/tc"
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+type mockLinks struct {
+	Links
+	mu           sync.Mutex
+	links        []netlink.Link
+	updates      chan netlink.LinkUpdate
+	subscribeErr error
+}
+
+func (mock *mockLinks) Subscribe(ctx context.Context) (<-chan netlink.LinkUpdate, error) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return mock.updates, mock.subscribeErr
+}
+
+func (mock *mockLinks) List() ([]netlink.Link, error) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return mock.links, nil
+}
+
+// attachedEbpfTc tracks interfaces the program runs on until their context is done
+type attachedEbpfTc struct {
+	ebpf_tc.Tc
+	mu       sync.Mutex
+	attached map[string]int
+}
+
+func (mock *attachedEbpfTc) Init(ctx context.Context, iface string) {
+	mock.mu.Lock()
+	mock.attached[iface]++
+	mock.mu.Unlock()
+	<-ctx.Done()
+	mock.mu.Lock()
+	mock.attached[iface]--
+	if mock.attached[iface] == 0 {
+		delete(mock.attached, iface)
+	}
+	mock.mu.Unlock()
+}
+
+func (mock *attachedEbpfTc) interfaces() map[string]int {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	result := make(map[string]int)
+	for iface, count := range mock.attached {
+		result[iface] = count
+	}
+	return result
+}
+
+func link(name string, index int) netlink.Link {
+	return &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: name, Index: index}}
+}
+
+func update(kind uint16, name string, index int) netlink.LinkUpdate {
+	return netlink.LinkUpdate{Header: unix.NlMsghdr{Type: kind}, Link: link(name, index)}
+}
+
+func newInterfacesLoader(cfg *config.Config, links Links, tc ebpf_tc.Tc) (*EbpfLoader, *config.Store) {
+	cfg.Loader.Source = "tc"
+	cfg.Loader.Interfaces.RefreshPeriod.Duration = 10 * time.Millisecond
+	store := config.NewStore(cfg)
+	loader := Init(store, &mockEbpfInet{}, tc, &mockEbpfSocketfilter{}, &mockEbpfDns{}, &mockEbpfHttp{})
+	loader.links = links
+	return loader, store
+}
+
+func TestWatchInterfaces(t *testing.T) {
+	links := &mockLinks{links: []netlink.Link{link("lo", 1), link("eth0", 2), link("cali0", 3)}, updates: make(chan netlink.LinkUpdate)}
+	tc := &attachedEbpfTc{attached: make(map[string]int)}
+	loader, store := newInterfacesLoader(config.Default(), links, tc)
+
+	loader.Load(context.Background())
+
+	// only pod interfaces by default
+	assert.Eventually(t, func() bool { return assert.ObjectsAreEqual(map[string]int{"cali0": 1}, tc.interfaces()) }, time.Second, 10*time.Millisecond)
+
+	// pods come and go
+	links.updates <- update(unix.RTM_NEWLINK, "veth1", 4)
+	links.updates <- update(unix.RTM_NEWLINK, "veth1", 4)
+	links.updates <- update(unix.RTM_NEWLINK, "veth2", 5)
+	links.updates <- update(unix.RTM_DELLINK, "veth1", 4)
+	assert.Eventually(t, func() bool { return assert.ObjectsAreEqual(map[string]int{"cali0": 1, "veth2": 1}, tc.interfaces()) }, time.Second, 10*time.Millisecond)
+
+	// a renamed interface is detached under its old name
+	links.updates <- update(unix.RTM_NEWLINK, "lxc2", 5)
+	assert.Eventually(t, func() bool { return assert.ObjectsAreEqual(map[string]int{"cali0": 1, "lxc2": 1}, tc.interfaces()) }, time.Second, 10*time.Millisecond)
+
+	// patterns follow configuration reloads
+	reloaded := config.Default()
+	reloaded.Loader.Source = "tc"
+	reloaded.Loader.Interfaces.Include = []string{"*"}
+	reloaded.Loader.Interfaces.Exclude = []string{"lo", "lxc*"}
+	store.Set(reloaded)
+	assert.Eventually(t, func() bool { return assert.ObjectsAreEqual(map[string]int{"cali0": 1, "eth0": 1}, tc.interfaces()) }, time.Second, 10*time.Millisecond)
+
+	interfaces := loader.Interfaces()
+	assert.Len(t, interfaces, 4)
+	assert.EqualValues(t, Interface{Name: "cali0", Index: 3, Attached: true, Since: interfaces[0].Since}, interfaces[0])
+	assert.EqualValues(t, "lo", interfaces[2].Name)
+	assert.False(t, interfaces[2].Attached)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, loader.Stop(ctx))
+	assert.Empty(t, tc.interfaces())
+}
+
+func TestWatchInterfacesResubscribe(t *testing.T) {
+	links := &mockLinks{links: []netlink.Link{link("cali0", 2), link("veth1", 4)}, updates: make(chan netlink.LinkUpdate)}
+	tc := &attachedEbpfTc{attached: make(map[string]int)}
+	loader, _ := newInterfacesLoader(config.Default(), links, tc)
+
+	loader.Load(context.Background())
+	assert.Eventually(t, func() bool { return assert.ObjectsAreEqual(map[string]int{"cali0": 1, "veth1": 1}, tc.interfaces()) }, time.Second, 10*time.Millisecond)
+
+	// veth1 disappears while updates are lost, it is detached once links are listed again
+	links.mu.Lock()
+	close(links.updates)
+	links.links = []netlink.Link{link("cali0", 2)}
+	links.subscribeErr = errors.New("no buffer space available")
+	links.mu.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	assert.EqualValues(t, map[string]int{"cali0": 1, "veth1": 1}, tc.interfaces())
+
+	links.mu.Lock()
+	links.updates = make(chan netlink.LinkUpdate)
+	links.subscribeErr = nil
+	links.mu.Unlock()
+	assert.Eventually(t, func() bool { return assert.ObjectsAreEqual(map[string]int{"cali0": 1}, tc.interfaces()) }, time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, loader.Stop(ctx))
+}
+
+func TestInterfacesHandler(t *testing.T) {
+	links := &mockLinks{links: []netlink.Link{link("veth0", 2), link("lo", 1)}, updates: make(chan netlink.LinkUpdate)}
+	tc := &attachedEbpfTc{attached: make(map[string]int)}
+	loader, _ := newInterfacesLoader(config.Default(), links, tc)
+	loader.Load(context.Background())
+	defer loader.Stop(context.Background())
+	assert.Eventually(t, func() bool { return len(tc.interfaces()) == 1 }, time.Second, 10*time.Millisecond)
+
+	rr := httptest.NewRecorder()
+	NewHandler(loader).InterfacesHandler(rr, httptest.NewRequest("GET", "/api/interfaces", nil))
+
+	var interfaces []Interface
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&interfaces))
+	assert.EqualValues(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.Len(t, interfaces, 2)
+	assert.EqualValues(t, "lo", interfaces[0].Name)
+	assert.False(t, interfaces[0].Attached)
+	assert.EqualValues(t, "veth0", interfaces[1].Name)
+	assert.True(t, interfaces[1].Attached)
+}
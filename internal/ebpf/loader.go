@@ -1,5 +1,8 @@
 package ebpf
 
+import "context"
+
 type Loader interface {
-	Load()
+	Load(ctx context.Context)
+	Stop(ctx context.Context) error
 }
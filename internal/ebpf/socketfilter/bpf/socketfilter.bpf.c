@@ -4,44 +4,75 @@
 #include "bpf_helpers.h"
 #include "bpf_endian.h"
 
-#define MAX_ENTRIES 1024 * 4
+#define MAX_ENTRIES 1024 * 4 // sized by the loader from loader.maps.handshakes
+#define FILTER_MAX_ENTRIES 4096
+#define FILTER_PORTS_MAX_ENTRIES 256
 
 #define ETH_HLEN 14
 #define ETH_P_IP 0x0800
+#define ETH_P_IPV6 0x86DD
+#define AF_INET 2
+#define AF_INET6 10
 
 #define HANDSHAKE_RECORD 0x16
 #define CLIENT_HELLO 0x01
 #define SERVER_HELLO 0x02
-#define SERVER_NAME_EXTENSION 0x00
 #define SUPPORTED_TLS_VERSIONS_EXTENSION 0x2b
+#define ALPN_EXTENSION 0x10
 
 #define HANDSHAKE_TYPE_OFFSET 4
 #define TLS_VERSION_OFFSET 2
 #define NEXT_BYTE 1
 #define RANDOM_SIZE 32
-#define SERVER_NAME_EXTENSION_LIST_TYPE_SIZE 3
-#define SUPPORTED_TLS_VERSIONS_EXTENSION_LENGTH_SIZE 1
 
-#define CIPHERS_MAX_SIZE 100
-#define SERVER_NAME_MAX_SIZE 100
 #define EXTENSION_LIST_MAX_SIZE 100
-#define SUPPORTED_TLS_VERSIONS_MAX_SIZE 8
+#define ALPN_MAX_SIZE 32
+
+#define RECORD_HEADER_SIZE 5
+#define RECORD_LENGTH_OFFSET 3
+#define SEGMENT_MAX_SIZE 2048
+#define SEGMENT_CHUNKS 8
+#define REASSEMBLIES_MAX_ENTRIES 1024
 
 struct tls_handshake_event {
-    u32 saddr;                                              // source IP
-    u32 daddr;                                              // destination IP
+    u8 saddr[16];                                           // source IP, IPv4 in the first 4 bytes
+    u8 daddr[16];                                           // destination IP, IPv4 in the first 4 bytes
     u16 sport;                                              // source port
     u16 dport;                                              // destination port
+    u16 family;                                             // address family, network byte order
 
-    u16 tls_version;                                        // supported tls version (not from extensions)
-    u8 tls_versions_length;                                 // length of supported tls versions in extensions
-    u16 tls_versions[SUPPORTED_TLS_VERSIONS_MAX_SIZE];      // supported tls versions in extensions
-    u16 ciphers_length;                                     // length of supported ciphers
-    u16 ciphers[CIPHERS_MAX_SIZE];                          // supported ciphers
-    u16 server_name_length;                                 // length of server name (domain)
-    unsigned char server_name[SERVER_NAME_MAX_SIZE];        // server name (domain)
     u16 used_tls_version;                                   // used tls version for communication
     u16 used_cipher;                                        // used cipher for communication
+    u8 used_alpn_length;                                    // length of the selected application protocol, 0 when not seen
+    u8 used_alpn[ALPN_MAX_SIZE];                            // application protocol selected by the server (TLS 1.2 only, encrypted in TLS 1.3)
+};
+
+// part of a ClientHello record, user space reassembles records larger than the segment they start in
+struct tls_segment_event {
+    u8 saddr[16];                                           // client IP, IPv4 in the first 4 bytes
+    u8 daddr[16];                                           // server IP, IPv4 in the first 4 bytes
+    u16 sport;                                              // client port
+    u16 dport;                                              // server port
+    u16 family;                                             // address family, network byte order
+    u32 record_seq;                                         // sequence number of the first byte of the record, network byte order
+    u32 record_length;                                      // length of the record with its header, network byte order
+    u32 seq;                                                // sequence number of the first byte of the payload, network byte order
+    u16 length;                                             // length of the payload, network byte order
+    u8 payload[SEGMENT_MAX_SIZE];                           // payload of the segment
+};
+
+// client to server direction of a connection
+struct flow_key {
+    u8 saddr[16];
+    u8 daddr[16];
+    u16 sport;
+    u16 dport;
+};
+
+// ClientHello record being reassembled, in host byte order
+struct reassembly {
+    u32 record_seq;
+    u32 record_length;
 };
 
 struct {
@@ -51,11 +82,135 @@ struct {
 	__type(value, struct tls_handshake_event);
 } events SEC(".maps");
 
+// connections whose ClientHello goes on in the next segments
 struct {
-    __uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
-    __uint(max_entries, MAX_ENTRIES);
+    __uint(type, BPF_MAP_TYPE_LRU_HASH);
+    __uint(max_entries, REASSEMBLIES_MAX_ENTRIES);
+    __type(key, struct flow_key);
+    __type(value, struct reassembly);
+} reassemblies SEC(".maps");
+
+// segment events are too big for the stack
+struct {
+    __uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+    __uint(max_entries, 1);
+    __type(key, __u32);
+    __type(value, struct tls_segment_event);
+} segment_buffer SEC(".maps");
+
+// set by the loader before loading, true when events go through the ring buffer (kernel 5.8+),
+// otherwise the loader turns output_events into a perf event array and the ring buffer branch is pruned by the verifier
+const volatile bool use_ringbuf = false;
+
+struct {
+    __uint(type, BPF_MAP_TYPE_RINGBUF);
+    __uint(max_entries, 256 * 1024);
 } output_events SEC(".maps");
 
+// turned into a perf event array along with output_events
+struct {
+    __uint(type, BPF_MAP_TYPE_RINGBUF);
+    __uint(max_entries, 256 * 1024);
+} output_segments SEC(".maps");
+
+// events dropped because the ring buffer was full, perf event array reports its losses itself
+struct {
+    __uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+    __uint(max_entries, 1);
+    __type(key, __u32);
+    __type(value, __u64);
+} lost_events SEC(".maps");
+
+static __always_inline void output_event(struct __sk_buff *ctx, void *output, void *event, __u64 size) {
+    if (use_ringbuf) {
+        if (bpf_ringbuf_output(output, event, size, 0) != 0) {
+            __u32 zero = 0;
+            __u64 *lost = bpf_map_lookup_elem(&lost_events, &zero);
+            if (lost)
+                __sync_fetch_and_add(lost, 1);
+        }
+    } else {
+        bpf_perf_event_output(ctx, output, BPF_F_CURRENT_CPU, event, size);
+    }
+}
+
+// key of excluded_addrs, IPv4 addresses are mapped into IPv6 (::ffff:0:0/96) so both families share the trie
+struct filter_key {
+    __u32 prefixlen;
+    __u8 addr[16];
+};
+
+// networks and pod IPs whose traffic is not reported, filled by the loader from loader.filter and sized by loader.maps
+struct {
+    __uint(type, BPF_MAP_TYPE_LPM_TRIE);
+    __uint(max_entries, FILTER_MAX_ENTRIES);
+    __type(key, struct filter_key);
+    __type(value, __u8);
+    __uint(map_flags, BPF_F_NO_PREALLOC);
+} excluded_addrs SEC(".maps");
+
+// ports in host byte order whose traffic is not reported, on either end
+struct {
+    __uint(type, BPF_MAP_TYPE_HASH);
+    __uint(max_entries, FILTER_PORTS_MAX_ENTRIES);
+    __type(key, __u16);
+    __type(value, __u8);
+} excluded_ports SEC(".maps");
+
+static __always_inline bool excluded_addr(__u16 family, __u8 *addr) {
+    struct filter_key key = {.prefixlen = 128};
+    if (family == AF_INET) {
+        key.addr[10] = 0xff;
+        key.addr[11] = 0xff;
+        __builtin_memcpy(&key.addr[12], addr, 4);
+    } else {
+        __builtin_memcpy(key.addr, addr, 16);
+    }
+    return bpf_map_lookup_elem(&excluded_addrs, &key) != NULL;
+}
+
+// traffic from or to excluded networks and ports never reaches user space
+static __always_inline bool excluded(__u16 family, __u8 *saddr, __u8 *daddr, __u16 sport, __u16 dport) {
+    return bpf_map_lookup_elem(&excluded_ports, &sport) || bpf_map_lookup_elem(&excluded_ports, &dport) ||
+        excluded_addr(family, saddr) || excluded_addr(family, daddr);
+}
+
+// hands the payload of a segment of a ClientHello record to user space
+static __always_inline void output_segment(struct __sk_buff *ctx, struct flow_key *flow, u16 family, struct reassembly *reassembly, u32 seq, u32 payload_offset, u32 payload_length) {
+    u32 zero = 0;
+    struct tls_segment_event *segment = bpf_map_lookup_elem(&segment_buffer, &zero);
+    if (!segment)
+        return;
+
+    __builtin_memcpy(segment->saddr, flow->saddr, sizeof(segment->saddr));
+    __builtin_memcpy(segment->daddr, flow->daddr, sizeof(segment->daddr));
+    segment->sport = flow->sport;
+    segment->dport = flow->dport;
+    segment->family = bpf_htons(family);
+    segment->record_seq = bpf_htonl(reassembly->record_seq);
+    segment->record_length = bpf_htonl(reassembly->record_length);
+
+    // payloads of segments not split yet by GSO or merged by GRO are passed in chunks
+    for (int i = 0; i < SEGMENT_CHUNKS; i++) {
+        u32 offset = i * SEGMENT_MAX_SIZE;
+        if (offset >= payload_length)
+            break;
+        // widened and hidden from the optimizer like the ALPN length, the verifier needs both bounds on the register
+        // passed to the helper and clang would fold the zero check into the loop condition
+        u64 length = payload_length - offset;
+        asm volatile("" : "+r"(length));
+        if (length == 0)
+            break;
+        if (length > SEGMENT_MAX_SIZE)
+            length = SEGMENT_MAX_SIZE;
+        if (bpf_skb_load_bytes(ctx, payload_offset + offset, segment->payload, length) != 0)
+            return;
+        segment->seq = bpf_htonl(seq + offset);
+        segment->length = bpf_htons(length);
+        output_event(ctx, &output_segments, segment, sizeof(struct tls_segment_event));
+    }
+}
+
 SEC("socket/http_filter")
 int socket__http_filter(struct __sk_buff *skb) {
 
@@ -67,8 +222,9 @@ int socket__http_filter(struct __sk_buff *skb) {
     __u32 payload_offset = 0;
     __u8 hdr_len;
 
-    __be32 saddr;
-    __be32 daddr;
+    __u8 saddr[16] = {};
+    __u8 daddr[16] = {};
+    __u16 family;
     __be16 source;
     __be16 dest;
     __be32 seq;
@@ -76,34 +232,44 @@ int socket__http_filter(struct __sk_buff *skb) {
 
     bpf_skb_load_bytes(skb, 12, &proto, 2);
     proto = __bpf_ntohs(proto);
-    if (proto != ETH_P_IP)
-        return 0;
+    if (proto == ETH_P_IP) {
+        // ip4 header lengths are variable
+        // access ihl as a u8 (linux/include/linux/skbuff.h)
+        bpf_skb_load_bytes(skb, ETH_HLEN, &hdr_len, sizeof(hdr_len));
+        hdr_len &= 0x0f;
+        hdr_len *= 4;
+
+        /* verify hlen meets minimum size requirements */
+        if (hdr_len < sizeof(struct iphdr))
+        {
+            return 0;
+        }
 
+        bpf_skb_load_bytes(skb, nhoff + offsetof(struct iphdr, protocol), &ip_proto, 1);
+        bpf_skb_load_bytes(skb, nhoff + offsetof(struct iphdr, tot_len), &tlen, sizeof(tlen));
 
-    // ip4 header lengths are variable
-    // access ihl as a u8 (linux/include/linux/skbuff.h)
-    bpf_skb_load_bytes(skb, ETH_HLEN, &hdr_len, sizeof(hdr_len));
-    hdr_len &= 0x0f;
-    hdr_len *= 4;
+        bpf_skb_load_bytes(skb, nhoff + offsetof(struct iphdr, saddr), &saddr, sizeof(__be32));
+        bpf_skb_load_bytes(skb, nhoff + offsetof(struct iphdr, daddr), &daddr, sizeof(__be32));
+        family = AF_INET;
+    } else if (proto == ETH_P_IPV6) {
+        // ip6 header has fixed length, extension headers are not followed
+        hdr_len = sizeof(struct ipv6hdr);
 
-    /* verify hlen meets minimum size requirements */
-    if (hdr_len < sizeof(struct iphdr))
-    {
+        bpf_skb_load_bytes(skb, nhoff + offsetof(struct ipv6hdr, nexthdr), &ip_proto, 1);
+
+        bpf_skb_load_bytes(skb, nhoff + offsetof(struct ipv6hdr, saddr), &saddr, sizeof(saddr));
+        bpf_skb_load_bytes(skb, nhoff + offsetof(struct ipv6hdr, daddr), &daddr, sizeof(daddr));
+        family = AF_INET6;
+    } else {
         return 0;
     }
 
-    bpf_skb_load_bytes(skb, nhoff + offsetof(struct iphdr, protocol), &ip_proto, 1);
-
     if (ip_proto != IPPROTO_TCP)
     {
         return 0;
     }
 
     tcp_hdr_len = nhoff + hdr_len;
-    bpf_skb_load_bytes(skb, nhoff + offsetof(struct iphdr, tot_len), &tlen, sizeof(tlen));
-
-    bpf_skb_load_bytes(skb, nhoff + offsetof(struct iphdr, saddr), &saddr, sizeof(saddr));
-    bpf_skb_load_bytes(skb, nhoff + offsetof(struct iphdr, daddr), &daddr, sizeof(daddr));
 
     bpf_skb_load_bytes(skb, tcp_hdr_len + offsetof(struct tcphdr, source), &source, sizeof(source));
     bpf_skb_load_bytes(skb, tcp_hdr_len + offsetof(struct tcphdr, dest), &dest, sizeof(dest));
@@ -118,6 +284,30 @@ int socket__http_filter(struct __sk_buff *skb) {
 
     payload_offset = ETH_HLEN + hdr_len + doff;
 
+    // check if payload_offset beyond length of __sk_buff struct
+    if (payload_offset >= skb->len)
+        return 0;
+
+    u32 payload_length = skb->len - payload_offset;
+    struct flow_key flow = {.sport = source, .dport = dest};
+    __builtin_memcpy(flow.saddr, saddr, sizeof(saddr));
+    __builtin_memcpy(flow.daddr, daddr, sizeof(daddr));
+
+    // next segments of a ClientHello larger than the segment it starts in
+    struct reassembly *reassembly = bpf_map_lookup_elem(&reassemblies, &flow);
+    if (reassembly) {
+        // sequence numbers wrap around, retransmitted segments may come before the record
+        s32 offset = bpf_ntohl(seq) - reassembly->record_seq;
+        if (offset >= 0 && offset < reassembly->record_length) {
+            output_segment(skb, &flow, family, reassembly, bpf_ntohl(seq), payload_offset, payload_length);
+            if (offset + payload_length >= reassembly->record_length)
+                bpf_map_delete_elem(&reassemblies, &flow);
+            return 0;
+        }
+        if (offset >= 0)
+            bpf_map_delete_elem(&reassemblies, &flow);
+    }
+
     u8 record_type;
     bpf_skb_load_bytes(skb, payload_offset, &record_type, sizeof(record_type));
 
@@ -133,75 +323,25 @@ int socket__http_filter(struct __sk_buff *skb) {
 
         if(handshake == CLIENT_HELLO) //clientHello
         {
-            bpf_printk("client");
-            struct tls_handshake_event event = {saddr, daddr, source, dest};
-
-            // tls version - not from extension
-            position += sizeof(handshake) + TLS_VERSION_OFFSET;
-            bpf_skb_load_bytes(skb, position + NEXT_BYTE, &event.tls_version, sizeof(event.tls_version));
-            event.tls_version = bpf_ntohs(event.tls_version);
-
-            // session id length
-            u8 session_id_length;
-            position += sizeof(event.tls_version) + RANDOM_SIZE;
-            bpf_skb_load_bytes(skb, position + NEXT_BYTE, &session_id_length, sizeof(session_id_length));
-
-            // ciphers length
-            position += sizeof(session_id_length) + session_id_length;
-            bpf_skb_load_bytes(skb, position + NEXT_BYTE, &event.ciphers_length, sizeof(event.ciphers_length));
-
-            //supported ciphers
-            u16 ciphers_length = bpf_ntohs(event.ciphers_length);
-
-            //int read_byte_len = ciphers_length > CIPHERS_MAX_SIZE ? CIPHERS_MAX_SIZE : ciphers_length <= 0 ? 1 : ciphers_length; - doesn't work on kernel < 6.x
-            position += sizeof(event.ciphers_length);
-            bpf_skb_load_bytes(skb, position + NEXT_BYTE, &event.ciphers, CIPHERS_MAX_SIZE);
-
-            //compression method length
-            u8 compression_method_length;
-            position += ciphers_length;
-            bpf_skb_load_bytes(skb, position + NEXT_BYTE, &compression_method_length, sizeof(compression_method_length));
-
-            //extensions
-            u16 extensions_length;
-            position += sizeof(compression_method_length) + compression_method_length;
-            bpf_skb_load_bytes(skb, position + NEXT_BYTE, &extensions_length, sizeof(extensions_length));
-            extensions_length = bpf_ntohs(extensions_length);
-
-            position += sizeof(extensions_length);
-            u16 next_extension = 0;
-            for(int c = 0; c < EXTENSION_LIST_MAX_SIZE ; c++) {
-
-                //extension type
-                u16 extension_type;
-                bpf_skb_load_bytes(skb, position + next_extension + NEXT_BYTE, &extension_type, sizeof(extension_type));
-                extension_type = bpf_ntohs(extension_type);
-
-                //extension length
-                u16 extension_length;
-                bpf_skb_load_bytes(skb, position + next_extension + sizeof(extension_type) + NEXT_BYTE, &extension_length, sizeof(extension_length));
-                extension_length = bpf_ntohs(extension_length);
-
-                if(extension_type == SERVER_NAME_EXTENSION)  // server_name extension
-                {
-                    bpf_skb_load_bytes(skb, position + next_extension + sizeof(extension_type) + sizeof(extension_length) + SERVER_NAME_EXTENSION_LIST_TYPE_SIZE + NEXT_BYTE, &event.server_name_length, sizeof(event.server_name_length));
-
-                    bpf_skb_load_bytes(skb, position + next_extension + sizeof(extension_type) + sizeof(extension_length) + SERVER_NAME_EXTENSION_LIST_TYPE_SIZE + sizeof(event.server_name_length) + NEXT_BYTE, &event.server_name, sizeof(event.server_name));
-                }
-
-                if(extension_type == SUPPORTED_TLS_VERSIONS_EXTENSION) //supported tls versions extension
-                {
-                    bpf_skb_load_bytes(skb, position + next_extension + sizeof(extension_type) + sizeof(extension_length) + SUPPORTED_TLS_VERSIONS_EXTENSION_LENGTH_SIZE, &event.tls_versions_length, sizeof(event.tls_versions_length));
+            // a handshake not stored is not reported when the ServerHello comes
+            if (excluded(family, saddr, daddr, bpf_ntohs(source), bpf_ntohs(dest)))
+                return 0;
 
-                    //int read_byte_len = event.tls_versions_length > SUPPORTED_TLS_VERSIONS_MAX_SIZE ? SUPPORTED_TLS_VERSIONS_MAX_SIZE : event.tls_versions_length <= 0 ? 1 : event.tls_versions_length;  - doesn't work on kernel < 6.x
-                    bpf_skb_load_bytes(skb, position + next_extension + sizeof(extension_type) + sizeof(extension_length) + SUPPORTED_TLS_VERSIONS_EXTENSION_LENGTH_SIZE + sizeof(event.tls_versions_length), &event.tls_versions, SUPPORTED_TLS_VERSIONS_MAX_SIZE);
-                }
-                next_extension += sizeof(extension_length) + extension_length + 2*NEXT_BYTE;
-                if(extensions_length <= next_extension) {
-                    break;
-                }
-            }
-            //store in events map based on sequence number
+            bpf_printk("client");
+            struct tls_handshake_event event = {.sport = source, .dport = dest, .family = bpf_htons(family)};
+            __builtin_memcpy(event.saddr, saddr, sizeof(saddr));
+            __builtin_memcpy(event.daddr, daddr, sizeof(daddr));
+
+            // the ClientHello is parsed in user space, fingerprints need all of its extensions in order
+            u16 record_length;
+            bpf_skb_load_bytes(skb, payload_offset + RECORD_LENGTH_OFFSET, &record_length, sizeof(record_length));
+            struct reassembly new_reassembly = {.record_seq = bpf_ntohl(seq), .record_length = RECORD_HEADER_SIZE + bpf_ntohs(record_length)};
+            // a ClientHello larger than the segment (e.g. with post-quantum key shares) goes on in the next segments
+            if (new_reassembly.record_length > payload_length)
+                bpf_map_update_elem(&reassemblies, &flow, &new_reassembly, BPF_ANY);
+            output_segment(skb, &flow, family, &new_reassembly, new_reassembly.record_seq, payload_offset, payload_length);
+
+            //store in events map based on sequence number, the ServerHello completes the event
             bpf_map_update_elem(&events, &ack_seq, &event, 0);
         }
         if(handshake == SERVER_HELLO) //serverHello
@@ -252,7 +392,20 @@ int socket__http_filter(struct __sk_buff *skb) {
                     if(extension_type == SUPPORTED_TLS_VERSIONS_EXTENSION) //used tls version extension
                     {
                         bpf_skb_load_bytes(skb, position + next_extension + sizeof(extension_type) + sizeof(extension_length) + NEXT_BYTE, &event->used_tls_version, sizeof(event->used_tls_version));
-                        break;
+                    }
+                    if(extension_type == ALPN_EXTENSION) //selected application protocol, a list of one protocol
+                    {
+                        u8 alpn_length;
+                        u16 alpn_position = position + next_extension + sizeof(extension_type) + sizeof(extension_length) + sizeof(u16) + NEXT_BYTE;
+                        bpf_skb_load_bytes(skb, alpn_position, &alpn_length, sizeof(alpn_length));
+                        // widened and hidden from the optimizer, clang would otherwise check the range on a copy and pass
+                        // the unchecked register to the helper
+                        u64 length = alpn_length;
+                        asm volatile("" : "+r"(length));
+                        if(length > 0 && length <= ALPN_MAX_SIZE) {
+                            bpf_skb_load_bytes(skb, alpn_position + sizeof(alpn_length), event->used_alpn, length);
+                            event->used_alpn_length = length;
+                        }
                     }
 
                     next_extension += sizeof(extension_length) + extension_length + 2*NEXT_BYTE;
@@ -260,8 +413,8 @@ int socket__http_filter(struct __sk_buff *skb) {
                         break;
                     }
                 }
-                //store event in BPF ringbuf events map
-                bpf_perf_event_output(skb, &output_events, 0xffffffffULL, event, sizeof(struct tls_handshake_event));
+                //store event in BPF ring buffer or perf event array
+                output_event(skb, &output_events, event, sizeof(struct tls_handshake_event));
             }
             //remove element from events based on sequence number
             bpf_map_delete_elem(&events, &seq);
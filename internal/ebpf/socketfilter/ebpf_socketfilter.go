@@ -5,57 +5,83 @@ import (
 	"context"
 	"encoding/binary"
 	"errors"
-	"log"
+	"fmt"
 	"log/slog"
-	"os"
-	"os/signal"
-	"syscall"
+	"time"
 
 	"github.com/cilium/ebpf"
 
-	"github.com/cilium/ebpf/perf"
 	"github.com/k8spacket/k8spacket/internal/broker"
+	"github.com/k8spacket/k8spacket/internal/config"
 	ebpf_tools "github.com/k8spacket/k8spacket/internal/ebpf/tools"
 	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/k8spacket/k8spacket/internal/status"
 	"golang.org/x/sys/unix"
 )
 
 //go:generate go run github.com/cilium/ebpf/cmd/bpf2go -go-package ebpf_socketfilter socketfilter ./bpf/socketfilter.bpf.c
 
+const component = "socketfilter"
+
 type EbpfSocketFilter struct {
-	Broker broker.Broker
+	Broker    broker.Broker
+	Transport config.TransportConfig
+	Maps      config.MapsConfig
 }
 
-func (ebpfSocketFilter *EbpfSocketFilter) Init() {
+func (ebpfSocketFilter *EbpfSocketFilter) Init(ctx context.Context) {
+
+	status.Report(component, status.Starting, "")
 
 	// Load pre-compiled programs and maps into the kernel.
 	objs := socketfilterObjects{}
-	if err := loadSocketfilterObjects(&objs, nil); err != nil {
+	if err := ebpfSocketFilter.load(&objs); err != nil {
 		var verr *ebpf.VerifierError
 		if errors.As(err, &verr) {
-			log.Fatalf("loading objects: %+v\n", verr)
+			slog.Error("[socketfilter] Loading objects", "Error", fmt.Sprintf("%+v", verr))
 		} else {
-			log.Fatalf("loading objects: %v", err)
+			slog.Error("[socketfilter] Loading objects", "Error", err)
 		}
+		status.Report(component, status.Down, "cannot load objects: "+err.Error())
+		return
 	}
 	defer objs.Close()
+	// excluded traffic is filtered in the kernel as soon as the program is attached
+	defer ebpf_tools.AttachFilter(objs.ExcludedAddrs, objs.ExcludedPorts)()
 
 	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW, int(ebpf_tools.Htons(unix.ETH_P_ALL)))
-	if err == nil {
-		ssoErr := unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_ATTACH_BPF, objs.SocketHttpFilter.FD())
-		if ssoErr != nil {
-			panic(ssoErr)
-		}
-	} else {
-		panic(err)
+	if err != nil {
+		slog.Error("[socketfilter] Cannot open raw socket", "Error", err)
+		status.Report(component, status.Down, "cannot open raw socket: "+err.Error())
+		return
+	}
+	defer unix.Close(fd)
+	if err := unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_ATTACH_BPF, objs.SocketHttpFilter.FD()); err != nil {
+		slog.Error("[socketfilter] Cannot attach socket filter", "Error", err)
+		status.Report(component, status.Down, "cannot attach socket filter: "+err.Error())
+		return
 	}
 
-	// create new reader for perf events
-	rd, err := perf.NewReader(objs.OutputEvents, os.Getpagesize())
+	// create new reader for ring buffer or perf events
+	rd, err := ebpf_tools.NewEventReader(objs.OutputEvents, ebpfSocketFilter.Transport)
 	if err != nil {
-		slog.Error("[socketfilter] Creating perf event reader", "Error", err)
+		slog.Error("[socketfilter] Creating event reader", "Error", err)
+		status.Report(component, status.Down, "cannot create event reader: "+err.Error())
+		return
 	}
 	defer rd.Close()
+	// ClientHellos come apart from the handshake events reported with the ServerHello
+	segments, err := ebpf_tools.NewEventReader(objs.OutputSegments, ebpfSocketFilter.Transport)
+	if err != nil {
+		slog.Error("[socketfilter] Creating segment reader", "Error", err)
+		status.Report(component, status.Down, "cannot create segment reader: "+err.Error())
+		return
+	}
+	defer segments.Close()
+	reassembler := ebpf_tools.NewTLSReassembler("socketfilter", ebpf_tools.AnyInterface, ebpfSocketFilter.Maps.Handshakes)
+	status.Report(component, status.Up, "")
+
+	go readSegments(segments, reassembler, ebpfSocketFilter)
 
 	go func() {
 		// socketfilterTlsHandshakeEvent is generated by bpf2go and represents perf event type in eBPF program
@@ -63,68 +89,119 @@ func (ebpfSocketFilter *EbpfSocketFilter) Init() {
 		for {
 			record, err := rd.Read()
 			if err != nil {
-				if errors.Is(err, perf.ErrClosed) {
+				if errors.Is(err, ebpf_tools.ErrClosed) {
 					slog.Info("[socketfilter] Received signal, exiting..")
 					return
 				}
+				ebpf_tools.ReadErrorsMetric.WithLabelValues("socketfilter", ebpf_tools.AnyInterface).Inc()
 				slog.Error("[socketfilter] Reading from reader", "Error", err)
 				continue
 			}
+			if record.LostSamples > 0 {
+				ebpf_tools.LostSamplesMetric.WithLabelValues("socketfilter", ebpf_tools.AnyInterface).Add(float64(record.LostSamples))
+				slog.Warn("[socketfilter] Perf buffer full, samples lost", "Lost", record.LostSamples)
+				continue
+			}
 
 			// Parse the perf event into a socketfilterTlsHandshakeEvent structure.
 			if err := binary.Read(bytes.NewBuffer(record.RawSample), binary.BigEndian, &event); err != nil {
+				ebpf_tools.ParseErrorsMetric.WithLabelValues("socketfilter", ebpf_tools.AnyInterface).Inc()
 				slog.Error("[socketfilter] Parsing perf event", "Error", err)
 				continue
 			}
+			ebpf_tools.EventsMetric.WithLabelValues(modules.SocketFilter.String()).Inc()
 
-			distribute(event, ebpfSocketFilter)
+			distribute(event, ebpfSocketFilter, reassembler)
 		}
 	}()
 
-	// graceful shutdown
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer stop()
-
-	<-ctx.Done()
+	// count events lost by the ring buffer until shutdown, deferred closes detach the program
+	ebpf_tools.WatchLostEvents(ctx, objs.LostEvents, "socketfilter", ebpf_tools.AnyInterface)
 
 	slog.Info("[socketfilter] Closed gracefully")
 }
 
-func distribute(event socketfilterTlsHandshakeEvent, ebpfSocketFilter *EbpfSocketFilter) {
-
-	tlsVersionsLen := int(event.TlsVersionsLength) / 2
-	if tlsVersionsLen > len(event.TlsVersions) {
-		tlsVersionsLen = len(event.TlsVersions)
+// readSegments hands payloads of ClientHellos to the reassembler until the reader is closed
+func readSegments(rd ebpf_tools.EventReader, reassembler *ebpf_tools.TLSReassembler, ebpfSocketFilter *EbpfSocketFilter) {
+	// socketfilterTlsSegmentEvent is generated by bpf2go and represents segment event type in eBPF program
+	var event socketfilterTlsSegmentEvent
+	for {
+		record, err := rd.Read()
+		if err != nil {
+			if errors.Is(err, ebpf_tools.ErrClosed) {
+				return
+			}
+			ebpf_tools.ReadErrorsMetric.WithLabelValues("socketfilter", ebpf_tools.AnyInterface).Inc()
+			slog.Error("[socketfilter] Reading segments from reader", "Error", err)
+			continue
+		}
+		if record.LostSamples > 0 {
+			ebpf_tools.LostSamplesMetric.WithLabelValues("socketfilter", ebpf_tools.AnyInterface).Add(float64(record.LostSamples))
+			slog.Warn("[socketfilter] Perf buffer full, segments lost", "Lost", record.LostSamples)
+			continue
+		}
+		if err := binary.Read(bytes.NewBuffer(record.RawSample), binary.BigEndian, &event); err != nil {
+			ebpf_tools.ParseErrorsMetric.WithLabelValues("socketfilter", ebpf_tools.AnyInterface).Inc()
+			slog.Error("[socketfilter] Parsing segment event", "Error", err)
+			continue
+		}
+		publish(ebpfSocketFilter, reassembler.AddSegment(segment(event), time.Now())...)
 	}
+}
 
-	ciphersLen := int(event.CiphersLength) / 2
-	if ciphersLen > len(event.Ciphers) {
-		ciphersLen = len(event.Ciphers)
-	}
+func segment(event socketfilterTlsSegmentEvent) ebpf_tools.TLSSegment {
+	length := min(int(event.Length), len(event.Payload))
+	return ebpf_tools.TLSSegment{
+		Client: modules.Address{
+			Addr: ebpf_tools.BytesToIP(event.Family, event.Saddr),
+			Port: event.Sport},
+		Server: modules.Address{
+			Addr: ebpf_tools.BytesToIP(event.Family, event.Daddr),
+			Port: event.Dport},
+		RecordSeq:    event.RecordSeq,
+		RecordLength: event.RecordLength,
+		Seq:          event.Seq,
+		Payload:      event.Payload[:length]}
+}
 
-	serverNameLen := int(event.ServerNameLength)
-	if serverNameLen > len(event.ServerName) {
-		serverNameLen = len(event.ServerName)
-	}
+func distribute(event socketfilterTlsHandshakeEvent, ebpfSocketFilter *EbpfSocketFilter, reassembler *ebpf_tools.TLSReassembler) {
 
 	tlsEvent := modules.TLSEvent{
 		Source: modules.SocketFilter,
 		Client: modules.Address{
-			Addr: ebpf_tools.IntToIP4(event.Saddr, binary.BigEndian.PutUint32),
+			Addr: ebpf_tools.BytesToIP(event.Family, event.Saddr),
 			Port: event.Sport},
 		Server: modules.Address{
-			Addr: ebpf_tools.IntToIP4(event.Daddr, binary.BigEndian.PutUint32),
+			Addr: ebpf_tools.BytesToIP(event.Family, event.Daddr),
 			Port: event.Dport},
-		TlsVersions:    event.TlsVersions[:tlsVersionsLen],
-		Ciphers:        event.Ciphers[:ciphersLen],
-		ServerName:     string(event.ServerName[:serverNameLen]),
 		UsedTlsVersion: event.UsedTlsVersion,
-		UsedCipher:     event.UsedCipher}
-	if len(tlsEvent.TlsVersions) <= 0 {
-		tlsEvent.TlsVersions = append(tlsEvent.TlsVersions, event.TlsVersion)
+		UsedCipher:     event.UsedCipher,
+		UsedAlpn:       string(event.UsedAlpn[:min(int(event.UsedAlpnLength), len(event.UsedAlpn))]),
+		Time:           time.Now()}
+
+	// the ClientHello is parsed in user space, the event is sent along with it
+	publish(ebpfSocketFilter, reassembler.Complete(tlsEvent, tlsEvent.Time)...)
+}
+
+func publish(ebpfSocketFilter *EbpfSocketFilter, events ...modules.TLSEvent) {
+	for _, tlsEvent := range events {
+		ebpf_tools.EnrichConnection(&tlsEvent.Client, &tlsEvent.Server)
+		ebpfSocketFilter.Broker.TLSEvent(tlsEvent)
 	}
+}
 
-	ebpf_tools.EnrichAddress(&tlsEvent.Client)
-	ebpf_tools.EnrichAddress(&tlsEvent.Server)
-	ebpfSocketFilter.Broker.TLSEvent(tlsEvent)
+// loadSocketfilterObjects with the events map set up for the configured transport and maps sized from the config
+func (ebpfSocketFilter *EbpfSocketFilter) load(objs *socketfilterObjects) error {
+	spec, err := loadSocketfilter()
+	if err != nil {
+		return err
+	}
+	ebpf_tools.ResizeMaps(spec, map[string]int{"events": ebpfSocketFilter.Maps.Handshakes, "excluded_addrs": ebpfSocketFilter.Maps.FilterAddresses})
+	if err := ebpf_tools.PrepareTransport(spec, "output_events", ebpfSocketFilter.Transport); err != nil {
+		return err
+	}
+	if err := ebpf_tools.PrepareTransport(spec, "output_segments", ebpfSocketFilter.Transport); err != nil {
+		return err
+	}
+	return spec.LoadAndAssign(objs, nil)
 }
@@ -1,15 +1,21 @@
 package ebpf_socketfilter
 
 import (
-	"encoding/binary"
+	"os"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/cilium/ebpf"
+	"github.com/k8spacket/k8spacket/internal/broker"
+	"github.com/k8spacket/k8spacket/internal/config"
 	ebpf_tools "github.com/k8spacket/k8spacket/internal/ebpf/tools"
 	"github.com/k8spacket/k8spacket/internal/modules"
 	"github.com/stretchr/testify/assert"
 )
 
 type fakeBrokerSF struct {
+	broker.Broker
 	last modules.TLSEvent
 }
 
@@ -17,52 +23,95 @@ func (f *fakeBrokerSF) DistributeEvents()               {}
 func (f *fakeBrokerSF) TCPEvent(event modules.TCPEvent) {}
 func (f *fakeBrokerSF) TLSEvent(event modules.TLSEvent) { f.last = event }
 
+func segmentEvent(evt socketfilterTlsHandshakeEvent, record []byte) socketfilterTlsSegmentEvent {
+	var segment socketfilterTlsSegmentEvent
+	segment.Saddr, segment.Daddr, segment.Family, segment.Sport, segment.Dport = evt.Saddr, evt.Daddr, evt.Family, evt.Sport, evt.Dport
+	segment.RecordSeq, segment.Seq = 1000, 1000
+	segment.RecordLength = uint32(len(record))
+	segment.Length = uint16(copy(segment.Payload[:], record))
+	return segment
+}
+
 func TestDistribute(t *testing.T) {
-	sNum := binary.BigEndian.Uint32([]byte{192, 168, 0, 10})
-	dNum := binary.BigEndian.Uint32([]byte{10, 0, 0, 5})
 
 	var evt socketfilterTlsHandshakeEvent
-	evt.Saddr = sNum
-	evt.Daddr = dNum
+	evt.Saddr = [16]uint8{192, 168, 0, 10}
+	evt.Daddr = [16]uint8{10, 0, 0, 5}
+	evt.Family = syscall.AF_INET
 	evt.Sport = 44321
 	evt.Dport = 443
-	evt.TlsVersion = 0x0304
-	// set TlsVersionsLength = 4 bytes -> two uint16 entries
-	evt.TlsVersionsLength = 4
-	evt.TlsVersions[0] = 0x0303
-	evt.TlsVersions[1] = 0x0304
-	// set ciphers length 4 -> two entries
-	evt.CiphersLength = 4
-	evt.Ciphers[0] = 0x1301
-	evt.Ciphers[1] = 0x1302
-	// server name
-	name := "example.local"
-	copy(evt.ServerName[:], []byte(name))
-	evt.ServerNameLength = uint16(len(name))
 	evt.UsedTlsVersion = 0x0304
 	evt.UsedCipher = 0x1301
+	evt.UsedAlpnLength = uint8(copy(evt.UsedAlpn[:], "http/1.1"))
+	name := "example.local"
 
 	fb := &fakeBrokerSF{}
 	filter := &EbpfSocketFilter{Broker: fb}
+	reassembler := ebpf_tools.NewTLSReassembler("socketfilter", ebpf_tools.AnyInterface, 16)
 
-	distribute(evt, filter)
+	publish(filter, reassembler.AddSegment(segment(segmentEvent(evt, ebpf_tools.ClientHelloRecord(name, []uint16{0x0303, 0x0304}, []uint16{0x1301, 0x1302}))), time.Now())...)
+	distribute(evt, filter, reassembler)
 
 	got := fb.last
 	assert.Equal(t, modules.SocketFilter, got.Source)
-	assert.Equal(t, ebpf_tools.IntToIP4(sNum, binary.BigEndian.PutUint32), got.Client.Addr)
+	assert.Equal(t, "192.168.0.10", got.Client.Addr)
 	assert.Equal(t, evt.Sport, got.Client.Port)
-	assert.Equal(t, ebpf_tools.IntToIP4(dNum, binary.BigEndian.PutUint32), got.Server.Addr)
+	assert.Equal(t, "10.0.0.5", got.Server.Addr)
 	assert.Equal(t, evt.Dport, got.Server.Port)
-	assert.Equal(t, 2, len(got.TlsVersions))
-	assert.Equal(t, uint16(0x0303), got.TlsVersions[0])
-	assert.Equal(t, uint16(0x0304), got.TlsVersions[1])
-	assert.Equal(t, 2, len(got.Ciphers))
-	assert.Equal(t, uint16(0x1301), got.Ciphers[0])
-	assert.Equal(t, uint16(0x1302), got.Ciphers[1])
+	assert.Equal(t, []uint16{0x0303, 0x0304}, got.TlsVersions)
+	assert.Equal(t, []uint16{0x1301, 0x1302}, got.Ciphers)
 	assert.Equal(t, name, got.ServerName)
 	assert.Equal(t, evt.UsedTlsVersion, got.UsedTlsVersion)
 	assert.Equal(t, evt.UsedCipher, got.UsedCipher)
+	assert.Equal(t, "http/1.1", got.UsedAlpn)
 	// EnrichAddress for private IPs sets Name to "N/A"
 	assert.Equal(t, "N/A", got.Client.Name)
 	assert.Equal(t, "N/A", got.Server.Name)
 }
+
+func TestDistributeIPv6(t *testing.T) {
+	var evt socketfilterTlsHandshakeEvent
+	evt.Saddr = [16]uint8{0xfd, 0x00, 15: 0x0a}
+	evt.Daddr = [16]uint8{0xfd, 0x00, 15: 0x05}
+	evt.Family = syscall.AF_INET6
+	evt.Sport = 44321
+	evt.Dport = 443
+
+	fb := &fakeBrokerSF{}
+	filter := &EbpfSocketFilter{Broker: fb}
+	reassembler := ebpf_tools.NewTLSReassembler("socketfilter", ebpf_tools.AnyInterface, 16)
+	distribute(evt, filter, reassembler)
+	publish(filter, reassembler.AddSegment(segment(segmentEvent(evt, ebpf_tools.ClientHelloRecord("v6.local", nil, []uint16{0x1301}))), time.Now())...)
+
+	got := fb.last
+	assert.Equal(t, "fd00::a", got.Client.Addr)
+	assert.Equal(t, "fd00::5", got.Server.Addr)
+	assert.Equal(t, []uint16{0x0303}, got.TlsVersions)
+	assert.Empty(t, got.UsedAlpn)
+	assert.Equal(t, "N/A", got.Client.Name)
+	assert.Equal(t, "N/A", got.Server.Name)
+}
+
+// every map, program and variable of the generated bindings is in the objects of both byte orders
+func TestObjects(t *testing.T) {
+	for _, object := range []string{"socketfilter_bpfel.o", "socketfilter_bpfeb.o"} {
+		spec, err := ebpf.LoadCollectionSpec(object)
+		assert.Nil(t, err, object)
+		assert.Nil(t, spec.Assign(&socketfilterSpecs{}), object)
+	}
+}
+
+// the program passes the verifier of the running kernel with both transports, loading it requires root
+func TestLoad(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("loading eBPF programs requires root")
+	}
+	for _, mode := range []string{ebpf_tools.TransportRingBuf, ebpf_tools.TransportPerf} {
+		ebpfSocketFilter := EbpfSocketFilter{
+			Transport: config.TransportConfig{Mode: mode, RingBufferSize: 256 * 1024, PerfBufferSize: 64 * 1024},
+			Maps:      config.MapsConfig{Handshakes: 4096, FilterAddresses: 4096}}
+		objs := socketfilterObjects{}
+		assert.Nil(t, ebpfSocketFilter.load(&objs), mode)
+		objs.Close()
+	}
+}
@@ -1,5 +1,7 @@
 package ebpf_socketfilter
 
+import "context"
+
 type SocketFilter interface {
-	Init()
+	Init(ctx context.Context)
 }
@@ -13,22 +13,54 @@ import (
 	"github.com/cilium/ebpf"
 )
 
+type socketfilterFilterKey struct {
+	_         structs.HostLayout
+	Prefixlen uint32
+	Addr      [16]uint8
+}
+
+type socketfilterFlowKey struct {
+	_     structs.HostLayout
+	Saddr [16]uint8
+	Daddr [16]uint8
+	Sport uint16
+	Dport uint16
+}
+
+type socketfilterReassembly struct {
+	_            structs.HostLayout
+	RecordSeq    uint32
+	RecordLength uint32
+}
+
 type socketfilterTlsHandshakeEvent struct {
-	_                 structs.HostLayout
-	Saddr             uint32
-	Daddr             uint32
-	Sport             uint16
-	Dport             uint16
-	TlsVersion        uint16
-	TlsVersionsLength uint8
-	_                 [1]byte
-	TlsVersions       [8]uint16
-	CiphersLength     uint16
-	Ciphers           [100]uint16
-	ServerNameLength  uint16
-	ServerName        [100]uint8
-	UsedTlsVersion    uint16
-	UsedCipher        uint16
+	_              structs.HostLayout
+	Saddr          [16]uint8
+	Daddr          [16]uint8
+	Sport          uint16
+	Dport          uint16
+	Family         uint16
+	UsedTlsVersion uint16
+	UsedCipher     uint16
+	UsedAlpnLength uint8
+	UsedAlpn       [32]uint8
+	_              [1]byte
+}
+
+type socketfilterTlsSegmentEvent struct {
+	_            structs.HostLayout
+	Saddr        [16]uint8
+	Daddr        [16]uint8
+	Sport        uint16
+	Dport        uint16
+	Family       uint16
+	_            [2]byte
+	RecordSeq    uint32
+	RecordLength uint32
+	Seq          uint32
+	Length       uint16
+	Payload      [2048]uint8
+	_            [2]byte
 }
 
 // loadSocketfilter returns the embedded CollectionSpec for socketfilter.
@@ -80,14 +112,21 @@ type socketfilterProgramSpecs struct {
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type socketfilterMapSpecs struct {
-	Events       *ebpf.MapSpec `ebpf:"events"`
-	OutputEvents *ebpf.MapSpec `ebpf:"output_events"`
+	Events         *ebpf.MapSpec `ebpf:"events"`
+	ExcludedAddrs  *ebpf.MapSpec `ebpf:"excluded_addrs"`
+	ExcludedPorts  *ebpf.MapSpec `ebpf:"excluded_ports"`
+	LostEvents     *ebpf.MapSpec `ebpf:"lost_events"`
+	OutputEvents   *ebpf.MapSpec `ebpf:"output_events"`
+	OutputSegments *ebpf.MapSpec `ebpf:"output_segments"`
+	Reassemblies   *ebpf.MapSpec `ebpf:"reassemblies"`
+	SegmentBuffer  *ebpf.MapSpec `ebpf:"segment_buffer"`
 }
 
 // socketfilterVariableSpecs contains global variables before they are loaded into the kernel.
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type socketfilterVariableSpecs struct {
+	UseRingbuf *ebpf.VariableSpec `ebpf:"use_ringbuf"`
 }
 
 // socketfilterObjects contains all objects after they have been loaded into the kernel.
@@ -110,14 +149,26 @@ func (o *socketfilterObjects) Close() error {
 //
 // It can be passed to loadSocketfilterObjects or ebpf.CollectionSpec.LoadAndAssign.
 type socketfilterMaps struct {
-	Events       *ebpf.Map `ebpf:"events"`
-	OutputEvents *ebpf.Map `ebpf:"output_events"`
+	Events         *ebpf.Map `ebpf:"events"`
+	ExcludedAddrs  *ebpf.Map `ebpf:"excluded_addrs"`
+	ExcludedPorts  *ebpf.Map `ebpf:"excluded_ports"`
+	LostEvents     *ebpf.Map `ebpf:"lost_events"`
+	OutputEvents   *ebpf.Map `ebpf:"output_events"`
+	OutputSegments *ebpf.Map `ebpf:"output_segments"`
+	Reassemblies   *ebpf.Map `ebpf:"reassemblies"`
+	SegmentBuffer  *ebpf.Map `ebpf:"segment_buffer"`
 }
 
 func (m *socketfilterMaps) Close() error {
 	return _SocketfilterClose(
 		m.Events,
+		m.ExcludedAddrs,
+		m.ExcludedPorts,
+		m.LostEvents,
 		m.OutputEvents,
+		m.OutputSegments,
+		m.Reassemblies,
+		m.SegmentBuffer,
 	)
 }
 
@@ -125,6 +176,7 @@ func (m *socketfilterMaps) Close() error {
 //
 // It can be passed to loadSocketfilterObjects or ebpf.CollectionSpec.LoadAndAssign.
 type socketfilterVariables struct {
+	UseRingbuf *ebpf.Variable `ebpf:"use_ringbuf"`
 }
 
 // socketfilterPrograms contains all programs after they have been loaded into the kernel.
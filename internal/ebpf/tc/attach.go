@@ -0,0 +1,185 @@
+package ebpf_tc
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	AttachAuto   = "auto"
+	AttachTCX    = "tcx"
+	AttachClsact = "clsact"
+)
+
+// attach uses TCX links when the kernel supports them (6.6+) and filters on the clsact qdisc otherwise, the returned
+// function removes what was added
+func (ebpfTc *EbpfTc) attach(iface netlink.Link, program *ebpf.Program) (func(), error) {
+	mode := ebpfTc.Attach.AttachMode
+	if mode == AttachTCX || mode == AttachAuto {
+		detach, err := attachTCX(iface, program)
+		if err == nil || mode == AttachTCX || !errors.Is(err, ebpf.ErrNotSupported) {
+			return detach, err
+		}
+		slog.Info("[tc] TCX not supported, attaching to clsact qdisc", "interface", iface.Attrs().Name)
+	}
+	return attachClsact(iface, program, ebpfTc.Attach)
+}
+
+// the program runs first and hands packets on, so programs of other tools still see every packet
+func attachTCX(iface netlink.Link, program *ebpf.Program) (func(), error) {
+	var links []link.Link
+	detach := func() {
+		for _, tcx := range links {
+			if err := tcx.Close(); err != nil {
+				slog.Warn("[tc] Cannot close TCX link", "interface", iface.Attrs().Name, "Error", err)
+			}
+		}
+	}
+	for _, attachType := range []ebpf.AttachType{ebpf.AttachTCXIngress, ebpf.AttachTCXEgress} {
+		tcx, err := link.AttachTCX(link.TCXOptions{Interface: iface.Attrs().Index, Program: program, Attach: attachType, Anchor: link.Head()})
+		if err != nil {
+			detach()
+			return nil, err
+		}
+		links = append(links, tcx)
+	}
+	slog.Info("[tc] Attached TCX links", "interface", iface.Attrs().Name)
+	return detach, nil
+}
+
+var (
+	// every instance adds its filters at the same priority and handle, filters are added and deleted one interface at
+	// a time so that an instance detaching cannot delete the filter of the one attached to the interface again meanwhile
+	clsactLock sync.Mutex
+	// interface indexes of the clsact qdiscs k8spacket created, whatever instance detaches last deletes the qdisc
+	createdClsact = make(map[int]bool)
+)
+
+// filters are added to the clsact qdisc already there (e.g. the one of Cilium), which is created when missing and then
+// deleted on detach unless other filters were added to it meanwhile
+func attachClsact(iface netlink.Link, program *ebpf.Program, tc config.TcConfig) (func(), error) {
+	clsactLock.Lock()
+	defer clsactLock.Unlock()
+	qdisc := &netlink.GenericQdisc{
+		QdiscAttrs: netlink.QdiscAttrs{
+			LinkIndex: iface.Attrs().Index,
+			Handle:    netlink.MakeHandle(0xffff, 0),
+			Parent:    netlink.HANDLE_CLSACT,
+		},
+		QdiscType: "clsact",
+	}
+	exists, err := hasClsact(iface)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list qdiscs: %w", err)
+	}
+	if !exists {
+		// add clsact qdisc on specific network interface, equivalent `tc qdisc add dev {{iface}} clsact`
+		if err := netlink.QdiscAdd(qdisc); err != nil {
+			return nil, fmt.Errorf("cannot add clsact qdisc: %w", err)
+		}
+		createdClsact[iface.Attrs().Index] = true
+	}
+
+	// the filter is told apart from the one of another instance by the program it runs
+	var id ebpf.ProgramID
+	if info, err := program.Info(); err == nil {
+		id, _ = info.ID()
+	}
+	var filters []*netlink.BpfFilter
+	remove := func() {
+		for _, filter := range filters {
+			if !isInstalled(iface, filter, id) {
+				continue
+			}
+			if err := netlink.FilterDel(filter); err != nil {
+				slog.Warn("[tc] Cannot del filter on close", "interface", iface.Attrs().Name, "Error", err)
+			}
+		}
+		if createdClsact[iface.Attrs().Index] && !hasFilters(iface) {
+			if err := netlink.QdiscDel(qdisc); err != nil {
+				slog.Warn("[tc] Cannot del clsact qdisc on close", "interface", iface.Attrs().Name, "Error", err)
+			}
+			delete(createdClsact, iface.Attrs().Index)
+		}
+	}
+	for _, parent := range []uint32{netlink.HANDLE_MIN_INGRESS, netlink.HANDLE_MIN_EGRESS} {
+		filter := newFilter(iface, program.FD(), parent, tc)
+		// add ingress/egress filter, equivalent `tc filter replace dev {{iface}} [ingress|egress] prio {{priority}} handle {{handle}} bpf da`,
+		// a filter left by a killed k8spacket is replaced, filters of other tools have other priorities or handles
+		// check `tc filter show dev {{iface}} [ingress|egress]`
+		if err := netlink.FilterReplace(filter); err != nil {
+			remove()
+			return nil, fmt.Errorf("cannot add filter: %w", err)
+		}
+		filters = append(filters, filter)
+	}
+	slog.Info("[tc] Attached clsact filters", "interface", iface.Attrs().Name, "Priority", tc.Priority, "Handle", tc.Handle)
+	return func() {
+		clsactLock.Lock()
+		defer clsactLock.Unlock()
+		remove()
+	}, nil
+}
+
+func newFilter(iface netlink.Link, programFD int, parent uint32, tc config.TcConfig) *netlink.BpfFilter {
+	return &netlink.BpfFilter{
+		FilterAttrs: netlink.FilterAttrs{
+			LinkIndex: iface.Attrs().Index,
+			Parent:    parent,
+			Handle:    netlink.MakeHandle(0, uint16(tc.Handle)),
+			Protocol:  unix.ETH_P_ALL,
+			Priority:  uint16(tc.Priority),
+		},
+		Fd:           programFD,
+		Name:         "k8spacket",
+		DirectAction: true,
+	}
+}
+
+// isInstalled tells whether the filter at the priority and handle of k8spacket still runs the program with id (any
+// program when the kernel does not report ids), the filter of an instance attached again replaced it otherwise
+func isInstalled(iface netlink.Link, filter *netlink.BpfFilter, id ebpf.ProgramID) bool {
+	filters, err := netlink.FilterList(iface, filter.Parent)
+	if err != nil {
+		slog.Warn("[tc] Cannot list filters on close", "interface", iface.Attrs().Name, "Error", err)
+		return false
+	}
+	for _, installed := range filters {
+		if bpf, ok := installed.(*netlink.BpfFilter); ok && bpf.Priority == filter.Priority && bpf.Handle == filter.Handle {
+			return id == 0 || bpf.Id == int(id)
+		}
+	}
+	return false
+}
+
+func hasClsact(iface netlink.Link) (bool, error) {
+	qdiscs, err := netlink.QdiscList(iface)
+	if err != nil {
+		return false, err
+	}
+	for _, qdisc := range qdiscs {
+		if qdisc.Type() == "clsact" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// filters that cannot be listed are assumed to exist, so that the qdisc is kept
+func hasFilters(iface netlink.Link) bool {
+	for _, parent := range []uint32{netlink.HANDLE_MIN_INGRESS, netlink.HANDLE_MIN_EGRESS} {
+		filters, err := netlink.FilterList(iface, parent)
+		if err != nil || len(filters) > 0 {
+			return true
+		}
+	}
+	return false
+}
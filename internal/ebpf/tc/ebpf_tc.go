@@ -6,77 +6,86 @@ import (
 	"encoding/binary"
 	"errors"
 	"log/slog"
-	"os"
-	"os/signal"
-	"syscall"
+	"time"
 
-	"github.com/cilium/ebpf/perf"
 	"github.com/k8spacket/k8spacket/internal/broker"
+	"github.com/k8spacket/k8spacket/internal/config"
 	ebpf_tools "github.com/k8spacket/k8spacket/internal/ebpf/tools"
 	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/k8spacket/k8spacket/internal/status"
 	"github.com/vishvananda/netlink"
-	"golang.org/x/sys/unix"
 )
 
 //go:generate go run github.com/cilium/ebpf/cmd/bpf2go -go-package ebpf_tc tc ./bpf/tc.bpf.c
 
 type EbpfTc struct {
-	Broker broker.Broker
+	Broker    broker.Broker
+	Transport config.TransportConfig
+	Maps      config.MapsConfig
+	Attach    config.TcConfig
 }
 
-func (ebpfTc *EbpfTc) Init(iface string) {
+func (ebpfTc *EbpfTc) Init(ctx context.Context, iface string) {
+
+	// every interface is reported separately and forgotten once the interface is gone
+	component := "tc/" + iface
+	status.Report(component, status.Starting, "")
+	defer status.Remove(component)
 
 	// Load pre-compiled programs and maps into the kernel.
 	objs := tcObjects{}
-	if err := loadTcObjects(&objs, nil); err != nil {
+	if err := ebpfTc.load(&objs); err != nil {
 		slog.Error("[tc] Loading objects", "Error", err)
+		status.Report(component, status.Down, "cannot load objects: "+err.Error())
+		// keep the failure reported as long as the interface is watched
+		<-ctx.Done()
+		return
 	}
 	defer objs.Close()
-
-	// get the file descriptor of the tc_filter program
-	progFd := objs.tcPrograms.TcFilter.FD()
+	// excluded traffic is filtered in the kernel as soon as the program is attached
+	defer ebpf_tools.AttachFilter(objs.ExcludedAddrs, objs.ExcludedPorts)()
 
 	// get link device by name (network interface name)
 	link, err := netlink.LinkByName(iface)
 	if err != nil {
 		slog.Error("[tc] Cannot find network intefrace", "interface", iface, "Error", err)
+		status.Report(component, status.Down, "cannot find network interface: "+err.Error())
+		<-ctx.Done()
+		return
 	}
 
-	// qdisc clsact - queueing discipline (qdisc) parent of ingress and egress filters
-	attrs := netlink.QdiscAttrs{
-		LinkIndex: link.Attrs().Index,
-		Handle:    netlink.MakeHandle(0xffff, 0),
-		Parent:    netlink.HANDLE_CLSACT,
-	}
-
-	qdisc := &netlink.GenericQdisc{
-		QdiscAttrs: attrs,
-		QdiscType:  "clsact",
-	}
-
-	// try to delete previous added clsact qdisc on specific network interface, equivalent `tc qdisc del dev {{iface}} clsact`
-	if err := netlink.QdiscDel(qdisc); err != nil {
-		slog.Error("[tc] Cannot del clsact qdisc", "Error", err)
-	}
-
-	// add clsact qdisc on specific network interface, equivalent `tc qdisc add dev {{iface}} clsact`
-	// check `qdisc show dev {{iface}}`
-	if err := netlink.QdiscAdd(qdisc); err != nil {
-		slog.Error("[tc] Cannot add clsact qdisc", "Error", err)
+	detach, err := ebpfTc.attach(link, objs.tcPrograms.TcFilter)
+	if err != nil {
+		slog.Error("[tc] Cannot attach program", "interface", iface, "Error", err)
+		status.Report(component, status.Down, "cannot attach program: "+err.Error())
+		<-ctx.Done()
+		return
 	}
+	// remove only what was added, so nothing stays attached after exit and other tools keep their programs
+	defer detach()
 
-	// add ingress filter
-	addFilter(link, progFd, netlink.HANDLE_MIN_INGRESS)
-
-	// add egress filter
-	addFilter(link, progFd, netlink.HANDLE_MIN_EGRESS)
-
-	// create new reader for perf events
-	rd, err := perf.NewReader(objs.OutputEvents, os.Getpagesize())
+	// create new reader for ring buffer or perf events
+	rd, err := ebpf_tools.NewEventReader(objs.OutputEvents, ebpfTc.Transport)
 	if err != nil {
-		slog.Error("[tc] Creating perf event reader", "Error", err)
+		slog.Error("[tc] Creating event reader", "Error", err)
+		status.Report(component, status.Down, "cannot create event reader: "+err.Error())
+		<-ctx.Done()
+		return
 	}
 	defer rd.Close()
+	// ClientHellos come apart from the handshake events reported with the ServerHello
+	segments, err := ebpf_tools.NewEventReader(objs.OutputSegments, ebpfTc.Transport)
+	if err != nil {
+		slog.Error("[tc] Creating segment reader", "Error", err)
+		status.Report(component, status.Down, "cannot create segment reader: "+err.Error())
+		<-ctx.Done()
+		return
+	}
+	defer segments.Close()
+	reassembler := ebpf_tools.NewTLSReassembler("tc", iface, ebpfTc.Maps.Handshakes)
+	status.Report(component, status.Up, "")
+
+	go readSegments(segments, iface, reassembler, ebpfTc)
 
 	go func() {
 		// tcTlsHandshakeEvent is generated by bpf2go and represents perf event type in eBPF program
@@ -84,94 +93,119 @@ func (ebpfTc *EbpfTc) Init(iface string) {
 		for {
 			record, err := rd.Read()
 			if err != nil {
-				if errors.Is(err, perf.ErrClosed) {
+				if errors.Is(err, ebpf_tools.ErrClosed) {
 					slog.Info("[tc] Received signal, exiting..")
 					return
 				}
+				ebpf_tools.ReadErrorsMetric.WithLabelValues("tc", iface).Inc()
 				slog.Error("[tc] Reading from reader", "Error", err)
 				continue
 			}
+			if record.LostSamples > 0 {
+				ebpf_tools.LostSamplesMetric.WithLabelValues("tc", iface).Add(float64(record.LostSamples))
+				slog.Warn("[tc] Perf buffer full, samples lost", "Lost", record.LostSamples)
+				continue
+			}
 
 			// Parse the perf event into a tcTlsHandshakeEvent structure.
 			if err := binary.Read(bytes.NewBuffer(record.RawSample), binary.BigEndian, &event); err != nil {
+				ebpf_tools.ParseErrorsMetric.WithLabelValues("tc", iface).Inc()
 				slog.Error("[tc] Parsing perf event", "Error", err)
 				continue
 			}
+			ebpf_tools.EventsMetric.WithLabelValues(modules.TC.String()).Inc()
 
-			distribute(event, ebpfTc)
+			distribute(event, ebpfTc, reassembler)
 		}
 	}()
 
-	// graceful shutdown
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer stop()
-
-	<-ctx.Done()
+	// count events lost by the ring buffer until shutdown, deferred closes detach the program
+	ebpf_tools.WatchLostEvents(ctx, objs.LostEvents, "tc", iface)
 
-	slog.Info("[tc] Closed gracefully")
+	slog.Info("[tc] Closed gracefully", "interface", iface)
 }
 
-func addFilter(link netlink.Link, programFD int, parent uint32) {
-
-	// filter attrs
-	filterAttrs := netlink.FilterAttrs{
-		LinkIndex: link.Attrs().Index,
-		Parent:    parent,
-		Handle:    netlink.MakeHandle(0, 1),
-		Protocol:  unix.ETH_P_ALL,
-		Priority:  1,
-	}
-
-	// bpf filter struct
-	filter := &netlink.BpfFilter{
-		FilterAttrs:  filterAttrs,
-		Fd:           programFD,
-		Name:         "tc",
-		DirectAction: true,
-	}
-
-	// add ingress/egress filter, equivalent `tc filter add dev {{iface}} [ingress|egress]`
-	// check `tc filter show dev {{iface}} [ingress|egress]`
-	if err := netlink.FilterAdd(filter); err != nil {
-		slog.Error("[tc] Cannot attach bpf object to filter", "Error", err)
+// readSegments hands payloads of ClientHellos to the reassembler until the reader is closed
+func readSegments(rd ebpf_tools.EventReader, iface string, reassembler *ebpf_tools.TLSReassembler, ebpfTc *EbpfTc) {
+	// tcTlsSegmentEvent is generated by bpf2go and represents segment event type in eBPF program
+	var event tcTlsSegmentEvent
+	for {
+		record, err := rd.Read()
+		if err != nil {
+			if errors.Is(err, ebpf_tools.ErrClosed) {
+				return
+			}
+			ebpf_tools.ReadErrorsMetric.WithLabelValues("tc", iface).Inc()
+			slog.Error("[tc] Reading segments from reader", "Error", err)
+			continue
+		}
+		if record.LostSamples > 0 {
+			ebpf_tools.LostSamplesMetric.WithLabelValues("tc", iface).Add(float64(record.LostSamples))
+			slog.Warn("[tc] Perf buffer full, segments lost", "Lost", record.LostSamples)
+			continue
+		}
+		if err := binary.Read(bytes.NewBuffer(record.RawSample), binary.BigEndian, &event); err != nil {
+			ebpf_tools.ParseErrorsMetric.WithLabelValues("tc", iface).Inc()
+			slog.Error("[tc] Parsing segment event", "Error", err)
+			continue
+		}
+		publish(ebpfTc, reassembler.AddSegment(segment(event), time.Now())...)
 	}
 }
 
-func distribute(event tcTlsHandshakeEvent, tc *EbpfTc) {
-
-	tlsVersionsLen := int(event.TlsVersionsLength) / 2
-	if tlsVersionsLen > len(event.TlsVersions) {
-		tlsVersionsLen = len(event.TlsVersions)
-	}
-
-	ciphersLen := int(event.CiphersLength) / 2
-	if ciphersLen > len(event.Ciphers) {
-		ciphersLen = len(event.Ciphers)
-	}
+func segment(event tcTlsSegmentEvent) ebpf_tools.TLSSegment {
+	length := min(int(event.Length), len(event.Payload))
+	return ebpf_tools.TLSSegment{
+		Client: modules.Address{
+			Addr: ebpf_tools.BytesToIP(event.Family, event.Saddr),
+			Port: event.Sport},
+		Server: modules.Address{
+			Addr: ebpf_tools.BytesToIP(event.Family, event.Daddr),
+			Port: event.Dport},
+		RecordSeq:    event.RecordSeq,
+		RecordLength: event.RecordLength,
+		Seq:          event.Seq,
+		Payload:      event.Payload[:length]}
+}
 
-	serverNameLen := int(event.ServerNameLength)
-	if serverNameLen > len(event.ServerName) {
-		serverNameLen = len(event.ServerName)
-	}
+func distribute(event tcTlsHandshakeEvent, tc *EbpfTc, reassembler *ebpf_tools.TLSReassembler) {
 
 	tlsEvent := modules.TLSEvent{
 		Source: modules.TC,
 		Client: modules.Address{
-			Addr: ebpf_tools.IntToIP4(event.Saddr, binary.BigEndian.PutUint32),
+			Addr: ebpf_tools.BytesToIP(event.Family, event.Saddr),
 			Port: event.Sport},
 		Server: modules.Address{
-			Addr: ebpf_tools.IntToIP4(event.Daddr, binary.BigEndian.PutUint32),
+			Addr: ebpf_tools.BytesToIP(event.Family, event.Daddr),
 			Port: event.Dport},
-		TlsVersions:    event.TlsVersions[:tlsVersionsLen],
-		Ciphers:        event.Ciphers[:ciphersLen],
-		ServerName:     string(event.ServerName[:serverNameLen]),
 		UsedTlsVersion: event.UsedTlsVersion,
-		UsedCipher:     event.UsedCipher}
-	if len(tlsEvent.TlsVersions) <= 0 {
-		tlsEvent.TlsVersions = append(tlsEvent.TlsVersions, event.TlsVersion)
+		UsedCipher:     event.UsedCipher,
+		UsedAlpn:       string(event.UsedAlpn[:min(int(event.UsedAlpnLength), len(event.UsedAlpn))]),
+		Time:           time.Now()}
+
+	// the ClientHello is parsed in user space, the event is sent along with it
+	publish(tc, reassembler.Complete(tlsEvent, tlsEvent.Time)...)
+}
+
+func publish(tc *EbpfTc, events ...modules.TLSEvent) {
+	for _, tlsEvent := range events {
+		ebpf_tools.EnrichConnection(&tlsEvent.Client, &tlsEvent.Server)
+		tc.Broker.TLSEvent(tlsEvent)
 	}
+}
 
-	ebpf_tools.EnrichAddress(&tlsEvent.Client)
-	ebpf_tools.EnrichAddress(&tlsEvent.Server)
-	tc.Broker.TLSEvent(tlsEvent)
+// loadTcObjects with the events map set up for the configured transport and maps sized from the config
+func (ebpfTc *EbpfTc) load(objs *tcObjects) error {
+	spec, err := loadTc()
+	if err != nil {
+		return err
+	}
+	ebpf_tools.ResizeMaps(spec, map[string]int{"events": ebpfTc.Maps.Handshakes, "excluded_addrs": ebpfTc.Maps.FilterAddresses})
+	if err := ebpf_tools.PrepareTransport(spec, "output_events", ebpfTc.Transport); err != nil {
+		return err
+	}
+	if err := ebpf_tools.PrepareTransport(spec, "output_segments", ebpfTc.Transport); err != nil {
+		return err
+	}
+	return spec.LoadAndAssign(objs, nil)
 }
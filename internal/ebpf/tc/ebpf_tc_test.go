@@ -1,16 +1,25 @@
 package ebpf_tc
 
 import (
-	"encoding/binary"
+	"context"
 	"os"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/cilium/ebpf"
+	"github.com/k8spacket/k8spacket/internal/broker"
+	"github.com/k8spacket/k8spacket/internal/config"
 	ebpf_tools "github.com/k8spacket/k8spacket/internal/ebpf/tools"
 	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/k8spacket/k8spacket/internal/status"
 	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
 )
 
 type fakeBrokerTC struct {
+	broker.Broker
 	last modules.TLSEvent
 }
 
@@ -18,51 +27,209 @@ func (f *fakeBrokerTC) DistributeEvents()               {}
 func (f *fakeBrokerTC) TCPEvent(event modules.TCPEvent) {}
 func (f *fakeBrokerTC) TLSEvent(event modules.TLSEvent) { f.last = event }
 
-func TestDistribute(t *testing.T) {
-	// ensure k8s enrichment uses disabled mode
-	os.Setenv("K8S_PACKET_K8S_RESOURCES_DISABLED", "true")
-
-	sNum := binary.BigEndian.Uint32([]byte{192, 168, 1, 100})
-	dNum := binary.BigEndian.Uint32([]byte{10, 1, 2, 3})
+// segmentEvent carries the part of the record from the offset on, up to length bytes
+func segmentEvent(evt tcTlsHandshakeEvent, record []byte, offset int, length int) tcTlsSegmentEvent {
+	var segment tcTlsSegmentEvent
+	segment.Saddr, segment.Daddr, segment.Family, segment.Sport, segment.Dport = evt.Saddr, evt.Daddr, evt.Family, evt.Sport, evt.Dport
+	segment.RecordSeq = 1000
+	segment.RecordLength = uint32(len(record))
+	segment.Seq = 1000 + uint32(offset)
+	segment.Length = uint16(copy(segment.Payload[:], record[offset:offset+length]))
+	return segment
+}
 
+func TestDistribute(t *testing.T) {
 	var evt tcTlsHandshakeEvent
-	evt.Saddr = sNum
-	evt.Daddr = dNum
+	evt.Saddr = [16]uint8{192, 168, 1, 100}
+	evt.Daddr = [16]uint8{10, 1, 2, 3}
+	evt.Family = syscall.AF_INET
 	evt.Sport = 15000
 	evt.Dport = 443
-	evt.TlsVersion = 0x0304
-	evt.TlsVersionsLength = 2 * 2 // two uint16
-	evt.TlsVersions[0] = 0x0303
-	evt.TlsVersions[1] = 0x0304
-	evt.CiphersLength = 2 * 2
-	evt.Ciphers[0] = 0x1301
-	evt.Ciphers[1] = 0x1302
-	name := "tc.example"
-	copy(evt.ServerName[:], []byte(name))
-	evt.ServerNameLength = uint16(len(name))
 	evt.UsedTlsVersion = 0x0304
 	evt.UsedCipher = 0x1301
+	name := "tc.example"
+	record := ebpf_tools.ClientHelloRecord(name, []uint16{0x0303, 0x0304}, []uint16{0x1301, 0x1302})
 
 	fb := &fakeBrokerTC{}
 	tcInst := &EbpfTc{Broker: fb}
+	reassembler := ebpf_tools.NewTLSReassembler("tc", "eth0", 16)
 
-	distribute(evt, tcInst)
+	publish(tcInst, reassembler.AddSegment(segment(segmentEvent(evt, record, 0, len(record))), time.Now())...)
+	distribute(evt, tcInst, reassembler)
 
 	got := fb.last
 	assert.Equal(t, modules.TC, got.Source)
-	assert.Equal(t, ebpf_tools.IntToIP4(sNum, binary.BigEndian.PutUint32), got.Client.Addr)
+	assert.Equal(t, "192.168.1.100", got.Client.Addr)
 	assert.Equal(t, evt.Sport, got.Client.Port)
-	assert.Equal(t, ebpf_tools.IntToIP4(dNum, binary.BigEndian.PutUint32), got.Server.Addr)
+	assert.Equal(t, "10.1.2.3", got.Server.Addr)
 	assert.Equal(t, evt.Dport, got.Server.Port)
-	assert.Equal(t, 2, len(got.TlsVersions))
-	assert.Equal(t, uint16(0x0303), got.TlsVersions[0])
-	assert.Equal(t, uint16(0x0304), got.TlsVersions[1])
-	assert.Equal(t, 2, len(got.Ciphers))
-	assert.Equal(t, uint16(0x1301), got.Ciphers[0])
-	assert.Equal(t, uint16(0x1302), got.Ciphers[1])
+	assert.Equal(t, []uint16{0x0303, 0x0304}, got.TlsVersions)
+	assert.Equal(t, []uint16{0x1301, 0x1302}, got.Ciphers)
+	assert.Equal(t, []uint16{0x0000, 0x002b}, got.Extensions)
 	assert.Equal(t, name, got.ServerName)
 	assert.Equal(t, evt.UsedTlsVersion, got.UsedTlsVersion)
 	assert.Equal(t, evt.UsedCipher, got.UsedCipher)
 	assert.Equal(t, "N/A", got.Client.Name)
 	assert.Equal(t, "N/A", got.Server.Name)
 }
+
+func TestDistributeIPv6(t *testing.T) {
+	var evt tcTlsHandshakeEvent
+	evt.Saddr = [16]uint8{0xfd, 0x00, 15: 0x0a}
+	evt.Daddr = [16]uint8{0xfd, 0x00, 15: 0x05}
+	evt.Family = syscall.AF_INET6
+	evt.Sport = 44321
+	evt.Dport = 443
+	record := ebpf_tools.ClientHelloRecord("v6.example", []uint16{0x0304}, []uint16{0x1301})
+
+	fb := &fakeBrokerTC{}
+	tcInst := &EbpfTc{Broker: fb}
+	reassembler := ebpf_tools.NewTLSReassembler("tc", "eth0", 16)
+	publish(tcInst, reassembler.AddSegment(segment(segmentEvent(evt, record, 0, len(record))), time.Now())...)
+	distribute(evt, tcInst, reassembler)
+
+	got := fb.last
+	assert.Equal(t, "fd00::a", got.Client.Addr)
+	assert.Equal(t, "fd00::5", got.Server.Addr)
+	assert.Equal(t, "v6.example", got.ServerName)
+	assert.Equal(t, "N/A", got.Client.Name)
+	assert.Equal(t, "N/A", got.Server.Name)
+}
+
+func TestDistributeReassembled(t *testing.T) {
+	var evt tcTlsHandshakeEvent
+	evt.Saddr = [16]uint8{192, 168, 1, 100}
+	evt.Daddr = [16]uint8{10, 1, 2, 3}
+	evt.Family = syscall.AF_INET
+	evt.Sport = 15000
+	evt.Dport = 443
+	evt.UsedTlsVersion = 0x0303
+	evt.UsedCipher = 0x1301
+	evt.UsedAlpnLength = uint8(copy(evt.UsedAlpn[:], "h2"))
+	record := ebpf_tools.ClientHelloRecord("pq.example", nil, []uint16{0x1301})
+
+	fb := &fakeBrokerTC{}
+	tcInst := &EbpfTc{Broker: fb}
+	reassembler := ebpf_tools.NewTLSReassembler("tc", "eth0", 16)
+
+	// the ServerHello comes before the rest of the ClientHello was read
+	distribute(evt, tcInst, reassembler)
+	publish(tcInst, reassembler.AddSegment(segment(segmentEvent(evt, record, 0, 20)), time.Now())...)
+	assert.Empty(t, fb.last.Client.Addr)
+
+	publish(tcInst, reassembler.AddSegment(segment(segmentEvent(evt, record, 20, len(record)-20)), time.Now())...)
+
+	got := fb.last
+	assert.Equal(t, "192.168.1.100", got.Client.Addr)
+	assert.Equal(t, "10.1.2.3", got.Server.Addr)
+	assert.Equal(t, "pq.example", got.ServerName)
+	assert.Equal(t, []uint16{0x0303}, got.TlsVersions)
+	assert.Equal(t, []uint16{0x1301}, got.Ciphers)
+	assert.Equal(t, evt.UsedCipher, got.UsedCipher)
+	assert.Equal(t, "h2", got.UsedAlpn)
+	assert.Equal(t, "N/A", got.Server.Name)
+}
+
+func TestNewFilter(t *testing.T) {
+	iface := &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "veth1", Index: 7}}
+
+	filter := newFilter(iface, 42, netlink.HANDLE_MIN_EGRESS, config.TcConfig{AttachMode: AttachClsact, Priority: 49152, Handle: 0x6b70})
+
+	assert.EqualValues(t, 7, filter.LinkIndex)
+	assert.EqualValues(t, netlink.HANDLE_MIN_EGRESS, filter.Parent)
+	assert.EqualValues(t, 0x6b70, filter.Handle)
+	assert.EqualValues(t, 49152, filter.Priority)
+	assert.EqualValues(t, unix.ETH_P_ALL, filter.Protocol)
+	assert.EqualValues(t, 42, filter.Fd)
+	assert.True(t, filter.DirectAction)
+}
+
+// every map, program and variable of the generated bindings is in the objects of both byte orders
+func TestObjects(t *testing.T) {
+	for _, object := range []string{"tc_bpfel.o", "tc_bpfeb.o"} {
+		spec, err := ebpf.LoadCollectionSpec(object)
+		assert.Nil(t, err, object)
+		assert.Nil(t, spec.Assign(&tcSpecs{}), object)
+	}
+}
+
+// the program passes the verifier of the running kernel with both transports, loading it requires root
+func TestLoad(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("loading eBPF programs requires root")
+	}
+	for _, mode := range []string{ebpf_tools.TransportRingBuf, ebpf_tools.TransportPerf} {
+		ebpfTc := EbpfTc{
+			Transport: config.TransportConfig{Mode: mode, RingBufferSize: 256 * 1024, PerfBufferSize: 64 * 1024},
+			Maps:      config.MapsConfig{Handshakes: 4096, FilterAddresses: 4096}}
+		objs := tcObjects{}
+		assert.Nil(t, ebpfTc.load(&objs), mode)
+		objs.Close()
+	}
+}
+
+// an interface gone before the program is attached stays reported as down while it is watched
+func TestInitMissingInterface(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("loading eBPF programs requires root")
+	}
+	ebpfTc := EbpfTc{
+		Transport: config.TransportConfig{Mode: ebpf_tools.TransportPerf, PerfBufferSize: 64 * 1024},
+		Maps:      config.MapsConfig{Handshakes: 4096, FilterAddresses: 4096}}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ebpfTc.Init(ctx, "kp-missing0")
+	}()
+
+	assert.Eventually(t, func() bool {
+		component, ok := status.Default().Component("tc/kp-missing0")
+		return ok && component.State == status.Down
+	}, 5*time.Second, 10*time.Millisecond)
+	component, _ := status.Default().Component("tc/kp-missing0")
+	assert.Contains(t, component.Reason, "cannot find network interface")
+
+	cancel()
+	<-done
+	_, ok := status.Default().Component("tc/kp-missing0")
+	assert.False(t, ok)
+}
+
+// detaching an instance leaves the filters of the one attached to the interface again in place, adding links requires root
+func TestAttachClsactAgain(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("adding links requires root")
+	}
+	iface := &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "kp-test0"}, PeerName: "kp-test1"}
+	if err := netlink.LinkAdd(iface); err != nil {
+		t.Fatal(err)
+	}
+	defer netlink.LinkDel(iface)
+	ebpfTc := EbpfTc{
+		Transport: config.TransportConfig{Mode: ebpf_tools.TransportPerf, PerfBufferSize: 64 * 1024},
+		Maps:      config.MapsConfig{Handshakes: 4096, FilterAddresses: 4096},
+		Attach:    config.TcConfig{AttachMode: AttachClsact, Priority: 1, Handle: 0x6b70}}
+	link, err := netlink.LinkByName(iface.Name)
+	assert.Nil(t, err)
+
+	old, again := tcObjects{}, tcObjects{}
+	assert.Nil(t, ebpfTc.load(&old))
+	defer old.Close()
+	assert.Nil(t, ebpfTc.load(&again))
+	defer again.Close()
+	detachOld, err := ebpfTc.attach(link, old.TcFilter)
+	assert.Nil(t, err)
+	detachAgain, err := ebpfTc.attach(link, again.TcFilter)
+	assert.Nil(t, err)
+
+	detachOld()
+	assert.True(t, hasFilters(link))
+	exists, _ := hasClsact(link)
+	assert.True(t, exists)
+
+	detachAgain()
+	assert.False(t, hasFilters(link))
+	exists, _ = hasClsact(link)
+	assert.False(t, exists)
+}
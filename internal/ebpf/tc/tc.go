@@ -1,5 +1,7 @@
 package ebpf_tc
 
+import "context"
+
 type Tc interface {
-	Init(iface string)
+	Init(ctx context.Context, iface string)
 }
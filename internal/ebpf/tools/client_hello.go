@@ -0,0 +1,174 @@
+package ebpf_tools
+
+import (
+	"encoding/binary"
+	"errors"
+	"slices"
+
+	"github.com/k8spacket/k8spacket/internal/modules"
+)
+
+const (
+	recordHeaderLen = 5
+	// plaintext records carry at most 2^14 bytes
+	maxRecordLen = recordHeaderLen + 1<<14
+
+	handshakeRecord       = 0x16
+	clientHello           = 0x01
+	serverNameExtension   = 0x0000
+	groupsExtension       = 0x000a
+	pointFormatsExtension = 0x000b
+	sigAlgsExtension      = 0x000d
+	alpnExtension         = 0x0010
+	tlsVersionsExtension  = 0x002b
+	serverNameHostName    = 0x00
+	handshakeHeaderLen    = 4
+	randomLen             = 32
+	minClientHelloBodyLen = 2 + randomLen + 1 + 2 + 1
+)
+
+var errNotClientHello = errors.New("not a TLS ClientHello")
+
+// ClientHello is what a client offers in the first message of a TLS handshake
+type ClientHello struct {
+	// legacy version of the message, TLS 1.3 is offered in TlsVersions only
+	TlsVersion  uint16
+	TlsVersions []uint16
+	Ciphers     []uint16
+	ServerName  string
+	// extension types in the order the client sent them
+	Extensions          []uint16
+	SupportedGroups     []uint16
+	PointFormats        []uint8
+	SignatureAlgorithms []uint16
+	Alpn                []string
+}
+
+// ParseClientHello reads a complete handshake record starting with a ClientHello, the message must fit in the record
+func ParseClientHello(record []byte) (ClientHello, error) {
+	if len(record) < recordHeaderLen {
+		return ClientHello{}, ErrTruncated
+	}
+	if record[0] != handshakeRecord {
+		return ClientHello{}, errNotClientHello
+	}
+	length := int(binary.BigEndian.Uint16(record[3:5]))
+	if len(record) < recordHeaderLen+length {
+		return ClientHello{}, ErrTruncated
+	}
+	message := record[recordHeaderLen : recordHeaderLen+length]
+	if len(message) < handshakeHeaderLen || message[0] != clientHello {
+		return ClientHello{}, errNotClientHello
+	}
+	bodyLen := int(message[1])<<16 | int(message[2])<<8 | int(message[3])
+	if len(message) < handshakeHeaderLen+bodyLen || bodyLen < minClientHelloBodyLen {
+		return ClientHello{}, ErrTruncated
+	}
+
+	body := &byteReader{data: message[handshakeHeaderLen : handshakeHeaderLen+bodyLen]}
+	hello := ClientHello{TlsVersion: body.uint16()}
+	body.skip(randomLen)
+	body.vector8()
+	hello.Ciphers = body.vector16().uint16s()
+	body.vector8()
+	// extensions are optional
+	extensions := body.vector16()
+	for extensions.len() > 0 {
+		extensionType := extensions.uint16()
+		extension := extensions.vector16()
+		hello.Extensions = append(hello.Extensions, extensionType)
+		switch extensionType {
+		case serverNameExtension:
+			names := extension.vector16()
+			for names.len() > 0 {
+				nameType := names.uint8()
+				name := names.vector16()
+				if nameType == serverNameHostName {
+					hello.ServerName = string(name.data)
+					break
+				}
+			}
+		case tlsVersionsExtension:
+			hello.TlsVersions = extension.vector8().uint16s()
+		case groupsExtension:
+			hello.SupportedGroups = extension.vector16().uint16s()
+		case pointFormatsExtension:
+			hello.PointFormats = slices.Clone(extension.vector8().data)
+		case sigAlgsExtension:
+			hello.SignatureAlgorithms = extension.vector16().uint16s()
+		case alpnExtension:
+			protocols := extension.vector16()
+			for protocols.len() > 0 {
+				hello.Alpn = append(hello.Alpn, string(protocols.vector8().data))
+			}
+		}
+	}
+	if body.failed || extensions.failed {
+		return ClientHello{}, ErrTruncated
+	}
+	return hello, nil
+}
+
+// Fill copies what the client offered into the handshake event
+func (hello ClientHello) Fill(event *modules.TLSEvent) {
+	event.TlsVersions, event.Ciphers, event.ServerName = hello.TlsVersions, hello.Ciphers, hello.ServerName
+	if len(event.TlsVersions) <= 0 {
+		event.TlsVersions = []uint16{hello.TlsVersion}
+	}
+	event.HelloVersion = hello.TlsVersion
+	event.Extensions, event.SupportedGroups, event.PointFormats = hello.Extensions, hello.SupportedGroups, hello.PointFormats
+	event.SignatureAlgorithms, event.Alpn = hello.SignatureAlgorithms, hello.Alpn
+}
+
+// byteReader reads big endian fields of a handshake message, reads past the end return zero values and mark it as failed
+type byteReader struct {
+	data   []byte
+	failed bool
+}
+
+func (reader *byteReader) len() int {
+	return len(reader.data)
+}
+
+func (reader *byteReader) skip(n int) []byte {
+	if n > len(reader.data) {
+		reader.data, reader.failed = nil, true
+		return nil
+	}
+	skipped := reader.data[:n]
+	reader.data = reader.data[n:]
+	return skipped
+}
+
+func (reader *byteReader) uint8() uint8 {
+	if b := reader.skip(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (reader *byteReader) uint16() uint16 {
+	if b := reader.skip(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+// vector8 and vector16 read a field prefixed by its length, nested readers fail along with their parent
+func (reader *byteReader) vector8() *byteReader {
+	data := reader.skip(int(reader.uint8()))
+	return &byteReader{data: data, failed: reader.failed}
+}
+
+func (reader *byteReader) vector16() *byteReader {
+	data := reader.skip(int(reader.uint16()))
+	return &byteReader{data: data, failed: reader.failed}
+}
+
+func (reader *byteReader) uint16s() []uint16 {
+	values := make([]uint16, 0, len(reader.data)/2)
+	for reader.len() >= 2 {
+		values = append(values, reader.uint16())
+	}
+	return values
+}
@@ -0,0 +1,25 @@
+package ebpf_tools
+
+// ClientHelloRecord builds a handshake record offering the versions, cipher suites and server name,
+// it is a fixture shared by tests of the eBPF loaders, which cannot import each other's _test.go files
+func ClientHelloRecord(serverName string, versions []uint16, ciphers []uint16) []byte {
+	vector16 := func(data []byte) []byte { return append([]byte{byte(len(data) >> 8), byte(len(data))}, data...) }
+	uint16s := func(values []uint16) []byte {
+		var data []byte
+		for _, value := range values {
+			data = append(data, byte(value>>8), byte(value))
+		}
+		return data
+	}
+	name := append([]byte{0x00}, vector16([]byte(serverName))...)
+	extensions := append([]byte{0x00, 0x00}, vector16(vector16(name))...)
+	extensions = append(extensions, 0x00, 0x2b, 0x00, byte(len(versions)*2+1), byte(len(versions)*2))
+	extensions = append(extensions, uint16s(versions)...)
+	body := append([]byte{0x03, 0x03}, make([]byte, 32)...)
+	body = append(body, 0x00)
+	body = append(body, vector16(uint16s(ciphers))...)
+	body = append(body, 0x01, 0x00)
+	body = append(body, vector16(extensions)...)
+	message := append([]byte{0x01, 0x00}, vector16(body)...)
+	return append([]byte{0x16, 0x03, 0x01}, vector16(message)...)
+}
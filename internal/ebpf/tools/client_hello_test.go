@@ -0,0 +1,92 @@
+package ebpf_tools
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordClientHello returns the first record sent by a Go client, post-quantum key shares make it larger than one MTU
+func recordClientHello(t *testing.T, serverName string) []byte {
+	client, server := net.Pipe()
+	defer server.Close()
+	go tls.Client(client, &tls.Config{ServerName: serverName, CurvePreferences: []tls.CurveID{tls.X25519MLKEM768, tls.X25519}, NextProtos: []string{"h2", "http/1.1"}}).Handshake()
+
+	header := make([]byte, recordHeaderLen)
+	_, err := io.ReadFull(server, header)
+	assert.NoError(t, err)
+	record := make([]byte, recordHeaderLen+int(binary.BigEndian.Uint16(header[3:5])))
+	copy(record, header)
+	_, err = io.ReadFull(server, record[recordHeaderLen:])
+	assert.NoError(t, err)
+	return record
+}
+
+func TestParseClientHello(t *testing.T) {
+	record := recordClientHello(t, "pq.example.com")
+	assert.Greater(t, len(record), 1500)
+
+	hello, err := ParseClientHello(record)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0x0303, hello.TlsVersion)
+	assert.EqualValues(t, []uint16{0x0304, 0x0303}, hello.TlsVersions)
+	assert.Contains(t, hello.Ciphers, uint16(0x1301))
+	assert.Contains(t, hello.Ciphers, uint16(0xc02f))
+	assert.EqualValues(t, "pq.example.com", hello.ServerName)
+	assert.EqualValues(t, []uint16{0x11ec, 0x001d}, hello.SupportedGroups)
+	assert.EqualValues(t, []uint8{0x00}, hello.PointFormats)
+	assert.Contains(t, hello.SignatureAlgorithms, uint16(0x0804))
+	assert.EqualValues(t, []string{"h2", "http/1.1"}, hello.Alpn)
+	assert.Contains(t, hello.Extensions, uint16(serverNameExtension))
+	assert.Contains(t, hello.Extensions, uint16(alpnExtension))
+}
+
+func TestFill(t *testing.T) {
+	var event modules.TLSEvent
+	ClientHello{TlsVersion: 0x0303, Ciphers: []uint16{0xc02f}, ServerName: "legacy.example", Alpn: []string{"h2"}}.Fill(&event)
+
+	assert.EqualValues(t, []uint16{0x0303}, event.TlsVersions)
+	assert.EqualValues(t, []uint16{0xc02f}, event.Ciphers)
+	assert.EqualValues(t, "legacy.example", event.ServerName)
+	assert.EqualValues(t, []string{"h2"}, event.Alpn)
+}
+
+func TestParseClientHelloErrors(t *testing.T) {
+	record := recordClientHello(t, "pq.example.com")
+	serverHello := append([]byte{}, record...)
+	serverHello[recordHeaderLen] = 0x02
+
+	var tests = []struct {
+		name   string
+		record []byte
+		err    error
+	}{
+		{"empty", nil, ErrTruncated},
+		{"application data", []byte{0x17, 0x03, 0x03, 0x00, 0x01, 0x00}, errNotClientHello},
+		{"server hello", serverHello, errNotClientHello},
+		{"truncated record", record[:1400], ErrTruncated},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseClientHello(test.record)
+
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+}
+
+func TestClientHelloRecord(t *testing.T) {
+	hello, err := ParseClientHello(ClientHelloRecord("fixture.example", []uint16{0x0303, 0x0304}, []uint16{0x1301, 0xc02f}))
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, "fixture.example", hello.ServerName)
+	assert.EqualValues(t, []uint16{0x0303, 0x0304}, hello.TlsVersions)
+	assert.EqualValues(t, []uint16{0x1301, 0xc02f}, hello.Ciphers)
+}
@@ -0,0 +1,181 @@
+package ebpf_tools
+
+import (
+	"context"
+	"log/slog"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/cilium/ebpf"
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/k8spacket/k8spacket/internal/thirdparty/k8s"
+)
+
+// FilterMap is the part of an eBPF map used to fill filter maps
+type FilterMap interface {
+	Put(key, value interface{}) error
+	Delete(key interface{}) error
+}
+
+// FilterKey is the key of the excluded_addrs LPM trie, IPv4 addresses are mapped into IPv6 so both families
+// share the trie
+type FilterKey struct {
+	Prefixlen uint32
+	Addr      [16]uint8
+}
+
+// filter maps of one loaded program and the keys put into them
+type filterMaps struct {
+	addrs    FilterMap
+	ports    FilterMap
+	addrKeys map[FilterKey]bool
+	portKeys map[uint16]bool
+}
+
+// Filter keeps filter maps of all loaded programs in line with loader.filter and pods of excluded namespaces
+type Filter struct {
+	mu       sync.Mutex
+	config   config.FilterConfig
+	addrKeys map[FilterKey]bool
+	portKeys map[uint16]bool
+	programs map[*filterMaps]bool
+}
+
+var filter = &Filter{programs: make(map[*filterMaps]bool)}
+
+// ResizeMaps sets the number of entries of maps by name before the collection is loaded, maps without a size
+// keep the one of the object
+func ResizeMaps(spec *ebpf.CollectionSpec, sizes map[string]int) {
+	for name, size := range sizes {
+		if mapSpec, ok := spec.Maps[name]; ok && size > 0 {
+			mapSpec.MaxEntries = uint32(size)
+		}
+	}
+}
+
+// ConfigureFilter sets traffic dropped in the kernel, the config is expected to be validated already.
+// Maps of loaded programs are updated right away.
+func ConfigureFilter(cfg config.FilterConfig) {
+	filter.mu.Lock()
+	defer filter.mu.Unlock()
+	filter.config = cfg
+	filter.refresh()
+}
+
+// RefreshFilter follows IPs of pods in excluded namespaces until ctx is done
+func RefreshFilter(ctx context.Context) {
+	for {
+		filter.mu.Lock()
+		period := filter.config.RefreshPeriod.Duration
+		filter.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(period):
+			filter.mu.Lock()
+			filter.refresh()
+			filter.mu.Unlock()
+		}
+	}
+}
+
+// AttachFilter fills the filter maps of a loaded program, they are kept up to date until the returned function
+// is called, which must happen before the maps are closed
+func AttachFilter(addrs FilterMap, ports FilterMap) func() {
+	maps := &filterMaps{addrs: addrs, ports: ports, addrKeys: make(map[FilterKey]bool), portKeys: make(map[uint16]bool)}
+	filter.mu.Lock()
+	defer filter.mu.Unlock()
+	filter.programs[maps] = true
+	filter.sync(maps)
+	return func() {
+		filter.mu.Lock()
+		defer filter.mu.Unlock()
+		delete(filter.programs, maps)
+	}
+}
+
+func (filter *Filter) refresh() {
+	filter.addrKeys, filter.portKeys = filterKeys(filter.config, k8sclient.GetPodIPsInNamespaces(filter.config.ExcludeNamespaces))
+	for maps := range filter.programs {
+		filter.sync(maps)
+	}
+}
+
+// only differences are written, keys failing to be written are retried on the next refresh
+func (filter *Filter) sync(maps *filterMaps) {
+	for key := range maps.addrKeys {
+		if !filter.addrKeys[key] {
+			if err := maps.addrs.Delete(key); err != nil {
+				slog.Error("[filter] Cannot delete excluded address", "Address", key.String(), "Error", err)
+				continue
+			}
+			delete(maps.addrKeys, key)
+		}
+	}
+	for key := range filter.addrKeys {
+		if !maps.addrKeys[key] {
+			if err := maps.addrs.Put(key, uint8(1)); err != nil {
+				slog.Error("[filter] Cannot exclude address", "Address", key.String(), "Error", err)
+				continue
+			}
+			maps.addrKeys[key] = true
+		}
+	}
+	for port := range maps.portKeys {
+		if !filter.portKeys[port] {
+			if err := maps.ports.Delete(port); err != nil {
+				slog.Error("[filter] Cannot delete excluded port", "Port", port, "Error", err)
+				continue
+			}
+			delete(maps.portKeys, port)
+		}
+	}
+	for port := range filter.portKeys {
+		if !maps.portKeys[port] {
+			if err := maps.ports.Put(port, uint8(1)); err != nil {
+				slog.Error("[filter] Cannot exclude port", "Port", port, "Error", err)
+				continue
+			}
+			maps.portKeys[port] = true
+		}
+	}
+}
+
+func filterKeys(cfg config.FilterConfig, podIPs []string) (map[FilterKey]bool, map[uint16]bool) {
+	addrKeys := make(map[FilterKey]bool)
+	for _, cidr := range cfg.ExcludeCIDRs {
+		if prefix, err := netip.ParsePrefix(cidr); err == nil {
+			addrKeys[newFilterKey(prefix.Masked())] = true
+		}
+	}
+	for _, ip := range podIPs {
+		if addr, err := netip.ParseAddr(ip); err == nil {
+			addrKeys[newFilterKey(netip.PrefixFrom(addr, addr.BitLen()))] = true
+		}
+	}
+	portKeys := make(map[uint16]bool)
+	for _, port := range cfg.ExcludePorts {
+		portKeys[uint16(port)] = true
+	}
+	return addrKeys, portKeys
+}
+
+// IPv4 prefixes become ::ffff:a.b.c.d with 96 more bits
+func newFilterKey(prefix netip.Prefix) FilterKey {
+	key := FilterKey{Prefixlen: uint32(prefix.Bits()), Addr: prefix.Addr().As16()}
+	if prefix.Addr().Is4() {
+		key.Prefixlen += 96
+	}
+	return key
+}
+
+func (key FilterKey) String() string {
+	addr := netip.AddrFrom16(key.Addr)
+	bits := int(key.Prefixlen)
+	if addr.Is4In6() {
+		addr = addr.Unmap()
+		bits -= 96
+	}
+	return netip.PrefixFrom(addr, bits).String()
+}
@@ -0,0 +1,108 @@
+package ebpf_tools
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+
+	"github.com/cilium/ebpf"
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockFilterMap struct {
+	FilterMap
+	data map[any]bool
+	full bool
+}
+
+func (mock *mockFilterMap) Put(key, value interface{}) error {
+	if mock.full {
+		return errors.New("map full")
+	}
+	mock.data[key] = true
+	return nil
+}
+
+func (mock *mockFilterMap) Delete(key interface{}) error {
+	delete(mock.data, key)
+	return nil
+}
+
+func TestResizeMaps(t *testing.T) {
+	spec := &ebpf.CollectionSpec{Maps: map[string]*ebpf.MapSpec{
+		"births": {Name: "births", MaxEntries: 100},
+		"events": {Name: "events", MaxEntries: 4096},
+	}}
+
+	ResizeMaps(spec, map[string]int{"births": 16384, "events": 0, "excluded_addrs": 4096})
+
+	assert.EqualValues(t, 16384, spec.Maps["births"].MaxEntries)
+	assert.EqualValues(t, 4096, spec.Maps["events"].MaxEntries)
+	assert.NotContains(t, spec.Maps, "excluded_addrs")
+}
+
+func TestFilterKey(t *testing.T) {
+
+	var tests = []struct {
+		prefix    string
+		prefixlen uint32
+	}{
+		{"10.0.0.0/8", 104},
+		{"10.244.0.5/32", 128},
+		{"fd00::/8", 8},
+		{"fd00:10:244::5/128", 128},
+	}
+
+	for _, test := range tests {
+		t.Run(test.prefix, func(t *testing.T) {
+			key := newFilterKey(netip.MustParsePrefix(test.prefix))
+
+			assert.EqualValues(t, test.prefixlen, key.Prefixlen)
+			assert.EqualValues(t, test.prefix, key.String())
+		})
+	}
+}
+
+func TestFilterKeys(t *testing.T) {
+	cfg := config.FilterConfig{ExcludeCIDRs: []string{"10.1.2.3/8", "fd00::/8"}, ExcludePorts: []int{9100, 10250}}
+
+	addrKeys, portKeys := filterKeys(cfg, []string{"10.244.0.5", "fd00:10:244::5", "pending"})
+
+	var addrs []string
+	for key := range addrKeys {
+		addrs = append(addrs, key.String())
+	}
+	assert.ElementsMatch(t, []string{"10.0.0.0/8", "fd00::/8", "10.244.0.5/32", "fd00:10:244::5/128"}, addrs)
+	assert.EqualValues(t, map[uint16]bool{9100: true, 10250: true}, portKeys)
+}
+
+func TestAttachFilter(t *testing.T) {
+	addrs := &mockFilterMap{data: make(map[any]bool)}
+	ports := &mockFilterMap{data: make(map[any]bool)}
+	ConfigureFilter(config.FilterConfig{ExcludeCIDRs: []string{"10.0.0.0/8"}, ExcludePorts: []int{9100}})
+
+	detach := AttachFilter(addrs, ports)
+
+	assert.EqualValues(t, map[any]bool{newFilterKey(netip.MustParsePrefix("10.0.0.0/8")): true}, addrs.data)
+	assert.EqualValues(t, map[any]bool{uint16(9100): true}, ports.data)
+
+	// only differences are written, failed writes are retried
+	ports.full = true
+	ConfigureFilter(config.FilterConfig{ExcludeCIDRs: []string{"fd00::/8"}, ExcludePorts: []int{9100, 10250}})
+
+	assert.EqualValues(t, map[any]bool{newFilterKey(netip.MustParsePrefix("fd00::/8")): true}, addrs.data)
+	assert.EqualValues(t, map[any]bool{uint16(9100): true}, ports.data)
+
+	ports.full = false
+	ConfigureFilter(config.FilterConfig{ExcludeCIDRs: []string{"fd00::/8"}, ExcludePorts: []int{9100, 10250}})
+
+	assert.EqualValues(t, map[any]bool{uint16(9100): true, uint16(10250): true}, ports.data)
+
+	// detached maps are not written anymore
+	detach()
+	ConfigureFilter(config.FilterConfig{})
+
+	assert.Len(t, addrs.data, 1)
+	assert.Len(t, ports.data, 2)
+}
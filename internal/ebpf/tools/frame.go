@@ -0,0 +1,55 @@
+package ebpf_tools
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	EthHeaderLen  = 14
+	IPv6HeaderLen = 40
+)
+
+var ErrTruncated = errors.New("truncated packet")
+
+// Packet is the IP packet carried by an Ethernet frame, Segment is the transport segment without Ethernet padding
+type Packet struct {
+	Src      net.IP
+	Dst      net.IP
+	Protocol uint8
+	Segment  []byte
+}
+
+// DecodeFrame reads the IPv4 or IPv6 packet of a frame captured by a raw socket, IPv6 extension headers are not followed
+func DecodeFrame(frame []byte) (Packet, error) {
+	if len(frame) < EthHeaderLen {
+		return Packet{}, ErrTruncated
+	}
+	packet := frame[EthHeaderLen:]
+	switch etherType := binary.BigEndian.Uint16(frame[12:14]); etherType {
+	case unix.ETH_P_IP:
+		if len(packet) < 20 {
+			return Packet{}, ErrTruncated
+		}
+		headerLen := int(packet[0]&0x0f) * 4
+		totalLen := int(binary.BigEndian.Uint16(packet[2:4]))
+		if headerLen < 20 || totalLen < headerLen {
+			return Packet{}, ErrTruncated
+		}
+		// frames may be cut by the socket filter, the segment is then cut as well
+		totalLen = min(totalLen, len(packet))
+		return Packet{Src: net.IP(packet[12:16]), Dst: net.IP(packet[16:20]), Protocol: packet[9], Segment: packet[headerLen:totalLen]}, nil
+	case unix.ETH_P_IPV6:
+		if len(packet) < IPv6HeaderLen {
+			return Packet{}, ErrTruncated
+		}
+		end := min(IPv6HeaderLen+int(binary.BigEndian.Uint16(packet[4:6])), len(packet))
+		return Packet{Src: net.IP(packet[8:24]), Dst: net.IP(packet[24:40]), Protocol: packet[6], Segment: packet[IPv6HeaderLen:end]}, nil
+	default:
+		return Packet{}, fmt.Errorf("unsupported ethertype %#04x", etherType)
+	}
+}
@@ -0,0 +1,50 @@
+package ebpf_tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeFrame(t *testing.T) {
+	ipv4 := append(make([]byte, 12), 0x08, 0x00,
+		0x45, 0, 0, 24, 0, 0, 0, 0, 64, 17, 0, 0, 10, 0, 0, 1, 10, 0, 0, 2,
+		1, 2, 3, 4,
+		// Ethernet padding
+		0, 0)
+	ipv6 := append(make([]byte, 12), 0x86, 0xdd,
+		0x60, 0, 0, 0, 0, 2, 6, 64,
+		0xfd, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1,
+		0xfd, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2,
+		5, 6)
+
+	var tests = []struct {
+		name     string
+		frame    []byte
+		src, dst string
+		protocol uint8
+		segment  []byte
+		err      string
+	}{
+		{"ipv4", ipv4, "10.0.0.1", "10.0.0.2", 17, []byte{1, 2, 3, 4}, ""},
+		{"ipv4 cut by the filter", ipv4[:36], "10.0.0.1", "10.0.0.2", 17, []byte{1, 2}, ""},
+		{"ipv6", ipv6, "fd00::1", "fd00::2", 6, []byte{5, 6}, ""},
+		{"arp", append(make([]byte, 12), 0x08, 0x06, 0), "", "", 0, nil, "unsupported ethertype 0x0806"},
+		{"truncated", ipv4[:20], "", "", 0, nil, "truncated packet"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			packet, err := DecodeFrame(test.frame)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.src, packet.Src.String())
+			assert.Equal(t, test.dst, packet.Dst.String())
+			assert.Equal(t, test.protocol, packet.Protocol)
+			assert.Equal(t, test.segment, packet.Segment)
+		})
+	}
+}
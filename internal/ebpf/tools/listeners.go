@@ -0,0 +1,184 @@
+package ebpf_tools
+
+import (
+	"bufio"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/modules"
+)
+
+// state of listening sockets in /proc/net/tcp, include/net/tcp_states.h
+const tcpListen = "0A"
+
+type procSocket struct {
+	family uint16
+	addr   [16]uint8
+	port   uint16
+	ino    uint64
+}
+
+// ScanListeners finds TCP sockets listening before the inet program was attached. /proc/<pid>/net/tcp lists sockets
+// of the network namespace of the process, so it is read once per namespace, and sockets are attributed to the
+// process holding their file descriptor.
+func ScanListeners() []modules.ListenEvent {
+	cgroups.mu.Lock()
+	procRoot, cgroupRoot := cgroups.procRoot, cgroups.root
+	cgroups.mu.Unlock()
+	if procRoot == "" {
+		return nil
+	}
+
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		slog.Error("[process] Scanning listening sockets", "Root", procRoot, "Error", err)
+		return nil
+	}
+
+	namespaces := make(map[uint64]bool)
+	owners := make(map[uint64]uint32)
+	var sockets []procSocket
+	for _, entry := range entries {
+		pid, err := strconv.ParseUint(entry.Name(), 10, 32)
+		if err != nil {
+			continue
+		}
+		dir := filepath.Join(procRoot, entry.Name())
+		// processes exit while scanning, their files disappear then
+		for _, ino := range socketInodes(dir) {
+			if _, ok := owners[ino]; !ok {
+				owners[ino] = uint32(pid)
+			}
+		}
+		netns, ok := inode(filepath.Join(dir, "ns", "net"))
+		if !ok || namespaces[netns] {
+			continue
+		}
+		namespaces[netns] = true
+		for family, file := range map[uint16]string{syscall.AF_INET: "tcp", syscall.AF_INET6: "tcp6"} {
+			if f, err := os.Open(filepath.Join(dir, "net", file)); err == nil {
+				sockets = append(sockets, parseProcNetTcp(f, family)...)
+				f.Close()
+			}
+		}
+	}
+
+	now := time.Now()
+	events := make([]modules.ListenEvent, 0, len(sockets))
+	for _, socket := range sockets {
+		event := modules.ListenEvent{
+			Listener: modules.Address{Addr: BytesToIP(socket.family, socket.addr), Port: socket.port},
+			Ino:      socket.ino,
+			Time:     now}
+		EnrichAddress(&event.Listener)
+		if pid, ok := owners[socket.ino]; ok {
+			dir := filepath.Join(procRoot, strconv.FormatUint(uint64(pid), 10))
+			AttributeProcess(&event.Listener, pid, readComm(dir), cgroupID(dir, cgroupRoot))
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// parseProcNetTcp reads listening sockets of /proc/net/tcp or tcp6. Addresses are written as 32-bit words in hex,
+// each in host byte order, ports in hex.
+func parseProcNetTcp(reader io.Reader, family uint16) []procSocket {
+	var sockets []procSocket
+	scanner := bufio.NewScanner(reader)
+	// header line
+	scanner.Scan()
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[3] != tcpListen {
+			continue
+		}
+		addr, port, ok := strings.Cut(fields[1], ":")
+		if !ok {
+			continue
+		}
+		raw, err := hex.DecodeString(addr)
+		if err != nil || (len(raw) != 4 && len(raw) != 16) {
+			continue
+		}
+		number, err := strconv.ParseUint(port, 16, 16)
+		if err != nil {
+			continue
+		}
+		ino, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil {
+			continue
+		}
+		socket := procSocket{family: family, port: uint16(number), ino: ino}
+		for word := 0; word < len(raw); word += 4 {
+			for i := range 4 {
+				socket.addr[word+i] = raw[word+3-i]
+			}
+		}
+		sockets = append(sockets, socket)
+	}
+	return sockets
+}
+
+// socket file descriptors link to socket:[<inode>]
+func socketInodes(dir string) []uint64 {
+	fds, err := os.ReadDir(filepath.Join(dir, "fd"))
+	if err != nil {
+		return nil
+	}
+	var inodes []uint64
+	for _, fd := range fds {
+		target, err := os.Readlink(filepath.Join(dir, "fd", fd.Name()))
+		if err != nil || !strings.HasPrefix(target, "socket:[") {
+			continue
+		}
+		if ino, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(target, "socket:["), "]"), 10, 64); err == nil {
+			inodes = append(inodes, ino)
+		}
+	}
+	return inodes
+}
+
+func inode(path string) (uint64, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return stat.Ino, true
+}
+
+func readComm(dir string) string {
+	comm, err := os.ReadFile(filepath.Join(dir, "comm"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(string(comm), "\n")
+}
+
+// the cgroup id known by eBPF programs is the inode of the cgroup v2 directory, /proc/<pid>/cgroup holds its path as 0::<path>
+func cgroupID(dir string, cgroupRoot string) uint64 {
+	if cgroupRoot == "" {
+		return 0
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "cgroup"))
+	if err != nil {
+		return 0
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if path, ok := strings.CutPrefix(line, "0::"); ok {
+			id, _ := inode(filepath.Join(cgroupRoot, path))
+			return id
+		}
+	}
+	return 0
+}
@@ -0,0 +1,102 @@
+package ebpf_tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+const procNetTcp = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 4711 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:0035 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 4712 1 0000000000000000 100 0 0 10 0
+   2: 0A00000A:1F90 0B00000A:D431 01 00000000:00000000 00:00000000 00000000     0        0 4713 1 0000000000000000 20 4 30 10 -1
+`
+
+const procNetTcp6 = `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000000000000:1F91 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 4714 1 0000000000000000 100 0 0 10 0
+   1: 000080FE00000000FF005450B6AD1DFE:0016 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 4715 1 0000000000000000 100 0 0 10 0
+`
+
+func TestParseProcNetTcp(t *testing.T) {
+	var tests = []struct {
+		name   string
+		file   string
+		family uint16
+		want   []string
+	}{
+		{"ipv4", procNetTcp, syscall.AF_INET, []string{"0.0.0.0:8080 4711", "127.0.0.1:53 4712"}},
+		{"ipv6", procNetTcp6, syscall.AF_INET6, []string{":::8081 4714", "fe80::5054:ff:fe1d:adb6:22 4715"}},
+		{"header only", "  sl  local_address\n", syscall.AF_INET, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			for _, socket := range parseProcNetTcp(strings.NewReader(test.file), test.family) {
+				got = append(got, fmt.Sprintf("%s:%d %d", BytesToIP(socket.family, socket.addr), socket.port, socket.ino))
+			}
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func mkProc(t *testing.T, root string, pid string, files map[string]string, sockets ...string) {
+	for name, content := range files {
+		path := filepath.Join(root, pid, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, pid, "fd"), 0755))
+	for fd, socket := range sockets {
+		assert.NoError(t, os.Symlink("socket:["+socket+"]", filepath.Join(root, pid, "fd", fmt.Sprint(fd+3))))
+	}
+}
+
+func TestScanListeners(t *testing.T) {
+	procRoot, cgroupRoot := t.TempDir(), t.TempDir()
+	mkCgroup(t, cgroupRoot, "kubepods/pod0a1b2c3d-0000-4000-8000-123456789abc/"+containerID)
+
+	mkProc(t, procRoot, "100", map[string]string{"ns/net": "", "net/tcp": procNetTcp, "comm": "nginx\n",
+		"cgroup": "0::/kubepods/pod0a1b2c3d-0000-4000-8000-123456789abc/" + containerID + "\n"}, "4711", "9999")
+	// same network namespace as 100, its sockets are listed once
+	mkProc(t, procRoot, "101", map[string]string{"net/tcp": procNetTcp, "comm": "coredns\n"}, "4712")
+	assert.NoError(t, os.MkdirAll(filepath.Join(procRoot, "101", "ns"), 0755))
+	assert.NoError(t, os.Link(filepath.Join(procRoot, "100", "ns", "net"), filepath.Join(procRoot, "101", "ns", "net")))
+	mkProc(t, procRoot, "102", map[string]string{"ns/net": "", "net/tcp6": procNetTcp6})
+	assert.NoError(t, os.MkdirAll(filepath.Join(procRoot, "self"), 0755))
+
+	ConfigureProcess(config.ProcessConfig{CgroupRoot: cgroupRoot, ProcRoot: procRoot, RescanPeriod: config.Duration{Duration: time.Hour}})
+	t.Cleanup(func() { ConfigureProcess(config.Default().Process) })
+
+	got := make(map[uint64]string)
+	for _, event := range ScanListeners() {
+		assert.False(t, event.Closed)
+		assert.False(t, event.Time.IsZero())
+		got[event.Ino] = fmt.Sprintf("%s:%d %s %s %d", event.Listener.Addr, event.Listener.Port, event.Listener.Name, event.Listener.Process, event.Listener.Pid)
+	}
+	assert.Equal(t, map[uint64]string{
+		4711: "0.0.0.0:8080 N/A nginx 100",
+		4712: "127.0.0.1:53 N/A coredns 101",
+		4714: ":::8081 N/A  0",
+		4715: "fe80::5054:ff:fe1d:adb6:22 N/A  0",
+	}, got)
+
+	// the cgroup of a process is found under the cgroup root by the path in /proc/<pid>/cgroup
+	_, ok := resolveCgroup(cgroupID(filepath.Join(procRoot, "100"), cgroupRoot))
+	assert.True(t, ok)
+	assert.Zero(t, cgroupID(filepath.Join(procRoot, "101"), cgroupRoot))
+}
+
+func TestScanListenersDisabled(t *testing.T) {
+	ConfigureProcess(config.ProcessConfig{ProcRoot: ""})
+	t.Cleanup(func() { ConfigureProcess(config.Default().Process) })
+
+	assert.Nil(t, ScanListeners())
+}
@@ -0,0 +1,54 @@
+package ebpf_tools
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// AnyInterface labels metrics of programs which are not attached to a single network interface
+const AnyInterface = "any"
+
+var (
+	LostSamplesMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "k8s_packet_ebpf_lost_samples_total",
+			Help: "Kubernetes packet perf samples lost because the perf buffer was full",
+		},
+		[]string{"program", "interface"},
+	)
+	ReadErrorsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "k8s_packet_ebpf_read_errors_total",
+			Help: "Kubernetes packet errors reading from the perf buffer",
+		},
+		[]string{"program", "interface"},
+	)
+	ParseErrorsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "k8s_packet_ebpf_parse_errors_total",
+			Help: "Kubernetes packet perf samples which cannot be parsed into an event",
+		},
+		[]string{"program", "interface"},
+	)
+	EventsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "k8s_packet_ebpf_events_total",
+			Help: "Kubernetes packet events read from eBPF programs",
+		},
+		[]string{"source"},
+	)
+	ReassembliesMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "k8s_packet_tls_reassembled_handshakes_total",
+			Help: "Kubernetes packet TLS handshakes whose ClientHello spans several TCP segments, by whether it was reassembled",
+		},
+		[]string{"program", "interface", "result"},
+	)
+	EnrichDurationMetric = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "k8s_packet_enrich_address_duration_seconds",
+			Help:    "Kubernetes packet time spent on resolving the name of an address",
+			Buckets: []float64{.0001, .0005, .001, .005, .01, .05, .1, .5, 1, 5},
+		},
+		[]string{"lookup"},
+	)
+)
@@ -0,0 +1,125 @@
+package ebpf_tools
+
+import (
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/k8spacket/k8spacket/internal/thirdparty/k8s"
+	"golang.org/x/sys/unix"
+)
+
+// container runtimes name cgroups after the pod UID and the container ID, with the systemd cgroup driver
+// .../kubepods-burstable-pod<uid with _>.slice/cri-containerd-<id>.scope, with cgroupfs .../kubepods/burstable/pod<uid>/<id>
+var reContainerCgroup = regexp.MustCompile(`pod([0-9a-f]{8}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{12})(?:\.slice)?/(?:[a-z-]+-)?([0-9a-f]{64})(?:\.scope)?$`)
+
+type cgroupContainer struct {
+	podUID      string
+	containerID string
+}
+
+// CgroupMap holds containers by cgroup id, which is the inode of the cgroup directory on cgroup v2
+type CgroupMap struct {
+	mu           sync.Mutex
+	root         string
+	procRoot     string
+	rescanPeriod time.Duration
+	scanned      time.Time
+	data         map[uint64]cgroupContainer
+}
+
+var cgroups = &CgroupMap{data: make(map[uint64]cgroupContainer)}
+
+// ConfigureProcess sets up the cgroup hierarchy to look containers up in, on reload of another root the known
+// cgroups are dropped
+func ConfigureProcess(process config.ProcessConfig) {
+	cgroups.mu.Lock()
+	defer cgroups.mu.Unlock()
+	cgroups.rescanPeriod = process.RescanPeriod.Duration
+	cgroups.procRoot = process.ProcRoot
+	if cgroups.root == process.CgroupRoot {
+		return
+	}
+	cgroups.root = process.CgroupRoot
+	cgroups.scanned = time.Time{}
+	cgroups.data = make(map[uint64]cgroupContainer)
+}
+
+// AttributeProcess names the local end of a connection after the process owning the socket and the pod of its container.
+// Pods with hostNetwork share the address of the node, so the pod found by the cgroup takes precedence over the node.
+func AttributeProcess(addr *modules.Address, pid uint32, comm string, cgroupID uint64) {
+	addr.Pid = pid
+	addr.Process = comm
+	container, ok := resolveCgroup(cgroupID)
+	if !ok {
+		return
+	}
+	if name, namespace, containerName := k8sclient.GetContainer(container.podUID, container.containerID); name != "" {
+		addr.Name = name
+		addr.Namespace = namespace
+		addr.Container = containerName
+	}
+}
+
+// CommToString converts a command name filled by eBPF programs, it is NUL terminated unless 16 characters long
+func CommToString(comm [16]int8) string {
+	var bytes [16]byte
+	for i, c := range comm {
+		bytes[i] = byte(c)
+	}
+	return unix.ByteSliceToString(bytes[:])
+}
+
+// unknown cgroups (new containers) trigger a scan of the hierarchy, at most once per rescan period
+func resolveCgroup(id uint64) (cgroupContainer, bool) {
+	if id == 0 {
+		return cgroupContainer{}, false
+	}
+	cgroups.mu.Lock()
+	defer cgroups.mu.Unlock()
+	if container, ok := cgroups.data[id]; ok {
+		return container, true
+	}
+	if cgroups.root == "" || time.Since(cgroups.scanned) < cgroups.rescanPeriod {
+		return cgroupContainer{}, false
+	}
+	cgroups.scanned = time.Now()
+	cgroups.data = scanCgroups(cgroups.root)
+	container, ok := cgroups.data[id]
+	return container, ok
+}
+
+func scanCgroups(root string) map[uint64]cgroupContainer {
+	data := make(map[uint64]cgroupContainer)
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			// cgroups of stopped containers disappear while walking
+			return nil
+		}
+		matches := reContainerCgroup.FindStringSubmatch(filepath.ToSlash(path))
+		if matches == nil {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			// the systemd driver replaces dashes of the pod UID with underscores
+			data[stat.Ino] = cgroupContainer{podUID: strings.ReplaceAll(matches[1], "_", "-"), containerID: matches[2]}
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("[process] Scanning cgroups", "Root", root, "Error", err)
+	}
+	return data
+}
@@ -0,0 +1,88 @@
+package ebpf_tools
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/stretchr/testify/assert"
+)
+
+const containerID = "4b825dc642cb6eb9a060e54bf8d69288fbee4904e2ae1f0a3ec16e4d9d61f4b2"
+
+func mkCgroup(t *testing.T, root string, path string) uint64 {
+	dir := filepath.Join(root, path)
+	assert.NoError(t, os.MkdirAll(dir, 0755))
+	info, err := os.Stat(dir)
+	assert.NoError(t, err)
+	return info.Sys().(*syscall.Stat_t).Ino
+}
+
+func TestResolveCgroup(t *testing.T) {
+	root := t.TempDir()
+	systemd := mkCgroup(t, root, "kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod6f1c2b7e_4c1d_4c1e_9a55_2b1f3a4d5e6f.slice/cri-containerd-"+containerID+".scope")
+	cgroupfs := mkCgroup(t, root, "kubepods/besteffort/pod0a1b2c3d-0000-4000-8000-123456789abc/"+containerID)
+	pod := mkCgroup(t, root, "kubepods/besteffort/pod0a1b2c3d-0000-4000-8000-123456789abc")
+	daemon := mkCgroup(t, root, "system.slice/kubelet.service")
+
+	ConfigureProcess(config.ProcessConfig{CgroupRoot: root, RescanPeriod: config.Duration{Duration: time.Hour}})
+
+	var tests = []struct {
+		name string
+		id   uint64
+		want cgroupContainer
+		ok   bool
+	}{
+		{"systemd driver", systemd, cgroupContainer{podUID: "6f1c2b7e-4c1d-4c1e-9a55-2b1f3a4d5e6f", containerID: containerID}, true},
+		{"cgroupfs driver", cgroupfs, cgroupContainer{podUID: "0a1b2c3d-0000-4000-8000-123456789abc", containerID: containerID}, true},
+		{"pod", pod, cgroupContainer{}, false},
+		{"node daemon", daemon, cgroupContainer{}, false},
+		{"no cgroup", 0, cgroupContainer{}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			container, ok := resolveCgroup(test.id)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.want, container)
+		})
+	}
+
+	// containers started after the scan are found once the rescan period is over
+	started := mkCgroup(t, root, "kubepods/pod11111111-2222-4333-8444-555555555555/"+containerID[:63]+"0")
+	_, ok := resolveCgroup(started)
+	assert.False(t, ok)
+	ConfigureProcess(config.ProcessConfig{CgroupRoot: root, RescanPeriod: config.Duration{Duration: time.Nanosecond}})
+	container, ok := resolveCgroup(started)
+	assert.True(t, ok)
+	assert.Equal(t, "11111111-2222-4333-8444-555555555555", container.podUID)
+
+	ConfigureProcess(config.Default().Process)
+}
+
+func TestAttributeProcess(t *testing.T) {
+	ConfigureProcess(config.ProcessConfig{CgroupRoot: "", RescanPeriod: config.Duration{Duration: time.Hour}})
+	defer ConfigureProcess(config.Default().Process)
+
+	addr := modules.Address{Addr: "10.0.0.1", Name: "node.worker", Namespace: "N/A"}
+	AttributeProcess(&addr, 1234, "kubelet", 42)
+
+	assert.Equal(t, modules.Address{Addr: "10.0.0.1", Name: "node.worker", Namespace: "N/A", Pid: 1234, Process: "kubelet"}, addr)
+}
+
+func TestCommToString(t *testing.T) {
+	var comm [16]int8
+	for i, c := range "curl" {
+		comm[i] = int8(c)
+	}
+	assert.Equal(t, "curl", CommToString(comm))
+
+	for i := range comm {
+		comm[i] = 'a'
+	}
+	assert.Equal(t, "aaaaaaaaaaaaaaaa", CommToString(comm))
+}
@@ -0,0 +1,28 @@
+package ebpf_tools
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cilium/ebpf"
+	"golang.org/x/sys/unix"
+)
+
+// OpenRawSocket opens a packet socket receiving frames of all interfaces accepted by the socket filter,
+// reads time out after timeout so that readers can check whether they should exit
+func OpenRawSocket(filter *ebpf.Program, timeout time.Duration) (int, error) {
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW, int(Htons(unix.ETH_P_ALL)))
+	if err != nil {
+		return -1, fmt.Errorf("cannot open raw socket: %w", err)
+	}
+	if err := unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_ATTACH_BPF, filter.FD()); err != nil {
+		unix.Close(fd)
+		return -1, fmt.Errorf("cannot attach socket filter: %w", err)
+	}
+	tv := unix.NsecToTimeval(timeout.Nanoseconds())
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+		unix.Close(fd)
+		return -1, fmt.Errorf("cannot set read timeout: %w", err)
+	}
+	return fd, nil
+}
@@ -0,0 +1,198 @@
+package ebpf_tools
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/modules"
+)
+
+const (
+	ReassemblyComplete   = "complete"
+	ReassemblyIncomplete = "incomplete"
+)
+
+// how long a ClientHello and its handshake event wait for each other
+var reassemblyTimeout = 10 * time.Second
+
+// TLSSegment is a part of a ClientHello record sent by an eBPF program, records larger than the TCP segment they start in
+// come in several parts
+type TLSSegment struct {
+	Client modules.Address
+	Server modules.Address
+	// sequence number of the first byte of the record and its length with the header
+	RecordSeq    uint32
+	RecordLength uint32
+	// sequence number of the first byte of the payload
+	Seq     uint32
+	Payload []byte
+}
+
+type flowKey struct {
+	client     string
+	clientPort uint16
+	server     string
+	serverPort uint16
+}
+
+func newFlowKey(client modules.Address, server modules.Address) flowKey {
+	return flowKey{client: client.Addr, clientPort: client.Port, server: server.Addr, serverPort: server.Port}
+}
+
+type reassembly struct {
+	started      time.Time
+	recordSeq    uint32
+	recordLength uint32
+	record       []byte
+	received     []bool
+	missing      int
+	// the record spans several segments
+	fragmented bool
+	hello      *ClientHello
+	// the record was not a valid ClientHello
+	failed bool
+	// the handshake event of eBPF programs waiting for the record
+	event *modules.TLSEvent
+}
+
+// TLSReassembler parses ClientHello records, put together when they span several TCP segments, and completes the handshake
+// events eBPF programs send with the ServerHello, segments and events may come in any order
+type TLSReassembler struct {
+	mu      sync.Mutex
+	program string
+	iface   string
+	// as many handshakes as the events map of the eBPF program keeps
+	size  int
+	flows map[flowKey]*reassembly
+}
+
+func NewTLSReassembler(program string, iface string, size int) *TLSReassembler {
+	return &TLSReassembler{program: program, iface: iface, size: size, flows: make(map[flowKey]*reassembly)}
+}
+
+// AddSegment stores the payload of a segment, handshake events completed by it are returned
+func (reassembler *TLSReassembler) AddSegment(segment TLSSegment, now time.Time) []modules.TLSEvent {
+	reassembler.mu.Lock()
+	defer reassembler.mu.Unlock()
+	events := reassembler.expire(now)
+
+	if segment.RecordLength <= recordHeaderLen || segment.RecordLength > maxRecordLen {
+		ParseErrorsMetric.WithLabelValues(reassembler.program, reassembler.iface).Inc()
+		return events
+	}
+	key := newFlowKey(segment.Client, segment.Server)
+	flow, ok := reassembler.flows[key]
+	if ok && flow.recordLength != 0 && flow.recordSeq != segment.RecordSeq {
+		// the connection sent another ClientHello, the former one cannot be completed anymore
+		events = append(events, reassembler.remove(key)...)
+		ok = false
+	}
+	if !ok {
+		if flow, ok = reassembler.add(key, now); !ok {
+			return events
+		}
+	}
+	if flow.recordLength == 0 {
+		flow.recordSeq, flow.recordLength = segment.RecordSeq, segment.RecordLength
+		flow.record = make([]byte, segment.RecordLength)
+		flow.received = make([]bool, segment.RecordLength)
+		flow.missing = int(segment.RecordLength)
+	}
+	if flow.hello != nil || flow.failed {
+		// retransmitted segment
+		return events
+	}
+
+	// sequence numbers wrap around, retransmitted bytes before the record are left out
+	offset := int64(int32(segment.Seq - segment.RecordSeq))
+	if offset != 0 || len(segment.Payload) < len(flow.record) {
+		flow.fragmented = true
+	}
+	for i, b := range segment.Payload {
+		position := offset + int64(i)
+		if position < 0 || position >= int64(len(flow.record)) {
+			continue
+		}
+		if !flow.received[position] {
+			flow.record[position], flow.received[position] = b, true
+			flow.missing--
+		}
+	}
+	if flow.missing > 0 {
+		return events
+	}
+
+	hello, err := ParseClientHello(flow.record)
+	flow.record, flow.received = nil, nil
+	if err != nil {
+		ParseErrorsMetric.WithLabelValues(reassembler.program, reassembler.iface).Inc()
+		slog.Debug("[tls] Parsing reassembled ClientHello", "program", reassembler.program, "Error", err)
+		flow.failed = true
+	} else {
+		flow.hello = &hello
+	}
+	if flow.event != nil {
+		events = append(events, reassembler.remove(key)...)
+	}
+	return events
+}
+
+// Complete fills the handshake event with its ClientHello, it is returned once the ClientHello is parsed
+func (reassembler *TLSReassembler) Complete(event modules.TLSEvent, now time.Time) []modules.TLSEvent {
+	reassembler.mu.Lock()
+	defer reassembler.mu.Unlock()
+	events := reassembler.expire(now)
+
+	key := newFlowKey(event.Client, event.Server)
+	flow, ok := reassembler.flows[key]
+	if !ok {
+		if flow, ok = reassembler.add(key, now); !ok {
+			return append(events, event)
+		}
+	}
+	flow.event = &event
+	if flow.hello != nil || flow.failed {
+		events = append(events, reassembler.remove(key)...)
+	}
+	return events
+}
+
+func (reassembler *TLSReassembler) add(key flowKey, now time.Time) (*reassembly, bool) {
+	if len(reassembler.flows) >= reassembler.size {
+		slog.Warn("[tls] Too many TLS handshakes at once, skipping", "program", reassembler.program, "interface", reassembler.iface)
+		return nil, false
+	}
+	flow := &reassembly{started: now}
+	reassembler.flows[key] = flow
+	return flow, true
+}
+
+// remove forgets the flow and returns its handshake event, filled with the ClientHello when there is one
+func (reassembler *TLSReassembler) remove(key flowKey) []modules.TLSEvent {
+	flow := reassembler.flows[key]
+	delete(reassembler.flows, key)
+	if flow.event == nil {
+		return nil
+	}
+	event, result := *flow.event, ReassemblyIncomplete
+	if flow.hello != nil {
+		flow.hello.Fill(&event)
+		result = ReassemblyComplete
+	}
+	if flow.fragmented {
+		ReassembliesMetric.WithLabelValues(reassembler.program, reassembler.iface, result).Inc()
+	}
+	return []modules.TLSEvent{event}
+}
+
+// expire forgets flows waiting too long, handshake events are returned without the ClientHello
+func (reassembler *TLSReassembler) expire(now time.Time) []modules.TLSEvent {
+	var events []modules.TLSEvent
+	for key, flow := range reassembler.flows {
+		if now.Sub(flow.started) > reassemblyTimeout {
+			events = append(events, reassembler.remove(key)...)
+		}
+	}
+	return events
+}
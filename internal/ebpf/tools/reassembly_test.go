@@ -0,0 +1,111 @@
+package ebpf_tools
+
+import (
+	"testing"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+const mss = 1448
+
+var (
+	reassemblyClient = modules.Address{Addr: "10.244.0.5", Port: 40000}
+	reassemblyServer = modules.Address{Addr: "10.96.0.10", Port: 443}
+)
+
+// segments cuts a record the way TCP does, sequence numbers wrap around within the record
+func segments(record []byte, recordSeq uint32) []TLSSegment {
+	var result []TLSSegment
+	for offset := 0; offset < len(record); offset += mss {
+		result = append(result, TLSSegment{
+			Client:       reassemblyClient,
+			Server:       reassemblyServer,
+			RecordSeq:    recordSeq,
+			RecordLength: uint32(len(record)),
+			Seq:          recordSeq + uint32(offset),
+			Payload:      record[offset:min(offset+mss, len(record))],
+		})
+	}
+	return result
+}
+
+func handshakeEvent() modules.TLSEvent {
+	return modules.TLSEvent{Source: modules.TC, Client: reassemblyClient, Server: reassemblyServer, UsedTlsVersion: 0x0304, UsedCipher: 0x1301}
+}
+
+func TestReassembleSegmentsFirst(t *testing.T) {
+	reassembler := NewTLSReassembler("tc", "reassembly-segments", 16)
+	record := recordClientHello(t, "pq.example.com")
+	parts := segments(record, 0xffffff00)
+	assert.Len(t, parts, 2)
+	now := time.Now()
+
+	// out of order and retransmitted
+	assert.Empty(t, reassembler.AddSegment(parts[1], now))
+	assert.Empty(t, reassembler.AddSegment(parts[1], now))
+	assert.Empty(t, reassembler.AddSegment(parts[0], now))
+
+	events := reassembler.Complete(handshakeEvent(), now)
+
+	assert.Len(t, events, 1)
+	assert.EqualValues(t, "pq.example.com", events[0].ServerName)
+	assert.EqualValues(t, []uint16{0x0304, 0x0303}, events[0].TlsVersions)
+	assert.Contains(t, events[0].Ciphers, uint16(0x1301))
+	assert.EqualValues(t, 0x1301, events[0].UsedCipher)
+	assert.Empty(t, reassembler.flows)
+	assert.EqualValues(t, 1, testutil.ToFloat64(ReassembliesMetric.WithLabelValues("tc", "reassembly-segments", ReassemblyComplete)))
+}
+
+func TestReassembleEventFirst(t *testing.T) {
+	reassembler := NewTLSReassembler("tc", "reassembly-event", 16)
+	parts := segments(recordClientHello(t, "pq.example.com"), 1000)
+	now := time.Now()
+
+	assert.Empty(t, reassembler.Complete(handshakeEvent(), now))
+	assert.Empty(t, reassembler.AddSegment(parts[0], now))
+	events := reassembler.AddSegment(parts[1], now)
+
+	assert.Len(t, events, 1)
+	assert.EqualValues(t, "pq.example.com", events[0].ServerName)
+	assert.Empty(t, reassembler.flows)
+}
+
+func TestReassembleIncomplete(t *testing.T) {
+	reassembler := NewTLSReassembler("tc", "reassembly-incomplete", 16)
+	parts := segments(recordClientHello(t, "pq.example.com"), 1000)
+	now := time.Now()
+
+	// the last segment was not captured
+	assert.Empty(t, reassembler.AddSegment(parts[0], now))
+	assert.Empty(t, reassembler.Complete(handshakeEvent(), now))
+
+	other := handshakeEvent()
+	other.Client.Port = 40001
+	events := reassembler.Complete(other, now.Add(reassemblyTimeout+time.Second))
+
+	// the expired handshake is reported without the ClientHello
+	assert.Len(t, events, 1)
+	assert.EqualValues(t, 40000, events[0].Client.Port)
+	assert.Empty(t, events[0].ServerName)
+	assert.Len(t, reassembler.flows, 1)
+	assert.EqualValues(t, 1, testutil.ToFloat64(ReassembliesMetric.WithLabelValues("tc", "reassembly-incomplete", ReassemblyIncomplete)))
+}
+
+func TestReassembleInvalidRecord(t *testing.T) {
+	reassembler := NewTLSReassembler("tc", "reassembly-invalid", 16)
+	record := make([]byte, 2000)
+	record[0] = 0x17
+	now := time.Now()
+
+	for _, part := range segments(record, 1000) {
+		assert.Empty(t, reassembler.AddSegment(part, now))
+	}
+	events := reassembler.Complete(handshakeEvent(), now)
+
+	assert.Len(t, events, 1)
+	assert.Empty(t, events[0].ServerName)
+	assert.EqualValues(t, 1, testutil.ToFloat64(ReassembliesMetric.WithLabelValues("tc", "reassembly-invalid", ReassemblyIncomplete)))
+}
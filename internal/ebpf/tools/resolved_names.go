@@ -0,0 +1,73 @@
+package ebpf_tools
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// how often expired names are dropped
+const resolvedNamesPurgePeriod = time.Minute
+
+type resolvedName struct {
+	name    string
+	expires time.Time
+}
+
+// names resolved with DNS by every client IP, names resolved by any client are kept under the empty client
+var resolvedNames = &struct {
+	mu         sync.RWMutex
+	data       map[string]map[string]resolvedName
+	lastPurged time.Time
+}{data: make(map[string]map[string]resolvedName)}
+
+// StoreResolvedName remembers that client resolved name to ip, the name is forgotten after ttl
+func StoreResolvedName(client string, ip string, name string, ttl time.Duration) {
+	name = strings.TrimSuffix(name, ".")
+	if ip == "" || name == "" {
+		return
+	}
+	now := time.Now()
+	entry := resolvedName{name: name, expires: now.Add(ttl)}
+
+	resolvedNames.mu.Lock()
+	defer resolvedNames.mu.Unlock()
+	for _, key := range []string{client, ""} {
+		names, ok := resolvedNames.data[key]
+		if !ok {
+			names = make(map[string]resolvedName)
+			resolvedNames.data[key] = names
+		}
+		names[ip] = entry
+	}
+	if now.Sub(resolvedNames.lastPurged) > resolvedNamesPurgePeriod {
+		purgeResolvedNames(now)
+		resolvedNames.lastPurged = now
+	}
+}
+
+// ResolvedName returns the name client resolved to ip, or the name any client resolved it to when client didn't
+func ResolvedName(client string, ip string) (string, bool) {
+	now := time.Now()
+	resolvedNames.mu.RLock()
+	defer resolvedNames.mu.RUnlock()
+	for _, key := range []string{client, ""} {
+		if entry, ok := resolvedNames.data[key][ip]; ok && now.Before(entry.expires) {
+			return entry.name, true
+		}
+	}
+	return "", false
+}
+
+func purgeResolvedNames(now time.Time) {
+	for client, names := range resolvedNames.data {
+		for ip, entry := range names {
+			if !now.Before(entry.expires) {
+				delete(names, ip)
+			}
+		}
+		if len(names) == 0 {
+			delete(resolvedNames.data, client)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package ebpf_tools
+
+import (
+	"testing"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolvedName(t *testing.T) {
+	StoreResolvedName("10.244.0.5", "203.0.113.10", "api.example.com.", time.Minute)
+	StoreResolvedName("10.244.0.6", "203.0.113.10", "cdn.example.com", time.Minute)
+	StoreResolvedName("10.244.0.5", "203.0.113.11", "expired.example.com", -time.Second)
+
+	var tests = []struct {
+		client string
+		ip     string
+		name   string
+		ok     bool
+	}{
+		{"10.244.0.5", "203.0.113.10", "api.example.com", true},
+		{"10.244.0.6", "203.0.113.10", "cdn.example.com", true},
+		{"10.244.0.7", "203.0.113.10", "cdn.example.com", true},
+		{"10.244.0.5", "203.0.113.11", "", false},
+		{"10.244.0.5", "203.0.113.12", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.client+"-"+test.ip, func(t *testing.T) {
+			name, ok := ResolvedName(test.client, test.ip)
+			assert.Equal(t, test.name, name)
+			assert.Equal(t, test.ok, ok)
+		})
+	}
+}
+
+func TestEnrichConnection(t *testing.T) {
+	StoreResolvedName("10.244.1.5", "198.51.100.20", "db.example.com", time.Minute)
+
+	client := modules.Address{Addr: "10.244.1.5", Port: 40000}
+	server := modules.Address{Addr: "198.51.100.20", Port: 5432}
+
+	EnrichConnection(&client, &server)
+
+	assert.Equal(t, "N/A", client.Name)
+	assert.Equal(t, "db.example.com", server.Name)
+}
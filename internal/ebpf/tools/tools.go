@@ -3,11 +3,13 @@ package ebpf_tools
 import (
 	"fmt"
 	"net"
-	"os"
 	"regexp"
 	"strings"
 	"sync"
+	"syscall"
+	"time"
 
+	"github.com/k8spacket/k8spacket/internal/config"
 	"github.com/k8spacket/k8spacket/internal/thirdparty/k8s"
 
 	"github.com/k8spacket/k8spacket/internal/modules"
@@ -26,15 +28,48 @@ type SafeMap struct {
 
 var domainsMap = &SafeMap{data: make(map[string]string)}
 var reverseLookupMap = &SafeMap{data: make(map[string]string)}
-var reReverseWhois = regexp.MustCompile(os.Getenv("K8S_PACKET_REVERSE_WHOIS_REGEXP"))
+var reReverseWhois = regexp.MustCompile("")
+var geoip2DbPath string
+
+// Configure sets up the reverse lookup of external IPs, the config is expected to be validated already.
+// On reload the cached lookups are dropped, so names are resolved again with the new settings.
+func Configure(reverse config.ReverseConfig) {
+	reverseLookupMap.mu.Lock()
+	defer reverseLookupMap.mu.Unlock()
+	if reReverseWhois.String() == reverse.WhoisRegexp && geoip2DbPath == reverse.GeoIP2DbPath {
+		return
+	}
+	reReverseWhois = regexp.MustCompile(reverse.WhoisRegexp)
+	geoip2DbPath = reverse.GeoIP2DbPath
+	reverseLookupMap.data = make(map[string]string)
+}
 
 func EnrichAddress(addr *modules.Address) {
+	enrichAddress(addr, "")
+}
+
+// EnrichConnection resolves names of both ends, the server is preferably named as the client resolved it with DNS
+func EnrichConnection(client *modules.Address, server *modules.Address) {
+	enrichAddress(client, "")
+	enrichAddress(server, client.Addr)
+}
+
+func enrichAddress(addr *modules.Address, resolver string) {
+	start := time.Now()
+	lookup := "k8s"
 	name, namespace := k8sclient.GetNameAndNamespace(addr.Addr)
 	addr.Name = name
 	if addr.Name == "" {
-		addr.Name = reverseLookup(addr.Addr, addr.Port)
+		if resolved, ok := ResolvedName(resolver, addr.Addr); ok {
+			lookup = "dns"
+			addr.Name = resolved
+		} else {
+			lookup = "reverse"
+			addr.Name = reverseLookup(addr.Addr, addr.Port)
+		}
 	}
 	addr.Namespace = namespace
+	EnrichDurationMetric.WithLabelValues(lookup).Observe(time.Since(start).Seconds())
 }
 
 // try to find domain (https only), organization name and (if GeoLite2 Free Geolocation Data enabled) country and city by external IP
@@ -64,7 +99,7 @@ func reverseLookup(ip string, port uint16) string {
 			reverseLookup += matches[1]
 		}
 
-		db, err := geoip2.Open(os.Getenv("K8S_PACKET_REVERSE_GEOIP2_DB_PATH"))
+		db, err := geoip2.Open(geoip2DbPath)
 		if err == nil {
 			defer db.Close()
 
@@ -83,10 +118,10 @@ func reverseLookup(ip string, port uint16) string {
 	return strings.Join(name, ", ")
 }
 
-// Check if an IP is private.
+// Check if an IP is private, IPv6 loopback, link-local and wildcard addresses of listening sockets are never resolved either.
 func privateIPCheck(ip string) bool {
 	ipAddress := net.ParseIP(ip)
-	return ipAddress.IsPrivate()
+	return ipAddress.IsPrivate() || ipAddress.IsLoopback() || ipAddress.IsLinkLocalUnicast() || ipAddress.IsUnspecified()
 }
 
 func StoreDomain(ip string, port uint16, domain string) {
@@ -97,10 +132,13 @@ func StoreDomain(ip string, port uint16, domain string) {
 	}
 }
 
-func IntToIP4(ipNum uint32, fn func(b []byte, c uint32)) string {
-	ip := make(net.IP, 4)
-	fn(ip, ipNum)
-	return ip.String()
+// BytesToIP converts an address in network byte order as filled by eBPF programs,
+// IPv4 occupies the first 4 bytes only
+func BytesToIP(family uint16, addr [16]uint8) string {
+	if family == syscall.AF_INET {
+		return net.IP(addr[:net.IPv4len]).String()
+	}
+	return net.IP(addr[:]).String()
 }
 
 func Htons(v uint16) uint16 {
@@ -1,20 +1,19 @@
 package ebpf_tools
 
 import (
-	"encoding/binary"
-	"regexp"
+	"syscall"
 	"testing"
 
+	"github.com/k8spacket/k8spacket/internal/config"
 	"github.com/k8spacket/k8spacket/internal/modules"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestEnrichAddress(t *testing.T) {
-	t.Setenv("K8S_PACKET_REVERSE_GEOIP2_DB_PATH", "../../../tests/units/GeoLite2-City-Test.mmdb")
-	oldRegexp := reReverseWhois
-	reReverseWhois = regexp.MustCompile("(?:OrgName:|org-name:)\\s*(.*)")
+	oldRegexp, oldGeoip2DbPath := reReverseWhois, geoip2DbPath
+	Configure(config.ReverseConfig{WhoisRegexp: "(?:OrgName:|org-name:)\\s*(.*)", GeoIP2DbPath: "../../../tests/units/GeoLite2-City-Test.mmdb"})
 	t.Cleanup(func() {
-		reReverseWhois = oldRegexp
+		Configure(config.ReverseConfig{WhoisRegexp: oldRegexp.String(), GeoIP2DbPath: oldGeoip2DbPath})
 	})
 
 	address := modules.Address{Addr: "8.8.8.8"}
@@ -46,11 +45,41 @@ func TestHtonsBehavior(t *testing.T) {
 	assert.Equal(t, expected, out)
 }
 
-func TestIntToIP4(t *testing.T) {
-	// bytes {1,2,3,4} as little endian uint32
-	num := binary.LittleEndian.Uint32([]byte{1, 2, 3, 4})
-	s := IntToIP4(num, binary.BigEndian.PutUint32)
-	assert.Equal(t, "4.3.2.1", s)
-	s = IntToIP4(num, binary.LittleEndian.PutUint32)
-	assert.Equal(t, "1.2.3.4", s)
+func TestBytesToIP(t *testing.T) {
+	var tests = []struct {
+		family uint16
+		addr   [16]uint8
+		want   string
+	}{
+		{syscall.AF_INET, [16]uint8{1, 2, 3, 4}, "1.2.3.4"},
+		{syscall.AF_INET6, [16]uint8{0x20, 0x01, 0x0d, 0xb8, 15: 1}, "2001:db8::1"},
+		{syscall.AF_INET6, [16]uint8{10: 0xff, 11: 0xff, 10, 0, 0, 1}, "10.0.0.1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.want, func(t *testing.T) {
+			assert.Equal(t, test.want, BytesToIP(test.family, test.addr))
+		})
+	}
+}
+
+func TestPrivateIPCheck(t *testing.T) {
+	var tests = []struct {
+		ip   string
+		want bool
+	}{
+		{"10.0.0.1", true},
+		{"127.0.0.1", true},
+		{"fe80::1", true},
+		{"0.0.0.0", true},
+		{"::", true},
+		{"8.8.8.8", false},
+		{"2001:4860:4860::8888", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.ip, func(t *testing.T) {
+			assert.Equal(t, test.want, privateIPCheck(test.ip))
+		})
+	}
 }
@@ -0,0 +1,154 @@
+package ebpf_tools
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/features"
+	"github.com/cilium/ebpf/perf"
+	"github.com/cilium/ebpf/ringbuf"
+	"github.com/k8spacket/k8spacket/internal/config"
+)
+
+const (
+	TransportAuto    = "auto"
+	TransportRingBuf = "ringbuf"
+	TransportPerf    = "perf"
+)
+
+// global variable of eBPF programs choosing the helper which outputs events
+const useRingBufVariable = "use_ringbuf"
+
+var lostEventsPeriod = 5 * time.Second
+
+// ErrClosed is returned by EventReader.Read once the reader is closed
+var ErrClosed = os.ErrClosed
+
+// EventReader reads raw events from a ring buffer or a perf event array
+type EventReader interface {
+	Read() (EventRecord, error)
+	Close() error
+}
+
+type EventRecord struct {
+	RawSample []byte
+	// events lost by a perf event array, losses of a ring buffer are counted by WatchLostEvents
+	LostSamples uint64
+}
+
+// UseRingBuffer tells if events should go through the ring buffer, auto prefers it when the kernel supports it (5.8+)
+func UseRingBuffer(mode string) bool {
+	switch mode {
+	case TransportRingBuf:
+		return true
+	case TransportPerf:
+		return false
+	default:
+		return features.HaveMapType(ebpf.RingBuf) == nil
+	}
+}
+
+// PrepareTransport turns the events map of the spec into a ring buffer or a perf event array, must be called before loading
+func PrepareTransport(spec *ebpf.CollectionSpec, events string, transport config.TransportConfig) error {
+	eventsSpec, ok := spec.Maps[events]
+	if !ok {
+		return fmt.Errorf("map %s not found", events)
+	}
+	variable, ok := spec.Variables[useRingBufVariable]
+	if !ok {
+		return fmt.Errorf("variable %s not found", useRingBufVariable)
+	}
+	ringBuffer := UseRingBuffer(transport.Mode)
+	setTransport(eventsSpec, ringBuffer, transport)
+	return variable.Set(ringBuffer)
+}
+
+func setTransport(eventsSpec *ebpf.MapSpec, ringBuffer bool, transport config.TransportConfig) {
+	if ringBuffer {
+		eventsSpec.Type = ebpf.RingBuf
+		eventsSpec.KeySize, eventsSpec.ValueSize = 0, 0
+		eventsSpec.MaxEntries = uint32(transport.RingBufferSize)
+	} else {
+		// size of perf event array is set to the number of CPUs when loading
+		eventsSpec.Type = ebpf.PerfEventArray
+		eventsSpec.KeySize, eventsSpec.ValueSize = 4, 4
+		eventsSpec.MaxEntries = 0
+	}
+	eventsSpec.Key, eventsSpec.Value = nil, nil
+}
+
+// NewEventReader creates a reader matching the type the events map was loaded with
+func NewEventReader(events *ebpf.Map, transport config.TransportConfig) (EventReader, error) {
+	if events.Type() == ebpf.RingBuf {
+		reader, err := ringbuf.NewReader(events)
+		if err != nil {
+			return nil, err
+		}
+		return &ringBufReader{reader: reader}, nil
+	}
+	reader, err := perf.NewReader(events, transport.PerfBufferSize)
+	if err != nil {
+		return nil, err
+	}
+	return &perfReader{reader: reader}, nil
+}
+
+type ringBufReader struct {
+	reader *ringbuf.Reader
+}
+
+func (ringBufReader *ringBufReader) Read() (EventRecord, error) {
+	record, err := ringBufReader.reader.Read()
+	return EventRecord{RawSample: record.RawSample}, err
+}
+
+func (ringBufReader *ringBufReader) Close() error {
+	return ringBufReader.reader.Close()
+}
+
+type perfReader struct {
+	reader *perf.Reader
+}
+
+func (perfReader *perfReader) Read() (EventRecord, error) {
+	record, err := perfReader.reader.Read()
+	return EventRecord{RawSample: record.RawSample, LostSamples: record.LostSamples}, err
+}
+
+func (perfReader *perfReader) Close() error {
+	return perfReader.reader.Close()
+}
+
+// WatchLostEvents adds events the ring buffer had no room for to LostSamplesMetric until ctx is done,
+// the eBPF program counts them per CPU in the lost map
+func WatchLostEvents(ctx context.Context, lost *ebpf.Map, program string, iface string) {
+	var reported uint64
+	ticker := time.NewTicker(lostEventsPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		var values []uint64
+		if err := lost.Lookup(uint32(0), &values); err != nil {
+			ReadErrorsMetric.WithLabelValues(program, iface).Inc()
+			slog.Error(fmt.Sprintf("[%s] Reading lost events", program), "Error", err)
+			continue
+		}
+		var total uint64
+		for _, value := range values {
+			total += value
+		}
+		if total > reported {
+			LostSamplesMetric.WithLabelValues(program, iface).Add(float64(total - reported))
+			slog.Warn(fmt.Sprintf("[%s] Ring buffer full, events lost", program), "Lost", total-reported, "interface", iface)
+			reported = total
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package ebpf_tools
+
+import (
+	"testing"
+
+	"github.com/cilium/ebpf"
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUseRingBuffer(t *testing.T) {
+	assert.True(t, UseRingBuffer(TransportRingBuf))
+	assert.False(t, UseRingBuffer(TransportPerf))
+}
+
+func TestSetTransport(t *testing.T) {
+	transport := config.TransportConfig{Mode: TransportAuto, RingBufferSize: 512 * 1024, PerfBufferSize: 4096}
+
+	var tests = []struct {
+		ringBuffer bool
+		mapType    ebpf.MapType
+		keySize    uint32
+		maxEntries uint32
+	}{
+		{true, ebpf.RingBuf, 0, 512 * 1024},
+		{false, ebpf.PerfEventArray, 4, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.mapType.String(), func(t *testing.T) {
+			spec := &ebpf.MapSpec{Name: "output_events", Type: ebpf.RingBuf, MaxEntries: 256 * 1024}
+
+			setTransport(spec, test.ringBuffer, transport)
+
+			assert.Equal(t, test.mapType, spec.Type)
+			assert.Equal(t, test.keySize, spec.KeySize)
+			assert.Equal(t, test.keySize, spec.ValueSize)
+			assert.Equal(t, test.maxEntries, spec.MaxEntries)
+		})
+	}
+}
+
+func TestPrepareTransportMissing(t *testing.T) {
+	spec := &ebpf.CollectionSpec{Maps: map[string]*ebpf.MapSpec{}}
+	assert.ErrorContains(t, PrepareTransport(spec, "output_events", config.TransportConfig{}), "map output_events not found")
+
+	spec.Maps["output_events"] = &ebpf.MapSpec{Type: ebpf.RingBuf}
+	assert.ErrorContains(t, PrepareTransport(spec, "output_events", config.TransportConfig{}), "variable use_ringbuf not found")
+}
@@ -0,0 +1,26 @@
+package backend
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/k8spacket/k8spacket/internal/modules/dns/stats"
+)
+
+type Handler struct {
+	stats *stats.DnsStats
+}
+
+func NewHandler(stats *stats.DnsStats) *Handler {
+	return &Handler{stats: stats}
+}
+
+func (handler *Handler) StatsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(handler.stats.Report())
+	if err != nil {
+		slog.Error("[api] Cannot prepare DNS stats response", "Error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,41 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/k8spacket/k8spacket/internal/modules/dns/model"
+	"github.com/k8spacket/k8spacket/internal/modules/dns/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsHandler(t *testing.T) {
+
+	dnsStats := stats.NewDnsStats()
+	event := modules.DNSEvent{Client: modules.Address{Addr: "10.244.1.5", Name: "api", Namespace: "shop"}, Server: modules.Address{Addr: "10.96.0.10"}, RCode: "NOERROR"}
+	dnsStats.Query(event)
+	dnsStats.Response(event, time.Millisecond)
+	handler := NewHandler(dnsStats)
+
+	req, err := http.NewRequest("GET", "/dns/api/stats", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(handler.StatsHandler).ServeHTTP(rr, req)
+
+	assert.EqualValues(t, http.StatusOK, rr.Code)
+	assert.EqualValues(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var report model.Report
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+	assert.Len(t, report.Pods, 1)
+	assert.EqualValues(t, "shop", report.Pods[0].Namespace)
+	assert.EqualValues(t, 1, report.Pods[0].Responses)
+	assert.Len(t, report.Resolvers, 1)
+	assert.EqualValues(t, "10.96.0.10", report.Resolvers[0].Name)
+}
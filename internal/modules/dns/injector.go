@@ -0,0 +1,55 @@
+package dns
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/k8spacket/k8spacket/internal/modules/dns/backend"
+	"github.com/k8spacket/k8spacket/internal/modules/dns/listener"
+	"github.com/k8spacket/k8spacket/internal/modules/dns/prometheus"
+	"github.com/k8spacket/k8spacket/internal/modules/dns/stats"
+)
+
+// Module follows DNS queries of pods, it names egress peers by the names pods resolved
+type Module struct {
+	broker       modules.Broker
+	listener     modules.Listener[modules.DNSEvent]
+	subscription modules.Subscription
+}
+
+func NewModule() *Module {
+	return &Module{}
+}
+
+func (module *Module) Name() string {
+	return "dns"
+}
+
+func (module *Module) Init(mux *http.ServeMux, broker modules.Broker, store *config.Store) error {
+	prometheus.Register()
+
+	dnsStats := stats.NewDnsStats()
+	mux.HandleFunc("/dns/api/stats", backend.NewHandler(dnsStats).StatsHandler)
+
+	module.broker = broker
+	module.listener = listener.NewListener(dnsStats, store)
+	return nil
+}
+
+func (module *Module) Start() error {
+	subscription, err := module.broker.SubscribeDNS(module.Name(), module.listener)
+	if err != nil {
+		return err
+	}
+	module.subscription = subscription
+	return nil
+}
+
+func (module *Module) Stop(_ context.Context) error {
+	if module.subscription != nil {
+		module.subscription.Unsubscribe()
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package dns
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/k8spacket/k8spacket/internal/broker"
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModule(t *testing.T) {
+
+	store := config.NewStore(config.Default())
+	distributionBroker := broker.Init(store)
+
+	module := NewModule()
+
+	assert.EqualValues(t, "dns", module.Name())
+	assert.NoError(t, module.Init(http.NewServeMux(), distributionBroker, store))
+	assert.NotEmpty(t, module.listener)
+
+	assert.NoError(t, module.Start())
+	_, err := distributionBroker.SubscribeDNS("dns", module.listener)
+	assert.Error(t, err)
+
+	assert.NoError(t, module.Stop(context.Background()))
+	_, err = distributionBroker.SubscribeDNS("dns", module.listener)
+	assert.NoError(t, err)
+}
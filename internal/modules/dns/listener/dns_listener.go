@@ -0,0 +1,101 @@
+package listener
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/config"
+	ebpf_tools "github.com/k8spacket/k8spacket/internal/ebpf/tools"
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/k8spacket/k8spacket/internal/modules/dns/model"
+	"github.com/k8spacket/k8spacket/internal/modules/dns/prometheus"
+	"github.com/k8spacket/k8spacket/internal/modules/dns/stats"
+)
+
+// a query is identified by the client socket, the resolver and the message ID
+type queryKey struct {
+	client string
+	port   uint16
+	server string
+	id     uint16
+}
+
+type DnsListener struct {
+	stats   *stats.DnsStats
+	store   *config.Store
+	mu      sync.Mutex
+	pending map[queryKey]time.Time
+	swept   time.Time
+}
+
+func NewListener(stats *stats.DnsStats, store *config.Store) modules.Listener[modules.DNSEvent] {
+	return &DnsListener{stats: stats, store: store, pending: make(map[queryKey]time.Time)}
+}
+
+func (listener *DnsListener) Listen(event modules.DNSEvent) {
+	cfg := listener.store.Get().Dns
+	key := queryKey{client: event.Client.Addr, port: event.Client.Port, server: event.Server.Addr, id: event.ID}
+
+	if !event.Response {
+		if listener.query(key, event.Time, cfg.QueryTimeout.Duration) {
+			listener.stats.Query(event)
+			prometheus.K8sPacketDnsQueriesMetric.WithLabelValues(event.Client.Namespace, model.Name(event.Client), model.Name(event.Server), event.Type).Inc()
+		}
+		return
+	}
+
+	// names are cached from every response, also the ones to queries seen before the start
+	for _, answer := range event.Answers {
+		ttl := max(time.Duration(answer.TTL)*time.Second, cfg.CacheMinTTL.Duration)
+		ebpf_tools.StoreResolvedName(event.Client.Addr, answer.Addr, event.Name, ttl)
+	}
+
+	sent, ok := listener.response(key)
+	if !ok {
+		return
+	}
+	latency := event.Time.Sub(sent)
+	listener.stats.Response(event, latency)
+	prometheus.K8sPacketDnsResponsesMetric.WithLabelValues(event.Client.Namespace, model.Name(event.Client), model.Name(event.Server), event.RCode).Inc()
+	prometheus.K8sPacketDnsLatencySecondsMetric.WithLabelValues(event.Client.Namespace, model.Name(event.Client), model.Name(event.Server)).Observe(latency.Seconds())
+	slog.Debug("[dns] Query answered",
+		"client", event.Client.Addr,
+		"clientName", event.Client.Name,
+		"server", event.Server.Addr,
+		"serverName", event.Server.Name,
+		"name", event.Name,
+		"type", event.Type,
+		"rcode", event.RCode,
+		"latency", latency)
+}
+
+// query remembers when the query was sent, false is returned for the same packet seen on another interface
+func (listener *DnsListener) query(key queryKey, sent time.Time, timeout time.Duration) bool {
+	listener.mu.Lock()
+	defer listener.mu.Unlock()
+	if sent.Sub(listener.swept) > timeout {
+		for pendingKey, pendingSent := range listener.pending {
+			if sent.Sub(pendingSent) > timeout {
+				delete(listener.pending, pendingKey)
+			}
+		}
+		listener.swept = sent
+	}
+	if _, ok := listener.pending[key]; ok {
+		return false
+	}
+	listener.pending[key] = sent
+	return true
+}
+
+// response returns when the query was sent, only the first copy of the response matches it
+func (listener *DnsListener) response(key queryKey) (time.Time, bool) {
+	listener.mu.Lock()
+	defer listener.mu.Unlock()
+	sent, ok := listener.pending[key]
+	if ok {
+		delete(listener.pending, key)
+	}
+	return sent, ok
+}
@@ -0,0 +1,66 @@
+package listener
+
+import (
+	"testing"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/config"
+	ebpf_tools "github.com/k8spacket/k8spacket/internal/ebpf/tools"
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/k8spacket/k8spacket/internal/modules/dns/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListen(t *testing.T) {
+
+	cfg := config.Default()
+	cfg.Dns.QueryTimeout.Duration = time.Second
+	dnsStats := stats.NewDnsStats()
+	listener := NewListener(dnsStats, config.NewStore(cfg))
+
+	now := time.Now()
+	client := modules.Address{Addr: "10.244.1.5", Port: 40000, Name: "api", Namespace: "shop"}
+	server := modules.Address{Addr: "10.96.0.10", Port: 53, Name: "kube-dns", Namespace: "kube-system"}
+	query := modules.DNSEvent{Client: client, Server: server, ID: 1, Name: "db.example.com", Type: "A", Time: now}
+	response := query
+	response.Response = true
+	response.RCode = "NOERROR"
+	response.Answers = []modules.DNSAnswer{{Addr: "203.0.113.40", TTL: 1}}
+	response.Time = now.Add(20 * time.Millisecond)
+
+	// the same packets seen on two interfaces
+	listener.Listen(query)
+	listener.Listen(query)
+	listener.Listen(response)
+	listener.Listen(response)
+
+	// a query which timed out is forgotten, its late response is not matched
+	late := query
+	late.ID = 2
+	listener.Listen(late)
+	next := query
+	next.ID = 3
+	next.Time = now.Add(2 * time.Second)
+	listener.Listen(next)
+	lateResponse := response
+	lateResponse.ID = 2
+	lateResponse.RCode = "NXDOMAIN"
+	lateResponse.Answers = nil
+	listener.Listen(lateResponse)
+
+	report := dnsStats.Report()
+	assert.Len(t, report.Pods, 1)
+	assert.EqualValues(t, "shop", report.Pods[0].Namespace)
+	assert.EqualValues(t, "api", report.Pods[0].Name)
+	assert.EqualValues(t, 3, report.Pods[0].Queries)
+	assert.EqualValues(t, 1, report.Pods[0].Responses)
+	assert.EqualValues(t, 0, report.Pods[0].NXDomain)
+	assert.EqualValues(t, 20, report.Pods[0].AvgLatencyMs)
+	assert.Len(t, report.Resolvers, 1)
+	assert.EqualValues(t, "kube-dns", report.Resolvers[0].Name)
+
+	// answers are kept at least for the minimum TTL
+	name, ok := ebpf_tools.ResolvedName("10.244.1.5", "203.0.113.40")
+	assert.True(t, ok)
+	assert.EqualValues(t, "db.example.com", name)
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/modules"
+)
+
+// Stats aggregates DNS traffic since the module started, rates are per second
+type Stats struct {
+	Queries      uint64  `json:"queries"`
+	Responses    uint64  `json:"responses"`
+	NXDomain     uint64  `json:"nxdomain"`
+	ServFail     uint64  `json:"servfail"`
+	QueryRate    float64 `json:"queryRate"`
+	NXDomainRate float64 `json:"nxdomainRate"`
+	ServFailRate float64 `json:"servfailRate"`
+	AvgLatencyMs float64 `json:"avgLatencyMs"`
+	MaxLatencyMs float64 `json:"maxLatencyMs"`
+}
+
+type PodStats struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Stats
+}
+
+type ResolverStats struct {
+	Name string `json:"name"`
+	Stats
+}
+
+type Report struct {
+	Since     time.Time       `json:"since"`
+	Pods      []PodStats      `json:"pods"`
+	Resolvers []ResolverStats `json:"resolvers"`
+}
+
+// Name of an address, the IP when it is not known
+func Name(address modules.Address) string {
+	if address.Name == "" || address.Name == "N/A" {
+		return address.Addr
+	}
+	return address.Name
+}
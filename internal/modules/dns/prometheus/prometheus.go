@@ -0,0 +1,37 @@
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	K8sPacketDnsQueriesMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "k8s_packet_dns_queries_total",
+			Help: "Kubernetes packet DNS queries sent by pods",
+		},
+		[]string{"namespace", "pod", "resolver", "type"},
+	)
+	K8sPacketDnsResponsesMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "k8s_packet_dns_responses_total",
+			Help: "Kubernetes packet DNS responses received by pods",
+		},
+		[]string{"namespace", "pod", "resolver", "rcode"},
+	)
+	K8sPacketDnsLatencySecondsMetric = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "k8s_packet_dns_latency_seconds",
+			Help:    "Kubernetes packet DNS latency seconds",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		},
+		[]string{"namespace", "pod", "resolver"},
+	)
+)
+
+// Register registers the metrics, metrics already registered are left untouched
+func Register() {
+	for _, collector := range []prometheus.Collector{K8sPacketDnsQueriesMetric, K8sPacketDnsResponsesMetric, K8sPacketDnsLatencySecondsMetric} {
+		_ = prometheus.Register(collector)
+	}
+}
@@ -0,0 +1,112 @@
+package stats
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/k8spacket/k8spacket/internal/modules/dns/model"
+)
+
+type podKey struct {
+	namespace string
+	name      string
+}
+
+type counters struct {
+	queries    uint64
+	responses  uint64
+	nxdomain   uint64
+	servfail   uint64
+	latency    time.Duration
+	maxLatency time.Duration
+}
+
+// DnsStats counts queries and responses per pod and per resolver since it was created
+type DnsStats struct {
+	mu        sync.Mutex
+	since     time.Time
+	now       func() time.Time
+	pods      map[podKey]*counters
+	resolvers map[string]*counters
+}
+
+func NewDnsStats() *DnsStats {
+	return &DnsStats{since: time.Now(), now: time.Now, pods: make(map[podKey]*counters), resolvers: make(map[string]*counters)}
+}
+
+func (dnsStats *DnsStats) Query(event modules.DNSEvent) {
+	dnsStats.mu.Lock()
+	defer dnsStats.mu.Unlock()
+	for _, counters := range dnsStats.counters(event) {
+		counters.queries++
+	}
+}
+
+func (dnsStats *DnsStats) Response(event modules.DNSEvent, latency time.Duration) {
+	dnsStats.mu.Lock()
+	defer dnsStats.mu.Unlock()
+	for _, counters := range dnsStats.counters(event) {
+		counters.responses++
+		switch event.RCode {
+		case "NXDOMAIN":
+			counters.nxdomain++
+		case "SERVFAIL":
+			counters.servfail++
+		}
+		counters.latency += latency
+		counters.maxLatency = max(counters.maxLatency, latency)
+	}
+}
+
+func (dnsStats *DnsStats) counters(event modules.DNSEvent) []*counters {
+	pod := podKey{namespace: event.Client.Namespace, name: model.Name(event.Client)}
+	if _, ok := dnsStats.pods[pod]; !ok {
+		dnsStats.pods[pod] = &counters{}
+	}
+	resolver := model.Name(event.Server)
+	if _, ok := dnsStats.resolvers[resolver]; !ok {
+		dnsStats.resolvers[resolver] = &counters{}
+	}
+	return []*counters{dnsStats.pods[pod], dnsStats.resolvers[resolver]}
+}
+
+func (dnsStats *DnsStats) Report() model.Report {
+	dnsStats.mu.Lock()
+	defer dnsStats.mu.Unlock()
+	// rates of the first second would be meaningless
+	elapsed := max(dnsStats.now().Sub(dnsStats.since).Seconds(), 1)
+	report := model.Report{Since: dnsStats.since, Pods: []model.PodStats{}, Resolvers: []model.ResolverStats{}}
+	for pod, counters := range dnsStats.pods {
+		report.Pods = append(report.Pods, model.PodStats{Namespace: pod.namespace, Name: pod.name, Stats: counters.stats(elapsed)})
+	}
+	for resolver, counters := range dnsStats.resolvers {
+		report.Resolvers = append(report.Resolvers, model.ResolverStats{Name: resolver, Stats: counters.stats(elapsed)})
+	}
+	slices.SortFunc(report.Pods, func(a, b model.PodStats) int {
+		return cmp.Or(cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Name, b.Name))
+	})
+	slices.SortFunc(report.Resolvers, func(a, b model.ResolverStats) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return report
+}
+
+func (counters *counters) stats(elapsed float64) model.Stats {
+	stats := model.Stats{
+		Queries:      counters.queries,
+		Responses:    counters.responses,
+		NXDomain:     counters.nxdomain,
+		ServFail:     counters.servfail,
+		QueryRate:    float64(counters.queries) / elapsed,
+		NXDomainRate: float64(counters.nxdomain) / elapsed,
+		ServFailRate: float64(counters.servfail) / elapsed,
+		MaxLatencyMs: float64(counters.maxLatency.Microseconds()) / 1000,
+	}
+	if counters.responses > 0 {
+		stats.AvgLatencyMs = float64(counters.latency.Microseconds()) / 1000 / float64(counters.responses)
+	}
+	return stats
+}
@@ -0,0 +1,53 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReport(t *testing.T) {
+
+	dnsStats := NewDnsStats()
+	dnsStats.now = func() time.Time { return dnsStats.since.Add(10 * time.Second) }
+
+	coredns := modules.Address{Addr: "10.244.0.2", Name: "coredns", Namespace: "kube-system"}
+	upstream := modules.Address{Addr: "192.168.1.1", Name: "N/A"}
+	api := modules.Address{Addr: "10.244.1.5", Name: "api", Namespace: "shop"}
+
+	var events = []struct {
+		event   modules.DNSEvent
+		latency time.Duration
+	}{
+		{modules.DNSEvent{Client: api, Server: coredns, RCode: "NOERROR"}, 2 * time.Millisecond},
+		{modules.DNSEvent{Client: api, Server: coredns, RCode: "NXDOMAIN"}, 4 * time.Millisecond},
+		{modules.DNSEvent{Client: coredns, Server: upstream, RCode: "SERVFAIL"}, 30 * time.Millisecond},
+	}
+	for _, test := range events {
+		dnsStats.Query(test.event)
+		dnsStats.Response(test.event, test.latency)
+	}
+	dnsStats.Query(modules.DNSEvent{Client: api, Server: coredns})
+
+	report := dnsStats.Report()
+
+	assert.Len(t, report.Pods, 2)
+	assert.EqualValues(t, "coredns", report.Pods[0].Name)
+	assert.EqualValues(t, 1, report.Pods[0].ServFail)
+	assert.EqualValues(t, "api", report.Pods[1].Name)
+	assert.EqualValues(t, 3, report.Pods[1].Queries)
+	assert.EqualValues(t, 2, report.Pods[1].Responses)
+	assert.EqualValues(t, 1, report.Pods[1].NXDomain)
+	assert.InDelta(t, 0.3, report.Pods[1].QueryRate, 0.0001)
+	assert.InDelta(t, 0.1, report.Pods[1].NXDomainRate, 0.0001)
+	assert.InDelta(t, 3, report.Pods[1].AvgLatencyMs, 0.0001)
+	assert.InDelta(t, 4, report.Pods[1].MaxLatencyMs, 0.0001)
+
+	assert.Len(t, report.Resolvers, 2)
+	assert.EqualValues(t, "192.168.1.1", report.Resolvers[0].Name)
+	assert.InDelta(t, 0.1, report.Resolvers[0].ServFailRate, 0.0001)
+	assert.EqualValues(t, "coredns", report.Resolvers[1].Name)
+	assert.EqualValues(t, 3, report.Resolvers[1].Queries)
+}
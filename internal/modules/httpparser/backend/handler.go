@@ -0,0 +1,82 @@
+package backend
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/modules/httpparser/model"
+	"github.com/k8spacket/k8spacket/internal/modules/httpparser/repository"
+)
+
+var reMatchAll = regexp.MustCompile("")
+
+type Handler struct {
+	repo repository.Repository[model.HTTPEdgeItem]
+}
+
+func NewHandler(repo repository.Repository[model.HTTPEdgeItem]) *Handler {
+	return &Handler{repo: repo}
+}
+
+func (handler *Handler) EdgeHandler(w http.ResponseWriter, r *http.Request) {
+	response, err := handler.filterEdges(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		slog.Error("[api] Cannot prepare HTTP edges response", "Error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (handler *Handler) filterEdges(query url.Values) ([]model.HTTPEdgeItem, error) {
+	from, err := parseTime(query.Get("from"))
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseTime(query.Get("to"))
+	if err != nil {
+		return nil, err
+	}
+	patternNs, err := parsePattern(query.Get("namespace"))
+	if err != nil {
+		return nil, err
+	}
+	patternIn, err := parsePattern(query.Get("include"))
+	if err != nil {
+		return nil, err
+	}
+	patternEx, err := parsePattern(query.Get("exclude"))
+	if err != nil {
+		return nil, err
+	}
+	return handler.repo.Query(from, to, patternNs, patternIn, patternEx), nil
+}
+
+// parseTime reads milliseconds since epoch, empty is the zero time which disables the filter
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	millis, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(millis), nil
+}
+
+func parsePattern(value string) (*regexp.Regexp, error) {
+	if value == "" {
+		return reMatchAll, nil
+	}
+	return regexp.Compile(value)
+}
@@ -0,0 +1,73 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/modules/httpparser/model"
+	"github.com/k8spacket/k8spacket/internal/modules/httpparser/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+var dbState = []model.HTTPEdgeItem{
+	{Src: "client-1", Dst: "server-1", Method: "GET", Path: "/api/orders/{id}", RequestCount: 10, ServerErrorCount: 1, Duration: 0.5},
+	{Src: "client-2", Dst: "server-1", Method: "POST", Path: "/api/orders", RequestCount: 3, ClientErrorCount: 2, Duration: 0.2},
+}
+
+type mockRepository struct {
+	repository.Repository[model.HTTPEdgeItem]
+	from, to                        time.Time
+	patternNs, patternIn, patternEx string
+}
+
+func (mock *mockRepository) Query(from time.Time, to time.Time, patternNs *regexp.Regexp, patternIn *regexp.Regexp, patternEx *regexp.Regexp) []model.HTTPEdgeItem {
+	mock.from, mock.to = from, to
+	mock.patternNs, mock.patternIn, mock.patternEx = patternNs.String(), patternIn.String(), patternEx.String()
+	return dbState
+}
+
+func TestEdgeHandler(t *testing.T) {
+
+	var tests = []struct {
+		query                           string
+		code                            int
+		from                            time.Time
+		patternNs, patternIn, patternEx string
+	}{
+		{"", http.StatusOK, time.Time{}, "", "", ""},
+		{"?from=1609506000000&namespace=ns&include=in&exclude=ex", http.StatusOK, time.UnixMilli(1609506000000), "ns", "in", "ex"},
+		{"?from=yesterday", http.StatusBadRequest, time.Time{}, "", "", ""},
+		{"?include=(unclosed", http.StatusBadRequest, time.Time{}, "", "", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			mockRepository := &mockRepository{}
+			handler := NewHandler(mockRepository)
+
+			req, err := http.NewRequest("GET", "/httpparser/edges"+test.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(handler.EdgeHandler).ServeHTTP(rr, req)
+
+			assert.EqualValues(t, test.code, rr.Code)
+			if test.code != http.StatusOK {
+				return
+			}
+			assert.EqualValues(t, test.from, mockRepository.from)
+			assert.EqualValues(t, test.patternNs, mockRepository.patternNs)
+			assert.EqualValues(t, test.patternIn, mockRepository.patternIn)
+			assert.EqualValues(t, test.patternEx, mockRepository.patternEx)
+
+			var result []model.HTTPEdgeItem
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+			assert.EqualValues(t, dbState, result)
+		})
+	}
+}
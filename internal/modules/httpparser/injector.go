@@ -0,0 +1,70 @@
+package httpparser
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/k8spacket/k8spacket/internal/modules/httpparser/backend"
+	"github.com/k8spacket/k8spacket/internal/modules/httpparser/listener"
+	"github.com/k8spacket/k8spacket/internal/modules/httpparser/model"
+	"github.com/k8spacket/k8spacket/internal/modules/httpparser/prometheus"
+	"github.com/k8spacket/k8spacket/internal/modules/httpparser/repository"
+	"github.com/k8spacket/k8spacket/internal/modules/httpparser/updater"
+	"github.com/k8spacket/k8spacket/internal/thirdparty/db"
+)
+
+// Module pairs plaintext HTTP/1.x requests with their responses into rate, errors and duration per edge and route
+type Module struct {
+	broker       modules.Broker
+	db           db.Db[model.HTTPEdgeItem]
+	listener     modules.Listener[modules.HTTPEvent]
+	subscription modules.Subscription
+}
+
+func NewModule() *Module {
+	return &Module{}
+}
+
+func (module *Module) Name() string {
+	return "httpparser"
+}
+
+func (module *Module) Init(mux *http.ServeMux, broker modules.Broker, store *config.Store) error {
+
+	prometheus.Configure(store.Get().HttpParser.Metrics)
+	store.OnChange(func(cfg *config.Config) {
+		prometheus.Configure(cfg.HttpParser.Metrics)
+	})
+
+	handler, err := db.New[model.HTTPEdgeItem]("http_edges")
+	if err != nil {
+		return err
+	}
+	repo := repository.NewDbRepository(handler)
+	controller := backend.NewHandler(repo)
+
+	mux.HandleFunc("/httpparser/edges", controller.EdgeHandler)
+
+	module.broker = broker
+	module.db = handler
+	module.listener = listener.NewListener(updater.NewUpdater(repo), store)
+	return nil
+}
+
+func (module *Module) Start() error {
+	subscription, err := module.broker.SubscribeHTTP(module.Name(), module.listener)
+	if err != nil {
+		return err
+	}
+	module.subscription = subscription
+	return nil
+}
+
+func (module *Module) Stop(_ context.Context) error {
+	if module.subscription != nil {
+		module.subscription.Unsubscribe()
+	}
+	return module.db.Close()
+}
@@ -0,0 +1,34 @@
+package httpparser
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/k8spacket/k8spacket/internal/broker"
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModule(t *testing.T) {
+
+	t.Chdir(t.TempDir())
+	cfg := config.Default()
+	cfg.HttpParser.Metrics.Enabled = true
+	store := config.NewStore(cfg)
+	distributionBroker := broker.Init(store)
+
+	module := NewModule()
+
+	assert.EqualValues(t, "httpparser", module.Name())
+	assert.NoError(t, module.Init(http.NewServeMux(), distributionBroker, store))
+	assert.NotEmpty(t, module.listener)
+
+	assert.NoError(t, module.Start())
+	_, err := distributionBroker.SubscribeHTTP("httpparser", module.listener)
+	assert.Error(t, err)
+
+	assert.NoError(t, module.Stop(context.Background()))
+	_, err = distributionBroker.SubscribeHTTP("httpparser", module.listener)
+	assert.NoError(t, err)
+}
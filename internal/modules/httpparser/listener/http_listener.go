@@ -0,0 +1,147 @@
+package listener
+
+import (
+	"log/slog"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/k8spacket/k8spacket/internal/modules/httpparser/prometheus"
+	"github.com/k8spacket/k8spacket/internal/modules/httpparser/updater"
+)
+
+// a connection is identified by the client socket, which is the same before and after DNAT of a service address
+type connectionKey struct {
+	client string
+	port   uint16
+}
+
+// connection keeps requests waiting for a response, HTTP/1.x answers them in order
+type connection struct {
+	pending     []modules.HTTPEvent
+	requested   bool
+	requestSeq  uint32
+	responded   bool
+	responseSeq uint32
+	lastSeen    time.Time
+}
+
+type HttpListener struct {
+	updater     updater.Updater
+	store       *config.Store
+	mu          sync.Mutex
+	connections map[connectionKey]*connection
+	swept       time.Time
+}
+
+func NewListener(updater updater.Updater, store *config.Store) modules.Listener[modules.HTTPEvent] {
+	return &HttpListener{updater: updater, store: store, connections: make(map[connectionKey]*connection)}
+}
+
+func (listener *HttpListener) Listen(event modules.HTTPEvent) {
+	cfg := listener.store.Get().HttpParser
+
+	if !event.Response {
+		listener.request(event, cfg.RequestTimeout.Duration)
+		return
+	}
+	request, ok := listener.response(event)
+	if !ok {
+		return
+	}
+
+	path := templatePath(request.Path, cfg.PathSegments)
+	duration := event.Time.Sub(request.Time).Seconds()
+	// the server address of the request is the one the client asked for, a service before DNAT
+	listener.updater.Update(request.Client, request.Server, request.Method, path, event.StatusCode, duration)
+	sendPrometheusMetrics(request, path, event.StatusCode, duration, cfg.Metrics)
+	slog.Debug("Request",
+		"src", request.Client.Addr,
+		"srcName", request.Client.Name,
+		"srcNS", request.Client.Namespace,
+		"dst", request.Server.Addr,
+		"dstName", request.Server.Name,
+		"dstNS", request.Server.Namespace,
+		"method", request.Method,
+		"host", request.Host,
+		"path", path,
+		"status", event.StatusCode,
+		"duration", duration)
+}
+
+// request queues the request, copies of the packet seen on other interfaces or retransmitted are skipped
+func (listener *HttpListener) request(event modules.HTTPEvent, timeout time.Duration) {
+	listener.mu.Lock()
+	defer listener.mu.Unlock()
+	listener.sweep(event.Time, timeout)
+
+	key := connectionKey{client: event.Client.Addr, port: event.Client.Port}
+	conn, ok := listener.connections[key]
+	if !ok {
+		conn = &connection{}
+		listener.connections[key] = conn
+	} else if conn.requested && conn.requestSeq == event.Seq {
+		return
+	}
+	// requests without response, e.g. the connection was reset, would shift the following responses
+	conn.pending = slices.DeleteFunc(conn.pending, func(pending modules.HTTPEvent) bool {
+		return event.Time.Sub(pending.Time) > timeout
+	})
+	conn.pending = append(conn.pending, event)
+	conn.requested = true
+	conn.requestSeq = event.Seq
+	conn.lastSeen = event.Time
+}
+
+// response returns the oldest request waiting on the connection, interim (1xx) responses do not answer it
+func (listener *HttpListener) response(event modules.HTTPEvent) (modules.HTTPEvent, bool) {
+	listener.mu.Lock()
+	defer listener.mu.Unlock()
+
+	conn, ok := listener.connections[connectionKey{client: event.Client.Addr, port: event.Client.Port}]
+	if !ok || (conn.responded && conn.responseSeq == event.Seq) {
+		return modules.HTTPEvent{}, false
+	}
+	conn.responded = true
+	conn.responseSeq = event.Seq
+	conn.lastSeen = event.Time
+	if len(conn.pending) == 0 || event.StatusCode < 200 && event.StatusCode != 101 {
+		return modules.HTTPEvent{}, false
+	}
+	request := conn.pending[0]
+	conn.pending = conn.pending[1:]
+	return request, true
+}
+
+// sweep forgets connections idle for longer than timeout, checked at most once per timeout
+func (listener *HttpListener) sweep(now time.Time, timeout time.Duration) {
+	if now.Sub(listener.swept) <= timeout {
+		return
+	}
+	for key, conn := range listener.connections {
+		if now.Sub(conn.lastSeen) > timeout {
+			delete(listener.connections, key)
+		}
+	}
+	listener.swept = now
+}
+
+func sendPrometheusMetrics(request modules.HTTPEvent, path string, statusCode int, duration float64, metrics config.HttpParserMetricsConfig) {
+	if !metrics.Enabled {
+		return
+	}
+	client, server := name(request.Client), name(request.Server)
+	prometheus.K8sPacketHttpRequestsMetric.WithLabelValues(request.Client.Namespace, client, request.Server.Namespace, server, request.Method, path, strconv.Itoa(statusCode)).Inc()
+	prometheus.K8sPacketHttpRequestDurationSecondsMetric.WithLabelValues(request.Client.Namespace, client, request.Server.Namespace, server, request.Method, path).Observe(duration)
+}
+
+// name of a peer, the IP when it is not known
+func name(address modules.Address) string {
+	if address.Name == "" || address.Name == "N/A" {
+		return address.Addr
+	}
+	return address.Name
+}
@@ -0,0 +1,96 @@
+package listener
+
+import (
+	"testing"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/k8spacket/k8spacket/internal/modules/httpparser/updater"
+	"github.com/stretchr/testify/assert"
+)
+
+type update struct {
+	client, server string
+	method, path   string
+	statusCode     int
+	duration       float64
+}
+
+type mockUpdater struct {
+	updater.Updater
+	updates []update
+}
+
+func (mockUpdater *mockUpdater) Update(client modules.Address, server modules.Address, method string, path string, statusCode int, duration float64) {
+	mockUpdater.updates = append(mockUpdater.updates, update{client.Addr, server.Addr, method, path, statusCode, duration})
+}
+
+func TestListen(t *testing.T) {
+
+	cfg := config.Default()
+	cfg.HttpParser.Metrics.Enabled = true
+	mockUpdater := &mockUpdater{}
+	listener := NewListener(mockUpdater, config.NewStore(cfg))
+
+	now := time.Now()
+	client := modules.Address{Addr: "10.244.1.5", Port: 40000}
+	service := modules.Address{Addr: "10.96.0.20", Port: 80}
+	pod := modules.Address{Addr: "10.244.2.7", Port: 8080}
+	request := func(server modules.Address, seq uint32, method string, path string, at time.Duration) modules.HTTPEvent {
+		return modules.HTTPEvent{Client: client, Server: server, Seq: seq, Method: method, Path: path, Time: now.Add(at)}
+	}
+	response := func(server modules.Address, seq uint32, statusCode int, at time.Duration) modules.HTTPEvent {
+		return modules.HTTPEvent{Client: client, Server: server, Seq: seq, Response: true, StatusCode: statusCode, Time: now.Add(at)}
+	}
+
+	// a request to a service is seen before and after DNAT, the response as well
+	listener.Listen(request(service, 1, "GET", "/api/orders/42", 0))
+	listener.Listen(request(pod, 1, "GET", "/api/orders/42", 0))
+	listener.Listen(response(pod, 100, 200, 10*time.Millisecond))
+	listener.Listen(response(service, 100, 200, 10*time.Millisecond))
+	// pipelined requests are answered in order, interim responses are skipped
+	listener.Listen(request(service, 2, "POST", "/api/orders", time.Second))
+	listener.Listen(request(service, 3, "DELETE", "/api/orders/7", time.Second))
+	listener.Listen(response(service, 200, 100, time.Second+time.Millisecond))
+	listener.Listen(response(service, 201, 201, time.Second+20*time.Millisecond))
+	listener.Listen(response(service, 202, 503, time.Second+30*time.Millisecond))
+	// a response without request is ignored
+	listener.Listen(response(service, 203, 200, 2*time.Second))
+	// a request which was never answered does not shift the following responses
+	listener.Listen(request(service, 4, "GET", "/health", 3*time.Second))
+	listener.Listen(request(service, 5, "GET", "/ready", 3*time.Second+cfg.HttpParser.RequestTimeout.Duration+time.Second))
+	listener.Listen(response(service, 204, 200, 3*time.Second+cfg.HttpParser.RequestTimeout.Duration+2*time.Second))
+
+	assert.Len(t, mockUpdater.updates, 4)
+	assert.Equal(t, update{"10.244.1.5", "10.96.0.20", "GET", "/api/orders/{id}", 200, 0.01}, mockUpdater.updates[0])
+	assert.Equal(t, "POST", mockUpdater.updates[1].method)
+	assert.Equal(t, 201, mockUpdater.updates[1].statusCode)
+	assert.InDelta(t, 0.02, mockUpdater.updates[1].duration, 0.0001)
+	assert.Equal(t, update{"10.244.1.5", "10.96.0.20", "DELETE", "/api/orders/{id}", 503, 0.03}, mockUpdater.updates[2])
+	assert.Equal(t, "/ready", mockUpdater.updates[3].path)
+}
+
+func TestTemplatePath(t *testing.T) {
+	var tests = []struct {
+		path string
+		want string
+	}{
+		{"", "/"},
+		{"/", "/"},
+		{"*", "*"},
+		{"/api/orders", "/api/orders"},
+		{"/orders/42/items", "/orders/{id}/items"},
+		{"/api/orders/42/items", "/api/orders/{id}/*"},
+		{"/users/3f2b9c1e-8d4a-4b6f-9e2d-1a2b3c4d5e6f", "/users/{id}"},
+		{"/blobs/deadbeef12", "/blobs/{id}"},
+		{"/sessions/eyJhbGciOiJIUzI1NiJ9abcdef", "/sessions/{id}"},
+		{"/api/v1/namespaces/default/pods", "/api/v1/namespaces/*"},
+		{"/static//app.js", "/static/app.js"},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			assert.Equal(t, test.want, templatePath(test.path, 3))
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package listener
+
+import (
+	"strings"
+)
+
+// templatePath replaces segments looking like IDs by {id} and keeps at most maxSegments segments,
+// so that the number of routes of a service stays bounded
+func templatePath(path string, maxSegments int) string {
+	if path == "*" {
+		return path
+	}
+	var segments []string
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" {
+			continue
+		}
+		if len(segments) == maxSegments {
+			return "/" + strings.Join(segments, "/") + "/*"
+		}
+		if isIdentifier(segment) {
+			segment = "{id}"
+		}
+		segments = append(segments, segment)
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+func isIdentifier(segment string) bool {
+	var digits, hex, other int
+	for _, char := range segment {
+		switch {
+		case char >= '0' && char <= '9':
+			digits++
+		case char >= 'a' && char <= 'f' || char >= 'A' && char <= 'F':
+			hex++
+		case char == '-' && len(segment) == 36:
+			// UUID
+		default:
+			other++
+		}
+	}
+	switch {
+	case digits == len(segment):
+		return true
+	case other == 0 && digits > 0 && len(segment) >= 8:
+		return true
+	default:
+		// tokens and other long generated names
+		return len(segment) >= 24
+	}
+}
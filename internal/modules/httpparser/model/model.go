@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// HTTPEdgeItem aggregates requests of one route (method and path template) between two peers, durations are in seconds
+type HTTPEdgeItem struct {
+	Src              string    `json:"src"`
+	SrcName          string    `json:"srcName"`
+	SrcNamespace     string    `json:"srcNamespace"`
+	Dst              string    `json:"dst"`
+	DstName          string    `json:"dstName"`
+	DstNamespace     string    `json:"dstNamespace"`
+	Method           string    `json:"method"`
+	Path             string    `json:"path"`
+	RequestCount     int64     `json:"requestCount"`
+	ClientErrorCount int64     `json:"clientErrorCount"`
+	ServerErrorCount int64     `json:"serverErrorCount"`
+	Duration         float64   `json:"duration"`
+	MaxDuration      float64   `json:"maxDuration"`
+	LastSeen         time.Time `json:"lastSeen"`
+}
@@ -0,0 +1,35 @@
+package prometheus
+
+import (
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	K8sPacketHttpRequestsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "k8s_packet_http_requests_total",
+			Help: "Kubernetes packet HTTP requests answered",
+		},
+		[]string{"src_namespace", "src_name", "dst_namespace", "dst_name", "method", "path", "code"},
+	)
+	K8sPacketHttpRequestDurationSecondsMetric = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "k8s_packet_http_request_duration_seconds",
+			Help:    "Kubernetes packet HTTP request duration seconds",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"src_namespace", "src_name", "dst_namespace", "dst_name", "method", "path"},
+	)
+)
+
+// Configure registers or unregisters the metrics, it is safe to call it again on configuration reload
+func Configure(metrics config.HttpParserMetricsConfig) {
+	for _, collector := range []prometheus.Collector{K8sPacketHttpRequestsMetric, K8sPacketHttpRequestDurationSecondsMetric} {
+		if metrics.Enabled {
+			_ = prometheus.Register(collector)
+		} else {
+			prometheus.Unregister(collector)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/modules/httpparser/model"
+	"github.com/k8spacket/k8spacket/internal/thirdparty/db"
+)
+
+type DbRepository struct {
+	dbHandler db.Db[model.HTTPEdgeItem]
+}
+
+func NewDbRepository(db db.Db[model.HTTPEdgeItem]) *DbRepository {
+	return &DbRepository{dbHandler: db}
+}
+
+func (repository *DbRepository) Read(key string) model.HTTPEdgeItem {
+	result, err := repository.dbHandler.Read(key)
+	if err != nil {
+		// can happen, silent
+		return model.HTTPEdgeItem{}
+	}
+	return result
+}
+
+func (repository *DbRepository) Query(from time.Time, to time.Time, patternNs *regexp.Regexp, patternIn *regexp.Regexp, patternEx *regexp.Regexp) []model.HTTPEdgeItem {
+	query := repository.dbHandler.QueryMatchFunc("Src", func(record *model.HTTPEdgeItem) (bool, error) {
+		if !from.IsZero() && !record.LastSeen.After(from) {
+			return false, nil
+		}
+		if !to.IsZero() && !record.LastSeen.Before(to) {
+			return false, nil
+		}
+		if patternNs.String() != "" && !patternNs.MatchString(record.SrcNamespace) && !patternNs.MatchString(record.DstNamespace) {
+			return false, nil
+		}
+		names := []string{record.Src, record.SrcName, record.Dst, record.DstName}
+		if patternIn.String() != "" && !matchAny(patternIn, names) {
+			return false, nil
+		}
+		if patternEx.String() != "" && matchAny(patternEx, names) {
+			return false, nil
+		}
+		return true, nil
+	})
+
+	result, err := repository.dbHandler.Query(&query)
+	if err != nil {
+		slog.Error("[db:http_edges:Query]", "Error", err)
+		return []model.HTTPEdgeItem{}
+	}
+	return result
+}
+
+func (repository *DbRepository) Set(key string, value *model.HTTPEdgeItem) {
+	err := repository.dbHandler.Upsert(key, value)
+	if err != nil {
+		slog.Error("[db:http_edges:Upsert]", "Error", err)
+	}
+}
+
+func matchAny(pattern *regexp.Regexp, values []string) bool {
+	for _, value := range values {
+		if pattern.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
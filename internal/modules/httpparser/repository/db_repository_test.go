@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/modules/httpparser/model"
+	"github.com/k8spacket/k8spacket/internal/thirdparty/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/timshannon/bolthold"
+)
+
+var dbState = []model.HTTPEdgeItem{
+	{LastSeen: time.Now().Add(time.Hour * -1), Src: "test"},
+	{LastSeen: time.Now(), SrcNamespace: "test", SrcName: "test"},
+	{LastSeen: time.Now().Add(time.Hour), DstNamespace: "test", Dst: "test"},
+	{LastSeen: time.Now().Add(time.Hour * 2), DstName: "test"},
+	{LastSeen: time.Now().Add(time.Hour * 2)},
+	{LastSeen: time.Now().Add(time.Hour * 1000)},
+}
+
+type mockDb struct {
+	db.Db[model.HTTPEdgeItem]
+	queryResult []model.HTTPEdgeItem
+}
+
+func (mock *mockDb) Read(key string) (model.HTTPEdgeItem, error) {
+	if key == "error" {
+		return model.HTTPEdgeItem{}, errors.New("cannot read db")
+	}
+	return model.HTTPEdgeItem{RequestCount: 3, Duration: 0.5}, nil
+}
+
+func (mock *mockDb) Query(query *bolthold.Query) ([]model.HTTPEdgeItem, error) {
+	if len(mock.queryResult) > 0 && mock.queryResult[0].LastSeen.After(time.Now().Add(time.Hour*999)) {
+		return []model.HTTPEdgeItem{}, errors.New("error")
+	}
+	return mock.queryResult, nil
+}
+
+func (mock *mockDb) QueryMatchFunc(field string, matchFunc func(*model.HTTPEdgeItem) (bool, error)) bolthold.Query {
+	mock.queryResult = []model.HTTPEdgeItem{}
+	for _, item := range dbState {
+		matched, _ := matchFunc(&item)
+		if matched {
+			mock.queryResult = append(mock.queryResult, item)
+		}
+	}
+	return bolthold.Query{}
+}
+
+func (mock *mockDb) Upsert(key string, value *model.HTTPEdgeItem) error {
+	if key == "error" {
+		return errors.New("error")
+	}
+	return nil
+}
+
+func TestRead(t *testing.T) {
+	repository := NewDbRepository(&mockDb{})
+	assert.EqualValues(t, model.HTTPEdgeItem{RequestCount: 3, Duration: 0.5}, repository.Read("key"))
+	assert.EqualValues(t, model.HTTPEdgeItem{}, repository.Read("error"))
+}
+
+func TestQuery(t *testing.T) {
+
+	var str bytes.Buffer
+	slog.SetDefault(slog.New(slog.NewTextHandler(&str, nil)))
+
+	var tests = []struct {
+		msg                             string
+		from, to                        time.Time
+		patternNs, patternIn, patternEx *regexp.Regexp
+		want                            []model.HTTPEdgeItem
+		error                           string
+	}{
+		{"from / to filter", time.Now().Add(time.Minute * -1), time.Now().Add(time.Minute), regexp.MustCompile(""), regexp.MustCompile(""), regexp.MustCompile(""), dbState[1:2], ""},
+		{"namespace filter", time.Now().Add(time.Hour * -3), time.Now().Add(time.Hour * 3), regexp.MustCompile("^test$"), regexp.MustCompile(""), regexp.MustCompile(""), dbState[1:3], ""},
+		{"include filter", time.Now().Add(time.Hour * -3), time.Now().Add(time.Hour * 3), regexp.MustCompile(""), regexp.MustCompile("test"), regexp.MustCompile(""), dbState[0:4], ""},
+		{"exclude filter", time.Now().Add(time.Hour * -3), time.Now().Add(time.Hour * 3), regexp.MustCompile(""), regexp.MustCompile(""), regexp.MustCompile("test"), dbState[4:5], ""},
+		{"error", time.Now().Add(time.Hour * 998), time.Now().Add(time.Hour * 1001), regexp.MustCompile(""), regexp.MustCompile(""), regexp.MustCompile(""), []model.HTTPEdgeItem{}, "[db:http_edges:Query] Error=error"},
+	}
+
+	repository := NewDbRepository(&mockDb{})
+	for _, test := range tests {
+		t.Run(test.msg, func(t *testing.T) {
+			result := repository.Query(test.from, test.to, test.patternNs, test.patternIn, test.patternEx)
+			assert.EqualValues(t, test.want, result)
+			assert.Contains(t, str.String(), test.error)
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+
+	var str bytes.Buffer
+	slog.SetDefault(slog.New(slog.NewTextHandler(&str, nil)))
+
+	repository := NewDbRepository(&mockDb{})
+	repository.Set("key", &model.HTTPEdgeItem{})
+	assert.NotContains(t, str.String(), "Upsert")
+	repository.Set("error", &model.HTTPEdgeItem{})
+	assert.Contains(t, str.String(), "[db:http_edges:Upsert] Error=error")
+}
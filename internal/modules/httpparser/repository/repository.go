@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/modules/httpparser/model"
+)
+
+type Repository[T model.HTTPEdgeItem] interface {
+	Read(key string) T
+	Query(from time.Time, to time.Time, patternNs *regexp.Regexp, patternIn *regexp.Regexp, patternEx *regexp.Regexp) []T
+	Set(key string, value *T)
+}
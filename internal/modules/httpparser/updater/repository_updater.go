@@ -0,0 +1,50 @@
+package updater
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/k8spacket/k8spacket/internal/modules/httpparser/model"
+	"github.com/k8spacket/k8spacket/internal/modules/httpparser/repository"
+	"github.com/k8spacket/k8spacket/internal/thirdparty/db"
+)
+
+type RepositoryUpdater struct {
+	repo repository.Repository[model.HTTPEdgeItem]
+	lock *sync.RWMutex
+}
+
+func NewUpdater(repo repository.Repository[model.HTTPEdgeItem]) *RepositoryUpdater {
+	return &RepositoryUpdater{repo: repo, lock: &sync.RWMutex{}}
+}
+
+func (updater *RepositoryUpdater) Update(client modules.Address, server modules.Address, method string, path string, statusCode int, duration float64) {
+	var id = strconv.Itoa(int(db.HashId(fmt.Sprintf("%s-%s-%s-%s", client.Addr, server.Addr, method, path))))
+
+	updater.lock.Lock()
+	defer updater.lock.Unlock()
+	var edge = updater.repo.Read(id)
+	if (model.HTTPEdgeItem{} == edge) {
+		edge = model.HTTPEdgeItem{Src: client.Addr, Dst: server.Addr, Method: method, Path: path}
+	}
+	edge.SrcName = client.Name
+	edge.SrcNamespace = client.Namespace
+	edge.DstName = server.Name
+	edge.DstNamespace = server.Namespace
+	edge.RequestCount++
+	switch {
+	case statusCode >= 500:
+		edge.ServerErrorCount++
+	case statusCode >= 400:
+		edge.ClientErrorCount++
+	}
+	edge.Duration += duration
+	if duration > edge.MaxDuration {
+		edge.MaxDuration = duration
+	}
+	edge.LastSeen = time.Now()
+	updater.repo.Set(id, &edge)
+}
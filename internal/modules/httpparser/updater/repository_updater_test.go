@@ -0,0 +1,51 @@
+package updater
+
+import (
+	"testing"
+
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/k8spacket/k8spacket/internal/modules/httpparser/model"
+	"github.com/k8spacket/k8spacket/internal/modules/httpparser/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockRepository struct {
+	repository.Repository[model.HTTPEdgeItem]
+	result model.HTTPEdgeItem
+}
+
+func (mock *mockRepository) Set(key string, value *model.HTTPEdgeItem) {
+	mock.result = *value
+}
+
+func (mock *mockRepository) Read(key string) model.HTTPEdgeItem {
+	return mock.result
+}
+
+func TestUpdate(t *testing.T) {
+
+	var tests = []struct {
+		name       string
+		item       model.HTTPEdgeItem
+		statusCode int
+		want       model.HTTPEdgeItem
+	}{
+		{"server error", model.HTTPEdgeItem{Src: "src", Dst: "dst", Method: "GET", Path: "/", RequestCount: 10, ClientErrorCount: 2, ServerErrorCount: 1, Duration: 0.5, MaxDuration: 0.5},
+			503, model.HTTPEdgeItem{Src: "src", SrcName: "srcName", SrcNamespace: "srcNs", Dst: "dst", DstName: "dstName", DstNamespace: "dstNs", Method: "GET", Path: "/", RequestCount: 11, ClientErrorCount: 2, ServerErrorCount: 2, Duration: 1.5, MaxDuration: 1}},
+		{"client error", model.HTTPEdgeItem{},
+			404, model.HTTPEdgeItem{Src: "src", SrcName: "srcName", SrcNamespace: "srcNs", Dst: "dst", DstName: "dstName", DstNamespace: "dstNs", Method: "GET", Path: "/", RequestCount: 1, ClientErrorCount: 1, Duration: 1, MaxDuration: 1}},
+		{"success", model.HTTPEdgeItem{},
+			200, model.HTTPEdgeItem{Src: "src", SrcName: "srcName", SrcNamespace: "srcNs", Dst: "dst", DstName: "dstName", DstNamespace: "dstNs", Method: "GET", Path: "/", RequestCount: 1, Duration: 1, MaxDuration: 1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepository := &mockRepository{result: test.item}
+			updater := NewUpdater(mockRepository)
+			updater.Update(modules.Address{Addr: "src", Name: "srcName", Namespace: "srcNs"}, modules.Address{Addr: "dst", Name: "dstName", Namespace: "dstNs"}, "GET", "/", test.statusCode, 1)
+			result := mockRepository.Read("")
+			test.want.LastSeen = result.LastSeen
+			assert.EqualValues(t, test.want, result)
+		})
+	}
+}
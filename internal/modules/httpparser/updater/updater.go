@@ -0,0 +1,9 @@
+package updater
+
+import (
+	"github.com/k8spacket/k8spacket/internal/modules"
+)
+
+type Updater interface {
+	Update(client modules.Address, server modules.Address, method string, path string, statusCode int, duration float64)
+}
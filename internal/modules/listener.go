@@ -1,5 +1,5 @@
 package modules
 
-type Listener[T TCPEvent | TLSEvent] interface {
+type Listener[T TCPEvent | TLSEvent | DNSEvent | HTTPEvent | ListenEvent] interface {
 	Listen(event T)
 }
@@ -0,0 +1,85 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/modules/listeners/model"
+	httpclient "github.com/k8spacket/k8spacket/internal/thirdparty/http"
+)
+
+func aggregateListeners(ctx context.Context, podIPs []string, query url.Values, port string, client httpclient.Client) []model.Listener {
+	all := make([]model.Listener, 0)
+	if len(podIPs) == 0 {
+		return all
+	}
+
+	const maxConcurrent = 5
+	const requestTimeout = 5 * time.Second
+
+	sem := make(chan struct{}, maxConcurrent)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	for _, ip := range podIPs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(ip string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			reqCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, fmt.Sprintf("http://%s/listeners/sockets?%s", net.JoinHostPort(ip, port), query.Encode()), nil)
+			if err != nil {
+				slog.Error("[api] Cannot get listeners", "Error", err)
+				return
+			}
+
+			start := time.Now()
+			resp, err := client.Do(req)
+			httpclient.ObservePeerRequest("listeners", start, resp, err)
+			if err != nil {
+				slog.Error("[api] Cannot get listeners", "Error", err)
+				return
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				slog.Error("[api] Cannot get listeners", "Error", fmt.Errorf("peer %s status %d", ip, resp.StatusCode))
+				return
+			}
+
+			data, err := io.ReadAll(resp.Body)
+			if err != nil {
+				slog.Error("[api] Cannot read listeners response", "Error", err)
+				return
+			}
+
+			var fetched []model.Listener
+			if err := json.Unmarshal(data, &fetched); err != nil {
+				slog.Error("[api] Cannot parse listeners response", "Error", err)
+				return
+			}
+			for i := range fetched {
+				fetched[i].Instance = ip
+			}
+
+			mu.Lock()
+			all = append(all, fetched...)
+			mu.Unlock()
+		}(ip)
+	}
+
+	wg.Wait()
+	return all
+}
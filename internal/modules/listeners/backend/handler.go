@@ -0,0 +1,97 @@
+package backend
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/k8spacket/k8spacket/internal/modules/listeners/inventory"
+	"github.com/k8spacket/k8spacket/internal/modules/listeners/model"
+	httpclient "github.com/k8spacket/k8spacket/internal/thirdparty/http"
+	k8sclient "github.com/k8spacket/k8spacket/internal/thirdparty/k8s"
+)
+
+var reMatchAll = regexp.MustCompile("")
+
+type Handler struct {
+	inventory  *inventory.Inventory
+	httpClient httpclient.Client
+	k8sClient  k8sclient.Client
+	store      *config.Store
+}
+
+func NewHandler(inventory *inventory.Inventory, httpClient httpclient.Client, k8sClient k8sclient.Client, store *config.Store) *Handler {
+	return &Handler{inventory: inventory, httpClient: httpClient, k8sClient: k8sClient, store: store}
+}
+
+// SocketsHandler lists sockets listening on the node of this instance
+func (handler *Handler) SocketsHandler(w http.ResponseWriter, r *http.Request) {
+	response, err := handler.filterListeners(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeResponse(w, response)
+}
+
+// ListenersHandler lists sockets listening on all nodes, fetched from every k8spacket instance
+func (handler *Handler) ListenersHandler(w http.ResponseWriter, r *http.Request) {
+	// a bad filter is rejected here rather than by every instance
+	if _, err := handler.filterListeners(r.URL.Query()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	api := handler.store.Get().Api
+	k8spacketIps := handler.k8sClient.GetPodIPsBySelectors(api.FieldSelector, api.LabelSelector)
+	response := aggregateListeners(r.Context(), k8spacketIps, r.URL.Query(), strconv.Itoa(api.Port), handler.httpClient)
+	inventory.Sort(response)
+	writeResponse(w, response)
+}
+
+func (handler *Handler) filterListeners(query url.Values) ([]model.Listener, error) {
+	patternNs, err := parsePattern(query.Get("namespace"))
+	if err != nil {
+		return nil, err
+	}
+	undeclaredOnly := false
+	if value := query.Get("undeclared"); value != "" {
+		if undeclaredOnly, err = strconv.ParseBool(value); err != nil {
+			return nil, err
+		}
+	}
+
+	response := make([]model.Listener, 0)
+	for _, listener := range handler.inventory.List() {
+		if !patternNs.MatchString(listener.Namespace) {
+			continue
+		}
+		// ports are checked on every request, specs of pods and services change after sockets start listening
+		declared, known := handler.k8sClient.IsPortDeclared(listener.Name, listener.Namespace, listener.Port)
+		listener.Undeclared = known && !declared
+		if undeclaredOnly && !listener.Undeclared {
+			continue
+		}
+		response = append(response, listener)
+	}
+	return response, nil
+}
+
+func parsePattern(value string) (*regexp.Regexp, error) {
+	if value == "" {
+		return reMatchAll, nil
+	}
+	return regexp.Compile(value)
+}
+
+func writeResponse(w http.ResponseWriter, response []model.Listener) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		slog.Error("[api] Cannot prepare listeners response", "Error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
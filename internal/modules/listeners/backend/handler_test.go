@@ -0,0 +1,119 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/k8spacket/k8spacket/internal/modules/listeners/inventory"
+	"github.com/k8spacket/k8spacket/internal/modules/listeners/model"
+	httpclient "github.com/k8spacket/k8spacket/internal/thirdparty/http"
+	k8sclient "github.com/k8spacket/k8spacket/internal/thirdparty/k8s"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockK8SClient struct {
+	k8sclient.Client
+}
+
+func (k8sClient *mockK8SClient) GetPodIPsBySelectors(fieldSelector string, labelSelector string) []string {
+	return []string{"10.0.0.1", "10.0.0.2"}
+}
+
+func (k8sClient *mockK8SClient) IsPortDeclared(name string, namespace string, port uint16) (bool, bool) {
+	if name != "pod.api" {
+		return false, false
+	}
+	return port == 8080, true
+}
+
+type mockHttpClient struct {
+	httpclient.Client
+}
+
+func (mockHttpClient *mockHttpClient) Do(req *http.Request) (*http.Response, error) {
+	if req.URL.Hostname() == "10.0.0.2" {
+		return nil, errors.New("connection refused")
+	}
+	if req.URL.Path != "/listeners/sockets" || req.URL.Query().Get("undeclared") != "true" {
+		return &http.Response{Body: io.NopCloser(bytes.NewBuffer(nil)), StatusCode: http.StatusNotFound}, nil
+	}
+	result, _ := json.Marshal([]model.Listener{{Addr: "0.0.0.0", Port: 6060, Name: "pod.api", Namespace: "shop", Undeclared: true}})
+	return &http.Response{Body: io.NopCloser(bytes.NewBuffer(result)), StatusCode: http.StatusOK}, nil
+}
+
+func newTestHandler() *Handler {
+	listeners := inventory.NewInventory()
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	listeners.Add(1, model.Listener{Addr: "0.0.0.0", Port: 8080, Name: "pod.api", Namespace: "shop", Since: since})
+	listeners.Add(2, model.Listener{Addr: "0.0.0.0", Port: 6060, Name: "pod.api", Namespace: "shop", Since: since})
+	listeners.Add(3, model.Listener{Addr: "0.0.0.0", Port: 22, Name: "N/A", Process: "sshd", Since: since})
+	return NewHandler(listeners, &mockHttpClient{}, &mockK8SClient{}, config.NewStore(config.Default()))
+}
+
+func TestSocketsHandler(t *testing.T) {
+	var tests = []struct {
+		name   string
+		query  string
+		status int
+		ports  []uint16
+	}{
+		{"all", "", http.StatusOK, []uint16{22, 6060, 8080}},
+		{"namespace", "?namespace=^shop$", http.StatusOK, []uint16{6060, 8080}},
+		{"undeclared", "?undeclared=true", http.StatusOK, []uint16{6060}},
+		{"bad namespace", "?namespace=(", http.StatusBadRequest, nil},
+		{"bad undeclared", "?undeclared=maybe", http.StatusBadRequest, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", "/listeners/sockets"+test.query, nil)
+			assert.NoError(t, err)
+
+			http.HandlerFunc(newTestHandler().SocketsHandler).ServeHTTP(rr, req)
+
+			assert.Equal(t, test.status, rr.Code)
+			if test.status != http.StatusOK {
+				return
+			}
+			var listeners []model.Listener
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &listeners))
+			var ports []uint16
+			for _, listener := range listeners {
+				ports = append(ports, listener.Port)
+				// only ports of known pods can be undeclared
+				assert.Equal(t, listener.Port == 6060, listener.Undeclared)
+			}
+			assert.Equal(t, test.ports, ports)
+		})
+	}
+}
+
+func TestListenersHandler(t *testing.T) {
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/api/listeners?undeclared=true", nil)
+	assert.NoError(t, err)
+
+	http.HandlerFunc(newTestHandler().ListenersHandler).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var listeners []model.Listener
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &listeners))
+	// the instance that cannot be reached is skipped
+	assert.Equal(t, []model.Listener{{Addr: "0.0.0.0", Port: 6060, Name: "pod.api", Namespace: "shop", Undeclared: true, Instance: "10.0.0.1"}}, listeners)
+
+	rr = httptest.NewRecorder()
+	req, err = http.NewRequest("GET", "/api/listeners?undeclared=maybe", nil)
+	assert.NoError(t, err)
+
+	http.HandlerFunc(newTestHandler().ListenersHandler).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -0,0 +1,57 @@
+package listeners
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/k8spacket/k8spacket/internal/modules/listeners/backend"
+	"github.com/k8spacket/k8spacket/internal/modules/listeners/inventory"
+	"github.com/k8spacket/k8spacket/internal/modules/listeners/listener"
+	httpclient "github.com/k8spacket/k8spacket/internal/thirdparty/http"
+	k8sclient "github.com/k8spacket/k8spacket/internal/thirdparty/k8s"
+)
+
+// Module keeps an inventory of TCP sockets listening on nodes, it points out ports pods listen on without declaring them
+type Module struct {
+	broker       modules.Broker
+	listener     modules.Listener[modules.ListenEvent]
+	subscription modules.Subscription
+}
+
+func NewModule() *Module {
+	return &Module{}
+}
+
+func (module *Module) Name() string {
+	return "listeners"
+}
+
+func (module *Module) Init(mux *http.ServeMux, broker modules.Broker, store *config.Store) error {
+	listeners := inventory.NewInventory()
+	controller := backend.NewHandler(listeners, &httpclient.HttpClient{}, &k8sclient.K8SClient{}, store)
+
+	mux.HandleFunc("/listeners/sockets", controller.SocketsHandler)
+	mux.HandleFunc("/api/listeners", controller.ListenersHandler)
+
+	module.broker = broker
+	module.listener = listener.NewListener(listeners)
+	return nil
+}
+
+func (module *Module) Start() error {
+	subscription, err := module.broker.SubscribeListen(module.Name(), module.listener)
+	if err != nil {
+		return err
+	}
+	module.subscription = subscription
+	return nil
+}
+
+func (module *Module) Stop(_ context.Context) error {
+	if module.subscription != nil {
+		module.subscription.Unsubscribe()
+	}
+	return nil
+}
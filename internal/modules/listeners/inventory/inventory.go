@@ -0,0 +1,55 @@
+package inventory
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+
+	"github.com/k8spacket/k8spacket/internal/modules/listeners/model"
+)
+
+// Inventory holds sockets listening on the node by inode
+type Inventory struct {
+	mu        sync.RWMutex
+	listeners map[uint64]model.Listener
+}
+
+func NewInventory() *Inventory {
+	return &Inventory{listeners: make(map[uint64]model.Listener)}
+}
+
+// Add stores a listening socket, a socket found at startup may be reported by the eBPF program too,
+// then it keeps the time it was seen first
+func (inventory *Inventory) Add(ino uint64, listener model.Listener) {
+	inventory.mu.Lock()
+	defer inventory.mu.Unlock()
+	if known, ok := inventory.listeners[ino]; ok && known.Since.Before(listener.Since) {
+		listener.Since = known.Since
+	}
+	inventory.listeners[ino] = listener
+}
+
+func (inventory *Inventory) Remove(ino uint64) {
+	inventory.mu.Lock()
+	defer inventory.mu.Unlock()
+	delete(inventory.listeners, ino)
+}
+
+// List returns the listening sockets ordered by namespace, name and port
+func (inventory *Inventory) List() []model.Listener {
+	inventory.mu.RLock()
+	listeners := make([]model.Listener, 0, len(inventory.listeners))
+	for _, listener := range inventory.listeners {
+		listeners = append(listeners, listener)
+	}
+	inventory.mu.RUnlock()
+	Sort(listeners)
+	return listeners
+}
+
+func Sort(listeners []model.Listener) {
+	slices.SortFunc(listeners, func(a, b model.Listener) int {
+		return cmp.Or(cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Name, b.Name), cmp.Compare(a.Port, b.Port),
+			cmp.Compare(a.Addr, b.Addr), cmp.Compare(a.Instance, b.Instance))
+	})
+}
@@ -0,0 +1,30 @@
+package inventory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/modules/listeners/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInventory(t *testing.T) {
+	start := time.Now()
+	inventory := NewInventory()
+
+	inventory.Add(1, model.Listener{Addr: "0.0.0.0", Port: 8080, Name: "pod.api", Namespace: "shop", Since: start})
+	inventory.Add(2, model.Listener{Addr: "0.0.0.0", Port: 22, Name: "N/A", Namespace: "", Process: "sshd", Since: start})
+	inventory.Add(3, model.Listener{Addr: "::", Port: 9090, Name: "pod.api", Namespace: "shop", Since: start})
+	inventory.Add(4, model.Listener{Addr: "0.0.0.0", Port: 53, Name: "pod.coredns", Namespace: "kube-system", Since: start})
+
+	// reported again by the eBPF program after the scan at startup
+	inventory.Add(1, model.Listener{Addr: "0.0.0.0", Port: 8080, Name: "pod.api", Namespace: "shop", Container: "api", Since: start.Add(time.Second)})
+	inventory.Remove(3)
+	inventory.Remove(42)
+
+	assert.Equal(t, []model.Listener{
+		{Addr: "0.0.0.0", Port: 22, Name: "N/A", Namespace: "", Process: "sshd", Since: start},
+		{Addr: "0.0.0.0", Port: 53, Name: "pod.coredns", Namespace: "kube-system", Since: start},
+		{Addr: "0.0.0.0", Port: 8080, Name: "pod.api", Namespace: "shop", Container: "api", Since: start},
+	}, inventory.List())
+}
@@ -0,0 +1,36 @@
+package listener
+
+import (
+	"log/slog"
+
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/k8spacket/k8spacket/internal/modules/listeners/inventory"
+	"github.com/k8spacket/k8spacket/internal/modules/listeners/model"
+)
+
+type ListenListener struct {
+	inventory *inventory.Inventory
+}
+
+func NewListener(inventory *inventory.Inventory) modules.Listener[modules.ListenEvent] {
+	return &ListenListener{inventory: inventory}
+}
+
+func (listener *ListenListener) Listen(event modules.ListenEvent) {
+	if event.Closed {
+		listener.inventory.Remove(event.Ino)
+		slog.Debug("Socket stopped listening", "addr", event.Listener.Addr, "port", event.Listener.Port, "name", event.Listener.Name, "namespace", event.Listener.Namespace)
+		return
+	}
+	listener.inventory.Add(event.Ino, model.Listener{
+		Addr:      event.Listener.Addr,
+		Port:      event.Listener.Port,
+		Name:      event.Listener.Name,
+		Namespace: event.Listener.Namespace,
+		Container: event.Listener.Container,
+		Pid:       event.Listener.Pid,
+		Process:   event.Listener.Process,
+		Since:     event.Time})
+	slog.Debug("Socket listening", "addr", event.Listener.Addr, "port", event.Listener.Port, "name", event.Listener.Name, "namespace", event.Listener.Namespace,
+		"container", event.Listener.Container, "process", event.Listener.Process, "pid", event.Listener.Pid)
+}
@@ -0,0 +1,31 @@
+package listener
+
+import (
+	"testing"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/k8spacket/k8spacket/internal/modules/listeners/inventory"
+	"github.com/k8spacket/k8spacket/internal/modules/listeners/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListen(t *testing.T) {
+	now := time.Now()
+	listeners := inventory.NewInventory()
+	listener := NewListener(listeners)
+
+	address := modules.Address{Addr: "0.0.0.0", Port: 8080, Name: "pod.api", Namespace: "shop", Container: "api", Pid: 42, Process: "server"}
+	listener.Listen(modules.ListenEvent{Listener: address, Ino: 4711, Time: now})
+	listener.Listen(modules.ListenEvent{Listener: modules.Address{Addr: "127.0.0.1", Port: 6060}, Ino: 4712, Time: now})
+
+	assert.Equal(t, []model.Listener{
+		{Addr: "127.0.0.1", Port: 6060, Since: now},
+		{Addr: "0.0.0.0", Port: 8080, Name: "pod.api", Namespace: "shop", Container: "api", Pid: 42, Process: "server", Since: now},
+	}, listeners.List())
+
+	listener.Listen(modules.ListenEvent{Listener: modules.Address{Addr: "127.0.0.1", Port: 6060}, Ino: 4712, Closed: true, Time: now})
+
+	assert.Len(t, listeners.List(), 1)
+	assert.Equal(t, uint16(8080), listeners.List()[0].Port)
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Listener is a TCP socket listening on a node, Addr is a wildcard address when it listens on all interfaces.
+// Undeclared marks ports of pods which neither their container specs nor services selecting them declare.
+type Listener struct {
+	Addr       string    `json:"addr"`
+	Port       uint16    `json:"port"`
+	Name       string    `json:"name"`
+	Namespace  string    `json:"namespace"`
+	Container  string    `json:"container,omitempty"`
+	Pid        uint32    `json:"pid,omitempty"`
+	Process    string    `json:"process,omitempty"`
+	Since      time.Time `json:"since"`
+	Undeclared bool      `json:"undeclared"`
+	// Instance is the k8spacket pod which reported the socket, set when aggregating the cluster
+	Instance string `json:"instance,omitempty"`
+}
@@ -1,12 +1,20 @@
 package modules
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
+// Address is an end of a connection. Container, Pid and Process are known for the local end of connections
+// captured by the inet program only, Pid is 0 for accepted connections
 type Address struct {
 	Addr      string
 	Port      uint16
 	Name      string
 	Namespace string
+	Container string
+	Pid       uint32
+	Process   string
 }
 type TCPEvent struct {
 	Client  Address
@@ -15,6 +23,57 @@ type TCPEvent struct {
 	RxB     uint64
 	DeltaUs uint64
 	Closed  bool
+	// Failed is a connection closed before the handshake completed, refused or timed out
+	Failed      bool
+	Retransmits uint32
+	Reset       bool
+	// smoothed round-trip time and its mean deviation in microseconds, 0 when the kernel took no sample
+	SrttUs   uint32
+	RttVarUs uint32
+	// when the event was captured, recordings keep it
+	Time time.Time
+}
+
+// DNSEvent is a DNS query or response, Client is always the side asking and Server the resolver
+type DNSEvent struct {
+	Client   Address
+	Server   Address
+	ID       uint16
+	Response bool
+	Name     string
+	Type     string
+	RCode    string
+	Answers  []DNSAnswer
+	Time     time.Time
+}
+
+// DNSAnswer is an address the name resolves to, valid for TTL seconds
+type DNSAnswer struct {
+	Addr string
+	TTL  uint32
+}
+
+// HTTPEvent is an HTTP/1.x request or status line, Client is always the side sending requests.
+// Copies of a packet seen on several interfaces share the TCP sequence number Seq
+type HTTPEvent struct {
+	Client     Address
+	Server     Address
+	Seq        uint32
+	Response   bool
+	Method     string
+	Host       string
+	Path       string
+	StatusCode int
+	Time       time.Time
+}
+
+// ListenEvent is a TCP socket starting to listen on Listener, or stopping when Closed. Ino is the inode of the socket,
+// it tells apart sockets of pods listening on the same wildcard address and port
+type ListenEvent struct {
+	Listener Address
+	Ino      uint64
+	Closed   bool
+	Time     time.Time
 }
 
 type EventSource int
@@ -22,6 +81,7 @@ type EventSource int
 const (
 	TC EventSource = iota
 	SocketFilter
+	Synthetic
 )
 
 func (source EventSource) String() string {
@@ -30,6 +90,8 @@ func (source EventSource) String() string {
 		return "TC"
 	case SocketFilter:
 		return "SocketFilter"
+	case Synthetic:
+		return "Synthetic"
 	default:
 		return fmt.Sprintf("EventSource(%d)", source)
 	}
@@ -44,4 +106,15 @@ type TLSEvent struct {
 	ServerName     string
 	UsedTlsVersion uint16
 	UsedCipher     uint16
+	// application protocol selected by the server, seen in TLS 1.2 only
+	UsedAlpn string
+	// when the handshake was captured, recordings keep it
+	Time time.Time
+	// ClientHello details fingerprinting the client (JA3, JA4), in the order the client sent them
+	HelloVersion        uint16
+	Extensions          []uint16
+	SupportedGroups     []uint16
+	PointFormats        []uint8
+	SignatureAlgorithms []uint16
+	Alpn                []string
 }
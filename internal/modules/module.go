@@ -0,0 +1,30 @@
+package modules
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/k8spacket/k8spacket/internal/config"
+)
+
+// Module is a pluggable feature of k8spacket. Init builds the module and registers its routes,
+// Start subscribes it to events and Stop unsubscribes it and releases its resources.
+type Module interface {
+	Name() string
+	Init(mux *http.ServeMux, broker Broker, store *config.Store) error
+	Start() error
+	Stop(ctx context.Context) error
+}
+
+// Broker is the part of the event broker modules use to receive events
+type Broker interface {
+	SubscribeTCP(subscriber string, listener Listener[TCPEvent]) (Subscription, error)
+	SubscribeTLS(subscriber string, listener Listener[TLSEvent]) (Subscription, error)
+	SubscribeDNS(subscriber string, listener Listener[DNSEvent]) (Subscription, error)
+	SubscribeHTTP(subscriber string, listener Listener[HTTPEvent]) (Subscription, error)
+	SubscribeListen(subscriber string, listener Listener[ListenEvent]) (Subscription, error)
+}
+
+type Subscription interface {
+	Unsubscribe()
+}
@@ -1,6 +1,8 @@
 package nodegraph
 
 import (
+	"context"
+	"github.com/k8spacket/k8spacket/internal/config"
 	"github.com/k8spacket/k8spacket/internal/modules/nodegraph/backend"
 	"github.com/k8spacket/k8spacket/internal/modules/nodegraph/listener"
 	"github.com/k8spacket/k8spacket/internal/modules/nodegraph/o11y"
@@ -18,14 +20,35 @@ import (
 	"github.com/k8spacket/k8spacket/internal/thirdparty/resource"
 )
 
-func Init(mux *http.ServeMux) modules.Listener[modules.TCPEvent] {
+type Module struct {
+	broker       modules.Broker
+	db           db.Db[model.ConnectionItem]
+	listener     modules.Listener[modules.TCPEvent]
+	subscription modules.Subscription
+}
+
+func NewModule() *Module {
+	return &Module{}
+}
+
+func (module *Module) Name() string {
+	return "nodegraph"
+}
 
-	prometheus.Init()
+func (module *Module) Init(mux *http.ServeMux, broker modules.Broker, store *config.Store) error {
 
-	handler, _ := db.New[model.ConnectionItem]("tcp_connections")
+	prometheus.Configure(store.Get().Nodegraph.Metrics)
+	store.OnChange(func(cfg *config.Config) {
+		prometheus.Configure(cfg.Nodegraph.Metrics)
+	})
+
+	handler, err := db.New[model.ConnectionItem]("tcp_connections")
+	if err != nil {
+		return err
+	}
 	repo := repository.NewDbRepository(handler)
 	controller := backend.NewHandler(repo)
-	o11yController := o11y.NewO11yHandler(&stats.StatsFactory{}, &httpclient.HttpClient{}, &k8sclient.K8SClient{}, &resource.FileResource{})
+	o11yController := o11y.NewO11yHandler(&stats.StatsFactory{}, &httpclient.HttpClient{}, &k8sclient.K8SClient{}, &resource.FileResource{}, store)
 
 	mux.HandleFunc("/nodegraph/connections", controller.ConnectionHandler)
 	mux.HandleFunc("/nodegraph/api/health", o11yController.Health)
@@ -33,8 +56,25 @@ func Init(mux *http.ServeMux) modules.Listener[modules.TCPEvent] {
 	mux.HandleFunc("/nodegraph/api/graph/data", o11yController.NodeGraphDataHandler)
 
 	nodegraphUpdater := updater.NewUpdater(repo)
-	tcpListener := listener.NewListener(nodegraphUpdater)
 
-	return tcpListener
+	module.broker = broker
+	module.db = handler
+	module.listener = listener.NewListener(nodegraphUpdater, store)
+	return nil
+}
+
+func (module *Module) Start() error {
+	subscription, err := module.broker.SubscribeTCP(module.Name(), module.listener)
+	if err != nil {
+		return err
+	}
+	module.subscription = subscription
+	return nil
+}
 
+func (module *Module) Stop(_ context.Context) error {
+	if module.subscription != nil {
+		module.subscription.Unsubscribe()
+	}
+	return module.db.Close()
 }
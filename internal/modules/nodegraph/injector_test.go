@@ -1,19 +1,33 @@
 package nodegraph
 
 import (
+	"context"
 	"net/http"
-	"os"
 	"testing"
 
+	"github.com/k8spacket/k8spacket/internal/broker"
+	"github.com/k8spacket/k8spacket/internal/config"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestInit(t *testing.T) {
+func TestModule(t *testing.T) {
 
-	os.Setenv("K8S_PACKET_TCP_METRICS_ENABLED", "true")
+	cfg := config.Default()
+	cfg.Nodegraph.Metrics.Enabled = true
+	store := config.NewStore(cfg)
+	distributionBroker := broker.Init(store)
 
-	listener := Init(http.NewServeMux())
+	module := NewModule()
 
-	assert.NotEmpty(t, listener)
+	assert.EqualValues(t, "nodegraph", module.Name())
+	assert.NoError(t, module.Init(http.NewServeMux(), distributionBroker, store))
+	assert.NotEmpty(t, module.listener)
 
+	assert.NoError(t, module.Start())
+	_, err := distributionBroker.SubscribeTCP("nodegraph", module.listener)
+	assert.Error(t, err)
+
+	assert.NoError(t, module.Stop(context.Background()))
+	_, err = distributionBroker.SubscribeTCP("nodegraph", module.listener)
+	assert.NoError(t, err)
 }
@@ -1,38 +1,56 @@
 package listener
 
 import (
+	"github.com/k8spacket/k8spacket/internal/config"
 	"github.com/k8spacket/k8spacket/internal/modules/nodegraph/updater"
 	"log/slog"
-	"os"
 	"strconv"
-	"time"
 
 	"github.com/k8spacket/k8spacket/internal/modules"
 	"github.com/k8spacket/k8spacket/internal/modules/nodegraph/prometheus"
 )
 
 type TcpListener struct {
-	updater           updater.Updater
-	tcpMetricsEnabled bool
+	updater updater.Updater
+	store   *config.Store
 }
 
-func NewListener(updater updater.Updater) modules.Listener[modules.TCPEvent] {
-	tcpMetricsEnabled, _ := strconv.ParseBool(os.Getenv("K8S_PACKET_TCP_METRICS_ENABLED"))
-	return &TcpListener{updater: updater, tcpMetricsEnabled: tcpMetricsEnabled}
+func NewListener(updater updater.Updater, store *config.Store) modules.Listener[modules.TCPEvent] {
+	return &TcpListener{updater: updater, store: store}
 }
 
 func (listener *TcpListener) Listen(event modules.TCPEvent) {
 
+	cfg := listener.store.Get().Nodegraph
+
 	var persistent = false
-	var persistentDuration, _ = time.ParseDuration(os.Getenv("K8S_PACKET_TCP_PERSISTENT_DURATION"))
-	if int(event.DeltaUs) > int(persistentDuration.Milliseconds()) {
+	if int(event.DeltaUs) > int(cfg.PersistentDuration.Milliseconds()) {
 		persistent = true
 	}
 
-	listener.updater.Update(event.Client.Addr, event.Client.Name, event.Client.Namespace, event.Server.Addr, event.Server.Name, event.Server.Namespace, persistent, float64(event.TxB), float64(event.RxB), float64(event.DeltaUs), event.Closed)
+	listener.updater.Update(event, persistent)
 
-	if event.Closed {
-		sendPrometheusMetrics(event, persistent, listener.tcpMetricsEnabled)
+	if event.Closed && event.Failed {
+		sendFailurePrometheusMetrics(event, cfg.Metrics)
+		slog.Warn("Connection failed",
+			"src", event.Client.Addr,
+			"srcName", event.Client.Name,
+			"srcPort", strconv.Itoa(int(event.Client.Port)),
+			"srcNS", event.Client.Namespace,
+			"dst", event.Server.Addr,
+			"dstName", event.Server.Name,
+			"dstPort", strconv.Itoa(int(event.Server.Port)),
+			"dstNS", event.Server.Namespace,
+			"reset", event.Reset,
+			"duration", float64(event.DeltaUs),
+			"srcContainer", event.Client.Container,
+			"srcProcess", event.Client.Process,
+			"srcPid", event.Client.Pid,
+			"dstContainer", event.Server.Container,
+			"dstProcess", event.Server.Process,
+			"dstPid", event.Server.Pid)
+	} else if event.Closed {
+		sendPrometheusMetrics(event, persistent, cfg.Metrics)
 		slog.Info("Connection",
 			"src", event.Client.Addr,
 			"srcName", event.Client.Name,
@@ -45,20 +63,52 @@ func (listener *TcpListener) Listen(event modules.TCPEvent) {
 			"persistent", persistent,
 			"bytesSent", float64(event.TxB),
 			"bytesReceived", float64(event.RxB),
-			"duration", float64(event.DeltaUs))
+			"duration", float64(event.DeltaUs),
+			"retransmits", event.Retransmits,
+			"reset", event.Reset,
+			"rtt", float64(event.SrttUs)/1000,
+			"rttVar", float64(event.RttVarUs)/1000,
+			"srcContainer", event.Client.Container,
+			"srcProcess", event.Client.Process,
+			"srcPid", event.Client.Pid,
+			"dstContainer", event.Server.Container,
+			"dstProcess", event.Server.Process,
+			"dstPid", event.Server.Pid)
 	}
 }
 
-func sendPrometheusMetrics(event modules.TCPEvent, persistent bool, tcpMetricsEnabled bool) {
-	if !tcpMetricsEnabled {
+// source ports of failed attempts are not kept, retried connects would get a new series each
+func sendFailurePrometheusMetrics(event modules.TCPEvent, metrics config.NodegraphMetricsConfig) {
+	if !metrics.Enabled {
+		return
+	}
+	var labels = []string{event.Client.Namespace, event.Client.Addr, event.Client.Name, event.Server.Addr, event.Server.Name, strconv.Itoa(int(event.Server.Port)), strconv.FormatBool(event.Reset)}
+	prometheus.K8sPacketConnectionFailuresMetric.WithLabelValues(append(labels, processLabels(event, metrics)...)...).Inc()
+}
+
+func sendPrometheusMetrics(event modules.TCPEvent, persistent bool, metrics config.NodegraphMetricsConfig) {
+	if !metrics.Enabled {
 		return
 	}
-	hideSrcPort, _ := strconv.ParseBool(os.Getenv("K8S_PACKET_TCP_METRICS_HIDE_SRC_PORT"))
 	var srcPortMetrics = strconv.Itoa(int(event.Client.Port))
-	if hideSrcPort {
+	if metrics.HideSrcPort {
 		srcPortMetrics = "dynamic"
 	}
-	prometheus.K8sPacketBytesSentMetric.WithLabelValues(event.Client.Namespace, event.Client.Addr, event.Client.Name, srcPortMetrics, event.Server.Addr, event.Server.Name, strconv.Itoa(int(event.Server.Port)), strconv.FormatBool(persistent)).Observe(float64(event.TxB))
-	prometheus.K8sPacketBytesReceivedMetric.WithLabelValues(event.Client.Namespace, event.Client.Addr, event.Client.Name, srcPortMetrics, event.Server.Addr, event.Server.Name, strconv.Itoa(int(event.Server.Port)), strconv.FormatBool(persistent)).Observe(float64(event.RxB))
-	prometheus.K8sPacketDurationSecondsMetric.WithLabelValues(event.Client.Namespace, event.Client.Addr, event.Client.Name, srcPortMetrics, event.Server.Addr, event.Server.Name, strconv.Itoa(int(event.Server.Port)), strconv.FormatBool(persistent)).Observe(float64(event.DeltaUs))
+	var labels = []string{event.Client.Namespace, event.Client.Addr, event.Client.Name, srcPortMetrics, event.Server.Addr, event.Server.Name, strconv.Itoa(int(event.Server.Port)), strconv.FormatBool(persistent)}
+	labels = append(labels, processLabels(event, metrics)...)
+	prometheus.K8sPacketBytesSentMetric.WithLabelValues(labels...).Observe(float64(event.TxB))
+	prometheus.K8sPacketBytesReceivedMetric.WithLabelValues(labels...).Observe(float64(event.RxB))
+	prometheus.K8sPacketDurationSecondsMetric.WithLabelValues(labels...).Observe(float64(event.DeltaUs))
+	if event.SrttUs > 0 {
+		prometheus.K8sPacketRttSecondsMetric.WithLabelValues(labels...).Observe(float64(event.SrttUs) / 1e6)
+		prometheus.K8sPacketRttDeviationSecondsMetric.WithLabelValues(labels...).Observe(float64(event.RttVarUs) / 1e6)
+	}
+}
+
+// containers and processes multiply series, they are left empty unless asked for
+func processLabels(event modules.TCPEvent, metrics config.NodegraphMetricsConfig) []string {
+	if !metrics.ProcessLabels {
+		return []string{"", "", "", ""}
+	}
+	return []string{event.Client.Container, event.Client.Process, event.Server.Container, event.Server.Process}
 }
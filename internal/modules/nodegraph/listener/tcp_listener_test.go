@@ -2,45 +2,74 @@ package listener
 
 import (
 	"bytes"
+	"github.com/k8spacket/k8spacket/internal/config"
 	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/k8spacket/k8spacket/internal/modules/nodegraph/prometheus"
 	"github.com/k8spacket/k8spacket/internal/modules/nodegraph/updater"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"log/slog"
-	"os"
 	"testing"
+	"time"
 )
 
 type mockUpdater struct {
 	updater.Updater
 	client, server string
+	failed         bool
 }
 
-func (mockUpdater *mockUpdater) Update(src string, srcName string, srcNamespace string, dst string, dstName string, dstNamespace string, persistent bool, bytesSent float64, bytesReceived float64, duration float64, closed bool) {
-	mockUpdater.client = src
-	mockUpdater.server = dst
+func (mockUpdater *mockUpdater) Update(event modules.TCPEvent, persistent bool) {
+	mockUpdater.client = event.Client.Addr
+	mockUpdater.server = event.Server.Addr
+	mockUpdater.failed = event.Failed
 }
 
 func TestListen(t *testing.T) {
 
 	var str bytes.Buffer
 
-	os.Setenv("K8S_PACKET_TCP_PERSISTENT_DURATION", "1ms")
-	os.Setenv("K8S_PACKET_TCP_METRICS_HIDE_SRC_PORT", "true")
-	os.Setenv("K8S_PACKET_TCP_METRICS_ENABLED", "true")
+	cfg := config.Default()
+	cfg.Nodegraph = config.NodegraphConfig{PersistentDuration: config.Duration{Duration: time.Millisecond},
+		Metrics: config.NodegraphMetricsConfig{Enabled: true, HideSrcPort: true}}
 
 	logger := slog.New(slog.NewTextHandler(&str, nil))
 
 	slog.SetDefault(logger)
 
 	mockUpdater := &mockUpdater{}
-	listener := NewListener(mockUpdater)
+	listener := NewListener(mockUpdater, config.NewStore(cfg))
 
-	event := modules.TCPEvent{Client: modules.Address{Addr: "client"}, Server: modules.Address{Addr: "server"}, DeltaUs: 2, Closed: true}
+	event := modules.TCPEvent{Client: modules.Address{Addr: "client", Container: "app", Pid: 42, Process: "curl"}, Server: modules.Address{Addr: "server"}, DeltaUs: 2, Closed: true, Retransmits: 3, Reset: true, SrttUs: 1500, RttVarUs: 200}
 	listener.Listen(event)
 
 	assert.EqualValues(t, event.Client.Addr, mockUpdater.client)
 	assert.EqualValues(t, event.Server.Addr, mockUpdater.server)
 
-	assert.Contains(t, str.String(), "Connection src=client srcName=\"\" srcPort=0 srcNS=\"\" dst=server dstName=\"\" dstPort=0 dstNS=\"\" persistent=true bytesSent=0 bytesReceived=0 duration=2")
+	assert.Contains(t, str.String(), "Connection src=client srcName=\"\" srcPort=0 srcNS=\"\" dst=server dstName=\"\" dstPort=0 dstNS=\"\" persistent=true bytesSent=0 bytesReceived=0 duration=2 retransmits=3 reset=true rtt=1.5 rttVar=0.2 srcContainer=app srcProcess=curl srcPid=42 dstContainer=\"\" dstProcess=\"\" dstPid=0")
+}
+
+func TestListenProcessLabels(t *testing.T) {
+	var tests = []struct {
+		client        string
+		processLabels bool
+		want          []string
+	}{
+		{"unlabelled", false, []string{"", "", "", ""}},
+		{"labelled", true, []string{"app", "curl", "db", ""}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.client, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.Nodegraph.Metrics = config.NodegraphMetricsConfig{Enabled: true, ProcessLabels: test.processLabels}
+
+			listener := NewListener(&mockUpdater{}, config.NewStore(cfg))
+
+			listener.Listen(modules.TCPEvent{Client: modules.Address{Addr: test.client, Port: 40000, Container: "app", Process: "curl"}, Server: modules.Address{Addr: "server", Port: 5432, Container: "db"}, Closed: true, Failed: true})
 
+			labels := append([]string{"", test.client, "", "server", "", "5432", "false"}, test.want...)
+			assert.EqualValues(t, 1, testutil.ToFloat64(prometheus.K8sPacketConnectionFailuresMetric.WithLabelValues(labels...)))
+		})
+	}
 }
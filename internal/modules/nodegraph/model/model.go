@@ -16,6 +16,16 @@ type ConnectionItem struct {
 	Duration       float64   `json:"duration"`
 	MaxDuration    float64   `json:"maxDuration"`
 	LastSeen       time.Time `json:"lastSeen"`
+	Retransmits    int64     `json:"retransmits"`
+	ConnReset      int64     `json:"connReset"`
+	Rtt            float64   `json:"rtt"`
+	MaxRtt         float64   `json:"maxRtt"`
+	RttCount       int64     `json:"rttCount"`
+	ConnFailed     int64     `json:"connFailed"`
+	SrcContainer   string    `json:"srcContainer"`
+	SrcProcess     string    `json:"srcProcess"`
+	DstContainer   string    `json:"dstContainer"`
+	DstProcess     string    `json:"dstProcess"`
 }
 
 type ConnectionEndpoint struct {
@@ -28,6 +38,12 @@ type ConnectionEndpoint struct {
 	BytesReceived  float64
 	Duration       float64
 	MaxDuration    float64
+	Retransmits    int64
+	ConnReset      int64
+	Rtt            float64
+	MaxRtt         float64
+	RttCount       int64
+	ConnFailed     int64
 }
 
 type NodeGraph struct {
@@ -64,4 +80,5 @@ type Edge struct {
 	Target        string `json:"target"`
 	MainStat      string `json:"mainStat"`
 	SecondaryStat string `json:"secondaryStat"`
+	Color         string `json:"color,omitempty"`
 }
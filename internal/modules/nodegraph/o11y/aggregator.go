@@ -7,6 +7,7 @@ import (
 	"github.com/k8spacket/k8spacket/internal/modules/nodegraph/stats"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
 	"net/url"
 	"sync"
@@ -39,13 +40,15 @@ func aggregateConnections(ctx context.Context, podIPs []string, query url.Values
 			reqCtx, cancel := context.WithTimeout(ctx, requestTimeout)
 			defer cancel()
 
-			req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, fmt.Sprintf("http://%s:%s/nodegraph/connections?%s", ip, port, query.Encode()), nil)
+			req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, fmt.Sprintf("http://%s/nodegraph/connections?%s", net.JoinHostPort(ip, port), query.Encode()), nil)
 			if err != nil {
 				slog.Error("[api] Cannot get stats", "Error", err)
 				return
 			}
 
+			start := time.Now()
 			resp, err := client.Do(req)
+			httpclient.ObservePeerRequest("nodegraph", start, resp, err)
 			if err != nil {
 				slog.Error("[api] Cannot get stats", "Error", err)
 				return
@@ -102,6 +105,14 @@ func prepareConnections(connectionItems map[string]model.ConnectionItem, connect
 		if conn.MaxDuration > dstEndpoint.MaxDuration {
 			dstEndpoint.MaxDuration = conn.MaxDuration
 		}
+		dstEndpoint.Rtt += conn.Rtt
+		dstEndpoint.RttCount += conn.RttCount
+		if conn.MaxRtt > dstEndpoint.MaxRtt {
+			dstEndpoint.MaxRtt = conn.MaxRtt
+		}
+		dstEndpoint.Retransmits += conn.Retransmits
+		dstEndpoint.ConnReset += conn.ConnReset
+		dstEndpoint.ConnFailed += conn.ConnFailed
 		connectionEndpoints[conn.Dst] = dstEndpoint
 	}
 }
@@ -2,14 +2,17 @@ package o11y
 
 import (
 	"encoding/json"
+	"github.com/k8spacket/k8spacket/internal/config"
 	"github.com/k8spacket/k8spacket/internal/modules/nodegraph/model"
 	"github.com/k8spacket/k8spacket/internal/modules/nodegraph/stats"
+	"github.com/k8spacket/k8spacket/internal/status"
 	httpclient "github.com/k8spacket/k8spacket/internal/thirdparty/http"
 	k8sclient "github.com/k8spacket/k8spacket/internal/thirdparty/k8s"
 	"github.com/k8spacket/k8spacket/internal/thirdparty/resource"
 	"log/slog"
 	"net/http"
-	"os"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -18,16 +21,33 @@ type O11yHandler struct {
 	httpClient httpclient.Client
 	k8sClient  k8sclient.Client
 	resource   resource.Resource
+	store      *config.Store
 }
 
-func NewO11yHandler(factory stats.Factory, httpClient httpclient.Client, k8sClient k8sclient.Client, resource resource.Resource) *O11yHandler {
-	return &O11yHandler{factory: factory, httpClient: httpClient, k8sClient: k8sClient, resource: resource}
+func NewO11yHandler(factory stats.Factory, httpClient httpclient.Client, k8sClient k8sclient.Client, resource resource.Resource, store *config.Store) *O11yHandler {
+	return &O11yHandler{factory: factory, httpClient: httpClient, k8sClient: k8sClient, resource: resource, store: store}
 }
 
+// connections come from exactly one of these loaders, depending on the loader mode
+var sources = []string{"inet", "synthetic", "replay"}
+
+const storeComponent = "db/tcp_connections"
+
+// Health lets the Grafana datasource know whether this instance captures and stores connections,
+// other components being down (e.g. the tc program of the tls module) do not matter to the node graph
 func (handler *O11yHandler) Health(w http.ResponseWriter, _ *http.Request) {
+	if !slices.ContainsFunc(sources, running) || !running(storeComponent) {
+		http.Error(w, "nodegraph not ready, see /api/status", http.StatusServiceUnavailable)
+		return
+	}
 	w.WriteHeader(200)
 }
 
+func running(name string) bool {
+	component, ok := status.Default().Component(name)
+	return ok && (component.State == status.Up || component.State == status.Degraded)
+}
+
 func (handler *O11yHandler) NodeGraphFieldsHandler(w http.ResponseWriter, r *http.Request) {
 	var selectedStats = ""
 	if len(r.URL.Query()["stats-type"]) > 0 {
@@ -81,10 +101,11 @@ func (handler *O11yHandler) getO11yStatsConfig(statsType string) (string, error)
 }
 
 func (handler *O11yHandler) buildO11yResponse(r *http.Request) (model.NodeGraph, error) {
-	var k8spacketIps = handler.k8sClient.GetPodIPsBySelectors(os.Getenv("K8S_PACKET_API_FIELD_SELECTOR"), os.Getenv("K8S_PACKET_API_LABEL_SELECTOR"))
+	api := handler.store.Get().Api
+	var k8spacketIps = handler.k8sClient.GetPodIPsBySelectors(api.FieldSelector, api.LabelSelector)
 	var connectionItems = make(map[string]model.ConnectionItem)
 
-	fetched := aggregateConnections(r.Context(), k8spacketIps, r.URL.Query(), os.Getenv("K8S_PACKET_TCP_LISTENER_PORT"), handler.httpClient)
+	fetched := aggregateConnections(r.Context(), k8spacketIps, r.URL.Query(), strconv.Itoa(api.Port), handler.httpClient)
 	for _, element := range fetched {
 		connectionItems[element.Src+"-"+element.Dst] = element
 	}
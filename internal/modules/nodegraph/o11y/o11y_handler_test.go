@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/k8spacket/k8spacket/internal/config"
 	"github.com/k8spacket/k8spacket/internal/modules/nodegraph/stats"
+	"github.com/k8spacket/k8spacket/internal/status"
 	httpclient "github.com/k8spacket/k8spacket/internal/thirdparty/http"
 	k8sclient "github.com/k8spacket/k8spacket/internal/thirdparty/k8s"
 	"github.com/k8spacket/k8spacket/internal/thirdparty/resource"
@@ -111,18 +113,40 @@ func (br *BrokenReader) Close() error {
 
 func TestHealth(t *testing.T) {
 
-	o11yController := NewO11yHandler(nil, nil, nil, nil)
-
-	req, err := http.NewRequest("GET", "/nodegraph/health", nil)
-	if err != nil {
-		t.Fatal(err)
+	var tests = []struct {
+		scenario   string
+		components map[string]status.State
+		want       int
+	}{
+		{"ready", map[string]status.State{"inet": status.Up, "db/tcp_connections": status.Up}, http.StatusOK},
+		{"synthetic", map[string]status.State{"synthetic": status.Up, "db/tcp_connections": status.Up}, http.StatusOK},
+		{"other component down", map[string]status.State{"inet": status.Up, "db/tcp_connections": status.Up, "tc/eth0": status.Down}, http.StatusOK},
+		{"inet down", map[string]status.State{"inet": status.Down, "db/tcp_connections": status.Up}, http.StatusServiceUnavailable},
+		{"inet starting", map[string]status.State{"inet": status.Starting, "db/tcp_connections": status.Up}, http.StatusServiceUnavailable},
+		{"store down", map[string]status.State{"inet": status.Up, "db/tcp_connections": status.Down}, http.StatusServiceUnavailable},
+		{"no store", map[string]status.State{"inet": status.Up}, http.StatusServiceUnavailable},
 	}
-	rr := httptest.NewRecorder()
-	handler := http.HandlerFunc(o11yController.Health)
-	handler.ServeHTTP(rr, req)
 
-	assert.EqualValues(t, rr.Code, http.StatusOK)
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			for name, state := range test.components {
+				status.Report(name, state, "")
+				defer status.Remove(name)
+			}
+
+			o11yController := NewO11yHandler(nil, nil, nil, nil, config.NewStore(config.Default()))
 
+			req, err := http.NewRequest("GET", "/nodegraph/health", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			handler := http.HandlerFunc(o11yController.Health)
+			handler.ServeHTTP(rr, req)
+
+			assert.EqualValues(t, test.want, rr.Code)
+		})
+	}
 }
 
 func TestNodeGraphFieldsHandler(t *testing.T) {
@@ -139,7 +163,8 @@ func TestNodeGraphFieldsHandler(t *testing.T) {
 				{FieldName: "source", Type: "string", Color: "", DisplayName: ""},
 				{FieldName: "target", Type: "string", Color: "", DisplayName: ""},
 				{FieldName: "mainStat", Type: "string", Color: "", DisplayName: ""},
-				{FieldName: "secondaryStat", Type: "string", Color: "", DisplayName: ""}},
+				{FieldName: "secondaryStat", Type: "string", Color: "", DisplayName: ""},
+				{FieldName: "color", Type: "string", Color: "", DisplayName: ""}},
 			NodesFields: []Field{
 				{FieldName: "id", Type: "string", Color: "", DisplayName: ""},
 				{FieldName: "title", Type: "string", Color: "", DisplayName: ""},
@@ -154,7 +179,8 @@ func TestNodeGraphFieldsHandler(t *testing.T) {
 				{FieldName: "source", Type: "string", Color: "", DisplayName: ""},
 				{FieldName: "target", Type: "string", Color: "", DisplayName: ""},
 				{FieldName: "mainStat", Type: "string", Color: "", DisplayName: ""},
-				{FieldName: "secondaryStat", Type: "string", Color: "", DisplayName: ""}},
+				{FieldName: "secondaryStat", Type: "string", Color: "", DisplayName: ""},
+				{FieldName: "color", Type: "string", Color: "", DisplayName: ""}},
 			NodesFields: []Field{
 				{FieldName: "id", Type: "string", Color: "", DisplayName: ""},
 				{FieldName: "title", Type: "string", Color: "", DisplayName: ""},
@@ -169,7 +195,8 @@ func TestNodeGraphFieldsHandler(t *testing.T) {
 				{FieldName: "source", Type: "string", Color: "", DisplayName: ""},
 				{FieldName: "target", Type: "string", Color: "", DisplayName: ""},
 				{FieldName: "mainStat", Type: "string", Color: "", DisplayName: ""},
-				{FieldName: "secondaryStat", Type: "string", Color: "", DisplayName: ""}},
+				{FieldName: "secondaryStat", Type: "string", Color: "", DisplayName: ""},
+				{FieldName: "color", Type: "string", Color: "", DisplayName: ""}},
 			NodesFields: []Field{
 				{FieldName: "id", Type: "string", Color: "", DisplayName: ""},
 				{FieldName: "title", Type: "string", Color: "", DisplayName: ""},
@@ -182,7 +209,7 @@ func TestNodeGraphFieldsHandler(t *testing.T) {
 	}
 
 	mockResource := &mockResource{}
-	o11yController := NewO11yHandler(&stats.StatsFactory{}, nil, nil, mockResource)
+	o11yController := NewO11yHandler(&stats.StatsFactory{}, nil, nil, mockResource, config.NewStore(config.Default()))
 
 	for _, test := range tests {
 		t.Run(test.scenario, func(t *testing.T) {
@@ -235,7 +262,7 @@ func TestNodeGraphDataHandler(t *testing.T) {
 	mockResource := &mockResource{}
 	mockHttpClient := &mockHttpClient{}
 	mockK8SClient := &mockK8SClient{}
-	o11yController := NewO11yHandler(&stats.StatsFactory{}, mockHttpClient, mockK8SClient, mockResource)
+	o11yController := NewO11yHandler(&stats.StatsFactory{}, mockHttpClient, mockK8SClient, mockResource, config.NewStore(config.Default()))
 
 	for _, test := range tests {
 		t.Run(test.scenario, func(t *testing.T) {
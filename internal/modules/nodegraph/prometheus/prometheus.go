@@ -1,9 +1,8 @@
 package prometheus
 
 import (
+	"github.com/k8spacket/k8spacket/internal/config"
 	"github.com/prometheus/client_golang/prometheus"
-	"os"
-	"strconv"
 )
 
 var (
@@ -12,29 +11,54 @@ var (
 			Name: "k8s_packet_bytes_sent",
 			Help: "Kubernetes packet bytes sent",
 		},
-		[]string{"ns", "src", "src_name", "src_port", "dst", "dst_name", "dst_port", "persistent"},
+		[]string{"ns", "src", "src_name", "src_port", "dst", "dst_name", "dst_port", "persistent", "src_container", "src_process", "dst_container", "dst_process"},
 	)
 	K8sPacketBytesReceivedMetric = prometheus.NewSummaryVec(
 		prometheus.SummaryOpts{
 			Name: "k8s_packet_bytes_received",
 			Help: "Kubernetes packet bytes received",
 		},
-		[]string{"ns", "src", "src_name", "src_port", "dst", "dst_name", "dst_port", "persistent"},
+		[]string{"ns", "src", "src_name", "src_port", "dst", "dst_name", "dst_port", "persistent", "src_container", "src_process", "dst_container", "dst_process"},
 	)
 	K8sPacketDurationSecondsMetric = prometheus.NewSummaryVec(
 		prometheus.SummaryOpts{
 			Name: "k8s_packet_duration_seconds",
 			Help: "Kubernetes packet duration seconds",
 		},
-		[]string{"ns", "src", "src_name", "src_port", "dst", "dst_name", "dst_port", "persistent"},
+		[]string{"ns", "src", "src_name", "src_port", "dst", "dst_name", "dst_port", "persistent", "src_container", "src_process", "dst_container", "dst_process"},
+	)
+	K8sPacketConnectionFailuresMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "k8s_packet_connection_failures_total",
+			Help: "Kubernetes packet connection attempts closed before established, refused (reset) or timed out",
+		},
+		[]string{"ns", "src", "src_name", "dst", "dst_name", "dst_port", "reset", "src_container", "src_process", "dst_container", "dst_process"},
+	)
+	K8sPacketRttSecondsMetric = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "k8s_packet_rtt_seconds",
+			Help:    "Kubernetes packet smoothed round-trip time seconds",
+			Buckets: prometheus.ExponentialBuckets(0.00005, 2, 16),
+		},
+		[]string{"ns", "src", "src_name", "src_port", "dst", "dst_name", "dst_port", "persistent", "src_container", "src_process", "dst_container", "dst_process"},
+	)
+	K8sPacketRttDeviationSecondsMetric = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "k8s_packet_rtt_deviation_seconds",
+			Help:    "Kubernetes packet round-trip time mean deviation seconds",
+			Buckets: prometheus.ExponentialBuckets(0.00005, 2, 16),
+		},
+		[]string{"ns", "src", "src_name", "src_port", "dst", "dst_name", "dst_port", "persistent", "src_container", "src_process", "dst_container", "dst_process"},
 	)
 )
 
-func Init() {
-	sendTCPMetrics, _ := strconv.ParseBool(os.Getenv("K8S_PACKET_TCP_METRICS_ENABLED"))
-	if sendTCPMetrics {
-		prometheus.MustRegister(K8sPacketBytesSentMetric)
-		prometheus.MustRegister(K8sPacketBytesReceivedMetric)
-		prometheus.MustRegister(K8sPacketDurationSecondsMetric)
+// Configure registers or unregisters the metrics, it is safe to call it again on configuration reload
+func Configure(metrics config.NodegraphMetricsConfig) {
+	for _, collector := range []prometheus.Collector{K8sPacketBytesSentMetric, K8sPacketBytesReceivedMetric, K8sPacketDurationSecondsMetric, K8sPacketRttSecondsMetric, K8sPacketRttDeviationSecondsMetric, K8sPacketConnectionFailuresMetric} {
+		if metrics.Enabled {
+			_ = prometheus.Register(collector)
+		} else {
+			prometheus.Unregister(collector)
+		}
 	}
 }
@@ -0,0 +1,53 @@
+package stats
+
+import (
+	"fmt"
+	"github.com/k8spacket/k8spacket/internal/modules/nodegraph/model"
+)
+
+// edges get red above this share of failed attempts, yellow when any attempt failed
+const failureRateThreshold = 0.05
+
+type ErrorsStats struct {
+	Stats
+}
+
+func (errors *ErrorsStats) GetConfig() model.Config {
+	return model.Config{Arc1: model.DisplayConfig{DisplayName: "Established connections", Color: "green"},
+		Arc2:          model.DisplayConfig{DisplayName: "Failed attempts", Color: "red"},
+		MainStat:      model.DisplayConfig{DisplayName: "Failed attempts "},
+		SecondaryStat: model.DisplayConfig{DisplayName: "Failure rate "}}
+}
+
+func (errors *ErrorsStats) FillNodeStats(node *model.Node, connEndpoint model.ConnectionEndpoint) {
+	var attempts = connEndpoint.ConnCount + connEndpoint.ConnFailed
+	if attempts > 0 {
+		node.MainStat = fmt.Sprintf("failed: %d", connEndpoint.ConnFailed)
+		node.SecondaryStat = fmt.Sprintf("rate: %.1f%%", 100*float64(connEndpoint.ConnFailed)/float64(attempts))
+		node.Arc1 = float64(connEndpoint.ConnCount) / float64(attempts)
+		node.Arc2 = float64(connEndpoint.ConnFailed) / float64(attempts)
+	} else {
+		node.MainStat = fmt.Sprint("failed: N/A")
+		node.SecondaryStat = fmt.Sprint("rate: N/A")
+	}
+}
+
+func (errors *ErrorsStats) FillEdgeStats(edge *model.Edge, connItem model.ConnectionItem) {
+	var attempts = connItem.ConnCount + connItem.ConnFailed
+	if attempts > 0 {
+		var failureRate = float64(connItem.ConnFailed) / float64(attempts)
+		edge.MainStat = fmt.Sprintf("failed: %d", connItem.ConnFailed)
+		edge.SecondaryStat = fmt.Sprintf("rate: %.1f%%", 100*failureRate)
+		switch {
+		case failureRate >= failureRateThreshold:
+			edge.Color = "red"
+		case connItem.ConnFailed > 0:
+			edge.Color = "yellow"
+		default:
+			edge.Color = "green"
+		}
+	} else {
+		edge.MainStat = fmt.Sprint("failed: N/A")
+		edge.SecondaryStat = fmt.Sprint("rate: N/A")
+	}
+}
@@ -0,0 +1,74 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/k8spacket/k8spacket/internal/modules/nodegraph/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorsGetConfig(t *testing.T) {
+	want := model.Config{Arc1: model.DisplayConfig{DisplayName: "Established connections", Color: "green"},
+		Arc2:          model.DisplayConfig{DisplayName: "Failed attempts", Color: "red"},
+		MainStat:      model.DisplayConfig{DisplayName: "Failed attempts "},
+		SecondaryStat: model.DisplayConfig{DisplayName: "Failure rate "}}
+
+	errorsStats := &ErrorsStats{}
+
+	result := errorsStats.GetConfig()
+
+	assert.EqualValues(t, want, result)
+}
+
+func TestErrorsFillNodeStats(t *testing.T) {
+
+	var tests = []struct {
+		connectionEndpoint model.ConnectionEndpoint
+		want               *model.Node
+	}{
+		{model.ConnectionEndpoint{ConnCount: 3, ConnFailed: 1}, &model.Node{MainStat: "failed: 1", SecondaryStat: "rate: 25.0%", Arc1: 0.75, Arc2: 0.25}},
+		{model.ConnectionEndpoint{ConnFailed: 2}, &model.Node{MainStat: "failed: 2", SecondaryStat: "rate: 100.0%", Arc1: 0, Arc2: 1}},
+		{model.ConnectionEndpoint{}, &model.Node{MainStat: "failed: N/A", SecondaryStat: "rate: N/A"}},
+	}
+
+	errorsStats := &ErrorsStats{}
+
+	for _, test := range tests {
+		t.Run(test.want.SecondaryStat, func(t *testing.T) {
+			t.Parallel()
+
+			node := &model.Node{}
+			errorsStats.FillNodeStats(node, test.connectionEndpoint)
+
+			assert.EqualValues(t, test.want, node)
+		},
+		)
+	}
+}
+
+func TestErrorsFillEdgeStats(t *testing.T) {
+	var tests = []struct {
+		name           string
+		ConnectionItem model.ConnectionItem
+		want           *model.Edge
+	}{
+		{"healthy", model.ConnectionItem{ConnCount: 50}, &model.Edge{MainStat: "failed: 0", SecondaryStat: "rate: 0.0%", Color: "green"}},
+		{"some failures", model.ConnectionItem{ConnCount: 99, ConnFailed: 1}, &model.Edge{MainStat: "failed: 1", SecondaryStat: "rate: 1.0%", Color: "yellow"}},
+		{"service down", model.ConnectionItem{ConnFailed: 7}, &model.Edge{MainStat: "failed: 7", SecondaryStat: "rate: 100.0%", Color: "red"}},
+		{"no attempts", model.ConnectionItem{}, &model.Edge{MainStat: "failed: N/A", SecondaryStat: "rate: N/A"}},
+	}
+
+	errorsStats := &ErrorsStats{}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			edge := &model.Edge{}
+			errorsStats.FillEdgeStats(edge, test.ConnectionItem)
+
+			assert.EqualValues(t, test.want, edge)
+		},
+		)
+	}
+}
@@ -0,0 +1,46 @@
+package stats
+
+import (
+	"fmt"
+	"github.com/k8spacket/k8spacket/internal/modules/nodegraph/model"
+	"time"
+)
+
+type LatencyStats struct {
+	Stats
+}
+
+func (latency *LatencyStats) GetConfig() model.Config {
+	return model.Config{Arc1: model.DisplayConfig{DisplayName: "Average RTT", Color: "blue"},
+		Arc2:          model.DisplayConfig{DisplayName: "Max RTT", Color: "orange"},
+		MainStat:      model.DisplayConfig{DisplayName: "Average RTT "},
+		SecondaryStat: model.DisplayConfig{DisplayName: "Max RTT "}}
+}
+
+func (latency *LatencyStats) FillNodeStats(node *model.Node, connEndpoint model.ConnectionEndpoint) {
+	if connEndpoint.RttCount > 0 && connEndpoint.MaxRtt > 0 {
+		var rtt = connEndpoint.Rtt / float64(connEndpoint.RttCount)
+		node.MainStat = fmt.Sprintf("avg: %s", rttString(rtt))
+		node.SecondaryStat = fmt.Sprintf("max: %s", rttString(connEndpoint.MaxRtt))
+		node.Arc1 = rtt / connEndpoint.MaxRtt
+		node.Arc2 = (connEndpoint.MaxRtt - rtt) / connEndpoint.MaxRtt
+	} else {
+		node.MainStat = fmt.Sprint("avg: N/A")
+		node.SecondaryStat = fmt.Sprint("max: N/A")
+	}
+}
+
+func (latency *LatencyStats) FillEdgeStats(edge *model.Edge, connItem model.ConnectionItem) {
+	if connItem.RttCount > 0 && connItem.MaxRtt > 0 {
+		edge.MainStat = fmt.Sprintf("avg: %s", rttString(connItem.Rtt/float64(connItem.RttCount)))
+		edge.SecondaryStat = fmt.Sprintf("max: %s", rttString(connItem.MaxRtt))
+	} else {
+		edge.MainStat = fmt.Sprint("avg: N/A")
+		edge.SecondaryStat = fmt.Sprint("max: N/A")
+	}
+}
+
+// RTT is kept in milliseconds, the kernel measures it in microseconds
+func rttString(rtt float64) string {
+	return time.Duration(rtt * float64(time.Millisecond)).Round(time.Microsecond).String()
+}
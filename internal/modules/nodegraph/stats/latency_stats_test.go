@@ -0,0 +1,72 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/k8spacket/k8spacket/internal/modules/nodegraph/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatencyGetConfig(t *testing.T) {
+	want := model.Config{Arc1: model.DisplayConfig{DisplayName: "Average RTT", Color: "blue"},
+		Arc2:          model.DisplayConfig{DisplayName: "Max RTT", Color: "orange"},
+		MainStat:      model.DisplayConfig{DisplayName: "Average RTT "},
+		SecondaryStat: model.DisplayConfig{DisplayName: "Max RTT "}}
+
+	latencyStats := &LatencyStats{}
+
+	result := latencyStats.GetConfig()
+
+	assert.EqualValues(t, want, result)
+}
+
+func TestLatencyFillNodeStats(t *testing.T) {
+
+	var tests = []struct {
+		connectionEndpoint model.ConnectionEndpoint
+		want               *model.Node
+	}{
+		{model.ConnectionEndpoint{Rtt: 1, RttCount: 4, MaxRtt: 0.5}, &model.Node{MainStat: "avg: 250µs", SecondaryStat: "max: 500µs", Arc1: 0.5, Arc2: 0.5}},
+		{model.ConnectionEndpoint{Rtt: 30, RttCount: 2, MaxRtt: 20}, &model.Node{MainStat: "avg: 15ms", SecondaryStat: "max: 20ms", Arc1: 0.75, Arc2: 0.25}},
+		{model.ConnectionEndpoint{ConnCount: 3}, &model.Node{MainStat: "avg: N/A", SecondaryStat: "max: N/A"}},
+	}
+
+	latencyStats := &LatencyStats{}
+
+	for _, test := range tests {
+		t.Run(test.want.MainStat, func(t *testing.T) {
+			t.Parallel()
+
+			node := &model.Node{}
+			latencyStats.FillNodeStats(node, test.connectionEndpoint)
+
+			assert.EqualValues(t, test.want, node)
+		},
+		)
+	}
+}
+
+func TestLatencyFillEdgeStats(t *testing.T) {
+	var tests = []struct {
+		ConnectionItem model.ConnectionItem
+		want           *model.Edge
+	}{
+		{model.ConnectionItem{Rtt: 0.3, RttCount: 2, MaxRtt: 0.2}, &model.Edge{MainStat: "avg: 150µs", SecondaryStat: "max: 200µs"}},
+		{model.ConnectionItem{Rtt: 1.2345, RttCount: 1, MaxRtt: 1.2345}, &model.Edge{MainStat: "avg: 1.235ms", SecondaryStat: "max: 1.235ms"}},
+		{model.ConnectionItem{ConnCount: 3}, &model.Edge{MainStat: "avg: N/A", SecondaryStat: "max: N/A"}},
+	}
+
+	latencyStats := &LatencyStats{}
+
+	for _, test := range tests {
+		t.Run(test.want.MainStat, func(t *testing.T) {
+			t.Parallel()
+
+			edge := &model.Edge{}
+			latencyStats.FillEdgeStats(edge, test.ConnectionItem)
+
+			assert.EqualValues(t, test.want, edge)
+		},
+		)
+	}
+}
@@ -0,0 +1,55 @@
+package stats
+
+import (
+	"fmt"
+	"github.com/k8spacket/k8spacket/internal/modules/nodegraph/model"
+)
+
+// edges get red above these rates, yellow when any segment was retransmitted or connection reset
+const (
+	retransmitsPerConnectionThreshold = 1.0
+	resetRateThreshold                = 0.05
+)
+
+type ReliabilityStats struct {
+	Stats
+}
+
+func (reliability *ReliabilityStats) GetConfig() model.Config {
+	return model.Config{Arc1: model.DisplayConfig{DisplayName: "Closed gracefully", Color: "green"},
+		Arc2:          model.DisplayConfig{DisplayName: "Reset connections", Color: "red"},
+		MainStat:      model.DisplayConfig{DisplayName: "Retransmits per connection "},
+		SecondaryStat: model.DisplayConfig{DisplayName: "Reset connections "}}
+}
+
+func (reliability *ReliabilityStats) FillNodeStats(node *model.Node, connEndpoint model.ConnectionEndpoint) {
+	if connEndpoint.ConnCount > 0 {
+		node.MainStat = fmt.Sprintf("retrans: %.2f/conn", float64(connEndpoint.Retransmits)/float64(connEndpoint.ConnCount))
+		node.SecondaryStat = fmt.Sprintf("resets: %d", connEndpoint.ConnReset)
+		node.Arc1 = float64(connEndpoint.ConnCount-connEndpoint.ConnReset) / float64(connEndpoint.ConnCount)
+		node.Arc2 = float64(connEndpoint.ConnReset) / float64(connEndpoint.ConnCount)
+	} else {
+		node.MainStat = fmt.Sprint("retrans: N/A")
+		node.SecondaryStat = fmt.Sprint("resets: N/A")
+	}
+}
+
+func (reliability *ReliabilityStats) FillEdgeStats(edge *model.Edge, connItem model.ConnectionItem) {
+	if connItem.ConnCount > 0 {
+		var retransmitRate = float64(connItem.Retransmits) / float64(connItem.ConnCount)
+		var resetRate = float64(connItem.ConnReset) / float64(connItem.ConnCount)
+		edge.MainStat = fmt.Sprintf("retrans: %.2f/conn", retransmitRate)
+		edge.SecondaryStat = fmt.Sprintf("resets: %d", connItem.ConnReset)
+		switch {
+		case retransmitRate >= retransmitsPerConnectionThreshold || resetRate >= resetRateThreshold:
+			edge.Color = "red"
+		case connItem.Retransmits > 0 || connItem.ConnReset > 0:
+			edge.Color = "yellow"
+		default:
+			edge.Color = "green"
+		}
+	} else {
+		edge.MainStat = fmt.Sprint("retrans: N/A")
+		edge.SecondaryStat = fmt.Sprint("resets: N/A")
+	}
+}
@@ -0,0 +1,75 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/k8spacket/k8spacket/internal/modules/nodegraph/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReliabilityGetConfig(t *testing.T) {
+	want := model.Config{Arc1: model.DisplayConfig{DisplayName: "Closed gracefully", Color: "green"},
+		Arc2:          model.DisplayConfig{DisplayName: "Reset connections", Color: "red"},
+		MainStat:      model.DisplayConfig{DisplayName: "Retransmits per connection "},
+		SecondaryStat: model.DisplayConfig{DisplayName: "Reset connections "}}
+
+	reliabilityStats := &ReliabilityStats{}
+
+	result := reliabilityStats.GetConfig()
+
+	assert.EqualValues(t, want, result)
+}
+
+func TestReliabilityFillNodeStats(t *testing.T) {
+
+	var tests = []struct {
+		connectionEndpoint model.ConnectionEndpoint
+		want               *model.Node
+	}{
+		{model.ConnectionEndpoint{ConnCount: 4, Retransmits: 6, ConnReset: 1}, &model.Node{MainStat: "retrans: 1.50/conn", SecondaryStat: "resets: 1", Arc1: 0.75, Arc2: 0.25}},
+		{model.ConnectionEndpoint{ConnCount: 2}, &model.Node{MainStat: "retrans: 0.00/conn", SecondaryStat: "resets: 0", Arc1: 1, Arc2: 0}},
+		{model.ConnectionEndpoint{}, &model.Node{MainStat: "retrans: N/A", SecondaryStat: "resets: N/A"}},
+	}
+
+	reliabilityStats := &ReliabilityStats{}
+
+	for _, test := range tests {
+		t.Run(test.want.MainStat, func(t *testing.T) {
+			t.Parallel()
+
+			node := &model.Node{}
+			reliabilityStats.FillNodeStats(node, test.connectionEndpoint)
+
+			assert.EqualValues(t, test.want, node)
+		},
+		)
+	}
+}
+
+func TestReliabilityFillEdgeStats(t *testing.T) {
+	var tests = []struct {
+		name           string
+		ConnectionItem model.ConnectionItem
+		want           *model.Edge
+	}{
+		{"healthy", model.ConnectionItem{ConnCount: 100}, &model.Edge{MainStat: "retrans: 0.00/conn", SecondaryStat: "resets: 0", Color: "green"}},
+		{"some retransmits", model.ConnectionItem{ConnCount: 100, Retransmits: 10, ConnReset: 1}, &model.Edge{MainStat: "retrans: 0.10/conn", SecondaryStat: "resets: 1", Color: "yellow"}},
+		{"heavy retransmits", model.ConnectionItem{ConnCount: 10, Retransmits: 25}, &model.Edge{MainStat: "retrans: 2.50/conn", SecondaryStat: "resets: 0", Color: "red"}},
+		{"resets", model.ConnectionItem{ConnCount: 10, ConnReset: 1}, &model.Edge{MainStat: "retrans: 0.00/conn", SecondaryStat: "resets: 1", Color: "red"}},
+		{"no connections", model.ConnectionItem{}, &model.Edge{MainStat: "retrans: N/A", SecondaryStat: "resets: N/A"}},
+	}
+
+	reliabilityStats := &ReliabilityStats{}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			edge := &model.Edge{}
+			reliabilityStats.FillEdgeStats(edge, test.ConnectionItem)
+
+			assert.EqualValues(t, test.want, edge)
+		},
+		)
+	}
+}
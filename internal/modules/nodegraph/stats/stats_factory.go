@@ -9,6 +9,12 @@ func (factory *StatsFactory) GetStats(statsType string) Stats {
 		return &BytesStats{}
 	case "duration":
 		return &DurationStats{}
+	case "reliability":
+		return &ReliabilityStats{}
+	case "latency":
+		return &LatencyStats{}
+	case "errors":
+		return &ErrorsStats{}
 	default:
 		return &ConnectionStats{}
 	}
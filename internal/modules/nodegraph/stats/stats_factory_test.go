@@ -15,6 +15,9 @@ func TestGetStats(t *testing.T) {
 	}{
 		{"bytes", &BytesStats{}},
 		{"duration", &DurationStats{}},
+		{"reliability", &ReliabilityStats{}},
+		{"latency", &LatencyStats{}},
+		{"errors", &ErrorsStats{}},
 		{"connection", &ConnectionStats{}},
 		{"", &ConnectionStats{}},
 	}
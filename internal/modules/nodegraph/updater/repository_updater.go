@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/k8spacket/k8spacket/internal/modules"
 	"github.com/k8spacket/k8spacket/internal/modules/nodegraph/model"
 	"github.com/k8spacket/k8spacket/internal/modules/nodegraph/repository"
 	"github.com/k8spacket/k8spacket/internal/thirdparty/db"
@@ -20,29 +21,56 @@ func NewUpdater(repo repository.Repository[model.ConnectionItem]) *RepositoryUpd
 	return &RepositoryUpdater{repo: repo, lock: &sync.RWMutex{}}
 }
 
-func (updater *RepositoryUpdater) Update(src string, srcName string, srcNamespace string, dst string, dstName string, dstNamespace string, persistent bool, bytesSent float64, bytesReceived float64, duration float64, closed bool) {
-	var id = strconv.Itoa(int(db.HashId(fmt.Sprintf("%s-%s", src, dst))))
+func (updater *RepositoryUpdater) Update(event modules.TCPEvent, persistent bool) {
+	client, server := event.Client, event.Server
+	var id = strconv.Itoa(int(db.HashId(fmt.Sprintf("%s-%s", client.Addr, server.Addr))))
 	updater.lock.Lock()
 	defer updater.lock.Unlock()
 	var connection = updater.repo.Read(id)
 	if (model.ConnectionItem{} == connection) {
-		connection = *&model.ConnectionItem{Src: src, Dst: dst}
+		connection = *&model.ConnectionItem{Src: client.Addr, Dst: server.Addr}
 	}
-	connection.SrcName = srcName
-	connection.SrcNamespace = srcNamespace
-	connection.DstName = dstName
-	connection.DstNamespace = dstNamespace
-	if closed {
+	connection.SrcName = client.Name
+	connection.SrcNamespace = client.Namespace
+	connection.DstName = server.Name
+	connection.DstNamespace = server.Namespace
+	// every node sees processes of its own sockets only, the other end keeps what was seen last
+	if client.Process != "" || client.Container != "" {
+		connection.SrcContainer = client.Container
+		connection.SrcProcess = client.Process
+	}
+	if server.Process != "" || server.Container != "" {
+		connection.DstContainer = server.Container
+		connection.DstProcess = server.Process
+	}
+	if event.Closed && event.Failed {
+		// refused or timed out attempts are kept apart from connections
+		connection.ConnFailed++
+	} else if event.Closed {
 		connection.ConnCount++
 		if persistent {
 			connection.ConnPersistent++
 		}
-		connection.BytesSent += bytesSent
-		connection.BytesReceived += bytesReceived
+		connection.BytesSent += float64(event.TxB)
+		connection.BytesReceived += float64(event.RxB)
+		var duration = float64(event.DeltaUs)
 		connection.Duration += duration
 		if duration > connection.MaxDuration {
 			connection.MaxDuration = duration
 		}
+		// connections without any RTT sample don't count in the average
+		var rtt = float64(event.SrttUs) / 1000
+		if rtt > 0 {
+			connection.Rtt += rtt
+			connection.RttCount++
+			if rtt > connection.MaxRtt {
+				connection.MaxRtt = rtt
+			}
+		}
+		connection.Retransmits += int64(event.Retransmits)
+		if event.Reset {
+			connection.ConnReset++
+		}
 	}
 	connection.LastSeen = time.Now()
 	updater.repo.Set(id, &connection)
@@ -1,6 +1,7 @@
 package updater
 
 import (
+	"github.com/k8spacket/k8spacket/internal/modules"
 	"github.com/k8spacket/k8spacket/internal/modules/nodegraph/model"
 	"github.com/k8spacket/k8spacket/internal/modules/nodegraph/repository"
 	"github.com/stretchr/testify/assert"
@@ -25,10 +26,10 @@ func TestUpdate(t *testing.T) {
 		item model.ConnectionItem
 		want model.ConnectionItem
 	}{
-		{model.ConnectionItem{Src: "src", Dst: "dst", ConnCount: 10, ConnPersistent: 5, BytesReceived: 1000, BytesSent: 500, Duration: 0.5, MaxDuration: 0.5},
-			model.ConnectionItem{Src: "src", SrcName: "srcName", SrcNamespace: "srcNs", Dst: "dst", DstName: "dstName", DstNamespace: "dstNs", ConnCount: 11, ConnPersistent: 6, BytesSent: 600, BytesReceived: 1200, Duration: 1.5, MaxDuration: 1}},
+		{model.ConnectionItem{Src: "src", Dst: "dst", ConnCount: 10, ConnPersistent: 5, BytesReceived: 1000, BytesSent: 500, Duration: 0.5, MaxDuration: 0.5, Retransmits: 4, ConnReset: 1, Rtt: 0.5, MaxRtt: 0.5, RttCount: 2},
+			model.ConnectionItem{Src: "src", SrcName: "srcName", SrcNamespace: "srcNs", Dst: "dst", DstName: "dstName", DstNamespace: "dstNs", ConnCount: 11, ConnPersistent: 6, BytesSent: 600, BytesReceived: 1200, Duration: 1.5, MaxDuration: 1, Retransmits: 7, ConnReset: 2, Rtt: 0.75, MaxRtt: 0.5, RttCount: 3, DstContainer: "db", DstProcess: "postgres"}},
 		{model.ConnectionItem{},
-			model.ConnectionItem{Src: "src", SrcName: "srcName", SrcNamespace: "srcNs", Dst: "dst", DstName: "dstName", DstNamespace: "dstNs", ConnCount: 1, ConnPersistent: 1, BytesSent: 100, BytesReceived: 200, Duration: 1, MaxDuration: 1}},
+			model.ConnectionItem{Src: "src", SrcName: "srcName", SrcNamespace: "srcNs", Dst: "dst", DstName: "dstName", DstNamespace: "dstNs", ConnCount: 1, ConnPersistent: 1, BytesSent: 100, BytesReceived: 200, Duration: 1, MaxDuration: 1, Retransmits: 3, ConnReset: 1, Rtt: 0.25, MaxRtt: 0.25, RttCount: 1, DstContainer: "db", DstProcess: "postgres"}},
 	}
 
 	for _, test := range tests {
@@ -37,7 +38,8 @@ func TestUpdate(t *testing.T) {
 			mockRepository := &mockRepository{result: test.item}
 			updater := NewUpdater(mockRepository)
 
-			updater.Update("src", "srcName", "srcNs", "dst", "dstName", "dstNs", true, 100, 200, 1, true)
+			updater.Update(modules.TCPEvent{Client: modules.Address{Addr: "src", Name: "srcName", Namespace: "srcNs"}, Server: modules.Address{Addr: "dst", Name: "dstName", Namespace: "dstNs", Container: "db", Pid: 7, Process: "postgres"},
+				TxB: 100, RxB: 200, DeltaUs: 1, SrttUs: 250, Retransmits: 3, Reset: true, Closed: true}, true)
 
 			result := mockRepository.Read("")
 
@@ -46,3 +48,17 @@ func TestUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateFailed(t *testing.T) {
+
+	mockRepository := &mockRepository{result: model.ConnectionItem{Src: "src", Dst: "dst", ConnCount: 2, Duration: 4, MaxDuration: 3, ConnFailed: 1, DstContainer: "db", DstProcess: "postgres"}}
+	updater := NewUpdater(mockRepository)
+
+	updater.Update(modules.TCPEvent{Client: modules.Address{Addr: "src", Name: "srcName", Namespace: "srcNs", Container: "app", Process: "curl"}, Server: modules.Address{Addr: "dst", Name: "dstName", Namespace: "dstNs"},
+		DeltaUs: 1000, Retransmits: 2, Reset: true, Failed: true, Closed: true}, false)
+
+	result := mockRepository.Read("")
+
+	want := model.ConnectionItem{Src: "src", SrcName: "srcName", SrcNamespace: "srcNs", Dst: "dst", DstName: "dstName", DstNamespace: "dstNs", ConnCount: 2, Duration: 4, MaxDuration: 3, ConnFailed: 2, SrcContainer: "app", SrcProcess: "curl", DstContainer: "db", DstProcess: "postgres", LastSeen: result.LastSeen}
+	assert.EqualValues(t, want, result)
+}
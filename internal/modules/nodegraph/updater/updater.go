@@ -1,5 +1,7 @@
 package updater
 
+import "github.com/k8spacket/k8spacket/internal/modules"
+
 type Updater interface {
-	Update(src string, srcName string, srcNamespace string, dst string, dstName string, dstNamespace string, persistent bool, bytesSent float64, bytesReceived float64, duration float64, closed bool)
+	Update(event modules.TCPEvent, persistent bool)
 }
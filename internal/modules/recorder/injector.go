@@ -0,0 +1,85 @@
+package recorder
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/k8spacket/k8spacket/internal/recording"
+)
+
+// Module writes every TCP and TLS event published by the broker to a recording, which can be fed back with the replay loader source
+type Module struct {
+	broker        modules.Broker
+	writer        recording.Writer
+	subscriptions []modules.Subscription
+}
+
+func NewModule() *Module {
+	return &Module{}
+}
+
+func (module *Module) Name() string {
+	return "recorder"
+}
+
+func (module *Module) Init(_ *http.ServeMux, broker modules.Broker, store *config.Store) error {
+	cfg := store.Get().Recorder
+	// a recording is appended to, so restarts do not overwrite events recorded before
+	file, err := os.OpenFile(cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	writer, err := recording.NewWriter(file, cfg.Format)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	slog.Info("[recorder] Recording events", "Path", cfg.Path, "Format", cfg.Format)
+
+	module.broker = broker
+	module.writer = writer
+	return nil
+}
+
+func (module *Module) Start() error {
+	tcp, err := module.broker.SubscribeTCP(module.Name(), &listener[modules.TCPEvent]{writer: module.writer, record: func(event *modules.TCPEvent) recording.Record {
+		return recording.Record{Time: event.Time, TCP: event}
+	}})
+	if err != nil {
+		return err
+	}
+	module.subscriptions = append(module.subscriptions, tcp)
+	tls, err := module.broker.SubscribeTLS(module.Name(), &listener[modules.TLSEvent]{writer: module.writer, record: func(event *modules.TLSEvent) recording.Record {
+		return recording.Record{Time: event.Time, TLS: event}
+	}})
+	if err != nil {
+		return err
+	}
+	module.subscriptions = append(module.subscriptions, tls)
+	return nil
+}
+
+func (module *Module) Stop(_ context.Context) error {
+	for _, subscription := range module.subscriptions {
+		subscription.Unsubscribe()
+	}
+	if module.writer == nil {
+		return nil
+	}
+	return module.writer.Close()
+}
+
+type listener[T modules.TCPEvent | modules.TLSEvent] struct {
+	writer recording.Writer
+	record func(event *T) recording.Record
+}
+
+func (listener *listener[T]) Listen(event T) {
+	if err := listener.writer.Write(listener.record(&event)); err != nil {
+		slog.Error("[recorder] Cannot record event", "Error", err)
+	}
+}
@@ -0,0 +1,78 @@
+package recorder
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/broker"
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/k8spacket/k8spacket/internal/recording"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModule(t *testing.T) {
+
+	for _, format := range []string{recording.JSONL, recording.Protobuf} {
+		t.Run(format, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.Recorder = config.RecorderConfig{Path: filepath.Join(t.TempDir(), "events"), Format: format}
+			store := config.NewStore(cfg)
+			distributionBroker := broker.Init(store)
+
+			module := NewModule()
+
+			assert.EqualValues(t, "recorder", module.Name())
+			assert.NoError(t, module.Init(http.NewServeMux(), distributionBroker, store))
+			assert.NoError(t, module.Start())
+
+			distributionBroker.DistributeEvents()
+			captured := time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC)
+			distributionBroker.TCPEvent(modules.TCPEvent{Client: modules.Address{Addr: "10.0.0.1", Port: 1234}, TxB: 10, Time: captured})
+			distributionBroker.TLSEvent(modules.TLSEvent{Source: modules.SocketFilter, ServerName: "example.com", Ciphers: []uint16{4865}})
+			assert.NoError(t, distributionBroker.Stop(context.Background()))
+			assert.NoError(t, module.Stop(context.Background()))
+
+			file, err := os.Open(cfg.Recorder.Path)
+			assert.NoError(t, err)
+			defer file.Close()
+			reader, err := recording.NewReader(file, format)
+			assert.NoError(t, err)
+
+			var tcp, tls []recording.Record
+			for {
+				record, err := reader.Read()
+				if err == io.EOF {
+					break
+				}
+				assert.NoError(t, err)
+				if record.TCP != nil {
+					tcp = append(tcp, record)
+				} else {
+					tls = append(tls, record)
+				}
+			}
+			assert.Len(t, tcp, 1)
+			assert.Len(t, tls, 1)
+			assert.EqualValues(t, "10.0.0.1", tcp[0].TCP.Client.Addr)
+			// records keep the time of capture, not the time the event reached the recorder
+			assert.True(t, captured.Equal(tcp[0].Time))
+			assert.EqualValues(t, 10, tcp[0].TCP.TxB)
+			assert.EqualValues(t, "example.com", tls[0].TLS.ServerName)
+			assert.EqualValues(t, []uint16{4865}, tls[0].TLS.Ciphers)
+		})
+	}
+}
+
+func TestModuleInitError(t *testing.T) {
+
+	cfg := config.Default()
+	cfg.Recorder = config.RecorderConfig{Path: filepath.Join(t.TempDir(), "missing", "events"), Format: recording.JSONL}
+
+	assert.Error(t, NewModule().Init(http.NewServeMux(), nil, config.NewStore(cfg)))
+}
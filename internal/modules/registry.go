@@ -0,0 +1,71 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/k8spacket/k8spacket/internal/config"
+)
+
+// Registry knows every available module and runs the ones enabled by configuration
+type Registry struct {
+	modules []Module
+	enabled []Module
+}
+
+func NewRegistry(modules ...Module) *Registry {
+	return &Registry{modules: modules}
+}
+
+func (registry *Registry) Init(mux *http.ServeMux, broker Broker, store *config.Store) error {
+	enabled := store.Get().Modules.Enabled
+	for _, name := range enabled {
+		if !slices.ContainsFunc(registry.modules, func(module Module) bool { return module.Name() == name }) {
+			return fmt.Errorf("unknown module %q", name)
+		}
+	}
+	for _, module := range registry.modules {
+		if !slices.Contains(enabled, module.Name()) {
+			slog.Info("[modules] Module disabled", "Module", module.Name())
+			continue
+		}
+		if err := module.Init(mux, broker, store); err != nil {
+			return fmt.Errorf("cannot init module %s: %w", module.Name(), err)
+		}
+		registry.enabled = append(registry.enabled, module)
+		slog.Info("[modules] Module initialized", "Module", module.Name())
+	}
+	return nil
+}
+
+func (registry *Registry) Start() error {
+	for _, module := range registry.enabled {
+		if err := module.Start(); err != nil {
+			return fmt.Errorf("cannot start module %s: %w", module.Name(), err)
+		}
+	}
+	return nil
+}
+
+// Stop stops enabled modules in reverse order and reports all failures
+func (registry *Registry) Stop(ctx context.Context) error {
+	var errs []error
+	for _, module := range slices.Backward(registry.enabled) {
+		if err := module.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("cannot stop module %s: %w", module.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (registry *Registry) Enabled() []string {
+	var names []string
+	for _, module := range registry.enabled {
+		names = append(names, module.Name())
+	}
+	return names
+}
@@ -0,0 +1,67 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockModule struct {
+	Module
+	name    string
+	calls   *[]string
+	stopErr error
+}
+
+func (mockModule *mockModule) Name() string {
+	return mockModule.name
+}
+
+func (mockModule *mockModule) Init(mux *http.ServeMux, broker Broker, store *config.Store) error {
+	*mockModule.calls = append(*mockModule.calls, "init "+mockModule.name)
+	return nil
+}
+
+func (mockModule *mockModule) Start() error {
+	*mockModule.calls = append(*mockModule.calls, "start "+mockModule.name)
+	return nil
+}
+
+func (mockModule *mockModule) Stop(ctx context.Context) error {
+	*mockModule.calls = append(*mockModule.calls, "stop "+mockModule.name)
+	return mockModule.stopErr
+}
+
+func TestRegistry(t *testing.T) {
+
+	var calls []string
+	first := &mockModule{name: "first", calls: &calls, stopErr: errors.New("db closed")}
+	second := &mockModule{name: "second", calls: &calls}
+	third := &mockModule{name: "third", calls: &calls}
+
+	cfg := config.Default()
+	cfg.Modules.Enabled = []string{"third", "first"}
+
+	registry := NewRegistry(first, second, third)
+
+	assert.NoError(t, registry.Init(http.NewServeMux(), nil, config.NewStore(cfg)))
+	assert.EqualValues(t, []string{"first", "third"}, registry.Enabled())
+	assert.NoError(t, registry.Start())
+	assert.ErrorContains(t, registry.Stop(context.Background()), "cannot stop module first: db closed")
+
+	assert.EqualValues(t, []string{"init first", "init third", "start first", "start third", "stop third", "stop first"}, calls)
+}
+
+func TestRegistryUnknownModule(t *testing.T) {
+
+	cfg := config.Default()
+	cfg.Modules.Enabled = []string{"dns"}
+
+	registry := NewRegistry()
+
+	assert.ErrorContains(t, registry.Init(http.NewServeMux(), nil, config.NewStore(cfg)), "unknown module \"dns\"")
+}
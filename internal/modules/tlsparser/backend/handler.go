@@ -74,6 +74,9 @@ func (handler *Handler) filterConnections(query url.Values) []model.TLSConnectio
 		}
 	}
 
-	slog.Info("[api:params]", "from", rangeFrom, "to", rangeTo)
-	return handler.repo.Query(rangeFrom, rangeTo)
+	ja3 := query.Get("ja3")
+	ja4 := query.Get("ja4")
+
+	slog.Info("[api:params]", "from", rangeFrom, "to", rangeTo, "ja3", ja3, "ja4", ja4)
+	return handler.repo.Query(rangeFrom, rangeTo, ja3, ja4)
 }
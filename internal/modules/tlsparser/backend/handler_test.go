@@ -28,12 +28,15 @@ type mockRepository struct {
 	resultConnection model.TLSConnection
 	resultDetails    model.TLSDetails
 	from, to         time.Time
+	ja3, ja4         string
 	scenario         string
 }
 
-func (mockRepository *mockRepository) Query(from time.Time, to time.Time) []model.TLSConnection {
+func (mockRepository *mockRepository) Query(from time.Time, to time.Time, ja3 string, ja4 string) []model.TLSConnection {
 	mockRepository.from = from
 	mockRepository.to = to
+	mockRepository.ja3 = ja3
+	mockRepository.ja4 = ja4
 	return dbState
 }
 
@@ -78,12 +81,14 @@ func TestTLSConnectionHandler_FilterConnections(t *testing.T) {
 
 	var tests = []struct {
 		scenario, from, to string
+		ja3, ja4           string
 		wantFrom, wantTo   time.Time
 		error              string
 	}{
-		{"correct", "1640998861000", "1675303322000", time.Time(time.Date(2022, time.January, 1, 1, 1, 1, 0, time.UTC)), time.Time(time.Date(2023, time.February, 2, 2, 2, 2, 0, time.UTC)), ""},
-		{"wrong from", "wrong from", "1675303322000", time.Time{}, time.Time(time.Date(2023, time.February, 2, 2, 2, 2, 0, time.UTC)), "[api] cannot parse value"},
-		{"wrong to", "1640998861000", "wrong to", time.Time(time.Date(2022, time.January, 1, 1, 1, 1, 0, time.UTC)), time.Time{}, "[api] cannot parse value"},
+		{"correct", "1640998861000", "1675303322000", "", "", time.Time(time.Date(2022, time.January, 1, 1, 1, 1, 0, time.UTC)), time.Time(time.Date(2023, time.February, 2, 2, 2, 2, 0, time.UTC)), ""},
+		{"fingerprints", "1640998861000", "1675303322000", "e5ba5d2d8d4d9e2b1a2a13f6e7f3a6b0", "t13d", time.Time(time.Date(2022, time.January, 1, 1, 1, 1, 0, time.UTC)), time.Time(time.Date(2023, time.February, 2, 2, 2, 2, 0, time.UTC)), ""},
+		{"wrong from", "wrong from", "1675303322000", "", "", time.Time{}, time.Time(time.Date(2023, time.February, 2, 2, 2, 2, 0, time.UTC)), "[api] cannot parse value"},
+		{"wrong to", "1640998861000", "wrong to", "", "", time.Time(time.Date(2022, time.January, 1, 1, 1, 1, 0, time.UTC)), time.Time{}, "[api] cannot parse value"},
 	}
 
 	mockRepository := &mockRepository{}
@@ -96,7 +101,7 @@ func TestTLSConnectionHandler_FilterConnections(t *testing.T) {
 			query.Add("from", test.from)
 			query.Add("to", test.to)
 
-			req, err := http.NewRequest("GET", fmt.Sprintf("/tlsparser/connections/?from=%s&to=%s", test.from, test.to), nil)
+			req, err := http.NewRequest("GET", fmt.Sprintf("/tlsparser/connections/?from=%s&to=%s&ja3=%s&ja4=%s", test.from, test.to, test.ja3, test.ja4), nil)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -110,6 +115,8 @@ func TestTLSConnectionHandler_FilterConnections(t *testing.T) {
 
 			assert.EqualValues(t, test.wantFrom, mockRepository.from)
 			assert.EqualValues(t, test.wantTo, mockRepository.to)
+			assert.EqualValues(t, test.ja3, mockRepository.ja3)
+			assert.EqualValues(t, test.ja4, mockRepository.ja4)
 			assert.Contains(t, str.String(), test.error)
 
 		})
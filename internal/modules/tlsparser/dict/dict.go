@@ -8,6 +8,25 @@ func ParseCipherSuite(cipherSuite uint16) string {
 	return cipherSuites[cipherSuite]
 }
 
+// TLSVersionCode is the reverse of ParseTLSVersion
+func TLSVersionCode(name string) (uint16, bool) {
+	return code(tlsVersions, name)
+}
+
+// CipherSuiteCode is the reverse of ParseCipherSuite
+func CipherSuiteCode(name string) (uint16, bool) {
+	return code(cipherSuites, name)
+}
+
+func code(dict map[uint16]string, name string) (uint16, bool) {
+	for code, value := range dict {
+		if value == name {
+			return code, true
+		}
+	}
+	return 0, false
+}
+
 var tlsVersions = map[uint16]string{
 	0x0300: "SSL 3.0",
 	0x0301: "TLS 1.0",
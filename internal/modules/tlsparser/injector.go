@@ -1,10 +1,14 @@
 package tlsparser
 
 import (
+	"context"
+	"errors"
+	"github.com/k8spacket/k8spacket/internal/config"
 	"github.com/k8spacket/k8spacket/internal/modules/tlsparser/backend"
 	"github.com/k8spacket/k8spacket/internal/modules/tlsparser/listener"
 	"github.com/k8spacket/k8spacket/internal/modules/tlsparser/o11y"
 	"github.com/k8spacket/k8spacket/internal/modules/tlsparser/storer"
+	"io"
 	"net/http"
 
 	"github.com/k8spacket/k8spacket/internal/modules"
@@ -18,24 +22,69 @@ import (
 	"github.com/k8spacket/k8spacket/internal/thirdparty/network"
 )
 
-func Init(mux *http.ServeMux) modules.Listener[modules.TLSEvent] {
+type Module struct {
+	broker       modules.Broker
+	dbs          []io.Closer
+	listener     modules.Listener[modules.TLSEvent]
+	subscription modules.Subscription
+}
+
+func NewModule() *Module {
+	return &Module{}
+}
+
+func (module *Module) Name() string {
+	return "tlsparser"
+}
 
-	prometheus.Init()
+func (module *Module) Init(mux *http.ServeMux, broker modules.Broker, store *config.Store) error {
 
-	handlerConnections, _ := db.New[model.TLSConnection]("tls_connections")
-	handlerDetails, _ := db.New[model.TLSDetails]("tls_details")
+	prometheus.Configure(store.Get().TlsParser.Metrics)
+	store.OnChange(func(cfg *config.Config) {
+		prometheus.Configure(cfg.TlsParser.Metrics)
+	})
+
+	handlerConnections, err := db.New[model.TLSConnection]("tls_connections")
+	if err != nil {
+		return err
+	}
+	handlerDetails, err := db.New[model.TLSDetails]("tls_details")
+	if err != nil {
+		return errors.Join(err, handlerConnections.Close())
+	}
 	repo := repository.NewDbRepository(handlerConnections, handlerDetails)
-	cert := update.NewUpdater(&network.HttpConnectionInspector{})
+	cert := update.NewUpdater(&network.HttpConnectionInspector{}, store)
 	handler := backend.NewHandler(repo)
-	o11yHandler := o11y.NewO11yHandler(&httpclient.HttpClient{}, &k8sclient.K8SClient{})
+	o11yHandler := o11y.NewO11yHandler(&httpclient.HttpClient{}, &k8sclient.K8SClient{}, store)
 
 	mux.HandleFunc("/tlsparser/connections/", handler.TLSConnectionHandler)
 	mux.HandleFunc("/tlsparser/api/data", o11yHandler.TLSParserConnectionsHandler)
 	mux.HandleFunc("/tlsparser/api/data/", o11yHandler.TLSParserConnectionDetailsHandler)
 
 	repositoryStorer := storer.NewStorer(repo, cert)
-	tlsListener := listener.NewListener(repositoryStorer)
 
-	return tlsListener
+	module.broker = broker
+	module.dbs = []io.Closer{handlerConnections, handlerDetails}
+	module.listener = listener.NewListener(repositoryStorer, store)
+	return nil
+}
+
+func (module *Module) Start() error {
+	subscription, err := module.broker.SubscribeTLS(module.Name(), module.listener)
+	if err != nil {
+		return err
+	}
+	module.subscription = subscription
+	return nil
+}
 
+func (module *Module) Stop(_ context.Context) error {
+	if module.subscription != nil {
+		module.subscription.Unsubscribe()
+	}
+	var errs []error
+	for _, db := range module.dbs {
+		errs = append(errs, db.Close())
+	}
+	return errors.Join(errs...)
 }
@@ -1,19 +1,34 @@
 package tlsparser
 
 import (
+	"context"
 	"net/http"
-	"os"
 	"testing"
 
+	"github.com/k8spacket/k8spacket/internal/broker"
+	"github.com/k8spacket/k8spacket/internal/config"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestInit(t *testing.T) {
+func TestModule(t *testing.T) {
 
-	os.Setenv("K8S_PACKET_TLS_METRICS_ENABLED", "true")
+	cfg := config.Default()
+	cfg.TlsParser.Metrics.RecordsEnabled = true
+	cfg.TlsParser.Metrics.ExpirationEnabled = true
+	store := config.NewStore(cfg)
+	distributionBroker := broker.Init(store)
 
-	listener := Init(http.NewServeMux())
+	module := NewModule()
 
-	assert.NotEmpty(t, listener)
+	assert.EqualValues(t, "tlsparser", module.Name())
+	assert.NoError(t, module.Init(http.NewServeMux(), distributionBroker, store))
+	assert.NotEmpty(t, module.listener)
 
+	assert.NoError(t, module.Start())
+	_, err := distributionBroker.SubscribeTLS("tlsparser", module.listener)
+	assert.Error(t, err)
+
+	assert.NoError(t, module.Stop(context.Background()))
+	_, err = distributionBroker.SubscribeTLS("tlsparser", module.listener)
+	assert.NoError(t, err)
 }
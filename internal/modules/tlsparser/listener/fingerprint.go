@@ -0,0 +1,134 @@
+package listener
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/k8spacket/k8spacket/internal/modules"
+)
+
+const (
+	serverNameExtension = 0x0000
+	alpnExtension       = 0x0010
+)
+
+// GREASE values (RFC 8701) are random per connection and left out of fingerprints
+func isGrease(value uint16) bool {
+	return value&0x0f0f == 0x0a0a && value>>8 == value&0xff
+}
+
+func withoutGrease(values []uint16) []uint16 {
+	return slices.DeleteFunc(slices.Clone(values), isGrease)
+}
+
+// ja3 is the MD5 of the ClientHello version, ciphers, extensions, supported groups and point formats
+func ja3(tlsEvent modules.TLSEvent) string {
+	if len(tlsEvent.Ciphers) <= 0 {
+		return ""
+	}
+	decimals := func(values []uint16) string {
+		var fields []string
+		for _, value := range values {
+			fields = append(fields, strconv.Itoa(int(value)))
+		}
+		return strings.Join(fields, "-")
+	}
+	var pointFormats []uint16
+	for _, pointFormat := range tlsEvent.PointFormats {
+		pointFormats = append(pointFormats, uint16(pointFormat))
+	}
+	fingerprint := strings.Join([]string{
+		strconv.Itoa(int(tlsEvent.HelloVersion)),
+		decimals(withoutGrease(tlsEvent.Ciphers)),
+		decimals(withoutGrease(tlsEvent.Extensions)),
+		decimals(withoutGrease(tlsEvent.SupportedGroups)),
+		decimals(pointFormats)}, ",")
+	hash := md5.Sum([]byte(fingerprint))
+	return hex.EncodeToString(hash[:])
+}
+
+// ja4 describes the client in a readable prefix (protocol, version, SNI, counts, ALPN) followed by truncated SHA256 of
+// sorted ciphers and of sorted extensions with signature algorithms
+func ja4(tlsEvent modules.TLSEvent) string {
+	if len(tlsEvent.Ciphers) <= 0 {
+		return ""
+	}
+	ciphers := withoutGrease(tlsEvent.Ciphers)
+	extensions := withoutGrease(tlsEvent.Extensions)
+
+	sni := "i"
+	if slices.Contains(extensions, serverNameExtension) || tlsEvent.ServerName != "" {
+		sni = "d"
+	}
+	prefix := fmt.Sprintf("t%s%s%02d%02d%s", ja4Version(tlsEvent.TlsVersions), sni, min(len(ciphers), 99), min(len(extensions), 99), ja4Alpn(tlsEvent.Alpn))
+
+	slices.Sort(ciphers)
+	hashedExtensions := slices.DeleteFunc(extensions, func(extension uint16) bool {
+		return extension == serverNameExtension || extension == alpnExtension
+	})
+	slices.Sort(hashedExtensions)
+	extensionsField := hexList(hashedExtensions)
+	if sigAlgs := withoutGrease(tlsEvent.SignatureAlgorithms); len(sigAlgs) > 0 {
+		extensionsField += "_" + hexList(sigAlgs)
+	}
+	return prefix + "_" + truncatedHash(hexList(ciphers), len(ciphers)) + "_" + truncatedHash(extensionsField, len(hashedExtensions))
+}
+
+func ja4Version(versions []uint16) string {
+	version := uint16(0)
+	if versions := withoutGrease(versions); len(versions) > 0 {
+		version = slices.Max(versions)
+	}
+	switch version {
+	case 0x0304:
+		return "13"
+	case 0x0303:
+		return "12"
+	case 0x0302:
+		return "11"
+	case 0x0301:
+		return "10"
+	case 0x0300:
+		return "s3"
+	default:
+		return "00"
+	}
+}
+
+// first and last characters of the first protocol, in hex when they are not alphanumeric
+func ja4Alpn(alpn []string) string {
+	if len(alpn) <= 0 || alpn[0] == "" {
+		return "00"
+	}
+	protocol := alpn[0]
+	first, last := protocol[0], protocol[len(protocol)-1]
+	if !isAlphanumeric(first) || !isAlphanumeric(last) {
+		return hex.EncodeToString([]byte{first})[:1] + hex.EncodeToString([]byte{last})[1:]
+	}
+	return string([]byte{first, last})
+}
+
+func isAlphanumeric(b byte) bool {
+	return b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+func hexList(values []uint16) string {
+	var fields []string
+	for _, value := range values {
+		fields = append(fields, fmt.Sprintf("%04x", value))
+	}
+	return strings.Join(fields, ",")
+}
+
+func truncatedHash(value string, count int) string {
+	if count <= 0 {
+		return "000000000000"
+	}
+	hash := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(hash[:])[:12]
+}
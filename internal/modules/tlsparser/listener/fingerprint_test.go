@@ -0,0 +1,65 @@
+package listener
+
+import (
+	"testing"
+
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/stretchr/testify/assert"
+)
+
+// ClientHello of Chrome in the JA4 specification, with GREASE values
+var chrome = modules.TLSEvent{
+	ServerName:   "example.com",
+	TlsVersions:  []uint16{0x3a3a, 0x0304, 0x0303},
+	HelloVersion: 0x0303,
+	Ciphers: []uint16{0x2a2a, 0x1301, 0x1302, 0x1303, 0xc02b, 0xc02f, 0xc02c, 0xc030, 0xcca9, 0xcca8, 0xc013, 0xc014, 0x009c,
+		0x009d, 0x002f, 0x0035},
+	Extensions: []uint16{0x4a4a, 0x0000, 0x0017, 0xff01, 0x000a, 0x000b, 0x0023, 0x0010, 0x0005, 0x000d, 0x0012, 0x0033, 0x002d,
+		0x002b, 0x001b, 0x4469, 0x0015},
+	SignatureAlgorithms: []uint16{0x0403, 0x0804, 0x0401, 0x0503, 0x0805, 0x0501, 0x0806, 0x0601},
+	Alpn:                []string{"h2", "http/1.1"},
+}
+
+func TestJA3(t *testing.T) {
+	var tests = []struct {
+		name  string
+		event modules.TLSEvent
+		want  string
+	}{
+		// example of the JA3 specification
+		{"legacy client", modules.TLSEvent{HelloVersion: 0x0301, Ciphers: []uint16{47, 53, 5, 10, 49161, 49162, 49171, 49172, 50, 56, 19, 4},
+			Extensions: []uint16{0x0a0a, 0, 10, 11}, SupportedGroups: []uint16{0x1a1a, 23, 24, 25}, PointFormats: []uint8{0}}, "ada70206e40642a3e4461f35503241d5"},
+		{"no ClientHello", modules.TLSEvent{UsedCipher: 0x1301}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.EqualValues(t, test.want, ja3(test.event))
+		})
+	}
+}
+
+func TestJA4(t *testing.T) {
+	noSni := chrome
+	noSni.ServerName = ""
+	noSni.Extensions = chrome.Extensions[2:]
+	noSni.Alpn = []string{"\x01http"}
+	legacy := modules.TLSEvent{TlsVersions: []uint16{0x0301}, Ciphers: []uint16{0x002f}}
+
+	var tests = []struct {
+		name  string
+		event modules.TLSEvent
+		want  string
+	}{
+		{"chrome", chrome, "t13d1516h2_8daaf6152771_e5627efa2ab1"},
+		{"without SNI and non alphanumeric ALPN", noSni, "t13i151500_8daaf6152771_e5627efa2ab1"},
+		{"without extensions", legacy, "t10i010000_ba72b8082249_000000000000"},
+		{"no ClientHello", modules.TLSEvent{UsedCipher: 0x1301}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.EqualValues(t, test.want, ja4(test.event))
+		})
+	}
+}
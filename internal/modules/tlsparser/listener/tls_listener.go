@@ -3,10 +3,10 @@ package listener
 import (
 	"encoding/json"
 	"log/slog"
-	"os"
 	"strconv"
 	"time"
 
+	"github.com/k8spacket/k8spacket/internal/config"
 	"github.com/k8spacket/k8spacket/internal/modules/tlsparser/storer"
 
 	"github.com/k8spacket/k8spacket/internal/modules"
@@ -16,18 +16,12 @@ import (
 )
 
 type TlsListener struct {
-	storer                      storer.Storer
-	tlsRecordsMeticsEnabled     bool
-	tlsExpirationMetricsEnabled bool
+	storer storer.Storer
+	store  *config.Store
 }
 
-func NewListener(storer storer.Storer) modules.Listener[modules.TLSEvent] {
-	tlsRecordsMeticsEnabled, _ := strconv.ParseBool(os.Getenv("K8S_PACKET_TLS_RECORDS_METRICS_ENABLED"))
-	tlsExpirationMetricsEnabled, _ := strconv.ParseBool(os.Getenv("K8S_PACKET_TLS_EXPIRATION_METRICS_ENABLED"))
-	return &TlsListener{storer: storer,
-		tlsRecordsMeticsEnabled:     tlsRecordsMeticsEnabled,
-		tlsExpirationMetricsEnabled: tlsExpirationMetricsEnabled,
-	}
+func NewListener(storer storer.Storer, store *config.Store) modules.Listener[modules.TLSEvent] {
+	return &TlsListener{storer: storer, store: store}
 }
 
 func (listener *TlsListener) Listen(tlsEvent modules.TLSEvent) {
@@ -42,6 +36,9 @@ func (listener *TlsListener) Listen(tlsEvent modules.TLSEvent) {
 		Domain:          tlsEvent.ServerName,
 		UsedTLSVersion:  dict.ParseTLSVersion(tlsEvent.UsedTlsVersion),
 		UsedCipherSuite: dict.ParseCipherSuite(tlsEvent.UsedCipher),
+		UsedAlpn:        tlsEvent.UsedAlpn,
+		JA3:             ja3(tlsEvent),
+		JA4:             ja4(tlsEvent),
 		LastSeen:        time.Now()}
 
 	tlsDetails := model.TLSDetails{
@@ -49,7 +46,11 @@ func (listener *TlsListener) Listen(tlsEvent modules.TLSEvent) {
 		Dst:             tlsEvent.Server.Addr,
 		Port:            tlsEvent.Server.Port,
 		UsedTLSVersion:  dict.ParseTLSVersion(tlsEvent.UsedTlsVersion),
-		UsedCipherSuite: dict.ParseCipherSuite(tlsEvent.UsedCipher)}
+		UsedCipherSuite: dict.ParseCipherSuite(tlsEvent.UsedCipher),
+		ClientAlpn:      tlsEvent.Alpn,
+		UsedAlpn:        tlsEvent.UsedAlpn,
+		JA3:             tlsConnection.JA3,
+		JA4:             tlsConnection.JA4}
 
 	for _, tlsVersion := range tlsEvent.TlsVersions {
 		tlsDetails.ClientTLSVersions = append(tlsDetails.ClientTLSVersions, dict.ParseTLSVersion(tlsVersion))
@@ -60,14 +61,19 @@ func (listener *TlsListener) Listen(tlsEvent modules.TLSEvent) {
 
 	listener.storer.StoreInDatabase(&tlsConnection, &tlsDetails)
 
-	sendPrometheusMetrics(tlsConnection, tlsDetails, listener.tlsRecordsMeticsEnabled, listener.tlsExpirationMetricsEnabled)
+	sendPrometheusMetrics(tlsConnection, tlsDetails, listener.store.Get().TlsParser.Metrics)
 
 	var j, _ = json.Marshal(tlsConnection)
 	slog.Info("TLS connection", "Source", tlsEvent.Source.String(), "Record", string(j))
 }
 
-func sendPrometheusMetrics(tlsConnection model.TLSConnection, tlsDetails model.TLSDetails, tlsRecordsMeticsEnabled bool, tlsExpirationMetricsEnabled bool) {
-	if tlsRecordsMeticsEnabled {
+func sendPrometheusMetrics(tlsConnection model.TLSConnection, tlsDetails model.TLSDetails, metrics config.TlsParserMetricsConfig) {
+	if metrics.RecordsEnabled {
+		// fingerprints multiply series, they are left empty unless asked for
+		var ja3, ja4 string
+		if metrics.FingerprintsEnabled {
+			ja3, ja4 = tlsConnection.JA3, tlsConnection.JA4
+		}
 		prometheus.K8sPacketTLSRecordMetric.WithLabelValues(
 			tlsConnection.SrcNamespace,
 			tlsConnection.Src,
@@ -77,9 +83,27 @@ func sendPrometheusMetrics(tlsConnection model.TLSConnection, tlsDetails model.T
 			strconv.Itoa(int(tlsConnection.DstPort)),
 			tlsConnection.Domain,
 			tlsConnection.UsedTLSVersion,
-			tlsConnection.UsedCipherSuite).Add(1)
+			tlsConnection.UsedCipherSuite,
+			ja3,
+			ja4).Add(1)
+	}
+	if metrics.AlpnEnabled {
+		// the server selects one of the offered protocols, TLS 1.3 encrypts its choice
+		for _, alpn := range tlsDetails.ClientAlpn {
+			selected := strconv.FormatBool(alpn == tlsDetails.UsedAlpn)
+			if tlsDetails.UsedAlpn == "" && tlsDetails.UsedTLSVersion == dict.ParseTLSVersion(0x0304) {
+				selected = "unknown"
+			}
+			prometheus.K8sPacketTLSAlpnMetric.WithLabelValues(
+				tlsConnection.Dst,
+				tlsConnection.DstName,
+				strconv.Itoa(int(tlsConnection.DstPort)),
+				tlsConnection.Domain,
+				alpn,
+				selected).Add(1)
+		}
 	}
-	if tlsExpirationMetricsEnabled {
+	if metrics.ExpirationEnabled {
 		prometheus.K8sPacketTLSCertificateExpirationCounterMetric.WithLabelValues(
 			tlsDetails.Dst,
 			strconv.Itoa(int(tlsDetails.Port)),
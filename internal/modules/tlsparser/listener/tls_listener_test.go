@@ -3,13 +3,15 @@ package listener
 import (
 	"bytes"
 	"log/slog"
-	"os"
 	"testing"
 
+	"github.com/k8spacket/k8spacket/internal/config"
 	"github.com/k8spacket/k8spacket/internal/modules/tlsparser/model"
+	"github.com/k8spacket/k8spacket/internal/modules/tlsparser/prometheus"
 	"github.com/k8spacket/k8spacket/internal/modules/tlsparser/storer"
 
 	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -17,6 +19,9 @@ type mockStorer struct {
 	storer.Storer
 	client, server, domain, usedCipher string
 	clientTLSVersions                  []string
+	ja4, detailsJa4                    string
+	usedAlpn                           string
+	clientAlpn                         []string
 }
 
 func (mock *mockStorer) StoreInDatabase(tlsConnection *model.TLSConnection, tlsDetails *model.TLSDetails) {
@@ -25,21 +30,24 @@ func (mock *mockStorer) StoreInDatabase(tlsConnection *model.TLSConnection, tlsD
 	mock.domain = tlsConnection.Domain
 	mock.usedCipher = tlsConnection.UsedCipherSuite
 	mock.clientTLSVersions = tlsDetails.ClientTLSVersions
+	mock.ja4 = tlsConnection.JA4
+	mock.detailsJa4 = tlsDetails.JA4
+	mock.usedAlpn = tlsConnection.UsedAlpn
+	mock.clientAlpn = tlsDetails.ClientAlpn
 }
 
 func TestListen(t *testing.T) {
 
 	var str bytes.Buffer
 
-	os.Setenv("K8S_PACKET_TLS_RECORDS_METRICS_ENABLED", "true")
-	os.Setenv("K8S_PACKET_TLS_EXPIRATION_METRICS_ENABLED", "true")
-
 	logger := slog.New(slog.NewTextHandler(&str, nil))
 
 	slog.SetDefault(logger)
 
 	mockStorer := &mockStorer{}
-	listener := NewListener(mockStorer)
+	cfg := config.Default()
+	cfg.TlsParser.Metrics = config.TlsParserMetricsConfig{RecordsEnabled: true, ExpirationEnabled: true}
+	listener := NewListener(mockStorer, config.NewStore(cfg))
 
 	event := modules.TLSEvent{Client: modules.Address{Addr: "client"},
 		Server:      modules.Address{Addr: "server"},
@@ -57,3 +65,52 @@ func TestListen(t *testing.T) {
 	assert.Contains(t, str.String(), "TLS connection")
 
 }
+
+func TestListenFingerprints(t *testing.T) {
+	mockStorer := &mockStorer{}
+	cfg := config.Default()
+	cfg.TlsParser.Metrics = config.TlsParserMetricsConfig{RecordsEnabled: true, FingerprintsEnabled: true}
+	listener := NewListener(mockStorer, config.NewStore(cfg))
+
+	event := chrome
+	event.Client, event.Server = modules.Address{Addr: "fingerprinted"}, modules.Address{Addr: "server", Port: 443}
+	event.UsedTlsVersion, event.UsedCipher = 0x0304, 0x1301
+	listener.Listen(event)
+
+	assert.EqualValues(t, "t13d1516h2_8daaf6152771_e5627efa2ab1", mockStorer.ja4)
+	assert.EqualValues(t, mockStorer.ja4, mockStorer.detailsJa4)
+	assert.EqualValues(t, 1, testutil.ToFloat64(prometheus.K8sPacketTLSRecordMetric.WithLabelValues("", "fingerprinted", "", "server", "", "443",
+		"example.com", "TLS 1.3", "TLS_AES_128_GCM_SHA256", ja3(event), "t13d1516h2_8daaf6152771_e5627efa2ab1")))
+}
+
+func TestListenAlpn(t *testing.T) {
+	mockStorer := &mockStorer{}
+	cfg := config.Default()
+	cfg.TlsParser.Metrics = config.TlsParserMetricsConfig{AlpnEnabled: true}
+	listener := NewListener(mockStorer, config.NewStore(cfg))
+
+	var tests = []struct {
+		name           string
+		server         string
+		usedTlsVersion uint16
+		usedAlpn       string
+		want           map[string]string
+	}{
+		{"selected", "alpn-selected", 0x0303, "h2", map[string]string{"h2": "true", "http/1.1": "false"}},
+		{"not selected", "alpn-none", 0x0303, "", map[string]string{"h2": "false", "http/1.1": "false"}},
+		{"encrypted", "alpn-encrypted", 0x0304, "", map[string]string{"h2": "unknown", "http/1.1": "unknown"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			listener.Listen(modules.TLSEvent{Client: modules.Address{Addr: "client"}, Server: modules.Address{Addr: test.server, Port: 443},
+				ServerName: "grpc.example", Alpn: []string{"h2", "http/1.1"}, UsedTlsVersion: test.usedTlsVersion, UsedAlpn: test.usedAlpn})
+
+			assert.EqualValues(t, test.usedAlpn, mockStorer.usedAlpn)
+			assert.EqualValues(t, []string{"h2", "http/1.1"}, mockStorer.clientAlpn)
+			for alpn, selected := range test.want {
+				assert.EqualValues(t, 1, testutil.ToFloat64(prometheus.K8sPacketTLSAlpnMetric.WithLabelValues(test.server, "", "443", "grpc.example", alpn, selected)))
+			}
+		})
+	}
+}
@@ -15,6 +15,9 @@ type TLSConnection struct {
 	Domain          string    `json:"domain"`
 	UsedTLSVersion  string    `json:"usedTLSVersion"`
 	UsedCipherSuite string    `json:"usedCipherSuite"`
+	UsedAlpn        string    `json:"usedAlpn"`
+	JA3             string    `json:"ja3"`
+	JA4             string    `json:"ja4"`
 	LastSeen        time.Time `json:"lastSeen"`
 }
 
@@ -34,5 +37,9 @@ type TLSDetails struct {
 	ClientCipherSuites []string    `json:"clientCipherSuites"`
 	UsedTLSVersion     string      `json:"usedTLSVersion"`
 	UsedCipherSuite    string      `json:"usedCipherSuite"`
+	ClientAlpn         []string    `json:"clientAlpn"`
+	UsedAlpn           string      `json:"usedAlpn"`
+	JA3                string      `json:"ja3"`
+	JA4                string      `json:"ja4"`
 	Certificate        Certificate `json:"certificate"`
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
 	"sync"
 	"time"
@@ -42,14 +43,21 @@ func aggregateTLSResponses[T model.TLSDetails | []model.TLSConnection](ctx conte
 			reqCtx, cancel := context.WithTimeout(ctx, requestTimeout)
 			defer cancel()
 
-			req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, fmt.Sprintf(urlTemplate, ip), nil)
+			// IPv6 pod addresses have to be bracketed in URLs
+			host := ip
+			if addr := net.ParseIP(ip); addr != nil && addr.To4() == nil {
+				host = "[" + ip + "]"
+			}
+			req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, fmt.Sprintf(urlTemplate, host), nil)
 			if err != nil {
 				slog.Error("[api] Cannot create HTTP request for stats", "Error", err)
 				resCh <- result{err: err}
 				return
 			}
 
+			start := time.Now()
 			resp, err := client.Do(req)
+			httpclient.ObservePeerRequest("tlsparser", start, resp, err)
 			if err != nil {
 				slog.Error("[api] Cannot get stats", "Error", err)
 				resCh <- result{err: err}
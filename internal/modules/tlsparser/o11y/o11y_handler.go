@@ -6,10 +6,10 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
-	"os"
 	"reflect"
 	"strings"
 
+	"github.com/k8spacket/k8spacket/internal/config"
 	httpclient "github.com/k8spacket/k8spacket/internal/thirdparty/http"
 	k8sclient "github.com/k8spacket/k8spacket/internal/thirdparty/k8s"
 
@@ -21,21 +21,22 @@ const connectionDetailsUri = "/tlsparser/api/data/"
 type O11yHandler struct {
 	httpClient httpclient.Client
 	k8sClient  k8sclient.Client
+	store      *config.Store
 }
 
-func NewO11yHandler(httpClient httpclient.Client, k8sClient k8sclient.Client) *O11yHandler {
-	return &O11yHandler{httpClient: httpClient, k8sClient: k8sClient}
+func NewO11yHandler(httpClient httpclient.Client, k8sClient k8sclient.Client, store *config.Store) *O11yHandler {
+	return &O11yHandler{httpClient: httpClient, k8sClient: k8sClient, store: store}
 }
 
 func (handler *O11yHandler) TLSParserConnectionsHandler(w http.ResponseWriter, req *http.Request) {
-	out := handler.buildConnectionsResponse(fmt.Sprintf("http://%%s:%s/tlsparser/connections/?%s", os.Getenv("K8S_PACKET_TCP_LISTENER_PORT"), req.URL.Query().Encode()))
+	out := handler.buildConnectionsResponse(fmt.Sprintf("http://%%s:%d/tlsparser/connections/?%s", handler.store.Get().Api.Port, req.URL.Query().Encode()))
 	prepareResponse(w, out)
 }
 
 func (handler *O11yHandler) TLSParserConnectionDetailsHandler(w http.ResponseWriter, req *http.Request) {
 	idParam := strings.TrimPrefix(req.URL.Path, connectionDetailsUri)
 	if len(strings.TrimSpace(idParam)) > 0 {
-		out := handler.buildDetailsResponse(fmt.Sprintf("http://%%s:%s/tlsparser/connections/%s?%s", os.Getenv("K8S_PACKET_TCP_LISTENER_PORT"), idParam, req.URL.Query().Encode()))
+		out := handler.buildDetailsResponse(fmt.Sprintf("http://%%s:%d/tlsparser/connections/%s?%s", handler.store.Get().Api.Port, idParam, req.URL.Query().Encode()))
 		prepareResponse(w, out)
 	} else {
 		handler.TLSParserConnectionsHandler(w, req)
@@ -61,7 +62,8 @@ func (handler *O11yHandler) buildDetailsResponse(url string) model.TLSDetails {
 }
 
 func buildResponse[T model.TLSDetails | []model.TLSConnection](handler *O11yHandler, url string, t T, resultFunc func(d T, s T) T) T {
-	var k8spacketIps = handler.k8sClient.GetPodIPsBySelectors(os.Getenv("K8S_PACKET_API_FIELD_SELECTOR"), os.Getenv("K8S_PACKET_API_LABEL_SELECTOR"))
+	api := handler.store.Get().Api
+	var k8spacketIps = handler.k8sClient.GetPodIPsBySelectors(api.FieldSelector, api.LabelSelector)
 
 	out, errs := aggregateTLSResponses(context.Background(), k8spacketIps, url, handler.httpClient, t, resultFunc)
 	if len(errs) > 0 {
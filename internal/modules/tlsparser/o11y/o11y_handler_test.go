@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/k8spacket/k8spacket/internal/config"
 	httpclient "github.com/k8spacket/k8spacket/internal/thirdparty/http"
 	k8sclient "github.com/k8spacket/k8spacket/internal/thirdparty/k8s"
 	"io"
@@ -87,7 +88,7 @@ func (br *BrokenReader) Close() error {
 }
 
 type mockK8SClient struct {
-	k8sClient k8sclient.Client
+	k8sclient.Client
 }
 
 func (k8sClient *mockK8SClient) GetPodIPsBySelectors(fieldSelector string, labelSelector string) []string {
@@ -110,7 +111,7 @@ func TestTLSParserConnectionsHandler(t *testing.T) {
 	mockHttpClient := &mockHttpClient{}
 	mockK8SClient := &mockK8SClient{}
 
-	o11yController := NewO11yHandler(mockHttpClient, mockK8SClient)
+	o11yController := NewO11yHandler(mockHttpClient, mockK8SClient, config.NewStore(config.Default()))
 
 	for _, test := range tests {
 		t.Run(test.scenario, func(t *testing.T) {
@@ -153,7 +154,7 @@ func TestTLSParserConnectionDetailsHandler(t *testing.T) {
 	mockHttpClient := &mockHttpClient{}
 	mockK8SClient := &mockK8SClient{}
 
-	o11yController := NewO11yHandler(mockHttpClient, mockK8SClient)
+	o11yController := NewO11yHandler(mockHttpClient, mockK8SClient, config.NewStore(config.Default()))
 
 	for _, test := range tests {
 		t.Run(test.scenario, func(t *testing.T) {
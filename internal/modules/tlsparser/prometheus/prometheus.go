@@ -1,9 +1,8 @@
 package prometheus
 
 import (
+	"github.com/k8spacket/k8spacket/internal/config"
 	"github.com/prometheus/client_golang/prometheus"
-	"os"
-	"strconv"
 )
 
 var (
@@ -12,7 +11,15 @@ var (
 			Name: "k8s_packet_tls_record",
 			Help: "Kubernetes packet TLS Record",
 		},
-		[]string{"ns", "src", "src_name", "dst", "dst_name", "dst_port", "domain", "tls_version", "cipher_suite"},
+		[]string{"ns", "src", "src_name", "dst", "dst_name", "dst_port", "domain", "tls_version", "cipher_suite", "ja3", "ja4"},
+	)
+
+	K8sPacketTLSAlpnMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "k8s_packet_tls_alpn_total",
+			Help: "Kubernetes packet TLS handshakes offering an application protocol, by whether the server selected it",
+		},
+		[]string{"dst", "dst_name", "dst_port", "domain", "alpn", "selected"},
 	)
 
 	K8sPacketTLSCertificateExpirationCounterMetric = prometheus.NewCounterVec(
@@ -31,14 +38,19 @@ var (
 	)
 )
 
-func Init() {
-	sendTLSRecordsMetrics, _ := strconv.ParseBool(os.Getenv("K8S_PACKET_TLS_RECORDS_METRICS_ENABLED"))
-	if sendTLSRecordsMetrics {
-		prometheus.MustRegister(K8sPacketTLSRecordMetric)
-	}
-	sendTLSExpirationMetrics, _ := strconv.ParseBool(os.Getenv("K8S_PACKET_TLS_EXPIRATION_METRICS_ENABLED"))
-	if sendTLSExpirationMetrics {
-		prometheus.MustRegister(K8sPacketTLSCertificateExpirationMetric)
-		prometheus.MustRegister(K8sPacketTLSCertificateExpirationCounterMetric)
+// Configure registers or unregisters the metrics, it is safe to call it again on configuration reload
+func Configure(metrics config.TlsParserMetricsConfig) {
+	toggle(metrics.RecordsEnabled, K8sPacketTLSRecordMetric)
+	toggle(metrics.AlpnEnabled, K8sPacketTLSAlpnMetric)
+	toggle(metrics.ExpirationEnabled, K8sPacketTLSCertificateExpirationMetric, K8sPacketTLSCertificateExpirationCounterMetric)
+}
+
+func toggle(enabled bool, collectors ...prometheus.Collector) {
+	for _, collector := range collectors {
+		if enabled {
+			_ = prometheus.Register(collector)
+		} else {
+			prometheus.Unregister(collector)
+		}
 	}
 }
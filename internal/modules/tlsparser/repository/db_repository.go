@@ -2,6 +2,7 @@ package repository
 
 import (
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/k8spacket/k8spacket/internal/modules/tlsparser/model"
@@ -17,7 +18,7 @@ func NewDbRepository(db db.Db[model.TLSConnection], dbDetails db.Db[model.TLSDet
 	return &DbRepository{dbConnectionHandler: db, dbDetailsHandler: dbDetails}
 }
 
-func (repository *DbRepository) Query(from time.Time, to time.Time) []model.TLSConnection {
+func (repository *DbRepository) Query(from time.Time, to time.Time, ja3 string, ja4 string) []model.TLSConnection {
 
 	query := repository.dbConnectionHandler.QueryMatchFunc("Src", func(record *model.TLSConnection) (bool, error) {
 		valid := true
@@ -29,6 +30,12 @@ func (repository *DbRepository) Query(from time.Time, to time.Time) []model.TLSC
 			valid = record.LastSeen.Before(to) &&
 				valid
 		}
+		if ja3 != "" {
+			valid = record.JA3 == ja3 && valid
+		}
+		if ja4 != "" {
+			valid = strings.HasPrefix(record.JA4, ja4) && valid
+		}
 
 		return valid, nil
 	})
@@ -15,7 +15,7 @@ import (
 
 var dbState = []model.TLSConnection{
 	{Src: "past", LastSeen: time.Now().Add(time.Hour * -1)},
-	{Src: "now", LastSeen: time.Now()},
+	{Src: "now", LastSeen: time.Now(), JA3: "e5ba5d2d8d4d9e2b1a2a13f6e7f3a6b0", JA4: "t13d1516h2_8daaf6152771_e5627efa2ab1"},
 	{Src: "future", LastSeen: time.Now().Add(time.Hour * 1)},
 	{Src: "error", LastSeen: time.Now().Add(time.Hour * 1000)},
 }
@@ -31,7 +31,7 @@ type mockDetailsDb struct {
 }
 
 func (mock *mockConnectionDb) Query(query *bolthold.Query) ([]model.TLSConnection, error) {
-	if mock.queryResult[0].LastSeen.After(time.Now().Add(time.Hour * 999)) {
+	if len(mock.queryResult) > 0 && mock.queryResult[0].LastSeen.After(time.Now().Add(time.Hour*999)) {
 		return []model.TLSConnection{}, errors.New("error")
 	}
 	return mock.queryResult, nil
@@ -103,11 +103,15 @@ func TestQuery(t *testing.T) {
 	var tests = []struct {
 		msg      string
 		from, to time.Time
+		ja3, ja4 string
 		want     []model.TLSConnection
 		error    string
 	}{
-		{"from / to filter", time.Now().Add(time.Minute * -1), time.Now().Add(time.Minute), dbState[1:2], ""},
-		{"error", time.Now().Add(time.Hour * 998), time.Now().Add(time.Hour * 1001), []model.TLSConnection{}, "[db:tls_connections:Query] Error=error"},
+		{"from / to filter", time.Now().Add(time.Minute * -1), time.Now().Add(time.Minute), "", "", dbState[1:2], ""},
+		{"ja3 filter", time.Time{}, time.Now().Add(time.Hour * 2), "e5ba5d2d8d4d9e2b1a2a13f6e7f3a6b0", "", dbState[1:2], ""},
+		{"ja4 prefix filter", time.Time{}, time.Now().Add(time.Hour * 2), "", "t13d1516h2", dbState[1:2], ""},
+		{"no matching fingerprint", time.Time{}, time.Now().Add(time.Hour * 2), "e5ba5d2d8d4d9e2b1a2a13f6e7f3a6b0", "t12", []model.TLSConnection{}, ""},
+		{"error", time.Now().Add(time.Hour * 998), time.Now().Add(time.Hour * 1001), "", "", []model.TLSConnection{}, "[db:tls_connections:Query] Error=error"},
 	}
 
 	mockConnectionDBHandler := &mockConnectionDb{}
@@ -118,7 +122,7 @@ func TestQuery(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.msg, func(t *testing.T) {
 
-			result := repository.Query(test.from, test.to)
+			result := repository.Query(test.from, test.to, test.ja3, test.ja4)
 
 			assert.EqualValues(t, test.want, result)
 			assert.Contains(t, str.String(), test.error)
@@ -6,7 +6,7 @@ import (
 )
 
 type Repository interface {
-	Query(from time.Time, to time.Time) []model.TLSConnection
+	Query(from time.Time, to time.Time, ja3 string, ja4 string) []model.TLSConnection
 	UpsertConnection(key string, value *model.TLSConnection)
 	Read(key string) model.TLSDetails
 	UpsertDetails(key string, value *model.TLSDetails, fn Fn)
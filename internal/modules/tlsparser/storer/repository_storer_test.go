@@ -16,7 +16,7 @@ type mockRepository struct {
 	resultDetails    model.TLSDetails
 }
 
-func (mockRepository *mockRepository) Query(from time.Time, to time.Time) []model.TLSConnection {
+func (mockRepository *mockRepository) Query(from time.Time, to time.Time, ja3 string, ja4 string) []model.TLSConnection {
 	return []model.TLSConnection{}
 }
 
@@ -1,9 +1,9 @@
 package update
 
 import (
+	"github.com/k8spacket/k8spacket/internal/config"
 	ebpf_tools "github.com/k8spacket/k8spacket/internal/ebpf/tools"
 	"log/slog"
-	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -16,16 +16,16 @@ import (
 
 type CertificateUpdater struct {
 	network network.ConnectionInspector
+	store   *config.Store
 }
 
-func NewUpdater(network network.ConnectionInspector) Updater {
-	return &CertificateUpdater{network: network}
+func NewUpdater(network network.ConnectionInspector, store *config.Store) Updater {
+	return &CertificateUpdater{network: network, store: store}
 }
 
 func (updater *CertificateUpdater) Update(newValue *model.TLSDetails, oldValue *model.TLSDetails) {
-	duration, _ := time.ParseDuration(os.Getenv("K8S_PACKET_TLS_CERTIFICATE_CACHE_TTL"))
 	// do update when it is the first time or time to live is exceeded
-	if !oldValue.Certificate.LastScrape.IsZero() && oldValue.Certificate.LastScrape.Add(duration).After(time.Now()) {
+	if !oldValue.Certificate.LastScrape.IsZero() && oldValue.Certificate.LastScrape.Add(updater.store.Get().TlsParser.CertificateCacheTTL.Duration).After(time.Now()) {
 		newValue.Certificate = oldValue.Certificate
 		return
 	}
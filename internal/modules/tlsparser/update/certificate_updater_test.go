@@ -5,11 +5,11 @@ import (
 	"encoding/pem"
 	"errors"
 	"fmt"
-	"os"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/k8spacket/k8spacket/internal/config"
 	"github.com/k8spacket/k8spacket/internal/modules/tlsparser/model"
 	"github.com/k8spacket/k8spacket/internal/thirdparty/network"
 	"github.com/stretchr/testify/assert"
@@ -131,8 +131,6 @@ func (mockConnectionInspector *mockConnectionInspector) GetPeerCertificates(addr
 
 func TestUpdateCertificateInfo(t *testing.T) {
 
-	os.Setenv("K8S_PACKET_TLS_CERTIFICATE_CACHE_TTL", "1h")
-
 	var tests = []struct {
 		scenario           string
 		oldValue, newValue model.TLSDetails
@@ -160,7 +158,9 @@ func TestUpdateCertificateInfo(t *testing.T) {
 
 	mockConnectionInspector := &mockConnectionInspector{}
 
-	updater := &CertificateUpdater{mockConnectionInspector}
+	cfg := config.Default()
+	cfg.TlsParser.CertificateCacheTTL.Duration = time.Hour
+	updater := NewUpdater(mockConnectionInspector, config.NewStore(cfg))
 
 	for _, test := range tests {
 		t.Run(test.scenario, func(t *testing.T) {
@@ -37,6 +37,16 @@ type ConnectionItem struct {
 	Duration       float64                `protobuf:"fixed64,11,opt,name=duration,proto3" json:"duration,omitempty"`
 	MaxDuration    float64                `protobuf:"fixed64,12,opt,name=maxDuration,proto3" json:"maxDuration,omitempty"`
 	LastSeen       *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=lastSeen,proto3" json:"lastSeen,omitempty"`
+	Retransmits    int64                  `protobuf:"varint,14,opt,name=retransmits,proto3" json:"retransmits,omitempty"`
+	ConnReset      int64                  `protobuf:"varint,15,opt,name=connReset,proto3" json:"connReset,omitempty"`
+	Rtt            float64                `protobuf:"fixed64,16,opt,name=rtt,proto3" json:"rtt,omitempty"`
+	MaxRtt         float64                `protobuf:"fixed64,17,opt,name=maxRtt,proto3" json:"maxRtt,omitempty"`
+	RttCount       int64                  `protobuf:"varint,18,opt,name=rttCount,proto3" json:"rttCount,omitempty"`
+	ConnFailed     int64                  `protobuf:"varint,19,opt,name=connFailed,proto3" json:"connFailed,omitempty"`
+	SrcContainer   string                 `protobuf:"bytes,20,opt,name=srcContainer,proto3" json:"srcContainer,omitempty"`
+	SrcProcess     string                 `protobuf:"bytes,21,opt,name=srcProcess,proto3" json:"srcProcess,omitempty"`
+	DstContainer   string                 `protobuf:"bytes,22,opt,name=dstContainer,proto3" json:"dstContainer,omitempty"`
+	DstProcess     string                 `protobuf:"bytes,23,opt,name=dstProcess,proto3" json:"dstProcess,omitempty"`
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
@@ -162,11 +172,81 @@ func (x *ConnectionItem) GetLastSeen() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *ConnectionItem) GetRetransmits() int64 {
+	if x != nil {
+		return x.Retransmits
+	}
+	return 0
+}
+
+func (x *ConnectionItem) GetConnReset() int64 {
+	if x != nil {
+		return x.ConnReset
+	}
+	return 0
+}
+
+func (x *ConnectionItem) GetRtt() float64 {
+	if x != nil {
+		return x.Rtt
+	}
+	return 0
+}
+
+func (x *ConnectionItem) GetMaxRtt() float64 {
+	if x != nil {
+		return x.MaxRtt
+	}
+	return 0
+}
+
+func (x *ConnectionItem) GetRttCount() int64 {
+	if x != nil {
+		return x.RttCount
+	}
+	return 0
+}
+
+func (x *ConnectionItem) GetConnFailed() int64 {
+	if x != nil {
+		return x.ConnFailed
+	}
+	return 0
+}
+
+func (x *ConnectionItem) GetSrcContainer() string {
+	if x != nil {
+		return x.SrcContainer
+	}
+	return ""
+}
+
+func (x *ConnectionItem) GetSrcProcess() string {
+	if x != nil {
+		return x.SrcProcess
+	}
+	return ""
+}
+
+func (x *ConnectionItem) GetDstContainer() string {
+	if x != nil {
+		return x.DstContainer
+	}
+	return ""
+}
+
+func (x *ConnectionItem) GetDstProcess() string {
+	if x != nil {
+		return x.DstProcess
+	}
+	return ""
+}
+
 var File_internal_proto_nodegraph_model_model_proto protoreflect.FileDescriptor
 
 const file_internal_proto_nodegraph_model_model_proto_rawDesc = "" +
 	"\n" +
-	"*internal/proto/nodegraph/model/model.proto\x12\x15proto.nodegraph.model\x1a\x1fgoogle/protobuf/timestamp.proto\"\xb0\x03\n" +
+	"*internal/proto/nodegraph/model/model.proto\x12\x15proto.nodegraph.model\x1a\x1fgoogle/protobuf/timestamp.proto\"\xde\x05\n" +
 	"\x0eConnectionItem\x12\x10\n" +
 	"\x03src\x18\x01 \x01(\tR\x03src\x12\x18\n" +
 	"\asrcName\x18\x02 \x01(\tR\asrcName\x12\"\n" +
@@ -181,7 +261,23 @@ const file_internal_proto_nodegraph_model_model_proto_rawDesc = "" +
 	" \x01(\x01R\rbytesReceived\x12\x1a\n" +
 	"\bduration\x18\v \x01(\x01R\bduration\x12 \n" +
 	"\vmaxDuration\x18\f \x01(\x01R\vmaxDuration\x126\n" +
-	"\blastSeen\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\blastSeenB?Z=github.com/k8spacket/k8spacket/internal/proto/nodegraph/modelb\x06proto3"
+	"\blastSeen\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\blastSeen\x12 \n" +
+	"\vretransmits\x18\x0e \x01(\x03R\vretransmits\x12\x1c\n" +
+	"\tconnReset\x18\x0f \x01(\x03R\tconnReset\x12\x10\n" +
+	"\x03rtt\x18\x10 \x01(\x01R\x03rtt\x12\x16\n" +
+	"\x06maxRtt\x18\x11 \x01(\x01R\x06maxRtt\x12\x1a\n" +
+	"\brttCount\x18\x12 \x01(\x03R\brttCount\x12\x1e\n" +
+	"\n" +
+	"connFailed\x18\x13 \x01(\x03R\n" +
+	"connFailed\x12\"\n" +
+	"\fsrcContainer\x18\x14 \x01(\tR\fsrcContainer\x12\x1e\n" +
+	"\n" +
+	"srcProcess\x18\x15 \x01(\tR\n" +
+	"srcProcess\x12\"\n" +
+	"\fdstContainer\x18\x16 \x01(\tR\fdstContainer\x12\x1e\n" +
+	"\n" +
+	"dstProcess\x18\x17 \x01(\tR\n" +
+	"dstProcessB?Z=github.com/k8spacket/k8spacket/internal/proto/nodegraph/modelb\x06proto3"
 
 var (
 	file_internal_proto_nodegraph_model_model_proto_rawDescOnce sync.Once
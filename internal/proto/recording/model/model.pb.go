@@ -0,0 +1,603 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v3.21.12
+// source: internal/proto/recording/model/model.proto
+
+package model
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Address struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Addr          string                 `protobuf:"bytes,1,opt,name=addr,proto3" json:"addr,omitempty"`
+	Port          uint32                 `protobuf:"varint,2,opt,name=port,proto3" json:"port,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Namespace     string                 `protobuf:"bytes,4,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Container     string                 `protobuf:"bytes,5,opt,name=container,proto3" json:"container,omitempty"`
+	Pid           uint32                 `protobuf:"varint,6,opt,name=pid,proto3" json:"pid,omitempty"`
+	Process       string                 `protobuf:"bytes,7,opt,name=process,proto3" json:"process,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Address) Reset() {
+	*x = Address{}
+	mi := &file_internal_proto_recording_model_model_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Address) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Address) ProtoMessage() {}
+
+func (x *Address) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_recording_model_model_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Address.ProtoReflect.Descriptor instead.
+func (*Address) Descriptor() ([]byte, []int) {
+	return file_internal_proto_recording_model_model_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Address) GetAddr() string {
+	if x != nil {
+		return x.Addr
+	}
+	return ""
+}
+
+func (x *Address) GetPort() uint32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *Address) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Address) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *Address) GetContainer() string {
+	if x != nil {
+		return x.Container
+	}
+	return ""
+}
+
+func (x *Address) GetPid() uint32 {
+	if x != nil {
+		return x.Pid
+	}
+	return 0
+}
+
+func (x *Address) GetProcess() string {
+	if x != nil {
+		return x.Process
+	}
+	return ""
+}
+
+type TCPEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Client        *Address               `protobuf:"bytes,1,opt,name=client,proto3" json:"client,omitempty"`
+	Server        *Address               `protobuf:"bytes,2,opt,name=server,proto3" json:"server,omitempty"`
+	TxB           uint64                 `protobuf:"varint,3,opt,name=txB,proto3" json:"txB,omitempty"`
+	RxB           uint64                 `protobuf:"varint,4,opt,name=rxB,proto3" json:"rxB,omitempty"`
+	DeltaUs       uint64                 `protobuf:"varint,5,opt,name=deltaUs,proto3" json:"deltaUs,omitempty"`
+	Closed        bool                   `protobuf:"varint,6,opt,name=closed,proto3" json:"closed,omitempty"`
+	Retransmits   uint32                 `protobuf:"varint,7,opt,name=retransmits,proto3" json:"retransmits,omitempty"`
+	Rst           bool                   `protobuf:"varint,8,opt,name=rst,proto3" json:"rst,omitempty"`
+	SrttUs        uint32                 `protobuf:"varint,9,opt,name=srttUs,proto3" json:"srttUs,omitempty"`
+	RttVarUs      uint32                 `protobuf:"varint,10,opt,name=rttVarUs,proto3" json:"rttVarUs,omitempty"`
+	Failed        bool                   `protobuf:"varint,11,opt,name=failed,proto3" json:"failed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TCPEvent) Reset() {
+	*x = TCPEvent{}
+	mi := &file_internal_proto_recording_model_model_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TCPEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TCPEvent) ProtoMessage() {}
+
+func (x *TCPEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_recording_model_model_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TCPEvent.ProtoReflect.Descriptor instead.
+func (*TCPEvent) Descriptor() ([]byte, []int) {
+	return file_internal_proto_recording_model_model_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TCPEvent) GetClient() *Address {
+	if x != nil {
+		return x.Client
+	}
+	return nil
+}
+
+func (x *TCPEvent) GetServer() *Address {
+	if x != nil {
+		return x.Server
+	}
+	return nil
+}
+
+func (x *TCPEvent) GetTxB() uint64 {
+	if x != nil {
+		return x.TxB
+	}
+	return 0
+}
+
+func (x *TCPEvent) GetRxB() uint64 {
+	if x != nil {
+		return x.RxB
+	}
+	return 0
+}
+
+func (x *TCPEvent) GetDeltaUs() uint64 {
+	if x != nil {
+		return x.DeltaUs
+	}
+	return 0
+}
+
+func (x *TCPEvent) GetClosed() bool {
+	if x != nil {
+		return x.Closed
+	}
+	return false
+}
+
+func (x *TCPEvent) GetRetransmits() uint32 {
+	if x != nil {
+		return x.Retransmits
+	}
+	return 0
+}
+
+func (x *TCPEvent) GetRst() bool {
+	if x != nil {
+		return x.Rst
+	}
+	return false
+}
+
+func (x *TCPEvent) GetSrttUs() uint32 {
+	if x != nil {
+		return x.SrttUs
+	}
+	return 0
+}
+
+func (x *TCPEvent) GetRttVarUs() uint32 {
+	if x != nil {
+		return x.RttVarUs
+	}
+	return 0
+}
+
+func (x *TCPEvent) GetFailed() bool {
+	if x != nil {
+		return x.Failed
+	}
+	return false
+}
+
+type TLSEvent struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Source              int32                  `protobuf:"varint,1,opt,name=source,proto3" json:"source,omitempty"`
+	Client              *Address               `protobuf:"bytes,2,opt,name=client,proto3" json:"client,omitempty"`
+	Server              *Address               `protobuf:"bytes,3,opt,name=server,proto3" json:"server,omitempty"`
+	TlsVersions         []uint32               `protobuf:"varint,4,rep,packed,name=tlsVersions,proto3" json:"tlsVersions,omitempty"`
+	Ciphers             []uint32               `protobuf:"varint,5,rep,packed,name=ciphers,proto3" json:"ciphers,omitempty"`
+	ServerName          string                 `protobuf:"bytes,6,opt,name=serverName,proto3" json:"serverName,omitempty"`
+	UsedTlsVersion      uint32                 `protobuf:"varint,7,opt,name=usedTlsVersion,proto3" json:"usedTlsVersion,omitempty"`
+	UsedCipher          uint32                 `protobuf:"varint,8,opt,name=usedCipher,proto3" json:"usedCipher,omitempty"`
+	HelloVersion        uint32                 `protobuf:"varint,9,opt,name=helloVersion,proto3" json:"helloVersion,omitempty"`
+	Extensions          []uint32               `protobuf:"varint,10,rep,packed,name=extensions,proto3" json:"extensions,omitempty"`
+	SupportedGroups     []uint32               `protobuf:"varint,11,rep,packed,name=supportedGroups,proto3" json:"supportedGroups,omitempty"`
+	PointFormats        []uint32               `protobuf:"varint,12,rep,packed,name=pointFormats,proto3" json:"pointFormats,omitempty"`
+	SignatureAlgorithms []uint32               `protobuf:"varint,13,rep,packed,name=signatureAlgorithms,proto3" json:"signatureAlgorithms,omitempty"`
+	Alpn                []string               `protobuf:"bytes,14,rep,name=alpn,proto3" json:"alpn,omitempty"`
+	UsedAlpn            string                 `protobuf:"bytes,15,opt,name=usedAlpn,proto3" json:"usedAlpn,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *TLSEvent) Reset() {
+	*x = TLSEvent{}
+	mi := &file_internal_proto_recording_model_model_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TLSEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TLSEvent) ProtoMessage() {}
+
+func (x *TLSEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_recording_model_model_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TLSEvent.ProtoReflect.Descriptor instead.
+func (*TLSEvent) Descriptor() ([]byte, []int) {
+	return file_internal_proto_recording_model_model_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *TLSEvent) GetSource() int32 {
+	if x != nil {
+		return x.Source
+	}
+	return 0
+}
+
+func (x *TLSEvent) GetClient() *Address {
+	if x != nil {
+		return x.Client
+	}
+	return nil
+}
+
+func (x *TLSEvent) GetServer() *Address {
+	if x != nil {
+		return x.Server
+	}
+	return nil
+}
+
+func (x *TLSEvent) GetTlsVersions() []uint32 {
+	if x != nil {
+		return x.TlsVersions
+	}
+	return nil
+}
+
+func (x *TLSEvent) GetCiphers() []uint32 {
+	if x != nil {
+		return x.Ciphers
+	}
+	return nil
+}
+
+func (x *TLSEvent) GetServerName() string {
+	if x != nil {
+		return x.ServerName
+	}
+	return ""
+}
+
+func (x *TLSEvent) GetUsedTlsVersion() uint32 {
+	if x != nil {
+		return x.UsedTlsVersion
+	}
+	return 0
+}
+
+func (x *TLSEvent) GetUsedCipher() uint32 {
+	if x != nil {
+		return x.UsedCipher
+	}
+	return 0
+}
+
+func (x *TLSEvent) GetHelloVersion() uint32 {
+	if x != nil {
+		return x.HelloVersion
+	}
+	return 0
+}
+
+func (x *TLSEvent) GetExtensions() []uint32 {
+	if x != nil {
+		return x.Extensions
+	}
+	return nil
+}
+
+func (x *TLSEvent) GetSupportedGroups() []uint32 {
+	if x != nil {
+		return x.SupportedGroups
+	}
+	return nil
+}
+
+func (x *TLSEvent) GetPointFormats() []uint32 {
+	if x != nil {
+		return x.PointFormats
+	}
+	return nil
+}
+
+func (x *TLSEvent) GetSignatureAlgorithms() []uint32 {
+	if x != nil {
+		return x.SignatureAlgorithms
+	}
+	return nil
+}
+
+func (x *TLSEvent) GetAlpn() []string {
+	if x != nil {
+		return x.Alpn
+	}
+	return nil
+}
+
+func (x *TLSEvent) GetUsedAlpn() string {
+	if x != nil {
+		return x.UsedAlpn
+	}
+	return ""
+}
+
+type Record struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Time  *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*Record_Tcp
+	//	*Record_Tls
+	Event         isRecord_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Record) Reset() {
+	*x = Record{}
+	mi := &file_internal_proto_recording_model_model_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Record) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Record) ProtoMessage() {}
+
+func (x *Record) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_recording_model_model_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Record.ProtoReflect.Descriptor instead.
+func (*Record) Descriptor() ([]byte, []int) {
+	return file_internal_proto_recording_model_model_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Record) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Record) GetEvent() isRecord_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *Record) GetTcp() *TCPEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Record_Tcp); ok {
+			return x.Tcp
+		}
+	}
+	return nil
+}
+
+func (x *Record) GetTls() *TLSEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Record_Tls); ok {
+			return x.Tls
+		}
+	}
+	return nil
+}
+
+type isRecord_Event interface {
+	isRecord_Event()
+}
+
+type Record_Tcp struct {
+	Tcp *TCPEvent `protobuf:"bytes,2,opt,name=tcp,proto3,oneof"`
+}
+
+type Record_Tls struct {
+	Tls *TLSEvent `protobuf:"bytes,3,opt,name=tls,proto3,oneof"`
+}
+
+func (*Record_Tcp) isRecord_Event() {}
+
+func (*Record_Tls) isRecord_Event() {}
+
+var File_internal_proto_recording_model_model_proto protoreflect.FileDescriptor
+
+const file_internal_proto_recording_model_model_proto_rawDesc = "" +
+	"\n" +
+	"*internal/proto/recording/model/model.proto\x12\x15proto.recording.model\x1a\x1fgoogle/protobuf/timestamp.proto\"\xad\x01\n" +
+	"\aAddress\x12\x12\n" +
+	"\x04addr\x18\x01 \x01(\tR\x04addr\x12\x12\n" +
+	"\x04port\x18\x02 \x01(\rR\x04port\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x1c\n" +
+	"\tnamespace\x18\x04 \x01(\tR\tnamespace\x12\x1c\n" +
+	"\tcontainer\x18\x05 \x01(\tR\tcontainer\x12\x10\n" +
+	"\x03pid\x18\x06 \x01(\rR\x03pid\x12\x18\n" +
+	"\aprocess\x18\a \x01(\tR\aprocess\"\xd0\x02\n" +
+	"\bTCPEvent\x126\n" +
+	"\x06client\x18\x01 \x01(\v2\x1e.proto.recording.model.AddressR\x06client\x126\n" +
+	"\x06server\x18\x02 \x01(\v2\x1e.proto.recording.model.AddressR\x06server\x12\x10\n" +
+	"\x03txB\x18\x03 \x01(\x04R\x03txB\x12\x10\n" +
+	"\x03rxB\x18\x04 \x01(\x04R\x03rxB\x12\x18\n" +
+	"\adeltaUs\x18\x05 \x01(\x04R\adeltaUs\x12\x16\n" +
+	"\x06closed\x18\x06 \x01(\bR\x06closed\x12 \n" +
+	"\vretransmits\x18\a \x01(\rR\vretransmits\x12\x10\n" +
+	"\x03rst\x18\b \x01(\bR\x03rst\x12\x16\n" +
+	"\x06srttUs\x18\t \x01(\rR\x06srttUs\x12\x1a\n" +
+	"\brttVarUs\x18\n" +
+	" \x01(\rR\brttVarUs\x12\x16\n" +
+	"\x06failed\x18\v \x01(\bR\x06failed\"\xaa\x04\n" +
+	"\bTLSEvent\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\x05R\x06source\x126\n" +
+	"\x06client\x18\x02 \x01(\v2\x1e.proto.recording.model.AddressR\x06client\x126\n" +
+	"\x06server\x18\x03 \x01(\v2\x1e.proto.recording.model.AddressR\x06server\x12 \n" +
+	"\vtlsVersions\x18\x04 \x03(\rR\vtlsVersions\x12\x18\n" +
+	"\aciphers\x18\x05 \x03(\rR\aciphers\x12\x1e\n" +
+	"\n" +
+	"serverName\x18\x06 \x01(\tR\n" +
+	"serverName\x12&\n" +
+	"\x0eusedTlsVersion\x18\a \x01(\rR\x0eusedTlsVersion\x12\x1e\n" +
+	"\n" +
+	"usedCipher\x18\b \x01(\rR\n" +
+	"usedCipher\x12\"\n" +
+	"\fhelloVersion\x18\t \x01(\rR\fhelloVersion\x12\x1e\n" +
+	"\n" +
+	"extensions\x18\n" +
+	" \x03(\rR\n" +
+	"extensions\x12(\n" +
+	"\x0fsupportedGroups\x18\v \x03(\rR\x0fsupportedGroups\x12\"\n" +
+	"\fpointFormats\x18\f \x03(\rR\fpointFormats\x120\n" +
+	"\x13signatureAlgorithms\x18\r \x03(\rR\x13signatureAlgorithms\x12\x12\n" +
+	"\x04alpn\x18\x0e \x03(\tR\x04alpn\x12\x1a\n" +
+	"\busedAlpn\x18\x0f \x01(\tR\busedAlpn\"\xab\x01\n" +
+	"\x06Record\x12.\n" +
+	"\x04time\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x123\n" +
+	"\x03tcp\x18\x02 \x01(\v2\x1f.proto.recording.model.TCPEventH\x00R\x03tcp\x123\n" +
+	"\x03tls\x18\x03 \x01(\v2\x1f.proto.recording.model.TLSEventH\x00R\x03tlsB\a\n" +
+	"\x05eventB?Z=github.com/k8spacket/k8spacket/internal/proto/recording/modelb\x06proto3"
+
+var (
+	file_internal_proto_recording_model_model_proto_rawDescOnce sync.Once
+	file_internal_proto_recording_model_model_proto_rawDescData []byte
+)
+
+func file_internal_proto_recording_model_model_proto_rawDescGZIP() []byte {
+	file_internal_proto_recording_model_model_proto_rawDescOnce.Do(func() {
+		file_internal_proto_recording_model_model_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_internal_proto_recording_model_model_proto_rawDesc), len(file_internal_proto_recording_model_model_proto_rawDesc)))
+	})
+	return file_internal_proto_recording_model_model_proto_rawDescData
+}
+
+var file_internal_proto_recording_model_model_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_internal_proto_recording_model_model_proto_goTypes = []any{
+	(*Address)(nil),               // 0: proto.recording.model.Address
+	(*TCPEvent)(nil),              // 1: proto.recording.model.TCPEvent
+	(*TLSEvent)(nil),              // 2: proto.recording.model.TLSEvent
+	(*Record)(nil),                // 3: proto.recording.model.Record
+	(*timestamppb.Timestamp)(nil), // 4: google.protobuf.Timestamp
+}
+var file_internal_proto_recording_model_model_proto_depIdxs = []int32{
+	0, // 0: proto.recording.model.TCPEvent.client:type_name -> proto.recording.model.Address
+	0, // 1: proto.recording.model.TCPEvent.server:type_name -> proto.recording.model.Address
+	0, // 2: proto.recording.model.TLSEvent.client:type_name -> proto.recording.model.Address
+	0, // 3: proto.recording.model.TLSEvent.server:type_name -> proto.recording.model.Address
+	4, // 4: proto.recording.model.Record.time:type_name -> google.protobuf.Timestamp
+	1, // 5: proto.recording.model.Record.tcp:type_name -> proto.recording.model.TCPEvent
+	2, // 6: proto.recording.model.Record.tls:type_name -> proto.recording.model.TLSEvent
+	7, // [7:7] is the sub-list for method output_type
+	7, // [7:7] is the sub-list for method input_type
+	7, // [7:7] is the sub-list for extension type_name
+	7, // [7:7] is the sub-list for extension extendee
+	0, // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_internal_proto_recording_model_model_proto_init() }
+func file_internal_proto_recording_model_model_proto_init() {
+	if File_internal_proto_recording_model_model_proto != nil {
+		return
+	}
+	file_internal_proto_recording_model_model_proto_msgTypes[3].OneofWrappers = []any{
+		(*Record_Tcp)(nil),
+		(*Record_Tls)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_internal_proto_recording_model_model_proto_rawDesc), len(file_internal_proto_recording_model_model_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_internal_proto_recording_model_model_proto_goTypes,
+		DependencyIndexes: file_internal_proto_recording_model_model_proto_depIdxs,
+		MessageInfos:      file_internal_proto_recording_model_model_proto_msgTypes,
+	}.Build()
+	File_internal_proto_recording_model_model_proto = out.File
+	file_internal_proto_recording_model_model_proto_goTypes = nil
+	file_internal_proto_recording_model_model_proto_depIdxs = nil
+}
@@ -101,6 +101,10 @@ type TLSDetails struct {
 	UsedTLSVersion     string                 `protobuf:"bytes,7,opt,name=usedTLSVersion,proto3" json:"usedTLSVersion,omitempty"`
 	UsedCipherSuite    string                 `protobuf:"bytes,8,opt,name=usedCipherSuite,proto3" json:"usedCipherSuite,omitempty"`
 	Certificate        *Certificate           `protobuf:"bytes,9,opt,name=certificate,proto3" json:"certificate,omitempty"`
+	Ja3                string                 `protobuf:"bytes,10,opt,name=ja3,proto3" json:"ja3,omitempty"`
+	Ja4                string                 `protobuf:"bytes,11,opt,name=ja4,proto3" json:"ja4,omitempty"`
+	ClientAlpn         []string               `protobuf:"bytes,12,rep,name=clientAlpn,proto3" json:"clientAlpn,omitempty"`
+	UsedAlpn           string                 `protobuf:"bytes,13,opt,name=usedAlpn,proto3" json:"usedAlpn,omitempty"`
 	unknownFields      protoimpl.UnknownFields
 	sizeCache          protoimpl.SizeCache
 }
@@ -198,6 +202,34 @@ func (x *TLSDetails) GetCertificate() *Certificate {
 	return nil
 }
 
+func (x *TLSDetails) GetJa3() string {
+	if x != nil {
+		return x.Ja3
+	}
+	return ""
+}
+
+func (x *TLSDetails) GetJa4() string {
+	if x != nil {
+		return x.Ja4
+	}
+	return ""
+}
+
+func (x *TLSDetails) GetClientAlpn() []string {
+	if x != nil {
+		return x.ClientAlpn
+	}
+	return nil
+}
+
+func (x *TLSDetails) GetUsedAlpn() string {
+	if x != nil {
+		return x.UsedAlpn
+	}
+	return ""
+}
+
 type TLSConnection struct {
 	state           protoimpl.MessageState `protogen:"open.v1"`
 	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -211,6 +243,9 @@ type TLSConnection struct {
 	UsedTLSVersion  string                 `protobuf:"bytes,9,opt,name=usedTLSVersion,proto3" json:"usedTLSVersion,omitempty"`
 	UsedCipherSuite string                 `protobuf:"bytes,10,opt,name=usedCipherSuite,proto3" json:"usedCipherSuite,omitempty"`
 	LastSeen        *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=lastSeen,proto3" json:"lastSeen,omitempty"`
+	Ja3             string                 `protobuf:"bytes,12,opt,name=ja3,proto3" json:"ja3,omitempty"`
+	Ja4             string                 `protobuf:"bytes,13,opt,name=ja4,proto3" json:"ja4,omitempty"`
+	UsedAlpn        string                 `protobuf:"bytes,14,opt,name=usedAlpn,proto3" json:"usedAlpn,omitempty"`
 	unknownFields   protoimpl.UnknownFields
 	sizeCache       protoimpl.SizeCache
 }
@@ -322,6 +357,27 @@ func (x *TLSConnection) GetLastSeen() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *TLSConnection) GetJa3() string {
+	if x != nil {
+		return x.Ja3
+	}
+	return ""
+}
+
+func (x *TLSConnection) GetJa4() string {
+	if x != nil {
+		return x.Ja4
+	}
+	return ""
+}
+
+func (x *TLSConnection) GetUsedAlpn() string {
+	if x != nil {
+		return x.UsedAlpn
+	}
+	return ""
+}
+
 var File_internal_proto_tlsparser_model_model_proto protoreflect.FileDescriptor
 
 const file_internal_proto_tlsparser_model_model_proto_rawDesc = "" +
@@ -333,7 +389,7 @@ const file_internal_proto_tlsparser_model_model_proto_rawDesc = "" +
 	"\vserverChain\x18\x03 \x01(\tR\vserverChain\x12:\n" +
 	"\n" +
 	"lastScrape\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
-	"lastScrape\"\xd0\x02\n" +
+	"lastScrape\"\xb0\x03\n" +
 	"\n" +
 	"TLSDetails\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
@@ -344,7 +400,14 @@ const file_internal_proto_tlsparser_model_model_proto_rawDesc = "" +
 	"\x12clientCipherSuites\x18\x06 \x03(\tR\x12clientCipherSuites\x12&\n" +
 	"\x0eusedTLSVersion\x18\a \x01(\tR\x0eusedTLSVersion\x12(\n" +
 	"\x0fusedCipherSuite\x18\b \x01(\tR\x0fusedCipherSuite\x12D\n" +
-	"\vcertificate\x18\t \x01(\v2\".proto.tlsparser.model.CertificateR\vcertificate\"\xd7\x02\n" +
+	"\vcertificate\x18\t \x01(\v2\".proto.tlsparser.model.CertificateR\vcertificate\x12\x10\n" +
+	"\x03ja3\x18\n" +
+	" \x01(\tR\x03ja3\x12\x10\n" +
+	"\x03ja4\x18\v \x01(\tR\x03ja4\x12\x1e\n" +
+	"\n" +
+	"clientAlpn\x18\f \x03(\tR\n" +
+	"clientAlpn\x12\x1a\n" +
+	"\busedAlpn\x18\r \x01(\tR\busedAlpn\"\x97\x03\n" +
 	"\rTLSConnection\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x10\n" +
 	"\x03src\x18\x02 \x01(\tR\x03src\x12\x18\n" +
@@ -357,7 +420,10 @@ const file_internal_proto_tlsparser_model_model_proto_rawDesc = "" +
 	"\x0eusedTLSVersion\x18\t \x01(\tR\x0eusedTLSVersion\x12(\n" +
 	"\x0fusedCipherSuite\x18\n" +
 	" \x01(\tR\x0fusedCipherSuite\x126\n" +
-	"\blastSeen\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\blastSeenB?Z=github.com/k8spacket/k8spacket/internal/proto/tlsparser/modelb\x06proto3"
+	"\blastSeen\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\blastSeen\x12\x10\n" +
+	"\x03ja3\x18\f \x01(\tR\x03ja3\x12\x10\n" +
+	"\x03ja4\x18\r \x01(\tR\x03ja4\x12\x1a\n" +
+	"\busedAlpn\x18\x0e \x01(\tR\busedAlpnB?Z=github.com/k8spacket/k8spacket/internal/proto/tlsparser/modelb\x06proto3"
 
 var (
 	file_internal_proto_tlsparser_model_model_proto_rawDescOnce sync.Once
@@ -0,0 +1,118 @@
+package recording
+
+import (
+	"github.com/k8spacket/k8spacket/internal/modules"
+	proto_recording "github.com/k8spacket/k8spacket/internal/proto/recording/model"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toProto(in Record) *proto_recording.Record {
+	out := &proto_recording.Record{Time: timestamppb.New(in.Time)}
+	if in.TCP != nil {
+		out.Event = &proto_recording.Record_Tcp{Tcp: &proto_recording.TCPEvent{
+			Client:      addressToProto(in.TCP.Client),
+			Server:      addressToProto(in.TCP.Server),
+			TxB:         in.TCP.TxB,
+			RxB:         in.TCP.RxB,
+			DeltaUs:     in.TCP.DeltaUs,
+			Closed:      in.TCP.Closed,
+			Retransmits: in.TCP.Retransmits,
+			Rst:         in.TCP.Reset,
+			SrttUs:      in.TCP.SrttUs,
+			RttVarUs:    in.TCP.RttVarUs,
+			Failed:      in.TCP.Failed,
+		}}
+	}
+	if in.TLS != nil {
+		out.Event = &proto_recording.Record_Tls{Tls: &proto_recording.TLSEvent{
+			Source:              int32(in.TLS.Source),
+			Client:              addressToProto(in.TLS.Client),
+			Server:              addressToProto(in.TLS.Server),
+			TlsVersions:         widen(in.TLS.TlsVersions),
+			Ciphers:             widen(in.TLS.Ciphers),
+			ServerName:          in.TLS.ServerName,
+			UsedTlsVersion:      uint32(in.TLS.UsedTlsVersion),
+			UsedCipher:          uint32(in.TLS.UsedCipher),
+			UsedAlpn:            in.TLS.UsedAlpn,
+			HelloVersion:        uint32(in.TLS.HelloVersion),
+			Extensions:          widen(in.TLS.Extensions),
+			SupportedGroups:     widen(in.TLS.SupportedGroups),
+			PointFormats:        widen(in.TLS.PointFormats),
+			SignatureAlgorithms: widen(in.TLS.SignatureAlgorithms),
+			Alpn:                in.TLS.Alpn,
+		}}
+	}
+	return out
+}
+
+func fromProto(in *proto_recording.Record) Record {
+	out := Record{Time: in.GetTime().AsTime()}
+	if tcp := in.GetTcp(); tcp != nil {
+		out.TCP = &modules.TCPEvent{
+			Client:      addressFromProto(tcp.GetClient()),
+			Server:      addressFromProto(tcp.GetServer()),
+			TxB:         tcp.GetTxB(),
+			RxB:         tcp.GetRxB(),
+			DeltaUs:     tcp.GetDeltaUs(),
+			Closed:      tcp.GetClosed(),
+			Retransmits: tcp.GetRetransmits(),
+			Reset:       tcp.GetRst(),
+			SrttUs:      tcp.GetSrttUs(),
+			RttVarUs:    tcp.GetRttVarUs(),
+			Failed:      tcp.GetFailed(),
+			Time:        out.Time,
+		}
+	}
+	if tls := in.GetTls(); tls != nil {
+		out.TLS = &modules.TLSEvent{
+			Source:              modules.EventSource(tls.GetSource()),
+			Client:              addressFromProto(tls.GetClient()),
+			Server:              addressFromProto(tls.GetServer()),
+			TlsVersions:         narrow[uint16](tls.GetTlsVersions()),
+			Ciphers:             narrow[uint16](tls.GetCiphers()),
+			ServerName:          tls.GetServerName(),
+			UsedTlsVersion:      uint16(tls.GetUsedTlsVersion()),
+			UsedCipher:          uint16(tls.GetUsedCipher()),
+			UsedAlpn:            tls.GetUsedAlpn(),
+			HelloVersion:        uint16(tls.GetHelloVersion()),
+			Extensions:          narrow[uint16](tls.GetExtensions()),
+			SupportedGroups:     narrow[uint16](tls.GetSupportedGroups()),
+			PointFormats:        narrow[uint8](tls.GetPointFormats()),
+			SignatureAlgorithms: narrow[uint16](tls.GetSignatureAlgorithms()),
+			Alpn:                tls.GetAlpn(),
+			Time:                out.Time,
+		}
+	}
+	return out
+}
+
+func addressToProto(in modules.Address) *proto_recording.Address {
+	return &proto_recording.Address{Addr: in.Addr, Port: uint32(in.Port), Name: in.Name, Namespace: in.Namespace, Container: in.Container, Pid: in.Pid, Process: in.Process}
+}
+
+func addressFromProto(in *proto_recording.Address) modules.Address {
+	return modules.Address{Addr: in.GetAddr(), Port: uint16(in.GetPort()), Name: in.GetName(), Namespace: in.GetNamespace(), Container: in.GetContainer(), Pid: in.GetPid(), Process: in.GetProcess()}
+}
+
+// protobuf has no 8-bit nor 16-bit integers
+func widen[T uint8 | uint16](in []T) []uint32 {
+	if in == nil {
+		return nil
+	}
+	out := make([]uint32, len(in))
+	for i, v := range in {
+		out[i] = uint32(v)
+	}
+	return out
+}
+
+func narrow[T uint8 | uint16](in []uint32) []T {
+	if in == nil {
+		return nil
+	}
+	out := make([]T, len(in))
+	for i, v := range in {
+		out[i] = T(v)
+	}
+	return out
+}
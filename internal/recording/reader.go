@@ -0,0 +1,47 @@
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	proto_recording "github.com/k8spacket/k8spacket/internal/proto/recording/model"
+	"google.golang.org/protobuf/encoding/protodelim"
+)
+
+type Reader interface {
+	// Read returns the next record or io.EOF at the end of the recording
+	Read() (Record, error)
+}
+
+type StreamReader struct {
+	decode func() (Record, error)
+}
+
+func NewReader(in io.Reader, format string) (*StreamReader, error) {
+	buffer := bufio.NewReader(in)
+	switch format {
+	case JSONL:
+		decoder := json.NewDecoder(buffer)
+		return &StreamReader{decode: func() (Record, error) {
+			var record Record
+			err := decoder.Decode(&record)
+			return record, err
+		}}, nil
+	case Protobuf:
+		return &StreamReader{decode: func() (Record, error) {
+			var record proto_recording.Record
+			if err := protodelim.UnmarshalFrom(buffer, &record); err != nil {
+				return Record{}, err
+			}
+			return fromProto(&record), nil
+		}}, nil
+	default:
+		return nil, fmt.Errorf("unknown recording format %q", format)
+	}
+}
+
+func (reader *StreamReader) Read() (Record, error) {
+	return reader.decode()
+}
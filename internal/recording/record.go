@@ -0,0 +1,19 @@
+package recording
+
+import (
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/modules"
+)
+
+const (
+	JSONL    = "jsonl"
+	Protobuf = "protobuf"
+)
+
+// Record is one event taken from the broker together with the time it was captured, exactly one of TCP and TLS is set
+type Record struct {
+	Time time.Time         `json:"time"`
+	TCP  *modules.TCPEvent `json:"tcp,omitempty"`
+	TLS  *modules.TLSEvent `json:"tls,omitempty"`
+}
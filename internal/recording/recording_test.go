@@ -0,0 +1,92 @@
+package recording
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/stretchr/testify/assert"
+)
+
+type buffer struct {
+	bytes.Buffer
+}
+
+func (buffer *buffer) Close() error {
+	return nil
+}
+
+func TestWriteRead(t *testing.T) {
+
+	records := []Record{
+		{Time: time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC), TCP: &modules.TCPEvent{
+			Client: modules.Address{Addr: "10.0.0.1", Port: 1234, Name: "pod.client", Namespace: "default", Container: "app", Pid: 42, Process: "curl"},
+			Server: modules.Address{Addr: "10.0.0.2", Port: 80, Name: "svc.server", Namespace: "default"},
+			TxB:    10, RxB: 20, DeltaUs: 30, Closed: true, Retransmits: 2, Reset: true, SrttUs: 120, RttVarUs: 15,
+			Time: time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC)}},
+		{Time: time.Date(2026, 10, 1, 10, 0, 0, 500, time.UTC), TCP: &modules.TCPEvent{
+			Client: modules.Address{Addr: "10.0.0.1", Port: 1236}, Server: modules.Address{Addr: "10.0.0.3", Port: 5432},
+			DeltaUs: 3000, Closed: true, Failed: true, Reset: true, Time: time.Date(2026, 10, 1, 10, 0, 0, 500, time.UTC)}},
+		{Time: time.Date(2026, 10, 1, 10, 0, 1, 0, time.UTC), TLS: &modules.TLSEvent{
+			Source:      modules.TC,
+			Client:      modules.Address{Addr: "10.0.0.1", Port: 1235},
+			Server:      modules.Address{Addr: "1.1.1.1", Port: 443},
+			TlsVersions: []uint16{772, 771}, Ciphers: []uint16{4865}, ServerName: "one.one.one.one",
+			UsedTlsVersion: 772, UsedCipher: 4865, HelloVersion: 771, Extensions: []uint16{0, 10, 11, 13, 16, 43},
+			SupportedGroups: []uint16{29, 23}, PointFormats: []uint8{0}, SignatureAlgorithms: []uint16{1027, 2052}, Alpn: []string{"h2", "http/1.1"},
+			UsedAlpn: "h2", Time: time.Date(2026, 10, 1, 10, 0, 1, 0, time.UTC)}},
+	}
+
+	for _, format := range []string{JSONL, Protobuf} {
+		t.Run(format, func(t *testing.T) {
+			out := &buffer{}
+			writer, err := NewWriter(out, format)
+			assert.NoError(t, err)
+			for _, record := range records {
+				assert.NoError(t, writer.Write(record))
+			}
+			assert.NoError(t, writer.Close())
+
+			reader, err := NewReader(&out.Buffer, format)
+			assert.NoError(t, err)
+			for _, want := range records {
+				got, err := reader.Read()
+				assert.NoError(t, err)
+				assert.True(t, want.Time.Equal(got.Time))
+				assert.EqualValues(t, want.TCP, got.TCP)
+				assert.EqualValues(t, want.TLS, got.TLS)
+			}
+			_, err = reader.Read()
+			assert.ErrorIs(t, err, io.EOF)
+		})
+	}
+}
+
+func TestFlush(t *testing.T) {
+	defer func(period time.Duration) { flushPeriod = period }(flushPeriod)
+	flushPeriod = 10 * time.Millisecond
+
+	out := &buffer{}
+	writer, err := NewWriter(out, JSONL)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Write(Record{Time: time.Now(), TCP: &modules.TCPEvent{TxB: 10}}))
+
+	// records are written out before the writer is closed
+	assert.Eventually(t, func() bool {
+		writer.mu.Lock()
+		defer writer.mu.Unlock()
+		return out.Len() > 0
+	}, time.Second, 10*time.Millisecond)
+	assert.NoError(t, writer.Close())
+}
+
+func TestUnknownFormat(t *testing.T) {
+
+	_, err := NewWriter(&buffer{}, "csv")
+	assert.ErrorContains(t, err, "unknown recording format")
+
+	_, err = NewReader(&bytes.Buffer{}, "csv")
+	assert.ErrorContains(t, err, "unknown recording format")
+}
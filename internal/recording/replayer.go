@@ -0,0 +1,135 @@
+package recording
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/broker"
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/k8spacket/k8spacket/internal/status"
+)
+
+const component = "replay"
+
+// modules fed by events the recorder does not write
+var unrecorded = []string{"dns", "httpparser", "listeners"}
+
+// Replayer is the loader of the replay source, it feeds a recording into the broker instead of eBPF programs,
+// so no kernel privileges are needed
+type Replayer struct {
+	store  *config.Store
+	broker broker.Broker
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewReplayer(store *config.Store, broker broker.Broker) *Replayer {
+	return &Replayer{store: store, broker: broker}
+}
+
+func (replayer *Replayer) Load(ctx context.Context) {
+	ctx, replayer.cancel = context.WithCancel(ctx)
+	replayer.done = make(chan struct{})
+	go func() {
+		defer close(replayer.done)
+		replayer.replay(ctx)
+	}()
+}
+
+// Stop interrupts the replay and waits until it returns or ctx is done
+func (replayer *Replayer) Stop(ctx context.Context) error {
+	if replayer.cancel == nil {
+		return nil
+	}
+	replayer.cancel()
+	select {
+	case <-replayer.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("replay not stopped: %w", ctx.Err())
+	}
+}
+
+func (replayer *Replayer) replay(ctx context.Context) {
+	cfg := replayer.store.Get().Loader.Replay
+	status.Report(component, status.Starting, "")
+
+	file, err := os.Open(cfg.Path)
+	if err != nil {
+		slog.Error("[replay] Cannot open recording", "Path", cfg.Path, "Error", err)
+		status.Report(component, status.Down, err.Error())
+		return
+	}
+	defer file.Close()
+	reader, err := NewReader(file, cfg.Format)
+	if err != nil {
+		status.Report(component, status.Down, err.Error())
+		return
+	}
+
+	slog.Info("[replay] Replaying recording", "Path", cfg.Path, "Format", cfg.Format, "Speed", cfg.Speed)
+	// recordings hold TCP and TLS events only
+	var starved []string
+	for _, module := range replayer.store.Get().Modules.Enabled {
+		if slices.Contains(unrecorded, module) {
+			starved = append(starved, module)
+		}
+	}
+	if len(starved) > 0 {
+		slog.Warn("[replay] DNS, HTTP and listen events are not recorded, modules get no events", "Modules", starved)
+	}
+	status.Report(component, status.Up, "")
+	// speed is read on every event to follow configuration reloads
+	count, err := Replay(ctx, reader, replayer.broker, func() float64 { return replayer.store.Get().Loader.Replay.Speed })
+	switch {
+	case errors.Is(err, context.Canceled):
+		slog.Info("[replay] Replay interrupted", "Events", count)
+	case err != nil:
+		slog.Error("[replay] Cannot read recording", "Events", count, "Error", err)
+		status.Report(component, status.Down, fmt.Sprintf("cannot read record %d: %v", count+1, err))
+	default:
+		slog.Info("[replay] Replay finished", "Events", count)
+		status.Report(component, status.Up, fmt.Sprintf("finished after %d events", count))
+	}
+}
+
+// Replay publishes records to the broker keeping gaps between them divided by speed, speed 0 publishes them
+// at once. It returns the number of published records.
+func Replay(ctx context.Context, reader Reader, broker broker.Broker, speed func() float64) (int, error) {
+	var previous time.Time
+	for count := 0; ; count++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+
+		if s := speed(); s > 0 && !previous.IsZero() && record.Time.After(previous) {
+			timer := time.NewTimer(time.Duration(float64(record.Time.Sub(previous)) / s))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return count, ctx.Err()
+			case <-timer.C:
+			}
+		} else if ctx.Err() != nil {
+			return count, ctx.Err()
+		}
+		previous = record.Time
+
+		if record.TCP != nil {
+			broker.TCPEvent(*record.TCP)
+		}
+		if record.TLS != nil {
+			broker.TLSEvent(*record.TLS)
+		}
+	}
+}
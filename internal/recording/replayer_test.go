@@ -0,0 +1,119 @@
+package recording
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/broker"
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/k8spacket/k8spacket/internal/status"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeBroker struct {
+	broker.Broker
+	tcp chan modules.TCPEvent
+	tls chan modules.TLSEvent
+}
+
+func newFakeBroker() *fakeBroker {
+	return &fakeBroker{tcp: make(chan modules.TCPEvent, 10), tls: make(chan modules.TLSEvent, 10)}
+}
+
+func (f *fakeBroker) TCPEvent(event modules.TCPEvent) { f.tcp <- event }
+func (f *fakeBroker) TLSEvent(event modules.TLSEvent) { f.tls <- event }
+
+func openRecording(t *testing.T) Reader {
+	file, err := os.Open("testdata/events.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { file.Close() })
+	reader, err := NewReader(file, JSONL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reader
+}
+
+func TestReplay(t *testing.T) {
+
+	// records of the recording are 50ms apart
+	var tests = []struct {
+		scenario string
+		speed    float64
+		min      time.Duration
+	}{
+		{"original speed", 1, 100 * time.Millisecond},
+		{"accelerated", 10, 10 * time.Millisecond},
+		{"as fast as possible", 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			fb := newFakeBroker()
+			start := time.Now()
+
+			count, err := Replay(context.Background(), openRecording(t), fb, func() float64 { return test.speed })
+
+			assert.NoError(t, err)
+			assert.EqualValues(t, 3, count)
+			assert.GreaterOrEqual(t, time.Since(start), test.min)
+			assert.Len(t, fb.tcp, 2)
+			assert.Len(t, fb.tls, 1)
+			tls := <-fb.tls
+			assert.EqualValues(t, modules.SocketFilter, tls.Source)
+			assert.EqualValues(t, "www.google.com", tls.ServerName)
+			assert.EqualValues(t, []uint16{4865, 4866, 4867}, tls.Ciphers)
+		})
+	}
+}
+
+func TestReplayCancel(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	fb := newFakeBroker()
+	go func() {
+		<-fb.tcp
+		cancel()
+	}()
+
+	count, err := Replay(ctx, openRecording(t), fb, func() float64 { return 0.001 })
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.EqualValues(t, 1, count)
+}
+
+func TestReplayer(t *testing.T) {
+
+	cfg := config.Default()
+	cfg.Loader.Source = "replay"
+	cfg.Loader.Replay = config.ReplayConfig{Path: "testdata/events.jsonl", Format: JSONL, Speed: 0}
+	fb := newFakeBroker()
+	replayer := NewReplayer(config.NewStore(cfg), fb)
+
+	replayer.Load(context.Background())
+	<-replayer.done
+
+	assert.NoError(t, replayer.Stop(context.Background()))
+	assert.Len(t, fb.tcp, 2)
+	assert.Len(t, fb.tls, 1)
+	assert.True(t, status.Default().Ready())
+}
+
+func TestReplayerMissingFile(t *testing.T) {
+
+	cfg := config.Default()
+	cfg.Loader.Replay = config.ReplayConfig{Path: "testdata/missing.jsonl", Format: JSONL}
+	replayer := NewReplayer(config.NewStore(cfg), newFakeBroker())
+
+	replayer.Load(context.Background())
+	<-replayer.done
+
+	assert.NoError(t, replayer.Stop(context.Background()))
+	component, _ := status.Default().Component("replay")
+	assert.EqualValues(t, status.Down, component.State)
+}
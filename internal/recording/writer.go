@@ -0,0 +1,86 @@
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protodelim"
+)
+
+type Writer interface {
+	Write(record Record) error
+	Close() error
+}
+
+// buffered records are written out at least this often, so a recording can be followed while events are rare
+var flushPeriod = time.Second
+
+// StreamWriter writes records one per line (jsonl) or length-delimited (protobuf), it is safe for concurrent use
+type StreamWriter struct {
+	mu     sync.Mutex
+	out    io.WriteCloser
+	buffer *bufio.Writer
+	encode func(record Record) error
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+func NewWriter(out io.WriteCloser, format string) (*StreamWriter, error) {
+	writer := &StreamWriter{out: out, buffer: bufio.NewWriter(out), stop: make(chan struct{}), done: make(chan struct{})}
+	switch format {
+	case JSONL:
+		encoder := json.NewEncoder(writer.buffer)
+		writer.encode = func(record Record) error { return encoder.Encode(record) }
+	case Protobuf:
+		writer.encode = func(record Record) error {
+			_, err := protodelim.MarshalTo(writer.buffer, toProto(record))
+			return err
+		}
+	default:
+		return nil, fmt.Errorf("unknown recording format %q", format)
+	}
+	go writer.flushPeriodically()
+	return writer, nil
+}
+
+func (writer *StreamWriter) Write(record Record) error {
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	return writer.encode(record)
+}
+
+func (writer *StreamWriter) flushPeriodically() {
+	defer close(writer.done)
+	ticker := time.NewTicker(flushPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-writer.stop:
+			return
+		case <-ticker.C:
+			writer.mu.Lock()
+			if err := writer.buffer.Flush(); err != nil {
+				slog.Error("[recorder] Cannot flush recording", "Error", err)
+			}
+			writer.mu.Unlock()
+		}
+	}
+}
+
+// Close flushes buffered records and closes the underlying file
+func (writer *StreamWriter) Close() error {
+	close(writer.stop)
+	<-writer.done
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	if err := writer.buffer.Flush(); err != nil {
+		writer.out.Close()
+		return err
+	}
+	return writer.out.Close()
+}
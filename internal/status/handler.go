@@ -0,0 +1,50 @@
+package status
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+type Handler struct {
+	registry *Registry
+}
+
+func NewHandler(registry *Registry) *Handler {
+	return &Handler{registry: registry}
+}
+
+type statusResponse struct {
+	Ready  bool    `json:"ready"`
+	Groups []Group `json:"groups"`
+}
+
+// HealthzHandler answers liveness probes as long as the process serves requests, components being down is a matter
+// of readiness and a restart would not bring back e.g. a tracepoint the kernel refuses
+func (handler *Handler) HealthzHandler(w http.ResponseWriter, _ *http.Request) {
+	probe(w, true)
+}
+
+// ReadyzHandler answers readiness probes, it fails until every group of components works at least partially
+func (handler *Handler) ReadyzHandler(w http.ResponseWriter, _ *http.Request) {
+	probe(w, handler.registry.Ready())
+}
+
+// StatusHandler explains the state of every component and why it is degraded or down
+func (handler *Handler) StatusHandler(w http.ResponseWriter, _ *http.Request) {
+	response := statusResponse{Ready: handler.registry.Ready(), Groups: handler.registry.Groups()}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.Error("[api] Cannot prepare status response", "Error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func probe(w http.ResponseWriter, ok bool) {
+	if !ok {
+		http.Error(w, "not ok, see /api/status", http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok"))
+}
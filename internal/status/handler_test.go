@@ -0,0 +1,58 @@
+package status
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbes(t *testing.T) {
+
+	var tests = []struct {
+		scenario string
+		state    State
+		healthz  int
+		readyz   int
+	}{
+		{"up", Up, http.StatusOK, http.StatusOK},
+		{"starting", Starting, http.StatusOK, http.StatusServiceUnavailable},
+		{"down", Down, http.StatusOK, http.StatusServiceUnavailable},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			registry := NewRegistry()
+			registry.Report("inet", test.state, "")
+			handler := NewHandler(registry)
+
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(handler.HealthzHandler).ServeHTTP(rr, httptest.NewRequest("GET", "/healthz", nil))
+			assert.EqualValues(t, test.healthz, rr.Code)
+
+			rr = httptest.NewRecorder()
+			http.HandlerFunc(handler.ReadyzHandler).ServeHTTP(rr, httptest.NewRequest("GET", "/readyz", nil))
+			assert.EqualValues(t, test.readyz, rr.Code)
+		})
+	}
+}
+
+func TestStatusHandler(t *testing.T) {
+	registry := NewRegistry()
+	registry.Report("tc/eth0", Up, "")
+	registry.Report("tc/eth1", Down, "cannot attach filter: operation not permitted")
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(NewHandler(registry).StatusHandler).ServeHTTP(rr, httptest.NewRequest("GET", "/api/status", nil))
+
+	assert.EqualValues(t, http.StatusOK, rr.Code)
+	assert.EqualValues(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var result statusResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+	assert.True(t, result.Ready)
+	assert.EqualValues(t, Degraded, result.Groups[0].State)
+	assert.EqualValues(t, "cannot attach filter: operation not permitted", result.Groups[0].Components[1].Reason)
+}
@@ -0,0 +1,131 @@
+package status
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type State string
+
+const (
+	Starting State = "starting"
+	Up       State = "up"
+	Degraded State = "degraded"
+	Down     State = "down"
+)
+
+// Component is a part of the capture pipeline reporting its own state, e.g. `tc/eth0` or `db/ConnectionItem`.
+// Components sharing the prefix before `/` form a group.
+type Component struct {
+	Name   string    `json:"name"`
+	State  State     `json:"state"`
+	Reason string    `json:"reason,omitempty"`
+	Since  time.Time `json:"since"`
+}
+
+type Group struct {
+	Name       string      `json:"name"`
+	State      State       `json:"state"`
+	Components []Component `json:"components"`
+}
+
+type Registry struct {
+	mu         sync.RWMutex
+	components map[string]Component
+}
+
+func NewRegistry() *Registry {
+	return &Registry{components: make(map[string]Component)}
+}
+
+var defaultRegistry = NewRegistry()
+
+// Default returns the registry components of the running instance report to
+func Default() *Registry {
+	return defaultRegistry
+}
+
+func Report(name string, state State, reason string) {
+	defaultRegistry.Report(name, state, reason)
+}
+
+func Remove(name string) {
+	defaultRegistry.Remove(name)
+}
+
+func (registry *Registry) Report(name string, state State, reason string) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	component, ok := registry.components[name]
+	if !ok || component.State != state {
+		component.Since = time.Now()
+	}
+	component.Name, component.State, component.Reason = name, state, reason
+	registry.components[name] = component
+}
+
+func (registry *Registry) Remove(name string) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	delete(registry.components, name)
+}
+
+// Component returns the last state reported by a component
+func (registry *Registry) Component(name string) (Component, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	component, ok := registry.components[name]
+	return component, ok
+}
+
+// Groups returns components grouped by name prefix. A group is up when all its components are up, degraded when
+// only some are up, starting while none is up yet and some are starting, and down when all are down.
+func (registry *Registry) Groups() []Group {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	groups := make(map[string]*Group)
+	for _, component := range registry.components {
+		name, _, _ := strings.Cut(component.Name, "/")
+		group, ok := groups[name]
+		if !ok {
+			group = &Group{Name: name}
+			groups[name] = group
+		}
+		group.Components = append(group.Components, component)
+	}
+	var result []Group
+	for _, group := range groups {
+		slices.SortFunc(group.Components, func(a, b Component) int { return strings.Compare(a.Name, b.Name) })
+		group.State = groupState(group.Components)
+		result = append(result, *group)
+	}
+	slices.SortFunc(result, func(a, b Group) int { return strings.Compare(a.Name, b.Name) })
+	return result
+}
+
+func groupState(components []Component) State {
+	count := make(map[State]int)
+	for _, component := range components {
+		count[component.State]++
+	}
+	switch {
+	case count[Up] == len(components):
+		return Up
+	case count[Up] > 0 || count[Degraded] > 0:
+		return Degraded
+	case count[Starting] > 0:
+		return Starting
+	default:
+		return Down
+	}
+}
+
+// Ready tells whether every group works, at least partially
+func (registry *Registry) Ready() bool {
+	groups := registry.Groups()
+	return len(groups) > 0 && !slices.ContainsFunc(groups, func(group Group) bool {
+		return group.State != Up && group.State != Degraded
+	})
+}
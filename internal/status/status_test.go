@@ -0,0 +1,70 @@
+package status
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroups(t *testing.T) {
+
+	type component struct {
+		name  string
+		state State
+	}
+
+	var tests = []struct {
+		scenario   string
+		components []component
+		want       State
+		ready      bool
+	}{
+		{"all up", []component{{"tc/eth0", Up}, {"tc/eth1", Up}}, Up, true},
+		{"some up", []component{{"tc/eth0", Up}, {"tc/eth1", Down}}, Degraded, true},
+		{"starting", []component{{"tc/eth0", Starting}, {"tc/eth1", Down}}, Starting, false},
+		{"all down", []component{{"tc/eth0", Down}, {"tc/eth1", Down}}, Down, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			registry := NewRegistry()
+			for _, component := range test.components {
+				registry.Report(component.name, component.state, "reason")
+			}
+
+			groups := registry.Groups()
+
+			assert.Len(t, groups, 1)
+			assert.EqualValues(t, "tc", groups[0].Name)
+			assert.EqualValues(t, test.want, groups[0].State)
+			assert.EqualValues(t, "tc/eth0", groups[0].Components[0].Name)
+			assert.EqualValues(t, test.ready, registry.Ready())
+		})
+	}
+}
+
+func TestReport(t *testing.T) {
+	registry := NewRegistry()
+
+	assert.False(t, registry.Ready())
+
+	registry.Report("inet", Starting, "")
+	since := registry.Groups()[0].Components[0].Since
+	registry.Report("inet", Starting, "still starting")
+	assert.EqualValues(t, since, registry.Groups()[0].Components[0].Since)
+	assert.EqualValues(t, "still starting", registry.Groups()[0].Components[0].Reason)
+
+	registry.Report("inet", Up, "")
+	registry.Report("k8s/informers", Up, "")
+	assert.True(t, registry.Ready())
+	assert.Len(t, registry.Groups(), 2)
+
+	component, ok := registry.Component("k8s/informers")
+	assert.True(t, ok)
+	assert.EqualValues(t, Up, component.State)
+
+	registry.Remove("k8s/informers")
+	assert.Len(t, registry.Groups(), 1)
+	_, ok = registry.Component("k8s/informers")
+	assert.False(t, ok)
+}
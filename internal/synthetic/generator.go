@@ -0,0 +1,174 @@
+package synthetic
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/broker"
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/k8spacket/k8spacket/internal/modules/tlsparser/dict"
+	"github.com/k8spacket/k8spacket/internal/status"
+)
+
+const (
+	component = "synthetic"
+	tick      = 10 * time.Millisecond
+)
+
+// application protocols offered by clients, picked per handshake so that a few fingerprints show up
+var alpns = [][]string{{"h2", "http/1.1"}, {"http/1.1"}, nil}
+
+// Generator is the loader of the synthetic source, it publishes fake TCP and TLS events to the broker
+// instead of eBPF programs, to demo dashboards and benchmark the pipeline without a cluster
+type Generator struct {
+	store  *config.Store
+	broker broker.Broker
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewGenerator(store *config.Store, broker broker.Broker) *Generator {
+	return &Generator{store: store, broker: broker}
+}
+
+func (generator *Generator) Load(ctx context.Context) {
+	cfg := generator.store.Get().Loader.Synthetic
+	seed := uint64(cfg.Seed)
+	if seed == 0 {
+		seed = uint64(time.Now().UnixNano())
+	}
+	random := rand.New(rand.NewPCG(seed, seed))
+	population := newPopulation(cfg.Pods, cfg.Services, cfg.ExternalHosts, random)
+	slog.Info("[synthetic] Generating traffic", "Pods", cfg.Pods, "Services", cfg.Services, "ExternalHosts", cfg.ExternalHosts, "Seed", seed)
+
+	ctx, generator.cancel = context.WithCancel(ctx)
+	generator.done = make(chan struct{})
+	go func() {
+		defer close(generator.done)
+		status.Report(component, status.Up, "")
+		generator.run(ctx, population, random)
+	}()
+}
+
+// Stop stops generating events and waits until the generator returns or ctx is done
+func (generator *Generator) Stop(ctx context.Context) error {
+	if generator.cancel == nil {
+		return nil
+	}
+	generator.cancel()
+	select {
+	case <-generator.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("synthetic generator not stopped: %w", ctx.Err())
+	}
+}
+
+// run publishes on every tick as many events as rates allow for the time elapsed, so high rates don't need
+// a timer per event. Settings are read on every tick to follow configuration reloads.
+func (generator *Generator) run(ctx context.Context, population *population, random *rand.Rand) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	last := time.Now()
+	var tcpDue, tlsDue float64
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("[synthetic] Receive signal, exiting...")
+			return
+		case now := <-ticker.C:
+			cfg := generator.store.Get().Loader.Synthetic
+			elapsed := now.Sub(last).Seconds()
+			last = now
+			for tcpDue += cfg.TcpRate * elapsed; tcpDue >= 1; tcpDue-- {
+				generator.broker.TCPEvent(tcpEvent(population, cfg, random, now))
+			}
+			for tlsDue += cfg.TlsRate * elapsed; tlsDue >= 1; tlsDue-- {
+				generator.broker.TLSEvent(tlsEvent(population, cfg, random, now))
+			}
+		}
+	}
+}
+
+// tcpEvent is a closed connection with a round-trip time up to 10ms, one out of ten retransmits a few segments
+// and one out of fifty is reset
+func tcpEvent(population *population, cfg config.SyntheticConfig, random *rand.Rand, now time.Time) modules.TCPEvent {
+	srtt := uint32(random.IntN(10000) + 50)
+	var retransmits uint32
+	if random.IntN(10) == 0 {
+		retransmits = uint32(random.IntN(5) + 1)
+	}
+	return modules.TCPEvent{
+		Client: population.pod(random),
+		Server: population.server(random),
+		TxB:    uint64(random.IntN(cfg.MaxBytes) + 1),
+		RxB:    uint64(random.IntN(cfg.MaxBytes) + 1),
+		// eBPF programs report the duration in milliseconds
+		DeltaUs:     uint64(random.Int64N(cfg.MaxDuration.Milliseconds() + 1)),
+		Closed:      true,
+		Retransmits: retransmits,
+		Reset:       random.IntN(50) == 0,
+		SrttUs:      srtt,
+		RttVarUs:    uint32(random.IntN(int(srtt)/2 + 1)),
+		Time:        now,
+	}
+}
+
+// tlsEvent offers all configured TLS versions and cipher suites, the server picks the first version and a random cipher suite.
+// The ClientHello carries the extensions a client sends for them, the selected protocol is only seen below TLS 1.3.
+func tlsEvent(population *population, cfg config.SyntheticConfig, random *rand.Rand, now time.Time) modules.TLSEvent {
+	versions := codes(cfg.TlsVersions, dict.TLSVersionCode)
+	ciphers := codes(cfg.CipherSuites, dict.CipherSuiteCode)
+	server, serverName := population.tlsServer(random)
+	alpn := alpns[random.IntN(len(alpns))]
+	// server_name, ec_point_formats, supported_groups, signature_algorithms, then ALPN, supported_versions and key_share
+	extensions := []uint16{0x0000, 0x000b, 0x000a, 0x000d}
+	if len(alpn) > 0 {
+		extensions = append(extensions, 0x0010)
+	}
+	extensions = append(extensions, 0x002b)
+	if slices.Contains(versions, 0x0304) {
+		extensions = append(extensions, 0x0033)
+	}
+	var usedAlpn string
+	if versions[0] != 0x0304 && len(alpn) > 0 {
+		usedAlpn = alpn[0]
+	}
+	return modules.TLSEvent{
+		Source:         modules.Synthetic,
+		Client:         population.pod(random),
+		Server:         server,
+		TlsVersions:    versions,
+		Ciphers:        ciphers,
+		ServerName:     serverName,
+		UsedTlsVersion: versions[0],
+		UsedCipher:     ciphers[random.IntN(len(ciphers))],
+		UsedAlpn:       usedAlpn,
+		Time:           now,
+		// the legacy version field stays at TLS 1.2 when TLS 1.3 is offered
+		HelloVersion: min(slices.Max(versions), 0x0303),
+		Extensions:   extensions,
+		// x25519, secp256r1, secp384r1
+		SupportedGroups: []uint16{0x001d, 0x0017, 0x0018},
+		PointFormats:    []uint8{0},
+		// ecdsa and rsa_pss_rsae with SHA-256 and SHA-384, rsa_pkcs1 with SHA-256
+		SignatureAlgorithms: []uint16{0x0403, 0x0804, 0x0503, 0x0805, 0x0401},
+		Alpn:                alpn,
+	}
+}
+
+// names are validated with the configuration, unknown ones are skipped
+func codes(names []string, code func(name string) (uint16, bool)) []uint16 {
+	var codes []uint16
+	for _, name := range names {
+		if value, ok := code(name); ok {
+			codes = append(codes, value)
+		}
+	}
+	return codes
+}
@@ -0,0 +1,147 @@
+package synthetic
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/k8spacket/k8spacket/internal/broker"
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/k8spacket/k8spacket/internal/modules"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeBroker struct {
+	broker.Broker
+	tcp atomic.Int64
+	tls atomic.Int64
+}
+
+func (f *fakeBroker) TCPEvent(event modules.TCPEvent) { f.tcp.Add(1) }
+func (f *fakeBroker) TLSEvent(event modules.TLSEvent) { f.tls.Add(1) }
+
+func TestPopulation(t *testing.T) {
+
+	population := newPopulation(300, 5, 260, rand.New(rand.NewPCG(1, 1)))
+
+	assert.Len(t, population.pods, 300)
+	assert.Len(t, population.services, 5)
+	assert.Len(t, population.external, 260)
+
+	addresses := make(map[string]bool)
+	for _, pod := range population.pods {
+		addresses[pod.Addr] = true
+	}
+	for _, service := range population.services {
+		addresses[service.Addr] = true
+	}
+	for _, host := range population.external {
+		addresses[host.address.Addr] = true
+	}
+	assert.Len(t, addresses, 565)
+
+	// the same seed gives the same population
+	assert.EqualValues(t, population, newPopulation(300, 5, 260, rand.New(rand.NewPCG(1, 1))))
+}
+
+func TestEvents(t *testing.T) {
+
+	var tests = []struct {
+		scenario      string
+		externalHosts int
+		serverName    string
+	}{
+		{"external hosts", 3, ".example.com"},
+		{"services only", 0, ".svc.cluster.local"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			cfg := config.Default().Loader.Synthetic
+			random := rand.New(rand.NewPCG(1, 1))
+			population := newPopulation(cfg.Pods, cfg.Services, test.externalHosts, random)
+			now := time.Now()
+			var retransmitted int
+
+			for range 100 {
+				tcp := tcpEvent(population, cfg, random, now)
+				assert.EqualValues(t, now, tcp.Time)
+				assert.Contains(t, tcp.Client.Name, "pod.")
+				assert.NotEmpty(t, tcp.Server.Name)
+				assert.LessOrEqual(t, tcp.TxB, uint64(cfg.MaxBytes))
+				assert.LessOrEqual(t, tcp.DeltaUs, uint64(cfg.MaxDuration.Milliseconds()))
+				assert.LessOrEqual(t, tcp.Retransmits, uint32(5))
+				assert.Positive(t, tcp.SrttUs)
+				assert.LessOrEqual(t, tcp.RttVarUs, tcp.SrttUs/2)
+				if tcp.Retransmits > 0 {
+					retransmitted++
+				}
+
+				tls := tlsEvent(population, cfg, random, now)
+				assert.EqualValues(t, now, tls.Time)
+				assert.EqualValues(t, modules.Synthetic, tls.Source)
+				assert.EqualValues(t, 443, tls.Server.Port)
+				assert.Contains(t, tls.ServerName, test.serverName)
+				assert.EqualValues(t, []uint16{0x0304, 0x0303}, tls.TlsVersions)
+				assert.EqualValues(t, 0x0304, tls.UsedTlsVersion)
+				assert.Contains(t, tls.Ciphers, tls.UsedCipher)
+				assert.EqualValues(t, 0x0303, tls.HelloVersion)
+				assert.Contains(t, tls.Extensions, uint16(0x0033))
+				assert.EqualValues(t, len(tls.Alpn) > 0, slices.Contains(tls.Extensions, 0x0010))
+				assert.NotEmpty(t, tls.SupportedGroups)
+				assert.NotEmpty(t, tls.PointFormats)
+				assert.NotEmpty(t, tls.SignatureAlgorithms)
+				// the selected protocol is encrypted in TLS 1.3
+				assert.Empty(t, tls.UsedAlpn)
+			}
+			assert.Positive(t, retransmitted)
+		})
+	}
+}
+
+func TestTls12Event(t *testing.T) {
+	cfg := config.Default().Loader.Synthetic
+	cfg.TlsVersions = []string{"TLS 1.2"}
+	random := rand.New(rand.NewPCG(1, 1))
+	population := newPopulation(cfg.Pods, cfg.Services, cfg.ExternalHosts, random)
+
+	var selected int
+	for range 100 {
+		tls := tlsEvent(population, cfg, random, time.Now())
+		assert.EqualValues(t, 0x0303, tls.HelloVersion)
+		assert.NotContains(t, tls.Extensions, uint16(0x0033))
+		if len(tls.Alpn) > 0 {
+			assert.EqualValues(t, tls.Alpn[0], tls.UsedAlpn)
+			selected++
+		} else {
+			assert.Empty(t, tls.UsedAlpn)
+		}
+	}
+	assert.Positive(t, selected)
+}
+
+func TestGenerator(t *testing.T) {
+
+	cfg := config.Default()
+	cfg.Loader.Source = "synthetic"
+	cfg.Loader.Synthetic.Seed = 1
+	cfg.Loader.Synthetic.TcpRate = 1000
+	cfg.Loader.Synthetic.TlsRate = 100
+	fb := &fakeBroker{}
+	generator := NewGenerator(config.NewStore(cfg), fb)
+
+	generator.Load(context.Background())
+	time.Sleep(200 * time.Millisecond)
+	assert.NoError(t, generator.Stop(context.Background()))
+
+	tcp, tls := fb.tcp.Load(), fb.tls.Load()
+	assert.InDelta(t, 200, tcp, 150)
+	assert.InDelta(t, 20, tls, 15)
+
+	// nothing is published once stopped
+	time.Sleep(50 * time.Millisecond)
+	assert.EqualValues(t, tcp, fb.tcp.Load())
+}
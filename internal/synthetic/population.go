@@ -0,0 +1,82 @@
+package synthetic
+
+import (
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/k8spacket/k8spacket/internal/modules"
+)
+
+var namespaces = []string{"shop", "payments", "monitoring", "default"}
+var apps = []string{"frontend", "cart", "checkout", "catalog", "auth", "search", "worker", "gateway"}
+var servicePorts = []uint16{80, 8080, 5432, 6379, 9090}
+
+// population is the fixed set of fake pods, services and external hosts traffic is generated between
+type population struct {
+	pods     []modules.Address
+	services []modules.Address
+	external []external
+}
+
+type external struct {
+	address    modules.Address
+	serverName string
+}
+
+func newPopulation(pods int, services int, externalHosts int, random *rand.Rand) *population {
+	population := &population{}
+	for i := range pods {
+		app := apps[i%len(apps)]
+		population.pods = append(population.pods, modules.Address{
+			Addr:      fmt.Sprintf("10.244.%d.%d", i/250, i%250+2),
+			Name:      fmt.Sprintf("pod.%s-%08x-%d", app, random.Uint32(), i),
+			Namespace: namespaces[i%len(namespaces)],
+		})
+	}
+	for i := range services {
+		population.services = append(population.services, modules.Address{
+			Addr:      fmt.Sprintf("10.96.%d.%d", i/250, i%250+10),
+			Port:      servicePorts[i%len(servicePorts)],
+			Name:      fmt.Sprintf("svc.%s-%d", apps[i%len(apps)], i),
+			Namespace: namespaces[i%len(namespaces)],
+		})
+	}
+	// addresses come from documentation ranges (RFC 5737), so they never collide with real hosts
+	for i := range externalHosts {
+		population.external = append(population.external, external{
+			address: modules.Address{
+				Addr: fmt.Sprintf("%s.%d", []string{"203.0.113", "198.51.100", "192.0.2"}[i/254%3], i%254+1),
+				Port: 443,
+				Name: fmt.Sprintf("EXAMPLE-ORG-%d", i),
+			},
+			serverName: fmt.Sprintf("api-%d.example.com", i),
+		})
+	}
+	return population
+}
+
+func (population *population) pod(random *rand.Rand) modules.Address {
+	pod := population.pods[random.IntN(len(population.pods))]
+	pod.Port = uint16(32768 + random.IntN(28232))
+	return pod
+}
+
+// server picks a service or an external host, all of them equally likely
+func (population *population) server(random *rand.Rand) modules.Address {
+	i := random.IntN(len(population.services) + len(population.external))
+	if i < len(population.services) {
+		return population.services[i]
+	}
+	return population.external[i-len(population.services)].address
+}
+
+// tlsServer picks an external host with its server name, or a service when there are no external hosts
+func (population *population) tlsServer(random *rand.Rand) (modules.Address, string) {
+	if len(population.external) == 0 {
+		service := population.services[random.IntN(len(population.services))]
+		service.Port = 443
+		return service, fmt.Sprintf("%s.%s.svc.cluster.local", service.Name[len("svc."):], service.Namespace)
+	}
+	host := population.external[random.IntN(len(population.external))]
+	return host.address, host.serverName
+}
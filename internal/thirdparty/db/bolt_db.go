@@ -3,18 +3,23 @@ package db
 import (
 	"fmt"
 	"hash/fnv"
+	"reflect"
+	"time"
 
+	http_model "github.com/k8spacket/k8spacket/internal/modules/httpparser/model"
 	tcp_model "github.com/k8spacket/k8spacket/internal/modules/nodegraph/model"
 	tls_model "github.com/k8spacket/k8spacket/internal/modules/tlsparser/model"
+	"github.com/k8spacket/k8spacket/internal/status"
 	"github.com/timshannon/bolthold"
 	"go.etcd.io/bbolt"
 )
 
-type BoltDb[T tls_model.TLSDetails | tls_model.TLSConnection | tcp_model.ConnectionItem] struct {
-	store *bolthold.Store
+type BoltDb[T tls_model.TLSDetails | tls_model.TLSConnection | tcp_model.ConnectionItem | http_model.HTTPEdgeItem] struct {
+	store  *bolthold.Store
+	bucket string
 }
 
-func New[T tls_model.TLSDetails | tls_model.TLSConnection | tcp_model.ConnectionItem](dbname string) (Db[T], error) {
+func New[T tls_model.TLSDetails | tls_model.TLSConnection | tcp_model.ConnectionItem | http_model.HTTPEdgeItem](dbname string) (Db[T], error) {
 	database, err := bolthold.Open(fmt.Sprintf("%s.db", dbname), 0600, &bolthold.Options{
 		Encoder: func(v interface{}) ([]byte, error) {
 			return marshalProto(v)
@@ -23,14 +28,19 @@ func New[T tls_model.TLSDetails | tls_model.TLSConnection | tcp_model.Connection
 			return unmarshalProto(data, v)
 		},
 	})
+	// bolthold keeps records of a type in the bucket named after the type
+	bucket := reflect.TypeFor[T]().Name()
 	if err != nil {
+		status.Report(component(bucket), status.Down, err.Error())
 		return nil, err
 	}
-	return &BoltDb[T]{database}, nil
+	status.Report(component(bucket), status.Up, "")
+	return &BoltDb[T]{store: database, bucket: bucket}, nil
 
 }
 
 func (boltDb *BoltDb[T]) Close() error {
+	status.Remove(component(boltDb.bucket))
 	return boltDb.store.Close()
 }
 
@@ -64,10 +74,23 @@ func (boltDb *BoltDb[T]) QueryMatchFunc(field string, matchFunc func(*T) (bool,
 }
 
 func (boltDb *BoltDb[T]) Upsert(key string, value *T) error {
-	return boltDb.store.Bolt().Update(
+	start := time.Now()
+	err := boltDb.store.Bolt().Update(
 		func(tx *bbolt.Tx) error {
 			return boltDb.store.TxUpsert(tx, key, value)
 		})
+	UpsertDurationMetric.WithLabelValues(boltDb.bucket).Observe(time.Since(start).Seconds())
+	if err != nil {
+		UpsertErrorsMetric.WithLabelValues(boltDb.bucket).Inc()
+		status.Report(component(boltDb.bucket), status.Down, "cannot upsert: "+err.Error())
+		return err
+	}
+	status.Report(component(boltDb.bucket), status.Up, "")
+	return nil
+}
+
+func component(bucket string) string {
+	return "db/" + bucket
 }
 
 func HashId(s string) uint32 {
@@ -3,8 +3,13 @@ package db
 import (
 	"path/filepath"
 	"testing"
+	"time"
 
+	http_model "github.com/k8spacket/k8spacket/internal/modules/httpparser/model"
 	tcp_model "github.com/k8spacket/k8spacket/internal/modules/nodegraph/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/timshannon/bolthold"
 )
@@ -48,6 +53,43 @@ func TestBoltDb_UpsertReadQuery(t *testing.T) {
 	assert.Equal(t, "5.5.5.5", res2[0].Dst)
 }
 
+func TestBoltDb_HTTPEdgeItem(t *testing.T) {
+	dbpath := filepath.Join(t.TempDir(), "testdb")
+	db, err := New[http_model.HTTPEdgeItem](dbpath)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	item := http_model.HTTPEdgeItem{Src: "1.1.1.1", Dst: "2.2.2.2", Method: "GET", Path: "/api/{id}", RequestCount: 3, ServerErrorCount: 1, Duration: 0.3, LastSeen: time.Now().UTC()}
+	assert.NoError(t, db.Upsert("k1", &item))
+
+	got, err := db.Read("k1")
+	assert.NoError(t, err)
+	assert.Equal(t, item, got)
+}
+
+func TestBoltDb_UpsertMetrics(t *testing.T) {
+	dbpath := filepath.Join(t.TempDir(), "testdb")
+	db, err := New[tcp_model.ConnectionItem](dbpath)
+	assert.NoError(t, err)
+
+	failures := testutil.ToFloat64(UpsertErrorsMetric.WithLabelValues("ConnectionItem"))
+	upserts := upsertCount(t, "ConnectionItem")
+
+	assert.NoError(t, db.Upsert("k1", &tcp_model.ConnectionItem{Src: "1.1.1.1", Dst: "2.2.2.2"}))
+	assert.Equal(t, failures, testutil.ToFloat64(UpsertErrorsMetric.WithLabelValues("ConnectionItem")))
+
+	assert.NoError(t, db.Close())
+	assert.Error(t, db.Upsert("k2", &tcp_model.ConnectionItem{Src: "1.1.1.1", Dst: "2.2.2.2"}))
+	assert.Equal(t, failures+1, testutil.ToFloat64(UpsertErrorsMetric.WithLabelValues("ConnectionItem")))
+	assert.Equal(t, upserts+2, upsertCount(t, "ConnectionItem"))
+}
+
+func upsertCount(t *testing.T, bucket string) uint64 {
+	metric := &dto.Metric{}
+	assert.NoError(t, UpsertDurationMetric.WithLabelValues(bucket).(prometheus.Metric).Write(metric))
+	return metric.GetHistogram().GetSampleCount()
+}
+
 func TestHashId(t *testing.T) {
 	h1 := HashId("abc")
 	h2 := HashId("abc")
@@ -3,8 +3,10 @@ package db
 import (
 	"fmt"
 
+	http_model "github.com/k8spacket/k8spacket/internal/modules/httpparser/model"
 	tcp_model "github.com/k8spacket/k8spacket/internal/modules/nodegraph/model"
 	tls_model "github.com/k8spacket/k8spacket/internal/modules/tlsparser/model"
+	proto_http "github.com/k8spacket/k8spacket/internal/proto/httpparser/model"
 	proto_tcp "github.com/k8spacket/k8spacket/internal/proto/nodegraph/model"
 	proto_tls "github.com/k8spacket/k8spacket/internal/proto/tlsparser/model"
 	"google.golang.org/protobuf/proto"
@@ -25,6 +27,10 @@ func tlsDetailsToProto(in *tls_model.TLSDetails) *proto_tls.TLSDetails {
 		ClientCipherSuites: in.ClientCipherSuites,
 		UsedTLSVersion:     in.UsedTLSVersion,
 		UsedCipherSuite:    in.UsedCipherSuite,
+		ClientAlpn:         in.ClientAlpn,
+		UsedAlpn:           in.UsedAlpn,
+		Ja3:                in.JA3,
+		Ja4:                in.JA4,
 		Certificate: &proto_tls.Certificate{
 			NotBefore:   timestamppb.New(in.Certificate.NotBefore),
 			NotAfter:    timestamppb.New(in.Certificate.NotAfter),
@@ -57,6 +63,10 @@ func tlsDetailsFromProto(in *proto_tls.TLSDetails) *tls_model.TLSDetails {
 		UsedTLSVersion:     in.UsedTLSVersion,
 		UsedCipherSuite:    in.UsedCipherSuite,
 		Certificate:        cert,
+		ClientAlpn:         in.ClientAlpn,
+		UsedAlpn:           in.UsedAlpn,
+		JA3:                in.Ja3,
+		JA4:                in.Ja4,
 	}
 }
 
@@ -76,6 +86,9 @@ func tlsConnectionToProto(in *tls_model.TLSConnection) *proto_tls.TLSConnection
 		Domain:          in.Domain,
 		UsedTLSVersion:  in.UsedTLSVersion,
 		UsedCipherSuite: in.UsedCipherSuite,
+		UsedAlpn:        in.UsedAlpn,
+		Ja3:             in.JA3,
+		Ja4:             in.JA4,
 		LastSeen:        timestamppb.New(in.LastSeen),
 	}
 }
@@ -95,6 +108,9 @@ func tlsConnectionFromProto(in *proto_tls.TLSConnection) *tls_model.TLSConnectio
 		Domain:          in.Domain,
 		UsedTLSVersion:  in.UsedTLSVersion,
 		UsedCipherSuite: in.UsedCipherSuite,
+		UsedAlpn:        in.UsedAlpn,
+		JA3:             in.Ja3,
+		JA4:             in.Ja4,
 		LastSeen:        in.LastSeen.AsTime(),
 	}
 }
@@ -118,6 +134,16 @@ func connectionItemToProto(in *tcp_model.ConnectionItem) *proto_tcp.ConnectionIt
 		Duration:       in.Duration,
 		MaxDuration:    in.MaxDuration,
 		LastSeen:       timestamppb.New(in.LastSeen),
+		Retransmits:    in.Retransmits,
+		ConnReset:      in.ConnReset,
+		Rtt:            in.Rtt,
+		MaxRtt:         in.MaxRtt,
+		RttCount:       in.RttCount,
+		ConnFailed:     in.ConnFailed,
+		SrcContainer:   in.SrcContainer,
+		SrcProcess:     in.SrcProcess,
+		DstContainer:   in.DstContainer,
+		DstProcess:     in.DstProcess,
 	}
 }
 
@@ -139,6 +165,61 @@ func connectionItemFromProto(in *proto_tcp.ConnectionItem) *tcp_model.Connection
 		Duration:       in.Duration,
 		MaxDuration:    in.MaxDuration,
 		LastSeen:       in.LastSeen.AsTime(),
+		Retransmits:    in.Retransmits,
+		ConnReset:      in.ConnReset,
+		Rtt:            in.Rtt,
+		MaxRtt:         in.MaxRtt,
+		RttCount:       in.RttCount,
+		ConnFailed:     in.ConnFailed,
+		SrcContainer:   in.SrcContainer,
+		SrcProcess:     in.SrcProcess,
+		DstContainer:   in.DstContainer,
+		DstProcess:     in.DstProcess,
+	}
+}
+
+// Converter functions for HTTPEdgeItem
+func httpEdgeItemToProto(in *http_model.HTTPEdgeItem) *proto_http.HTTPEdgeItem {
+	if in == nil {
+		return nil
+	}
+	return &proto_http.HTTPEdgeItem{
+		Src:              in.Src,
+		SrcName:          in.SrcName,
+		SrcNamespace:     in.SrcNamespace,
+		Dst:              in.Dst,
+		DstName:          in.DstName,
+		DstNamespace:     in.DstNamespace,
+		Method:           in.Method,
+		Path:             in.Path,
+		RequestCount:     in.RequestCount,
+		ClientErrorCount: in.ClientErrorCount,
+		ServerErrorCount: in.ServerErrorCount,
+		Duration:         in.Duration,
+		MaxDuration:      in.MaxDuration,
+		LastSeen:         timestamppb.New(in.LastSeen),
+	}
+}
+
+func httpEdgeItemFromProto(in *proto_http.HTTPEdgeItem) *http_model.HTTPEdgeItem {
+	if in == nil {
+		return nil
+	}
+	return &http_model.HTTPEdgeItem{
+		Src:              in.Src,
+		SrcName:          in.SrcName,
+		SrcNamespace:     in.SrcNamespace,
+		Dst:              in.Dst,
+		DstName:          in.DstName,
+		DstNamespace:     in.DstNamespace,
+		Method:           in.Method,
+		Path:             in.Path,
+		RequestCount:     in.RequestCount,
+		ClientErrorCount: in.ClientErrorCount,
+		ServerErrorCount: in.ServerErrorCount,
+		Duration:         in.Duration,
+		MaxDuration:      in.MaxDuration,
+		LastSeen:         in.LastSeen.AsTime(),
 	}
 }
 
@@ -168,6 +249,12 @@ func marshalProto(v interface{}) ([]byte, error) {
 	case tcp_model.ConnectionItem:
 		protoVal := connectionItemToProto(&val)
 		return marshalMessage(protoVal)
+	case *http_model.HTTPEdgeItem:
+		protoVal := httpEdgeItemToProto(val)
+		return marshalMessage(protoVal)
+	case http_model.HTTPEdgeItem:
+		protoVal := httpEdgeItemToProto(&val)
+		return marshalMessage(protoVal)
 	default:
 		return nil, fmt.Errorf("unsupported type for protobuf marshaling: %T", v)
 	}
@@ -207,6 +294,14 @@ func unmarshalProto(data []byte, v interface{}) error {
 		domainVal := connectionItemFromProto(protoVal)
 		*val = *domainVal
 		return nil
+	case *http_model.HTTPEdgeItem:
+		protoVal := &proto_http.HTTPEdgeItem{}
+		if err := unmarshalMessage(data, protoVal); err != nil {
+			return err
+		}
+		domainVal := httpEdgeItemFromProto(protoVal)
+		*val = *domainVal
+		return nil
 	default:
 		return fmt.Errorf("unsupported type for protobuf unmarshaling: %T", v)
 	}
@@ -1,12 +1,13 @@
 package db
 
 import (
+	http_model "github.com/k8spacket/k8spacket/internal/modules/httpparser/model"
 	tcp_model "github.com/k8spacket/k8spacket/internal/modules/nodegraph/model"
 	tls_model "github.com/k8spacket/k8spacket/internal/modules/tlsparser/model"
 	"github.com/timshannon/bolthold"
 )
 
-type Db[T tls_model.TLSDetails | tls_model.TLSConnection | tcp_model.ConnectionItem] interface {
+type Db[T tls_model.TLSDetails | tls_model.TLSConnection | tcp_model.ConnectionItem | http_model.HTTPEdgeItem] interface {
 	Query(query *bolthold.Query) ([]T, error)
 	QueryMatchFunc(field string, matchFunc func(*T) (bool, error)) bolthold.Query
 	Read(key string) (T, error)
@@ -0,0 +1,23 @@
+package db
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	UpsertDurationMetric = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "k8s_packet_db_upsert_duration_seconds",
+			Help:    "Kubernetes packet time spent on upserting a record into the database",
+			Buckets: []float64{.0005, .001, .005, .01, .025, .05, .1, .25, .5, 1},
+		},
+		[]string{"bucket"},
+	)
+	UpsertErrorsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "k8s_packet_db_upsert_errors_total",
+			Help: "Kubernetes packet failed upserts into the database",
+		},
+		[]string{"bucket"},
+	)
+)
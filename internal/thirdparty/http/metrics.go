@@ -0,0 +1,27 @@
+package httpclient
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var PeerRequestDurationMetric = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "k8s_packet_peer_request_duration_seconds",
+		Help:    "Kubernetes packet duration of requests to peer pods when aggregating data",
+		Buckets: prometheus.DefBuckets,
+	},
+	[]string{"module", "status"},
+)
+
+// ObservePeerRequest records the duration of a request to a peer pod, labelled with the response status or "error"
+func ObservePeerRequest(module string, start time.Time, resp *http.Response, err error) {
+	status := "error"
+	if err == nil && resp != nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	PeerRequestDurationMetric.WithLabelValues(module, status).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,42 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObservePeerRequest(t *testing.T) {
+
+	var tests = []struct {
+		name   string
+		resp   *http.Response
+		err    error
+		status string
+	}{
+		{"ok", &http.Response{StatusCode: http.StatusOK}, nil, "200"},
+		{"server error", &http.Response{StatusCode: http.StatusInternalServerError}, nil, "500"},
+		{"transport error", nil, errors.New("transport error"), "error"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			before := sampleCount(t, "test", test.status)
+
+			ObservePeerRequest("test", time.Now(), test.resp, test.err)
+
+			assert.Equal(t, before+1, sampleCount(t, "test", test.status))
+		})
+	}
+}
+
+func sampleCount(t *testing.T, module string, status string) uint64 {
+	metric := &dto.Metric{}
+	assert.NoError(t, PeerRequestDurationMetric.WithLabelValues(module, status).(prometheus.Metric).Write(metric))
+	return metric.GetHistogram().GetSampleCount()
+}
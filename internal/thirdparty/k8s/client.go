@@ -2,4 +2,5 @@ package k8sclient
 
 type Client interface {
 	GetPodIPsBySelectors(fieldSelector string, labelSelector string) []string
+	IsPortDeclared(name string, namespace string, port uint16) (declared bool, known bool)
 }
@@ -3,20 +3,24 @@ package k8sclient
 import (
 	"context"
 	"fmt"
+	"github.com/k8spacket/k8spacket/internal/config"
+	"github.com/k8spacket/k8spacket/internal/status"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"log/slog"
-	"os"
 	"slices"
-	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
+const informersComponent = "k8s/informers"
+
 type ipResourceInfoType string
 
 const (
@@ -40,28 +44,86 @@ type SafeMap struct {
 	data map[string]ipResourceInfo
 }
 
-var k8sInfo *SafeMap
+var k8sInfo = &SafeMap{data: make(map[string]ipResourceInfo)}
+
+type containerInfo struct {
+	Name      string
+	Namespace string
+	Container string
+}
+
+// ContainerMap finds pods of processes by the IDs their cgroups are named after, idsByPodUID tells which container
+// IDs belong to a pod so that they are forgotten with it
+type ContainerMap struct {
+	mu          sync.RWMutex
+	byID        map[string]containerInfo
+	byPodUID    map[string]containerInfo
+	idsByPodUID map[string][]string
+}
+
+var containers = newContainerMap()
 
+func newContainerMap() *ContainerMap {
+	return &ContainerMap{byID: make(map[string]containerInfo), byPodUID: make(map[string]containerInfo), idsByPodUID: make(map[string][]string)}
+}
+
+type podPorts struct {
+	labels map[string]string
+	ports  []int32
+}
+
+type servicePorts struct {
+	namespace string
+	selector  map[string]string
+	ports     []int32
+}
+
+// PortMap holds ports of pods, declared in their container specs or as numeric target ports of services selecting them
+type PortMap struct {
+	mu       sync.RWMutex
+	pods     map[string]podPorts
+	services map[string]servicePorts
+}
+
+var ports = newPortMap()
+
+func newPortMap() *PortMap {
+	return &PortMap{pods: make(map[string]podPorts), services: make(map[string]servicePorts)}
+}
+
+// clientset stays nil until Init connects to the cluster
 var clientset *kubernetes.Clientset
 
-var disabledK8sResource, _ = strconv.ParseBool(os.Getenv("K8S_PACKET_K8S_RESOURCES_DISABLED"))
+// Init starts informers of pods, services and nodes unless Kubernetes resources are disabled
+func Init(k8s config.K8sConfig) {
+	if k8s.ResourcesDisabled {
+		return
+	}
+	_, clientset = configClusterClient()
+	factory := informers.NewSharedInformerFactoryWithOptions(clientset, 5*time.Minute)
+	stopChan := make(chan struct{})
+	createPodInformer(factory)
+	createSvcInformer(factory)
+	createNodeInformer(factory)
+	factory.Start(stopChan)
+	go reportSync(factory, stopChan)
+}
 
-func init() {
-	k8sInfo = &SafeMap{data: make(map[string]ipResourceInfo)}
-	if !disabledK8sResource {
-		_, clientset = configClusterClient()
-		factory := informers.NewSharedInformerFactoryWithOptions(clientset, 5*time.Minute)
-		stopChan := make(chan struct{})
-		createPodInformer(factory)
-		createSvcInformer(factory)
-		createNodeInformer(factory)
-		factory.Start(stopChan)
+// until informers sync, names of pods, services and nodes are unknown
+func reportSync(factory informers.SharedInformerFactory, stopChan chan struct{}) {
+	status.Report(informersComponent, status.Starting, "waiting for informers to sync")
+	for informer, synced := range factory.WaitForCacheSync(stopChan) {
+		if !synced {
+			status.Report(informersComponent, status.Down, fmt.Sprintf("informer of %v not synced", informer))
+			return
+		}
 	}
+	status.Report(informersComponent, status.Up, "")
 }
 
 func (k8sClient *K8SClient) GetPodIPsBySelectors(fieldSelector string, labelSelector string) []string {
 
-	if disabledK8sResource {
+	if clientset == nil {
 		return []string{"127.0.0.1"}
 	}
 
@@ -80,6 +142,10 @@ func (k8sClient *K8SClient) GetPodIPsBySelectors(fieldSelector string, labelSele
 	return list
 }
 
+func (k8sClient *K8SClient) IsPortDeclared(name string, namespace string, port uint16) (bool, bool) {
+	return IsPortDeclared(name, namespace, port)
+}
+
 func configClusterClient() (error, *kubernetes.Clientset) {
 
 	config, err := rest.InClusterConfig()
@@ -102,7 +168,11 @@ func createPodInformer(factory informers.SharedInformerFactory) {
 
 	_, err := podInformer.AddEventHandler(cache.FilteringResourceEventHandler{
 		FilterFunc: func(obj interface{}) bool {
-			pod := obj.(*v1.Pod)
+			// the final state of pods deleted while the watch was down is unknown, they are forgotten anyway
+			pod, ok := obj.(*v1.Pod)
+			if !ok {
+				return true
+			}
 			return slices.ContainsFunc(pod.Status.Conditions, func(condition v1.PodCondition) bool {
 				return condition.Type == v1.PodReady && condition.Status == v1.ConditionTrue
 			})
@@ -114,6 +184,10 @@ func createPodInformer(factory informers.SharedInformerFactory) {
 			UpdateFunc: func(oldObj interface{}, obj interface{}) {
 				addPod(obj)
 			},
+			// pods no longer ready are handed over as deleted too
+			DeleteFunc: func(obj interface{}) {
+				deletePod(obj)
+			},
 		}})
 	if err != nil {
 		fmt.Println(err)
@@ -128,8 +202,88 @@ func addPod(obj interface{}) {
 		Name:               "pod." + pod.Name,
 		Namespace:          pod.Namespace,
 	}
-	addItem(pod.Status.PodIP, ipResourceInfo)
-	slog.Debug("Added pod", "Name", pod.Name, "Namespace", pod.Namespace, "IP", pod.Status.PodIP)
+	// PodIPs holds one address per family on dual-stack clusters, PodIP is the first of them
+	for _, podIP := range pod.Status.PodIPs {
+		addItem(podIP.IP, ipResourceInfo)
+	}
+	if len(pod.Status.PodIPs) == 0 && pod.Status.PodIP != "" {
+		addItem(pod.Status.PodIP, ipResourceInfo)
+	}
+	addContainers(pod)
+	addPodPorts(pod)
+	slog.Debug("Added pod", "Name", pod.Name, "Namespace", pod.Namespace, "IPs", pod.Status.PodIPs)
+}
+
+// deletePod forgets containers and ports of a deleted pod, which comes in a tombstone when its final state was missed
+func deletePod(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	pod, ok := obj.(*v1.Pod)
+	if !ok {
+		return
+	}
+	deleteContainers(pod)
+	deletePodPorts(pod)
+	slog.Debug("Deleted pod", "Name", pod.Name, "Namespace", pod.Namespace)
+}
+
+// container IDs are reported with the runtime as scheme, e.g. containerd://<id>, IDs of restarted containers are
+// replaced
+func addContainers(pod *v1.Pod) {
+	containers.mu.Lock()
+	defer containers.mu.Unlock()
+	uid := string(pod.UID)
+	containers.byPodUID[uid] = containerInfo{Name: "pod." + pod.Name, Namespace: pod.Namespace}
+	var ids []string
+	for _, statuses := range [][]v1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+		for _, status := range statuses {
+			if _, id, ok := strings.Cut(status.ContainerID, "://"); ok && id != "" {
+				containers.byID[id] = containerInfo{Name: "pod." + pod.Name, Namespace: pod.Namespace, Container: status.Name}
+				ids = append(ids, id)
+			}
+		}
+	}
+	for _, id := range containers.idsByPodUID[uid] {
+		if !slices.Contains(ids, id) {
+			delete(containers.byID, id)
+		}
+	}
+	containers.idsByPodUID[uid] = ids
+}
+
+func deleteContainers(pod *v1.Pod) {
+	containers.mu.Lock()
+	defer containers.mu.Unlock()
+	uid := string(pod.UID)
+	for _, id := range containers.idsByPodUID[uid] {
+		delete(containers.byID, id)
+	}
+	delete(containers.idsByPodUID, uid)
+	delete(containers.byPodUID, uid)
+}
+
+// init containers with restartPolicy Always are sidecars, they listen like other containers
+func addPodPorts(pod *v1.Pod) {
+	var declared []int32
+	for _, containers := range [][]v1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for _, container := range containers {
+			for _, port := range container.Ports {
+				if port.Protocol == "" || port.Protocol == v1.ProtocolTCP {
+					declared = append(declared, port.ContainerPort)
+				}
+			}
+		}
+	}
+	ports.mu.Lock()
+	defer ports.mu.Unlock()
+	ports.pods[pod.Namespace+"/pod."+pod.Name] = podPorts{labels: pod.Labels, ports: declared}
+}
+
+func deletePodPorts(pod *v1.Pod) {
+	ports.mu.Lock()
+	defer ports.mu.Unlock()
+	delete(ports.pods, pod.Namespace+"/pod."+pod.Name)
 }
 
 func createSvcInformer(factory informers.SharedInformerFactory) {
@@ -142,6 +296,9 @@ func createSvcInformer(factory informers.SharedInformerFactory) {
 		UpdateFunc: func(oldObj interface{}, obj interface{}) {
 			addSvc(obj)
 		},
+		DeleteFunc: func(obj interface{}) {
+			deleteSvc(obj)
+		},
 	})
 }
 
@@ -152,8 +309,53 @@ func addSvc(obj interface{}) {
 		Name:               "svc." + svc.Name,
 		Namespace:          svc.Namespace,
 	}
-	addItem(svc.Spec.ClusterIP, ipResourceInfo)
-	slog.Debug("Added svc", "Name", svc.Name, "Namespace", svc.Namespace, "IP", svc.Spec.ClusterIP)
+	clusterIPs := svc.Spec.ClusterIPs
+	if len(clusterIPs) == 0 {
+		clusterIPs = []string{svc.Spec.ClusterIP}
+	}
+	for _, clusterIP := range clusterIPs {
+		// headless services have no cluster IP
+		if clusterIP == "" || clusterIP == v1.ClusterIPNone {
+			continue
+		}
+		addItem(clusterIP, ipResourceInfo)
+	}
+	addServicePorts(svc)
+	slog.Debug("Added svc", "Name", svc.Name, "Namespace", svc.Namespace, "IPs", clusterIPs)
+}
+
+// deleteSvc forgets ports of a deleted service, which comes in a tombstone when its final state was missed
+func deleteSvc(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	svc, ok := obj.(*v1.Service)
+	if !ok {
+		return
+	}
+	ports.mu.Lock()
+	defer ports.mu.Unlock()
+	delete(ports.services, svc.Namespace+"/"+svc.Name)
+	slog.Debug("Deleted svc", "Name", svc.Name, "Namespace", svc.Namespace)
+}
+
+// named target ports refer to ports of container specs, which are declared already
+func addServicePorts(svc *v1.Service) {
+	var declared []int32
+	for _, port := range svc.Spec.Ports {
+		if (port.Protocol != "" && port.Protocol != v1.ProtocolTCP) || port.TargetPort.Type != intstr.Int {
+			continue
+		}
+		// target port defaults to the port of the service
+		target := port.TargetPort.IntVal
+		if target == 0 {
+			target = port.Port
+		}
+		declared = append(declared, target)
+	}
+	ports.mu.Lock()
+	defer ports.mu.Unlock()
+	ports.services[svc.Namespace+"/"+svc.Name] = servicePorts{namespace: svc.Namespace, selector: svc.Spec.Selector, ports: declared}
 }
 
 func createNodeInformer(factory informers.SharedInformerFactory) {
@@ -175,11 +377,11 @@ func addNode(obj interface{}) {
 		Name:               "node." + node.Name,
 		Namespace:          "N/A",
 	}
+	// dual-stack nodes report an internal IP per family
 	for _, address := range node.Status.Addresses {
 		if address.Type == v1.NodeInternalIP {
 			addItem(address.Address, ipResourceInfo)
 			slog.Debug("Added node", "Name", node.Name, "IP", address.Address)
-			break
 		}
 	}
 }
@@ -195,6 +397,68 @@ func GetNameAndNamespace(id string) (string, string) {
 	}
 }
 
+// GetPodIPsInNamespaces returns IPs of pods in the namespaces, pods with hostNetwork share the IP of the node
+// and are not returned
+func GetPodIPsInNamespaces(namespaces []string) []string {
+	k8sInfo.mu.RLock()
+	defer k8sInfo.mu.RUnlock()
+	var ips []string
+	for ip, item := range k8sInfo.data {
+		if item.ipResourceInfoType == Pod && slices.Contains(namespaces, item.Namespace) {
+			ips = append(ips, ip)
+		}
+	}
+	slices.Sort(ips)
+	return ips
+}
+
+// GetContainer returns the pod and container name of a container, the container is empty when only the pod is known
+// (e.g. the sandbox of the pod)
+func GetContainer(podUID string, containerID string) (string, string, string) {
+	containers.mu.RLock()
+	defer containers.mu.RUnlock()
+	if item, ok := containers.byID[containerID]; ok {
+		return item.Name, item.Namespace, item.Container
+	}
+	if item, ok := containers.byPodUID[podUID]; ok {
+		return item.Name, item.Namespace, ""
+	}
+	return "", "", ""
+}
+
+// IsPortDeclared tells whether a pod declares a TCP port in a container spec or through a service selecting it,
+// known is false for names that are not pods
+func IsPortDeclared(name string, namespace string, port uint16) (declared bool, known bool) {
+	ports.mu.RLock()
+	defer ports.mu.RUnlock()
+	pod, known := ports.pods[namespace+"/"+name]
+	if !known {
+		return false, false
+	}
+	if slices.Contains(pod.ports, int32(port)) {
+		return true, true
+	}
+	for _, service := range ports.services {
+		if service.namespace == namespace && selects(service.selector, pod.labels) && slices.Contains(service.ports, int32(port)) {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// services without selector have endpoints managed by hand, they select no pod
+func selects(selector map[string]string, labels map[string]string) bool {
+	if len(selector) == 0 {
+		return false
+	}
+	for key, value := range selector {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
 func addItem(id string, info ipResourceInfo) {
 	k8sInfo.mu.Lock()
 	defer k8sInfo.mu.Unlock()
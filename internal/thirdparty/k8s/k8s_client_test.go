@@ -1,24 +1,22 @@
 package k8sclient
 
 import (
-	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/cache"
 )
 
 func TestGetNameAndNamespace_Empty(t *testing.T) {
-	// Ensure k8s resources are disabled for init
-	os.Setenv("K8S_PACKET_K8S_RESOURCES_DISABLED", "true")
-
 	name, ns := GetNameAndNamespace("no-such-ip")
 	assert.Equal(t, "", name)
 	assert.Equal(t, "", ns)
 }
 
 func TestAddItem_NodeThenPodBehavior(t *testing.T) {
-	os.Setenv("K8S_PACKET_K8S_RESOURCES_DISABLED", "true")
-
 	// reset map
 	k8sInfo = &SafeMap{data: make(map[string]ipResourceInfo)}
 
@@ -36,8 +34,6 @@ func TestAddItem_NodeThenPodBehavior(t *testing.T) {
 }
 
 func TestAddItem_PodThenNodeBehavior(t *testing.T) {
-	os.Setenv("K8S_PACKET_K8S_RESOURCES_DISABLED", "true")
-
 	k8sInfo = &SafeMap{data: make(map[string]ipResourceInfo)}
 
 	// add Pod first
@@ -54,9 +50,221 @@ func TestAddItem_PodThenNodeBehavior(t *testing.T) {
 }
 
 func TestK8SClient_GetPodIPsBySelectors_Disabled(t *testing.T) {
-	os.Setenv("K8S_PACKET_K8S_RESOURCES_DISABLED", "true")
-
 	client := &K8SClient{}
 	res := client.GetPodIPsBySelectors("", "")
 	assert.Equal(t, []string{"127.0.0.1"}, res)
 }
+
+func TestAddDualStackResources(t *testing.T) {
+	k8sInfo = &SafeMap{data: make(map[string]ipResourceInfo)}
+
+	addPod(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Status:     v1.PodStatus{PodIP: "10.244.0.5", PodIPs: []v1.PodIP{{IP: "10.244.0.5"}, {IP: "fd00:10:244::5"}}},
+	})
+	addSvc(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       v1.ServiceSpec{ClusterIP: "10.96.0.10", ClusterIPs: []string{"10.96.0.10", "fd00:10:96::a"}},
+	})
+	addSvc(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "headless", Namespace: "default"},
+		Spec:       v1.ServiceSpec{ClusterIP: v1.ClusterIPNone, ClusterIPs: []string{v1.ClusterIPNone}},
+	})
+	addNode(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker"},
+		Status: v1.NodeStatus{Addresses: []v1.NodeAddress{
+			{Type: v1.NodeHostName, Address: "worker"},
+			{Type: v1.NodeInternalIP, Address: "172.18.0.2"},
+			{Type: v1.NodeInternalIP, Address: "fc00:f853:ccd:e793::2"},
+		}},
+	})
+
+	var tests = []struct {
+		ip        string
+		name      string
+		namespace string
+	}{
+		{"10.244.0.5", "pod.web", "default"},
+		{"fd00:10:244::5", "pod.web", "default"},
+		{"10.96.0.10", "svc.web", "default"},
+		{"fd00:10:96::a", "svc.web", "default"},
+		{v1.ClusterIPNone, "", ""},
+		{"172.18.0.2", "node.worker", "N/A"},
+		{"fc00:f853:ccd:e793::2", "node.worker", "N/A"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.ip, func(t *testing.T) {
+			name, namespace := GetNameAndNamespace(test.ip)
+			assert.Equal(t, test.name, name)
+			assert.Equal(t, test.namespace, namespace)
+		})
+	}
+}
+
+func TestGetPodIPsInNamespaces(t *testing.T) {
+	k8sInfo = &SafeMap{data: make(map[string]ipResourceInfo)}
+
+	addPod(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"},
+		Status:     v1.PodStatus{PodIPs: []v1.PodIP{{IP: "10.244.0.2"}, {IP: "fd00:10:244::2"}}},
+	})
+	addPod(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Status:     v1.PodStatus{PodIPs: []v1.PodIP{{IP: "10.244.0.5"}}},
+	})
+	addSvc(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "kube-dns", Namespace: "kube-system"},
+		Spec:       v1.ServiceSpec{ClusterIPs: []string{"10.96.0.10"}},
+	})
+	addNode(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker"},
+		Status:     v1.NodeStatus{Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "172.18.0.2"}}},
+	})
+	// hostNetwork pod
+	addPod(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "kube-proxy", Namespace: "kube-system"},
+		Status:     v1.PodStatus{PodIPs: []v1.PodIP{{IP: "172.18.0.2"}}},
+	})
+
+	assert.EqualValues(t, []string{"10.244.0.2", "fd00:10:244::2"}, GetPodIPsInNamespaces([]string{"kube-system"}))
+	assert.EqualValues(t, []string{"10.244.0.2", "10.244.0.5", "fd00:10:244::2"}, GetPodIPsInNamespaces([]string{"kube-system", "default"}))
+	assert.Empty(t, GetPodIPsInNamespaces(nil))
+}
+
+func TestGetContainer(t *testing.T) {
+	containers = newContainerMap()
+
+	addContainers(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "monitoring", UID: "6f1c2b7e-4c1d-4c1e-9a55-2b1f3a4d5e6f"},
+		Status: v1.PodStatus{
+			InitContainerStatuses: []v1.ContainerStatus{{Name: "init", ContainerID: "containerd://aaa"}},
+			ContainerStatuses:     []v1.ContainerStatus{{Name: "collector", ContainerID: "cri-o://bbb"}, {Name: "waiting"}},
+		},
+	})
+
+	var tests = []struct {
+		name        string
+		podUID      string
+		containerID string
+		want        [3]string
+	}{
+		{"container", "", "bbb", [3]string{"pod.agent", "monitoring", "collector"}},
+		{"init container", "", "aaa", [3]string{"pod.agent", "monitoring", "init"}},
+		{"sandbox", "6f1c2b7e-4c1d-4c1e-9a55-2b1f3a4d5e6f", "ccc", [3]string{"pod.agent", "monitoring", ""}},
+		{"unknown", "other", "ddd", [3]string{"", "", ""}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			name, namespace, container := GetContainer(test.podUID, test.containerID)
+			assert.Equal(t, test.want, [3]string{name, namespace, container})
+		})
+	}
+}
+
+func TestDeleteContainers(t *testing.T) {
+	containers = newContainerMap()
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "monitoring", UID: "uid"},
+		Status:     v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{{Name: "collector", ContainerID: "containerd://aaa"}}},
+	}
+	addContainers(pod)
+
+	// a restarted container gets a new ID
+	restarted := pod.DeepCopy()
+	restarted.Status.ContainerStatuses[0].ContainerID = "containerd://bbb"
+	addContainers(restarted)
+	_, _, container := GetContainer("", "aaa")
+	assert.Empty(t, container)
+	_, _, container = GetContainer("", "bbb")
+	assert.Equal(t, "collector", container)
+
+	deletePod(cache.DeletedFinalStateUnknown{Key: "monitoring/agent", Obj: restarted})
+	name, _, _ := GetContainer("uid", "bbb")
+	assert.Empty(t, name)
+	assert.Empty(t, containers.byID)
+	assert.Empty(t, containers.byPodUID)
+	assert.Empty(t, containers.idsByPodUID)
+}
+
+func TestIsPortDeclared(t *testing.T) {
+	ports = newPortMap()
+
+	addPodPorts(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "shop", Labels: map[string]string{"app": "api"}},
+		Spec: v1.PodSpec{
+			InitContainers: []v1.Container{{Name: "proxy", Ports: []v1.ContainerPort{{ContainerPort: 15001}}}},
+			Containers: []v1.Container{{Name: "api", Ports: []v1.ContainerPort{
+				{ContainerPort: 8080, Protocol: v1.ProtocolTCP}, {ContainerPort: 5353, Protocol: v1.ProtocolUDP}}}},
+		},
+	})
+	addServicePorts(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "shop"},
+		Spec: v1.ServiceSpec{Selector: map[string]string{"app": "api"}, Ports: []v1.ServicePort{
+			{Port: 80, TargetPort: intstr.FromInt32(9090)},
+			{Port: 9091},
+			{Port: 443, TargetPort: intstr.FromString("https")}}},
+	})
+	addServicePorts(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "shop"},
+		Spec:       v1.ServiceSpec{Selector: map[string]string{"app": "other"}, Ports: []v1.ServicePort{{Port: 7000}}},
+	})
+	addServicePorts(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "staging"},
+		Spec:       v1.ServiceSpec{Selector: map[string]string{"app": "api"}, Ports: []v1.ServicePort{{Port: 7001}}},
+	})
+
+	var tests = []struct {
+		name     string
+		pod      string
+		port     uint16
+		declared bool
+		known    bool
+	}{
+		{"container port", "pod.api", 8080, true, true},
+		{"sidecar port", "pod.api", 15001, true, true},
+		{"udp container port", "pod.api", 5353, false, true},
+		{"service target port", "pod.api", 9090, true, true},
+		{"service port as target", "pod.api", 9091, true, true},
+		{"service of other pods", "pod.api", 7000, false, true},
+		{"service of other namespace", "pod.api", 7001, false, true},
+		{"undeclared", "pod.api", 6060, false, true},
+		{"not a pod", "node.worker", 22, false, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			declared, known := IsPortDeclared(test.pod, "shop", test.port)
+			assert.Equal(t, test.declared, declared)
+			assert.Equal(t, test.known, known)
+		})
+	}
+}
+
+func TestDeletePorts(t *testing.T) {
+	ports = newPortMap()
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "shop", Labels: map[string]string{"app": "api"}},
+		Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "api", Ports: []v1.ContainerPort{{ContainerPort: 8080}}}}},
+	}
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "shop"},
+		Spec:       v1.ServiceSpec{Selector: map[string]string{"app": "api"}, Ports: []v1.ServicePort{{Port: 9090}}},
+	}
+	addPodPorts(pod)
+	addServicePorts(svc)
+
+	deleteSvc(cache.DeletedFinalStateUnknown{Key: "shop/api", Obj: svc})
+	declared, known := IsPortDeclared("pod.api", "shop", 9090)
+	assert.False(t, declared)
+	assert.True(t, known)
+	assert.Empty(t, ports.services)
+
+	deletePod(pod)
+	_, known = IsPortDeclared("pod.api", "shop", 8080)
+	assert.False(t, known)
+	assert.Empty(t, ports.pods)
+}